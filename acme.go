@@ -0,0 +1,42 @@
+package chain
+
+import (
+	"net/http"
+	"strings"
+)
+
+// acmeChallengePrefix is the path under which ACME HTTP-01 challenges are served.
+const acmeChallengePrefix = "/.well-known/acme-challenge/"
+
+// WithACME routes ACME HTTP-01 challenge requests (paths under
+// /.well-known/acme-challenge/) to handler, typically the challenge handler of an
+// autocert.Manager, so that certificates can be issued automatically. Challenge
+// requests bypass the router entirely: Wrap and pre-routing middleware,
+// authorization and Finally hooks are not applied, so an authentication or HTTPS
+// redirect policy cannot block validation. Mount it on the router serving port 80:
+//
+//	m := &autocert.Manager{
+//		Prompt:     autocert.AcceptTOS,
+//		HostPolicy: autocert.HostWhitelist("example.com"),
+//		Cache:      autocert.DirCache("certs"),
+//	}
+//	go http.ListenAndServe(":80", chain.New().WithACME(m.HTTPHandler(nil)))
+//	http.Serve(m.Listener(), mux)
+//
+// Returns the Mux instance for chaining.
+func (m *Mux) WithACME(handler http.Handler) *Mux {
+	if handler == nil {
+		panic("chain: nil handler passed to WithACME")
+	}
+	m.root.acme = handler
+	return m
+}
+
+// acmeHandler returns the ACME challenge handler if r is a challenge request and
+// one is configured, or nil otherwise.
+func (m *Mux) acmeHandler(r *http.Request) http.Handler {
+	if m.acme == nil || !strings.HasPrefix(r.URL.Path, acmeChallengePrefix) {
+		return nil
+	}
+	return m.acme
+}
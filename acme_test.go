@@ -0,0 +1,37 @@
+package chain_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jpl-au/chain"
+)
+
+func TestWithACME(t *testing.T) {
+	challenge := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("token"))
+	})
+	deny := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		})
+	}
+	mux := chain.New().WithACME(challenge)
+	mux.Wrap(deny)
+	mux.UsePre(deny)
+	mux.Use(deny)
+	mux.HandleFunc("GET /", func(w http.ResponseWriter, r *http.Request) {})
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/.well-known/acme-challenge/abc", nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "token" {
+		t.Errorf("challenge = %d %q, want 200 token", rec.Code, rec.Body)
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("other request = %d, want middleware applied", rec.Code)
+	}
+}
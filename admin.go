@@ -0,0 +1,204 @@
+package chain
+
+import (
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// AdminOptions configures the admin API mounted by Admin.
+type AdminOptions struct {
+	// Auth guards every admin endpoint, typically by checking an operator token or
+	// client certificate. Required.
+	Auth func(http.Handler) http.Handler
+	// LogLevel, when set, can be read and changed at runtime.
+	LogLevel *slog.LevelVar
+	// Maintenance, when set, can be switched on and off at runtime. Add it to the
+	// Windows of the groups it should take offline.
+	Maintenance *MaintenanceSwitch
+	// Reload, when set, is called to reload configuration.
+	Reload func(r *http.Request) error
+	// BaseURL is the address used in the curl examples. Defaults to
+	// "http://localhost:8080".
+	BaseURL string
+}
+
+// Admin mounts a JSON admin API under prefix for operating the router at runtime:
+//
+//	GET  {prefix}/routes       route table
+//	GET  {prefix}/slo          SLO status per route
+//	GET  {prefix}/deprecated   usage counts of deprecated routes
+//	GET  {prefix}/examples     curl command for each route
+//	GET  {prefix}/disabled     routes switched off with Disable
+//	PUT  {prefix}/disabled     {"pattern": p, "disabled": true, "status": 410, "reason": r}
+//	GET  {prefix}/log-level    current log level            (LogLevel)
+//	PUT  {prefix}/log-level    {"level": "debug"}            (LogLevel)
+//	GET  {prefix}/maintenance  maintenance switch state      (Maintenance)
+//	PUT  {prefix}/maintenance  {"enabled": true, "until": t} (Maintenance)
+//	POST {prefix}/reload       reload configuration          (Reload)
+//
+// Endpoints marked with an option are registered only when it is set. All of them
+// run behind opts.Auth and any middleware already registered on m.
+// Returns the Mux instance for method chaining.
+func (m *Mux) Admin(prefix string, opts AdminOptions) *Mux {
+	if opts.Auth == nil {
+		panic("chain: nil Auth passed to Admin")
+	}
+	m.Route(prefix, func(admin *Mux) {
+		admin.Use(opts.Auth)
+		admin.HandleFunc("GET /routes", func(w http.ResponseWriter, r *http.Request) {
+			JSON(w, http.StatusOK, m.RouteTable())
+		})
+		admin.HandleFunc("GET /slo", func(w http.ResponseWriter, r *http.Request) {
+			JSON(w, http.StatusOK, m.SLOReport())
+		})
+		admin.HandleFunc("GET /deprecated", func(w http.ResponseWriter, r *http.Request) {
+			JSON(w, http.StatusOK, m.DeprecatedUsage())
+		})
+		admin.HandleFunc("GET /examples", func(w http.ResponseWriter, r *http.Request) {
+			JSON(w, http.StatusOK, m.CurlExamples(CurlOptions{BaseURL: opts.BaseURL}))
+		})
+		admin.HandleFunc("GET /disabled", func(w http.ResponseWriter, r *http.Request) {
+			JSON(w, http.StatusOK, m.DisabledRoutes())
+		})
+		admin.HandleFunc("PUT /disabled", func(w http.ResponseWriter, r *http.Request) {
+			var req struct {
+				Pattern  string `json:"pattern"`
+				Disabled bool   `json:"disabled"`
+				Status   int    `json:"status"`
+				Reason   string `json:"reason"`
+			}
+			if err := Bind(w, r, &req); err != nil {
+				WriteError(w, r, http.StatusBadRequest, err.Error())
+				return
+			}
+			if _, ok := m.lookup(req.Pattern); !ok {
+				WriteError(w, r, http.StatusNotFound, "unknown pattern "+req.Pattern)
+				return
+			}
+			if req.Status != 0 && (req.Status < 400 || req.Status > 599) {
+				WriteError(w, r, http.StatusBadRequest, "status must be an error status")
+				return
+			}
+			if req.Disabled {
+				m.Disable(req.Pattern, req.Status, req.Reason)
+			} else {
+				m.Enable(req.Pattern)
+			}
+			JSON(w, http.StatusOK, m.DisabledRoutes())
+		})
+
+		if lv := opts.LogLevel; lv != nil {
+			type level struct {
+				Level string `json:"level"`
+			}
+			admin.HandleFunc("GET /log-level", func(w http.ResponseWriter, r *http.Request) {
+				JSON(w, http.StatusOK, level{lv.Level().String()})
+			})
+			admin.HandleFunc("PUT /log-level", func(w http.ResponseWriter, r *http.Request) {
+				var req level
+				if err := Bind(w, r, &req); err != nil {
+					WriteError(w, r, http.StatusBadRequest, err.Error())
+					return
+				}
+				var l slog.Level
+				if err := l.UnmarshalText([]byte(req.Level)); err != nil {
+					WriteError(w, r, http.StatusBadRequest, err.Error())
+					return
+				}
+				lv.Set(l)
+				m.log().Info("chain: log level changed", "level", l.String())
+				JSON(w, http.StatusOK, level{l.String()})
+			})
+		}
+
+		if sw := opts.Maintenance; sw != nil {
+			admin.HandleFunc("GET /maintenance", func(w http.ResponseWriter, r *http.Request) {
+				JSON(w, http.StatusOK, sw.state())
+			})
+			admin.HandleFunc("PUT /maintenance", func(w http.ResponseWriter, r *http.Request) {
+				var req maintenanceState
+				if err := Bind(w, r, &req); err != nil {
+					WriteError(w, r, http.StatusBadRequest, err.Error())
+					return
+				}
+				if req.Enabled {
+					var until time.Time
+					if req.Until != nil {
+						until = *req.Until
+					}
+					sw.Enable(until)
+				} else {
+					sw.Disable()
+				}
+				m.log().Info("chain: maintenance switched", "enabled", req.Enabled)
+				JSON(w, http.StatusOK, sw.state())
+			})
+		}
+
+		if opts.Reload != nil {
+			admin.HandleFunc("POST /reload", func(w http.ResponseWriter, r *http.Request) {
+				err := opts.Reload(r)
+				m.root.events.emit(ConfigReloaded{Err: err})
+				if err != nil {
+					WriteError(w, r, http.StatusInternalServerError, err.Error())
+					return
+				}
+				m.log().Info("chain: configuration reloaded")
+				w.WriteHeader(http.StatusNoContent)
+			})
+		}
+	})
+	return m
+}
+
+// MaintenanceSwitch is a MaintenanceWindow switched on and off at runtime, for
+// example through Admin. The zero value is off and ready to use.
+type MaintenanceSwitch struct {
+	mu      sync.Mutex
+	enabled bool
+	until   time.Time
+}
+
+// maintenanceState is the JSON form of a MaintenanceSwitch.
+type maintenanceState struct {
+	Enabled bool       `json:"enabled"`
+	Until   *time.Time `json:"until,omitempty"`
+}
+
+// Enable takes routes offline. If until is non-zero, maintenance ends
+// automatically then and clients are told when to retry; otherwise it lasts until
+// Disable is called.
+func (s *MaintenanceSwitch) Enable(until time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.enabled, s.until = true, until
+}
+
+// Disable brings routes back online.
+func (s *MaintenanceSwitch) Disable() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.enabled, s.until = false, time.Time{}
+}
+
+// Active implements MaintenanceWindow.
+func (s *MaintenanceSwitch) Active(t time.Time) (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.enabled || !s.until.IsZero() && !t.Before(s.until) {
+		return time.Time{}, false
+	}
+	return s.until, true
+}
+
+// state returns the switch settings as reported by Admin.
+func (s *MaintenanceSwitch) state() maintenanceState {
+	until, ok := s.Active(time.Now())
+	st := maintenanceState{Enabled: ok}
+	if ok && !until.IsZero() {
+		st.Until = &until
+	}
+	return st
+}
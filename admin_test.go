@@ -0,0 +1,126 @@
+package chain_test
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jpl-au/chain"
+)
+
+func TestAdmin(t *testing.T) {
+	var level slog.LevelVar
+	var sw chain.MaintenanceSwitch
+	reloads := 0
+
+	mux := chain.New()
+	mux.Route("/api", func(api *chain.Mux) {
+		api.Maintenance(chain.MaintenanceOptions{Windows: []chain.MaintenanceWindow{&sw}})
+		api.HandleFunc("GET /users", func(w http.ResponseWriter, r *http.Request) {})
+	})
+	mux.Admin("/admin", chain.AdminOptions{
+		Auth: func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Authorization") != "Bearer ops" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				next.ServeHTTP(w, r)
+			})
+		},
+		LogLevel:    &level,
+		Maintenance: &sw,
+		Reload: func(r *http.Request) error {
+			reloads++
+			if reloads > 1 {
+				return errors.New("bad config")
+			}
+			return nil
+		},
+	})
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer ops")
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/admin/routes", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected unauthenticated request rejected, got %d", rec.Code)
+	}
+
+	var routes []chain.RouteInfo
+	json.Unmarshal(do("GET", "/admin/routes", "").Body.Bytes(), &routes)
+	if len(routes) == 0 || routes[0].Pattern != "GET /api/users" {
+		t.Errorf("Unexpected route table %+v", routes)
+	}
+
+	if rec := do("PUT", "/admin/disabled", `{"pattern":"GET /api/users","disabled":true,"status":410,"reason":"gone"}`); rec.Code != http.StatusOK {
+		t.Errorf("Expected route disabled, got %d %q", rec.Code, rec.Body.String())
+	}
+	if rec := do("GET", "/api/users", ""); rec.Code != http.StatusGone {
+		t.Errorf("Expected disabled route to answer 410, got %d", rec.Code)
+	}
+	if rec := do("PUT", "/admin/disabled", `{"pattern":"GET /missing","disabled":true}`); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected unknown pattern rejected, got %d", rec.Code)
+	}
+	if rec := do("PUT", "/admin/disabled", `{"pattern":"GET /api/users","disabled":false}`); rec.Body.String() != "{}\n" {
+		t.Errorf("Expected route enabled, got %q", rec.Body.String())
+	}
+
+	var examples []chain.CurlExample
+	json.Unmarshal(do("GET", "/admin/examples", "").Body.Bytes(), &examples)
+	if len(examples) == 0 || examples[0].Command != "curl 'http://localhost:8080/api/users'" {
+		t.Errorf("Unexpected examples %+v", examples)
+	}
+
+	if rec := do("PUT", "/admin/log-level", `{"level":"debug"}`); rec.Code != http.StatusOK || level.Level() != slog.LevelDebug {
+		t.Errorf("Expected level changed to debug, got %d %v", rec.Code, level.Level())
+	}
+	if rec := do("PUT", "/admin/log-level", `{"level":"loud"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected invalid level rejected, got %d", rec.Code)
+	}
+
+	until := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	if rec := do("PUT", "/admin/maintenance", `{"enabled":true,"until":"`+until+`"}`); !strings.Contains(rec.Body.String(), `"enabled":true`) {
+		t.Errorf("Expected maintenance enabled, got %q", rec.Body.String())
+	}
+	if rec := do("GET", "/api/users", ""); rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") == "" {
+		t.Errorf("Expected 503 with Retry-After during maintenance, got %d", rec.Code)
+	}
+	do("PUT", "/admin/maintenance", `{"enabled":false}`)
+	if rec := do("GET", "/api/users", ""); rec.Code != http.StatusOK {
+		t.Errorf("Expected 200 after maintenance, got %d", rec.Code)
+	}
+
+	if rec := do("POST", "/admin/reload", ""); rec.Code != http.StatusNoContent {
+		t.Errorf("Expected 204 on reload, got %d", rec.Code)
+	}
+	if rec := do("POST", "/admin/reload", ""); rec.Code != http.StatusInternalServerError || !strings.Contains(rec.Body.String(), "bad config") {
+		t.Errorf("Expected reload failure reported, got %d %q", rec.Code, rec.Body.String())
+	}
+}
+
+func TestMaintenanceSwitchExpires(t *testing.T) {
+	var sw chain.MaintenanceSwitch
+	sw.Enable(time.Now().Add(-time.Second))
+	if _, ok := sw.Active(time.Now()); ok {
+		t.Error("Expected switch with past end to be inactive")
+	}
+	sw.Enable(time.Time{})
+	if _, ok := sw.Active(time.Now()); !ok {
+		t.Error("Expected open-ended switch to be active")
+	}
+}
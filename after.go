@@ -0,0 +1,183 @@
+package chain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrWorkQueueFull is reported when work scheduled with AfterResponse is dropped
+// because the worker pool's queue is full.
+var ErrWorkQueueFull = errors.New("chain: after-response queue full")
+
+// WorkerOptions configures a WorkerPool.
+type WorkerOptions struct {
+	// Workers is the number of goroutines running scheduled work. Defaults to 4.
+	Workers int
+	// Queue is the number of jobs that may wait for a worker. Defaults to 100.
+	Queue int
+	// Reporter receives panics in jobs and jobs dropped because the queue was full.
+	// Defaults to NopReporter.
+	Reporter Reporter
+}
+
+// job is work scheduled by AfterResponse.
+type job struct {
+	ctx context.Context
+	fn  func(ctx context.Context)
+	r   *http.Request
+}
+
+// WorkerPool runs work scheduled with AfterResponse on a bounded set of goroutines.
+type WorkerPool struct {
+	opts    WorkerOptions
+	queue   chan job
+	wg      sync.WaitGroup
+	mu      sync.RWMutex
+	closed  bool
+	dropped atomic.Uint64
+}
+
+// NewWorkerPool starts a pool of workers. Call Close on shutdown to finish the work
+// already queued.
+func NewWorkerPool(opts WorkerOptions) *WorkerPool {
+	if opts.Workers <= 0 {
+		opts.Workers = 4
+	}
+	if opts.Queue <= 0 {
+		opts.Queue = 100
+	}
+	if opts.Reporter == nil {
+		opts.Reporter = NopReporter
+	}
+	p := &WorkerPool{opts: opts, queue: make(chan job, opts.Queue)}
+	p.wg.Add(opts.Workers)
+	for range opts.Workers {
+		go p.work()
+	}
+	return p
+}
+
+// Dropped returns the number of jobs discarded because the queue was full or the
+// pool was closed.
+func (p *WorkerPool) Dropped() uint64 {
+	return p.dropped.Load()
+}
+
+// Close stops accepting jobs and waits for queued jobs to finish or for ctx to be
+// done, whichever comes first.
+func (p *WorkerPool) Close(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.queue)
+	}
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// submit queues jobs without blocking, dropping those that do not fit.
+func (p *WorkerPool) submit(jobs []job) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	for _, j := range jobs {
+		if p.closed {
+			p.dropped.Add(1)
+			continue
+		}
+		select {
+		case p.queue <- j:
+		default:
+			p.dropped.Add(1)
+			p.opts.Reporter.Report(j.ctx, ErrWorkQueueFull, nil, j.r)
+		}
+	}
+}
+
+// work runs queued jobs until the pool is closed.
+func (p *WorkerPool) work() {
+	defer p.wg.Done()
+	for j := range p.queue {
+		p.run(j)
+	}
+}
+
+// run calls a job, reporting a panic instead of letting it kill the process.
+func (p *WorkerPool) run(j job) {
+	defer func() {
+		if v := recover(); v != nil {
+			p.opts.Reporter.Report(j.ctx, fmt.Errorf("after-response job: %w", panicError(v)), debug.Stack(), j.r)
+		}
+	}()
+	j.fn(j.ctx)
+}
+
+// afterKey is the context key under which the request's scheduled work is stored.
+type afterKey struct{}
+
+// afterResponse collects the work scheduled during one request.
+type afterResponse struct {
+	mu   sync.Mutex
+	jobs []job
+}
+
+// WithWorkerPool enables AfterResponse, running scheduled work on pool once the
+// handler has completed. Work scheduled by a request that panics is discarded.
+// Calling WithWorkerPool inside a group registers it on the root Mux.
+// Returns the Mux instance for method chaining.
+func (m *Mux) WithWorkerPool(pool *WorkerPool) *Mux {
+	if pool == nil {
+		panic("chain: nil pool passed to WithWorkerPool")
+	}
+	return m.UsePre(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			after := &afterResponse{}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), afterKey{}, after)))
+			after.mu.Lock()
+			jobs := after.jobs
+			after.jobs = nil
+			after.mu.Unlock()
+			if len(jobs) > 0 {
+				pool.submit(jobs)
+			}
+		})
+	})
+}
+
+// AfterResponse schedules fn to run once the response to r has been written, so
+// that slow work such as sending email or recording analytics does not delay the
+// client. fn receives a context that carries the request's values but is not
+// cancelled when the request ends. It reports false, without scheduling fn, if the
+// router was not configured with WithWorkerPool.
+func AfterResponse(r *http.Request, fn func(ctx context.Context)) bool {
+	if fn == nil {
+		panic("chain: nil function passed to AfterResponse")
+	}
+	after, ok := r.Context().Value(afterKey{}).(*afterResponse)
+	if !ok {
+		return false
+	}
+	ctx := context.WithoutCancel(r.Context())
+	detached := r.Clone(ctx)
+	detached.Body = http.NoBody
+
+	after.mu.Lock()
+	after.jobs = append(after.jobs, job{ctx: ctx, fn: fn, r: detached})
+	after.mu.Unlock()
+	return true
+}
@@ -0,0 +1,89 @@
+package chain_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jpl-au/chain"
+)
+
+type ctxKey string
+
+func TestAfterResponse(t *testing.T) {
+	pool := chain.NewWorkerPool(chain.WorkerOptions{Workers: 1})
+	mux := chain.New().WithWorkerPool(pool)
+
+	ran := make(chan string, 1)
+	release := make(chan struct{})
+	mux.HandleFunc("GET /signup", func(w http.ResponseWriter, r *http.Request) {
+		r = r.WithContext(context.WithValue(r.Context(), ctxKey("user"), "ada"))
+		ok := chain.AfterResponse(r, func(ctx context.Context) {
+			<-release
+			if ctx.Err() != nil {
+				t.Error("Expected job context not to be cancelled")
+			}
+			ran <- ctx.Value(ctxKey("user")).(string)
+		})
+		if !ok {
+			t.Error("Expected AfterResponse to schedule the job")
+		}
+		w.Write([]byte("welcome"))
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/signup", nil).WithContext(ctx))
+	cancel()
+
+	// The response is complete while the job is still blocked
+	if rec.Body.String() != "welcome" {
+		t.Errorf("Expected body 'welcome', got '%s'", rec.Body.String())
+	}
+	close(release)
+
+	select {
+	case user := <-ran:
+		if user != "ada" {
+			t.Errorf("Expected job to see request values, got '%s'", user)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Job did not run")
+	}
+
+	if err := pool.Close(context.Background()); err != nil {
+		t.Errorf("Expected clean close, got %v", err)
+	}
+}
+
+func TestAfterResponseWithoutPool(t *testing.T) {
+	mux := chain.New()
+	mux.HandleFunc("GET /", func(w http.ResponseWriter, r *http.Request) {
+		if chain.AfterResponse(r, func(context.Context) {}) {
+			t.Error("Expected AfterResponse to report false without a pool")
+		}
+	})
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+}
+
+func TestWorkerPoolDropsWhenFull(t *testing.T) {
+	pool := chain.NewWorkerPool(chain.WorkerOptions{Workers: 1, Queue: 1})
+	mux := chain.New().WithWorkerPool(pool)
+
+	release := make(chan struct{})
+	mux.HandleFunc("GET /", func(w http.ResponseWriter, r *http.Request) {
+		for range 3 {
+			chain.AfterResponse(r, func(context.Context) { <-release })
+		}
+	})
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	// One job may be running and one queued, so at least one is dropped
+	if pool.Dropped() == 0 {
+		t.Error("Expected jobs beyond the queue to be dropped")
+	}
+	close(release)
+	pool.Close(context.Background())
+}
@@ -0,0 +1,83 @@
+package chain
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Alias registers alias as an alternative pattern for the route previously
+// registered as canonical on this Mux. The alias serves the canonical handler,
+// middleware included, so wildcards in the alias must use the same names as those
+// in the canonical pattern. Both patterns are relative to the Mux's prefix.
+// It panics if canonical has not been registered.
+// Returns the Mux instance for method chaining.
+func (m *Mux) Alias(alias, canonical string) *Mux {
+	rt := m.canonicalRoute(canonical, "Alias")
+	if rt.alias == "" {
+		rt.alias = rt.info.Pattern
+	}
+	rt.info.Pattern = m.prefixPattern(alias)
+	rt.info.Prefix = m.prefix
+	m.handle(rt)
+	return m
+}
+
+// AliasRedirect registers alias as a legacy pattern that redirects to the route
+// registered as canonical on this Mux. Wildcards are carried over by name, and the
+// query string is preserved, so "/v1/people/{id}" can redirect to "/users/{id}".
+// GET and HEAD requests receive 301 Moved Permanently; other methods receive 308
+// Permanent Redirect so that clients resend the body. It panics if canonical has
+// not been registered. Returns the Mux instance for method chaining.
+func (m *Mux) AliasRedirect(alias, canonical string) *Mux {
+	rt := m.canonicalRoute(canonical, "AliasRedirect")
+	target := rt.info.Pattern
+	if i := strings.IndexByte(target, '/'); i > 0 {
+		target = target[i:]
+	}
+
+	m.handle(route{info: RouteInfo{Pattern: m.prefixPattern(alias), Prefix: m.prefix}, handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		location, ok := expandPattern(target, r)
+		if !ok {
+			WriteError(w, r, http.StatusBadRequest, "")
+			return
+		}
+		if r.URL.RawQuery != "" {
+			location += "?" + r.URL.RawQuery
+		}
+		code := http.StatusMovedPermanently
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			code = http.StatusPermanentRedirect
+		}
+		http.Redirect(w, r, location, code)
+	})})
+	return m
+}
+
+// canonicalRoute returns the route registered under canonical on this Mux,
+// panicking with a message naming caller if it does not exist.
+func (m *Mux) canonicalRoute(canonical, caller string) route {
+	rt, ok := m.lookup(m.prefixPattern(canonical))
+	if !ok {
+		panic("chain: unknown canonical pattern " + canonical + " passed to " + caller)
+	}
+	return rt
+}
+
+// expandPattern substitutes the wildcards in path with the values matched for r.
+// "{name}" is replaced with r.PathValue(name) escaped as a single path segment,
+// "{name...}" with each of its segments escaped, and "{$}" is removed. It reports
+// false if the result does not start with a single "/", as a value beginning with
+// "/" in a leading "{name...}" would otherwise produce a protocol-relative URL
+// pointing at another host.
+func expandPattern(path string, r *http.Request) (string, bool) {
+	var b strings.Builder
+	for _, s := range patternSegments(path) {
+		if s.param == "" {
+			b.WriteString(s.literal)
+		} else {
+			b.WriteString(escapeWildcard(s, r.PathValue(s.param)))
+		}
+	}
+	expanded := b.String()
+	return expanded, strings.HasPrefix(expanded, "/") && !strings.HasPrefix(expanded, "//")
+}
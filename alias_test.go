@@ -0,0 +1,88 @@
+package chain_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jpl-au/chain"
+)
+
+func TestAlias(t *testing.T) {
+	mux := chain.New()
+	mux.Route("/api", func(api *chain.Mux) {
+		api.Use(tag("api"))
+		api.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("user " + r.PathValue("id")))
+		})
+		api.Alias("GET /people/{id}", "GET /users/{id}")
+	})
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/api/people/5", nil))
+	if rec.Body.String() != "user 5" {
+		t.Errorf("Expected body 'user 5', got '%s'", rec.Body.String())
+	}
+	if got := strings.Join(rec.Header().Values("X-Order"), ","); got != "api" {
+		t.Errorf("Expected alias to apply canonical middleware, got '%s'", got)
+	}
+}
+
+func TestAliasRedirect(t *testing.T) {
+	mux := chain.New()
+	mux.HandleFunc("/users/{id}/files/{path...}", func(w http.ResponseWriter, r *http.Request) {})
+	mux.AliasRedirect("/v1/people/{id}/files/{path...}", "/users/{id}/files/{path...}")
+
+	tests := []struct {
+		method   string
+		url      string
+		status   int
+		location string
+	}{
+		{"GET", "/v1/people/5/files/a/b.txt?dl=1", http.StatusMovedPermanently, "/users/5/files/a/b.txt?dl=1"},
+		{"POST", "/v1/people/5/files/c", http.StatusPermanentRedirect, "/users/5/files/c"},
+		{"GET", "/v1/people/a%2Fb%3Fx/files/c%23d/e", http.StatusMovedPermanently, "/users/a%2Fb%3Fx/files/c%23d/e"},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.url, nil))
+		if rec.Code != tt.status {
+			t.Errorf("%s %s: expected status %d, got %d", tt.method, tt.url, tt.status, rec.Code)
+		}
+		if got := rec.Header().Get("Location"); got != tt.location {
+			t.Errorf("%s %s: expected Location '%s', got '%s'", tt.method, tt.url, tt.location, got)
+		}
+	}
+}
+
+func TestAliasUnknownCanonicalPanics(t *testing.T) {
+	defer func() {
+		r := recover()
+		msg, ok := r.(string)
+		if !ok || msg != "chain: unknown canonical pattern GET /missing passed to Alias" {
+			t.Fatalf("Unexpected panic '%v'", r)
+		}
+	}()
+
+	chain.New().Alias("GET /old", "GET /missing")
+}
+
+func TestAliasRedirectOpenRedirect(t *testing.T) {
+	mux := chain.New()
+	mux.HandleFunc("/{path...}", func(w http.ResponseWriter, r *http.Request) {})
+	mux.AliasRedirect("/old/{path...}", "/{path...}")
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/old/%2Fevil.com", nil))
+	if rec.Code != http.StatusBadRequest || rec.Header().Get("Location") != "" {
+		t.Errorf("Expected protocol-relative redirect refused, got %d %q", rec.Code, rec.Header().Get("Location"))
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/old/docs/a.txt", nil))
+	if got := rec.Header().Get("Location"); got != "/docs/a.txt" {
+		t.Errorf("Expected redirect to /docs/a.txt, got %q", got)
+	}
+}
@@ -0,0 +1,90 @@
+package chain
+
+import (
+	"net/http"
+)
+
+// Requirements describes the roles and permissions a route demands.
+// A request must satisfy every listed role and every listed permission.
+type Requirements struct {
+	Roles       []string
+	Permissions []string
+}
+
+// Authorizer decides whether a request satisfies a route's declared requirements.
+// Implementations typically read the authenticated principal from the request
+// context, as placed there by authentication middleware.
+type Authorizer interface {
+	Authorize(r *http.Request, req Requirements) bool
+}
+
+// AuthorizerFunc adapts an ordinary function to the Authorizer interface.
+type AuthorizerFunc func(r *http.Request, req Requirements) bool
+
+// Authorize calls f(r, req).
+func (f AuthorizerFunc) Authorize(r *http.Request, req Requirements) bool {
+	return f(r, req)
+}
+
+// WithAuthorizer sets the Authorizer used to check role and permission requirements.
+// The authorizer is shared by the whole router, so it may be set from any group.
+// Routes that declare requirements while no authorizer is configured are denied.
+// Returns the Mux instance for chaining.
+func (m *Mux) WithAuthorizer(authz Authorizer) *Mux {
+	m.root.authorizer = authz
+	return m
+}
+
+// WithForbidden sets a custom handler for 403 Forbidden responses produced when
+// authorization fails. Returns the Mux instance for chaining.
+func (m *Mux) WithForbidden(handler http.Handler) *Mux {
+	m.root.forbidden = handler
+	return m
+}
+
+// RequireRole declares roles that routes registered on this Mux require.
+// Like middleware, requirements apply to routes registered afterwards and are
+// inherited by nested groups. Returns the Mux instance for method chaining.
+func (m *Mux) RequireRole(roles ...string) *Mux {
+	m.required.Roles = append(m.required.Roles, roles...)
+	return m
+}
+
+// RequirePermission declares permissions that routes registered on this Mux require.
+// Like middleware, requirements apply to routes registered afterwards and are
+// inherited by nested groups. Returns the Mux instance for method chaining.
+func (m *Mux) RequirePermission(perms ...string) *Mux {
+	m.required.Permissions = append(m.required.Permissions, perms...)
+	return m
+}
+
+// authorize wraps handler with a check of the requirements declared so far.
+// Handlers without requirements are returned unchanged.
+func (m *Mux) authorize(handler http.Handler) http.Handler {
+	if len(m.required.Roles) == 0 && len(m.required.Permissions) == 0 {
+		return handler
+	}
+	req := m.required.clone()
+	root := m.root
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The authorizer is looked up per request so WithAuthorizer may be
+		// called after routes are registered
+		if root.authorizer == nil || !root.authorizer.Authorize(r, req) {
+			if root.forbidden != nil {
+				root.forbidden.ServeHTTP(w, r)
+				return
+			}
+			WriteError(w, r, http.StatusForbidden, "")
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// clone returns a copy of req that does not share backing arrays.
+func (req Requirements) clone() Requirements {
+	return Requirements{
+		Roles:       append([]string(nil), req.Roles...),
+		Permissions: append([]string(nil), req.Permissions...),
+	}
+}
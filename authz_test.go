@@ -0,0 +1,90 @@
+package chain_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/jpl-au/chain"
+)
+
+type principalKey struct{}
+
+// roleAuthorizer grants access when the principal's roles cover every requirement.
+var roleAuthorizer = chain.AuthorizerFunc(func(r *http.Request, req chain.Requirements) bool {
+	roles, _ := r.Context().Value(principalKey{}).([]string)
+	for _, role := range append(req.Roles, req.Permissions...) {
+		if !slices.Contains(roles, role) {
+			return false
+		}
+	}
+	return true
+})
+
+// withRoles returns middleware that authenticates the request with the given roles.
+func withRoles(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), principalKey{}, roles)))
+		})
+	}
+}
+
+func TestRequireRole(t *testing.T) {
+	tests := []struct {
+		name   string
+		roles  []string
+		path   string
+		status int
+	}{
+		{"public route", nil, "/public", http.StatusOK},
+		{"admin allowed", []string{"admin"}, "/admin", http.StatusOK},
+		{"admin denied", []string{"user"}, "/admin", http.StatusForbidden},
+		{"permission allowed", []string{"admin", "orders:write"}, "/admin/orders", http.StatusOK},
+		{"permission denied", []string{"admin"}, "/admin/orders", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := chain.New().WithAuthorizer(roleAuthorizer)
+			mux.Use(withRoles(tt.roles...))
+			mux.HandleFunc("GET /public", func(w http.ResponseWriter, r *http.Request) {})
+			mux.Group(func(admin *chain.Mux) {
+				admin.RequireRole("admin")
+				admin.HandleFunc("GET /admin", func(w http.ResponseWriter, r *http.Request) {})
+				admin.Group(func(orders *chain.Mux) {
+					orders.RequirePermission("orders:write")
+					orders.HandleFunc("GET /admin/orders", func(w http.ResponseWriter, r *http.Request) {})
+				})
+			})
+
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest("GET", tt.path, nil))
+			if rec.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, rec.Code)
+			}
+		})
+	}
+}
+
+func TestRequireRoleWithoutAuthorizerDenies(t *testing.T) {
+	mux := chain.New().
+		WithForbidden(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte("Custom 403"))
+		}))
+	mux.Group(func(g *chain.Mux) {
+		g.RequireRole("admin").HandleFunc("GET /admin", func(w http.ResponseWriter, r *http.Request) {})
+	})
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/admin", nil))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403, got %d", rec.Code)
+	}
+	if rec.Body.String() != "Custom 403" {
+		t.Errorf("Expected body 'Custom 403', got '%s'", rec.Body.String())
+	}
+}
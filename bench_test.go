@@ -0,0 +1,125 @@
+package chain_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jpl-au/chain"
+)
+
+// discardWriter is a reusable http.ResponseWriter so that benchmarks measure the
+// router rather than the recorder.
+type discardWriter struct{ header http.Header }
+
+func (w *discardWriter) Header() http.Header         { return w.header }
+func (w *discardWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (w *discardWriter) WriteHeader(int)             {}
+
+// noop is a handler that does no work of its own.
+func noop(w http.ResponseWriter, r *http.Request) {}
+
+// passthrough is middleware that does no work of its own.
+func passthrough(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r)
+	})
+}
+
+// benchRouters are the router configurations covered by the performance budget,
+// each with the request it serves.
+var benchRouters = []struct {
+	name   string
+	router func() http.Handler
+	path   string
+}{
+	{"stdlib", func() http.Handler {
+		mux := http.NewServeMux()
+		mux.HandleFunc("GET /users/{id}", noop)
+		return mux
+	}, "/users/42"},
+	{"plain", func() http.Handler {
+		return chain.New().HandleFunc("GET /users/{id}", noop)
+	}, "/users/42"},
+	{"middleware10", func() http.Handler {
+		mux := chain.New()
+		for range 10 {
+			mux.Use(passthrough)
+		}
+		return mux.HandleFunc("GET /users/{id}", noop)
+	}, "/users/42"},
+	{"prefixed", func() http.Handler {
+		mux := chain.New()
+		mux.Route("/api", func(api *chain.Mux) {
+			api.Route("/v1", func(v1 *chain.Mux) {
+				v1.HandleFunc("GET /users/{id}", noop)
+			})
+		})
+		return mux
+	}, "/api/v1/users/42"},
+	{"custom404", func() http.Handler {
+		return chain.New().
+			WithNotFound(http.HandlerFunc(noop)).
+			HandleFunc("GET /users/{id}", noop)
+	}, "/users/42"},
+	{"zeroalloc", func() http.Handler {
+		mux := chain.New().WithZeroAlloc()
+		for range 10 {
+			mux.Use(passthrough)
+		}
+		return mux.HandleFunc("GET /users/{id}", noop)
+	}, "/users/42"},
+	{"notfound", func() http.Handler {
+		return chain.New().HandleFunc("GET /users/{id}", noop)
+	}, "/missing"},
+	{"notfound-custom", func() http.Handler {
+		return chain.New().
+			WithNotFound(http.HandlerFunc(noop)).
+			HandleFunc("GET /users/{id}", noop)
+	}, "/missing"},
+}
+
+func BenchmarkServeHTTP(b *testing.B) {
+	for _, bb := range benchRouters {
+		b.Run(bb.name, func(b *testing.B) {
+			h := bb.router()
+			r := httptest.NewRequest("GET", bb.path, nil)
+			w := &discardWriter{header: make(http.Header)}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for range b.N {
+				h.ServeHTTP(w, r)
+				clear(w.header)
+			}
+		})
+	}
+}
+
+// allocBudget is the most heap allocations per request allowed for each router
+// in benchRouters, as documented in the README's performance budget. Raising a
+// figure needs a reason in the change that does it.
+var allocBudget = map[string]float64{
+	"stdlib":          1,
+	"plain":           4,
+	"middleware10":    4,
+	"prefixed":        4,
+	"custom404":       4,
+	"zeroalloc":       1,
+	"notfound":        15,
+	"notfound-custom": 15,
+}
+
+func TestAllocationBudget(t *testing.T) {
+	for _, bb := range benchRouters {
+		h := bb.router()
+		r := httptest.NewRequest("GET", bb.path, nil)
+		w := &discardWriter{header: make(http.Header)}
+		allocs := testing.AllocsPerRun(100, func() {
+			h.ServeHTTP(w, r)
+			clear(w.header)
+		})
+		if budget := allocBudget[bb.name]; allocs > budget {
+			t.Errorf("%s: %v allocs per request, budget %v", bb.name, allocs, budget)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package chain
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"net/url"
+	"reflect"
+)
+
+// Errors returned by Bind.
+var (
+	// ErrUnsupportedMediaType is returned for request bodies in a format Bind
+	// cannot decode. Handlers typically respond with 415.
+	ErrUnsupportedMediaType = errors.New("chain: unsupported media type")
+	// ErrXMLDoctype is returned for XML bodies containing a document type
+	// declaration, which could define entities expanding to huge documents.
+	ErrXMLDoctype = errors.New("chain: XML document type declarations are not allowed")
+)
+
+// bindLimit caps the size of request bodies decoded by Bind.
+const bindLimit = 1 << 20
+
+// Bind decodes the request body into v with the codec matching its Content-Type,
+// from those set with WithCodecs: by default JSON for application/json or no
+// Content-Type, and XML for application/xml and text/xml. Bodies larger than
+// 1 MiB are rejected with an *http.MaxBytesError, and ErrUnsupportedMediaType is
+// returned for other formats. Handlers needing a lower limit can wrap r.Body with
+// http.MaxBytesReader first.
+//
+// URL-encoded and multipart forms are decoded into the struct v points to. Keys
+// address nested structs with dots and slice elements with brackets, as in
+// "items[0].name", and repeated keys fill slices. Fields match their form tag or
+// their name ignoring case; time.Time fields are parsed with the layout in their
+// layout tag, defaulting to RFC 3339:
+//
+//	type Order struct {
+//		Customer string    `form:"customer"`
+//		Due      time.Time `form:"due" layout:"2006-01-02"`
+//		Items    []struct {
+//			Name string `form:"name"`
+//			Qty  *int   `form:"qty"`
+//		} `form:"items"`
+//	}
+//
+// Values that cannot be converted are returned as ValidationErrors keyed by the
+// full path, ready for WriteValidationErrors. Uploaded files are ignored; use
+// Upload to stream them.
+//
+// Fields of the struct v points to, and of its embedded structs, can also be
+// filled from the request itself: those tagged path from r.PathValue, query from
+// the URL query and header from the request headers, converted as form values
+// are. These are set after the body is decoded, so they take precedence over it,
+// and a request without a body is not an error for a struct that has them:
+//
+//	type UpdateOrder struct {
+//		ID      int       `path:"id"`
+//		DryRun  bool      `query:"dry_run"`
+//		IfMatch string    `header:"If-Match"`
+//		Items   []string  `json:"items"`
+//	}
+//
+// Values that cannot be converted are returned as ValidationErrors keyed by the
+// tag's name.
+func Bind(w http.ResponseWriter, r *http.Request, v any) error {
+	params := hasParamFields(v)
+	if !params || r.ContentLength != 0 {
+		if err := bindBody(w, r, v); err != nil {
+			return err
+		}
+	}
+	if !params {
+		return nil
+	}
+	var errs ValidationErrors
+	bindParams(r, r.URL.Query(), reflect.ValueOf(v).Elem(), &errs)
+	return errs.Err()
+}
+
+// bindBody decodes the request body into v for Bind.
+func bindBody(w http.ResponseWriter, r *http.Request, v any) error {
+	mediaType := "application/json"
+	if ct := r.Header.Get("Content-Type"); ct != "" {
+		var err error
+		if mediaType, _, err = mime.ParseMediaType(ct); err != nil {
+			return ErrUnsupportedMediaType
+		}
+	}
+
+	switch mediaType {
+	case "application/x-www-form-urlencoded":
+		r.Body = http.MaxBytesReader(w, r.Body, bindLimit)
+		if err := r.ParseForm(); err != nil {
+			return err
+		}
+		return decodeForm(r.PostForm, v)
+	case "multipart/form-data":
+		r.Body = http.MaxBytesReader(w, r.Body, bindLimit)
+		if err := r.ParseMultipartForm(bindLimit); err != nil {
+			return err
+		}
+		return decodeForm(r.MultipartForm.Value, v)
+	}
+
+	codec, ok := codecForType(codecsFor(w), mediaType)
+	if !ok {
+		return ErrUnsupportedMediaType
+	}
+	return codec.Decode(http.MaxBytesReader(w, r.Body, bindLimit), v)
+}
+
+// paramTags are the struct tags naming the request values Bind sets outside the
+// body.
+var paramTags = [...]string{"path", "query", "header"}
+
+// hasParamFields reports whether v points to a struct with fields tagged path,
+// query or header.
+func hasParamFields(v any) bool {
+	t := reflect.TypeOf(v)
+	if t == nil || t.Kind() != reflect.Pointer || t.Elem().Kind() != reflect.Struct {
+		return false
+	}
+	return structHasParams(t.Elem())
+}
+
+// structHasParams reports whether struct type t, or a struct it embeds, has
+// fields tagged path, query or header.
+func structHasParams(t reflect.Type) bool {
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct && structHasParams(f.Type) {
+			return true
+		}
+		for _, tag := range paramTags {
+			if f.IsExported() && f.Tag.Get(tag) != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// bindParams sets the fields of struct v tagged path, query or header from r,
+// recording values that cannot be converted in errs.
+func bindParams(r *http.Request, query url.Values, v reflect.Value, errs *ValidationErrors) {
+	t := v.Type()
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			bindParams(r, query, v.Field(i), errs)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+
+		var name string
+		var values []string
+		if name = f.Tag.Get("path"); name != "" {
+			if value := r.PathValue(name); value != "" {
+				values = []string{value}
+			}
+		} else if name = f.Tag.Get("query"); name != "" {
+			values = query[name]
+		} else if name = f.Tag.Get("header"); name != "" {
+			values = r.Header.Values(name)
+		}
+		if len(values) == 0 {
+			continue
+		}
+		if msg := setFormScalars(v.Field(i), values, f.Tag.Get("layout")); msg != "" {
+			errs.Add(name, "type", "", msg)
+		}
+	}
+}
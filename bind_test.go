@@ -0,0 +1,148 @@
+package chain_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jpl-au/chain"
+)
+
+type order struct {
+	ID    int    `json:"id" xml:"id,attr"`
+	Items string `json:"items" xml:"items"`
+}
+
+func TestBind(t *testing.T) {
+	tests := []struct {
+		name, contentType, body string
+		want                    order
+		err                     error
+	}{
+		{"json", "application/json", `{"id":1,"items":"tea"}`, order{1, "tea"}, nil},
+		{"no content type", "", `{"id":2}`, order{ID: 2}, nil},
+		{"xml", "application/xml; charset=utf-8", `<?xml version="1.0"?><order id="3"><items>cake</items></order>`, order{3, "cake"}, nil},
+		{"text xml", "text/xml", `<order id="4"></order>`, order{ID: 4}, nil},
+		{"doctype", "application/xml", `<?xml version="1.0"?><!DOCTYPE lolz [<!ENTITY lol "lol">]><order id="5"></order>`, order{}, chain.ErrXMLDoctype},
+		{"unsupported", "text/plain", `id=6`, order{}, chain.ErrUnsupportedMediaType},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("POST", "/orders", strings.NewReader(tt.body))
+		if tt.contentType != "" {
+			req.Header.Set("Content-Type", tt.contentType)
+		}
+		var got order
+		err := chain.Bind(httptest.NewRecorder(), req, &got)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s: expected error %v, got %v", tt.name, tt.err, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: expected %+v, got %+v", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestBindLimit(t *testing.T) {
+	body := `<order><items>` + strings.Repeat("x", 2<<20) + `</items></order>`
+	req := httptest.NewRequest("POST", "/orders", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/xml")
+	var got order
+	var maxErr *http.MaxBytesError
+	if err := chain.Bind(httptest.NewRecorder(), req, &got); !errors.As(err, &maxErr) {
+		t.Errorf("Expected MaxBytesError, got %v", err)
+	}
+}
+
+func TestRespond(t *testing.T) {
+	tests := []struct {
+		accept, contentType string
+	}{
+		{"", "application/json"},
+		{"application/xml", "application/xml; charset=utf-8"},
+		{"text/xml;q=0.9, application/json", "application/json"},
+		{"application/json;q=0.5, application/xml", "application/xml; charset=utf-8"},
+		{"text/html, */*;q=0.8", "application/json"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/orders/1", nil)
+		req.Header.Set("Accept", tt.accept)
+		rec := httptest.NewRecorder()
+		if err := chain.Respond(rec, req, http.StatusOK, order{1, "tea"}); err != nil {
+			t.Fatal(err)
+		}
+		if ct := rec.Header().Get("Content-Type"); ct != tt.contentType {
+			t.Errorf("Accept %q: expected %s, got %s", tt.accept, tt.contentType, ct)
+		}
+		if rec.Header().Get("Vary") != "Accept" {
+			t.Errorf("Accept %q: expected Vary: Accept", tt.accept)
+		}
+	}
+}
+
+func TestXML(t *testing.T) {
+	rec := httptest.NewRecorder()
+	chain.XML(rec, http.StatusCreated, order{7, "scones"})
+	want := `<?xml version="1.0" encoding="UTF-8"?>` + "\n" + `<order id="7"><items>scones</items></order>`
+	if rec.Code != http.StatusCreated || rec.Body.String() != want {
+		t.Errorf("Expected 201 %q, got %d %q", want, rec.Code, rec.Body.String())
+	}
+}
+
+type Paging struct {
+	Page int `query:"page"`
+}
+
+type updateOrder struct {
+	Paging
+	ID      int       `path:"id"`
+	Tags    []string  `query:"tag"`
+	Since   time.Time `query:"since" layout:"2006-01-02"`
+	IfMatch string    `header:"If-Match"`
+	Items   string    `json:"items"`
+}
+
+func TestBindParams(t *testing.T) {
+	var got updateOrder
+	var err error
+	mux := chain.New()
+	mux.HandleFunc("/orders/{id}", func(w http.ResponseWriter, r *http.Request) {
+		got = updateOrder{}
+		err = chain.Bind(w, r, &got)
+	})
+
+	req := httptest.NewRequest("PUT", "/orders/7?tag=a&tag=b&page=2&since=2024-05-01", strings.NewReader(`{"id":99,"items":"tea"}`))
+	req.Header.Set("If-Match", `"v1"`)
+	mux.ServeHTTP(httptest.NewRecorder(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := updateOrder{
+		Paging:  Paging{Page: 2},
+		ID:      7,
+		Tags:    []string{"a", "b"},
+		Since:   time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+		IfMatch: `"v1"`,
+		Items:   "tea",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+
+	// Without a body, only the request values are bound
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/orders/8", nil))
+	if err != nil || got.ID != 8 {
+		t.Errorf("Expected ID bound without a body, got %+v, %v", got, err)
+	}
+
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/orders/x?page=y", nil))
+	var verrs chain.ValidationErrors
+	if !errors.As(err, &verrs) || len(verrs) != 2 || verrs[0].Field != "page" || verrs[1].Field != "id" {
+		t.Errorf("Expected validation errors for page and id, got %v", err)
+	}
+}
@@ -0,0 +1,54 @@
+package chain
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+)
+
+// BufferBody reads r's body into memory and installs a fresh reader over it as
+// r.Body, along with a GetBody that returns further copies, so that several
+// readers, such as signature verification, Bind and RetryTransport, can each
+// consume the body in turn. It returns the body, which must not be modified.
+// Calling BufferBody again on the same request returns the same bytes without
+// reading the network again.
+//
+// Bodies larger than limit, or 1 MiB if limit is zero or negative, are rejected
+// with an *http.MaxBytesError; r.Body is then left readable from the start, so a
+// later handler can still stream it. A request without a body returns nil.
+func BufferBody(r *http.Request, limit int64) ([]byte, error) {
+	if r == nil {
+		panic("chain: nil request passed to BufferBody")
+	}
+	if limit <= 0 {
+		limit = bindLimit
+	}
+	if r.GetBody != nil {
+		// Already buffered, or replayable as built by http.NewRequest
+		rc, err := r.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		r.Body = rc
+	}
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > limit {
+		r.Body = readCloser{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+		return nil, &http.MaxBytesError{Limit: limit}
+	}
+	r.Body.Close()
+
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	r.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	r.ContentLength = int64(len(body))
+	return body, nil
+}
@@ -0,0 +1,73 @@
+package chain_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jpl-au/chain"
+)
+
+func TestBufferBody(t *testing.T) {
+	var verified, bound string
+	verify := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, err := chain.BufferBody(r, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			verified = string(body)
+			next.ServeHTTP(w, r)
+		})
+	}
+	mux := chain.New().Use(verify)
+	mux.HandleFunc("POST /orders", func(w http.ResponseWriter, r *http.Request) {
+		var o struct{ ID int }
+		if err := chain.Bind(w, r, &o); err != nil {
+			t.Fatal(err)
+		}
+		again, _ := chain.BufferBody(r, 0)
+		replayed, _ := r.GetBody()
+		b, _ := io.ReadAll(replayed)
+		bound = string(again) + "|" + string(b)
+		if r.ContentLength != 8 {
+			t.Errorf("Expected ContentLength 8, got %d", r.ContentLength)
+		}
+	})
+
+	// The body is sent chunked, so it has no GetBody or ContentLength to start with
+	req := httptest.NewRequest("POST", "/orders", io.NopCloser(strings.NewReader(`{"ID":7}`)))
+	req.ContentLength = -1
+	mux.ServeHTTP(httptest.NewRecorder(), req)
+
+	if verified != `{"ID":7}` {
+		t.Errorf("Expected verifier to read body, got '%s'", verified)
+	}
+	if bound != `{"ID":7}|{"ID":7}` {
+		t.Errorf("Expected body to be re-readable after Bind, got '%s'", bound)
+	}
+}
+
+func TestBufferBodyLimit(t *testing.T) {
+	req := httptest.NewRequest("POST", "/", strings.NewReader("0123456789"))
+	_, err := chain.BufferBody(req, 4)
+	var tooLarge *http.MaxBytesError
+	if !errors.As(err, &tooLarge) || tooLarge.Limit != 4 {
+		t.Fatalf("Expected MaxBytesError with limit 4, got %v", err)
+	}
+	rest, _ := io.ReadAll(req.Body)
+	if string(rest) != "0123456789" {
+		t.Errorf("Expected the body to remain readable from the start, got '%s'", rest)
+	}
+}
+
+func TestBufferBodyEmpty(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	body, err := chain.BufferBody(req, 0)
+	if body != nil || err != nil {
+		t.Errorf("Expected nil body and error, got %q, %v", body, err)
+	}
+}
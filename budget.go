@@ -0,0 +1,147 @@
+package chain
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// ErrBudgetExhausted is returned by Spend, Call and clients from Client when the
+// request's budget cannot cover a downstream call.
+var ErrBudgetExhausted = errors.New("chain: request budget exhausted")
+
+// BudgetOptions configures the Budget middleware.
+type BudgetOptions struct {
+	// Latency is the time the route has to respond. The request's context expires
+	// when it runs out. Zero leaves the deadline unchanged.
+	Latency time.Duration
+	// Cost is the number of cost units, in whatever measure the application
+	// chooses, that downstream calls may spend. Zero means unlimited.
+	Cost int64
+	// Reserve is held back from the deadline of downstream calls made through Call
+	// and Client, leaving the handler time to respond when they run out.
+	Reserve time.Duration
+}
+
+// budgetKey is the context key for the request's budget.
+type budgetKey struct{}
+
+// RequestBudget is the latency and cost budget of a request, shared by the
+// downstream calls made while serving it. It is safe for concurrent use.
+type RequestBudget struct {
+	deadline time.Time
+	reserve  time.Duration
+	limited  bool
+	cost     atomic.Int64
+}
+
+// Budget returns middleware that assigns a budget to each request: a deadline
+// Latency from now and a number of cost units. Downstream calls made through
+// Call, or through clients returned by Client, are charged against the cost and
+// given a deadline Reserve ahead of the route's, so that a slow dependency cannot
+// use up the time needed to respond. If the deadline passes before the handler
+// has responded, the request receives 503 Service Unavailable once the handler
+// returns.
+func Budget(opts BudgetOptions) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			b := &RequestBudget{reserve: opts.Reserve, limited: opts.Cost > 0}
+			b.cost.Store(opts.Cost)
+			if opts.Latency > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, opts.Latency)
+				defer cancel()
+			}
+			b.deadline, _ = ctx.Deadline()
+			ctx = context.WithValue(ctx, budgetKey{}, b)
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				if rw := findResponseWriter(w); rw != nil && !rw.written && !rw.hijacked {
+					WriteError(w, r, http.StatusServiceUnavailable, "")
+				}
+			}
+		})
+	}
+}
+
+// BudgetFrom returns the budget assigned by Budget, reporting false if the
+// middleware did not run.
+func BudgetFrom(ctx context.Context) (*RequestBudget, bool) {
+	b, ok := ctx.Value(budgetKey{}).(*RequestBudget)
+	return b, ok
+}
+
+// Deadline returns the time by which the request must be answered, reporting
+// false if it has none.
+func (b *RequestBudget) Deadline() (time.Time, bool) {
+	return b.deadline, !b.deadline.IsZero()
+}
+
+// Cost returns the cost units left, or -1 if cost is unlimited.
+func (b *RequestBudget) Cost() int64 {
+	if !b.limited {
+		return -1
+	}
+	return b.cost.Load()
+}
+
+// Spend charges cost units to the budget, returning ErrBudgetExhausted without
+// charging anything if fewer remain.
+func (b *RequestBudget) Spend(cost int64) error {
+	if !b.limited || cost <= 0 {
+		return nil
+	}
+	for {
+		left := b.cost.Load()
+		if left < cost {
+			return ErrBudgetExhausted
+		}
+		if b.cost.CompareAndSwap(left, left-cost) {
+			return nil
+		}
+	}
+}
+
+// callContext charges cost and derives the context for a downstream call, whose
+// deadline is Reserve ahead of the request's.
+func (b *RequestBudget) callContext(ctx context.Context, cost int64) (context.Context, context.CancelFunc, error) {
+	if err := b.Spend(cost); err != nil {
+		return nil, nil, err
+	}
+	if b.deadline.IsZero() {
+		ctx, cancel := context.WithCancel(ctx)
+		return ctx, cancel, nil
+	}
+	deadline := b.deadline.Add(-b.reserve)
+	if !time.Now().Before(deadline) {
+		return nil, nil, ErrBudgetExhausted
+	}
+	ctx, cancel := context.WithDeadline(ctx, deadline)
+	return ctx, cancel, nil
+}
+
+// Call runs fn, a downstream call such as a database query, within the budget of
+// the request served with ctx. cost units are charged up front and fn's context
+// expires Reserve ahead of the request's deadline. Call returns
+// ErrBudgetExhausted without running fn if the budget cannot cover it, and simply
+// runs fn if Budget did not run:
+//
+//	err := chain.Call(r.Context(), 5, func(ctx context.Context) error {
+//		return db.QueryRowContext(ctx, query, id).Scan(&user)
+//	})
+func Call(ctx context.Context, cost int64, fn func(context.Context) error) error {
+	b, ok := BudgetFrom(ctx)
+	if !ok {
+		return fn(ctx)
+	}
+	ctx, cancel, err := b.callContext(ctx, cost)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+	return fn(ctx)
+}
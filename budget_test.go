@@ -0,0 +1,106 @@
+package chain_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jpl-au/chain"
+)
+
+func TestBudgetCost(t *testing.T) {
+	mux := chain.New()
+	mux.Use(chain.Budget(chain.BudgetOptions{Cost: 10}))
+	mux.HandleFunc("GET /", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		var calls int
+		call := func(context.Context) error { calls++; return nil }
+
+		if err := chain.Call(ctx, 6, call); err != nil {
+			t.Errorf("first call: %v", err)
+		}
+		if err := chain.Call(ctx, 6, call); !errors.Is(err, chain.ErrBudgetExhausted) {
+			t.Errorf("second call = %v, want ErrBudgetExhausted", err)
+		}
+		if calls != 1 {
+			t.Errorf("fn ran %d times, want 1", calls)
+		}
+		b, _ := chain.BudgetFrom(ctx)
+		if b.Cost() != 4 {
+			t.Errorf("Cost = %d, want 4", b.Cost())
+		}
+	})
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+}
+
+func TestBudgetDeadline(t *testing.T) {
+	mux := chain.New()
+	mux.Use(chain.Budget(chain.BudgetOptions{Latency: time.Second, Reserve: 200 * time.Millisecond}))
+	mux.HandleFunc("GET /", func(w http.ResponseWriter, r *http.Request) {
+		b, _ := chain.BudgetFrom(r.Context())
+		if _, ok := b.Deadline(); !ok {
+			t.Error("budget has no deadline")
+		}
+		if b.Cost() != -1 {
+			t.Errorf("Cost = %d, want -1 for unlimited", b.Cost())
+		}
+		chain.Call(r.Context(), 0, func(ctx context.Context) error {
+			callDeadline, _ := ctx.Deadline()
+			routeDeadline, _ := r.Context().Deadline()
+			if gap := routeDeadline.Sub(callDeadline); gap != 200*time.Millisecond {
+				t.Errorf("call deadline %v ahead of route, want 200ms", gap)
+			}
+			return nil
+		})
+	})
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+}
+
+func TestBudgetExpired(t *testing.T) {
+	mux := chain.New()
+	mux.Use(chain.Budget(chain.BudgetOptions{Latency: 10 * time.Millisecond, Reserve: 5 * time.Millisecond}))
+	mux.HandleFunc("GET /", func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		err := chain.Call(r.Context(), 0, func(context.Context) error { return nil })
+		if !errors.Is(err, chain.ErrBudgetExhausted) {
+			t.Errorf("call after deadline = %v, want ErrBudgetExhausted", err)
+		}
+	})
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want 503", rec.Code)
+	}
+}
+
+func TestBudgetClient(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer upstream.Close()
+
+	mux := chain.New()
+	mux.Use(chain.Budget(chain.BudgetOptions{Cost: 3}))
+	mux.HandleFunc("GET /", func(w http.ResponseWriter, r *http.Request) {
+		client := chain.Client(r, chain.ClientOptions{Cost: 2})
+		resp, err := client.Get(upstream.URL)
+		if err != nil {
+			t.Fatalf("first request: %v", err)
+		}
+		resp.Body.Close()
+		if _, err := client.Get(upstream.URL); !errors.Is(err, chain.ErrBudgetExhausted) {
+			t.Errorf("second request = %v, want ErrBudgetExhausted", err)
+		}
+	})
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+}
+
+func TestCallWithoutBudget(t *testing.T) {
+	var ran bool
+	err := chain.Call(context.Background(), 100, func(context.Context) error { ran = true; return nil })
+	if err != nil || !ran {
+		t.Errorf("Call without budget = %v, ran %v", err, ran)
+	}
+}
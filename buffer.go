@@ -0,0 +1,71 @@
+package chain
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+)
+
+// bufferedResponse is an http.ResponseWriter that captures the status, headers and
+// body of a response in memory so that middleware can inspect or rewrite them
+// before anything is sent to the client.
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+// newBufferedResponse returns a bufferedResponse whose headers start as a copy of h.
+func newBufferedResponse(h http.Header) *bufferedResponse {
+	return &bufferedResponse{header: h.Clone()}
+}
+
+// Header returns the buffered response headers.
+func (b *bufferedResponse) Header() http.Header {
+	return b.header
+}
+
+// WriteHeader records the status code. Only the first call has an effect.
+func (b *bufferedResponse) WriteHeader(status int) {
+	if b.status == 0 {
+		b.status = status
+	}
+}
+
+// Write appends p to the buffered body.
+func (b *bufferedResponse) Write(p []byte) (int, error) {
+	if b.status == 0 {
+		b.status = http.StatusOK
+	}
+	return b.body.Write(p)
+}
+
+// Status returns the recorded status code, defaulting to 200 OK.
+func (b *bufferedResponse) Status() int {
+	if b.status == 0 {
+		return http.StatusOK
+	}
+	return b.status
+}
+
+// writeTo sends the buffered response to w with the given body, replacing any
+// headers already set on w and recalculating Content-Length.
+func (b *bufferedResponse) writeTo(w http.ResponseWriter, body []byte) {
+	h := w.Header()
+	for k := range h {
+		delete(h, k)
+	}
+	for k, v := range b.header {
+		h[k] = v
+	}
+	if bodyAllowed(b.Status()) {
+		h.Set("Content-Length", strconv.Itoa(len(body)))
+	}
+	w.WriteHeader(b.Status())
+	w.Write(body)
+}
+
+// bodyAllowed reports whether a response with the given status may carry a body.
+func bodyAllowed(status int) bool {
+	return status >= 200 && status != http.StatusNoContent && status != http.StatusNotModified
+}
@@ -0,0 +1,101 @@
+package chain
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CachePolicy builds a Cache-Control header value. Create one with CacheControl
+// and chain directive methods:
+//
+//	chain.CacheControl().Public().MaxAge(time.Hour).StaleWhileRevalidate(time.Minute)
+type CachePolicy struct {
+	directives []string
+}
+
+// CacheControl returns an empty CachePolicy.
+func CacheControl() *CachePolicy {
+	return &CachePolicy{}
+}
+
+// Public adds the public directive.
+func (p *CachePolicy) Public() *CachePolicy { return p.add("public") }
+
+// Private adds the private directive.
+func (p *CachePolicy) Private() *CachePolicy { return p.add("private") }
+
+// NoCache adds the no-cache directive.
+func (p *CachePolicy) NoCache() *CachePolicy { return p.add("no-cache") }
+
+// NoStore adds the no-store directive.
+func (p *CachePolicy) NoStore() *CachePolicy { return p.add("no-store") }
+
+// NoTransform adds the no-transform directive.
+func (p *CachePolicy) NoTransform() *CachePolicy { return p.add("no-transform") }
+
+// MustRevalidate adds the must-revalidate directive.
+func (p *CachePolicy) MustRevalidate() *CachePolicy { return p.add("must-revalidate") }
+
+// Immutable adds the immutable directive.
+func (p *CachePolicy) Immutable() *CachePolicy { return p.add("immutable") }
+
+// MaxAge adds the max-age directive, truncated to whole seconds.
+func (p *CachePolicy) MaxAge(d time.Duration) *CachePolicy { return p.seconds("max-age", d) }
+
+// SMaxAge adds the s-maxage directive for shared caches, truncated to whole seconds.
+func (p *CachePolicy) SMaxAge(d time.Duration) *CachePolicy { return p.seconds("s-maxage", d) }
+
+// StaleWhileRevalidate adds the stale-while-revalidate directive (RFC 5861).
+func (p *CachePolicy) StaleWhileRevalidate(d time.Duration) *CachePolicy {
+	return p.seconds("stale-while-revalidate", d)
+}
+
+// StaleIfError adds the stale-if-error directive (RFC 5861).
+func (p *CachePolicy) StaleIfError(d time.Duration) *CachePolicy {
+	return p.seconds("stale-if-error", d)
+}
+
+// String returns the Cache-Control header value.
+func (p *CachePolicy) String() string {
+	return strings.Join(p.directives, ", ")
+}
+
+// Middleware returns middleware that applies the policy to successful and
+// redirect responses that do not already set Cache-Control.
+func (p *CachePolicy) Middleware() func(http.Handler) http.Handler {
+	value := p.String()
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			OnWriteHeader(w, func(status int) {
+				// Error responses keep their own caching semantics
+				if status < 400 && w.Header().Get("Cache-Control") == "" {
+					w.Header().Set("Cache-Control", value)
+				}
+			})
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func (p *CachePolicy) add(directive string) *CachePolicy {
+	p.directives = append(p.directives, directive)
+	return p
+}
+
+func (p *CachePolicy) seconds(directive string, d time.Duration) *CachePolicy {
+	return p.add(directive + "=" + strconv.FormatInt(int64(d/time.Second), 10))
+}
+
+// CacheControl declares the cache policy for routes registered afterwards on this
+// Mux and its groups. A nested group can declare its own policy to override it.
+// The header is only added to responses below 400 that do not set Cache-Control
+// themselves. Returns the Mux instance for method chaining.
+func (m *Mux) CacheControl(policy *CachePolicy) *Mux {
+	if policy == nil {
+		panic("chain: nil policy passed to CacheControl")
+	}
+	m.cache = policy.Middleware()
+	return m
+}
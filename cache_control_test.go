@@ -0,0 +1,55 @@
+package chain_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jpl-au/chain"
+)
+
+func TestCachePolicyString(t *testing.T) {
+	got := chain.CacheControl().Public().MaxAge(time.Hour).StaleWhileRevalidate(30 * time.Second).String()
+	want := "public, max-age=3600, stale-while-revalidate=30"
+	if got != want {
+		t.Errorf("Expected '%s', got '%s'", want, got)
+	}
+}
+
+func TestMuxCacheControl(t *testing.T) {
+	mux := chain.New()
+	mux.Route("/static", func(static *chain.Mux) {
+		static.CacheControl(chain.CacheControl().Public().MaxAge(24 * time.Hour).Immutable())
+		static.HandleFunc("GET /app.js", func(w http.ResponseWriter, r *http.Request) {})
+		static.HandleFunc("GET /missing.js", func(w http.ResponseWriter, r *http.Request) {
+			http.NotFound(w, r)
+		})
+
+		static.Group(func(private *chain.Mux) {
+			private.CacheControl(chain.CacheControl().Private().NoStore())
+			private.HandleFunc("GET /me.json", func(w http.ResponseWriter, r *http.Request) {})
+		})
+	})
+	mux.HandleFunc("GET /custom", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-cache")
+	})
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/static/app.js", "public, max-age=86400, immutable"},
+		{"/static/missing.js", ""},
+		{"/static/me.json", "private, no-store"},
+		{"/custom", "no-cache"},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest("GET", tt.path, nil))
+		if got := rec.Header().Get("Cache-Control"); got != tt.want {
+			t.Errorf("%s: expected Cache-Control '%s', got '%s'", tt.path, tt.want, got)
+		}
+	}
+}
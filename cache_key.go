@@ -0,0 +1,59 @@
+package chain
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// CacheKeyQuery declares the query parameters that distinguish the responses of
+// routes registered afterwards on this Mux, for CacheKey. Other parameters, such
+// as tracking and cache-busting parameters, are left out of the key, and calling
+// CacheKeyQuery with no parameters leaves out the whole query. Routes without a
+// declaration key on every parameter.
+// Returns the Mux instance for method chaining.
+func (m *Mux) CacheKeyQuery(params ...string) *Mux {
+	m.cacheQuery = make([]string, len(params))
+	copy(m.cacheQuery, params)
+	return m
+}
+
+// CacheKey returns the canonical cache key of a request: its method, host and
+// the pattern of the route serving it, followed by the route's path wildcard
+// values and the query parameters significant to the route, declared with
+// CacheKeyQuery, in a normalised order. Requests that differ only in the order
+// of their query parameters, or in parameters the route ignores, share a key.
+// ResponseCache and MicroCache key on it by default, so custom middleware that
+// deduplicates or caches requests should use it too.
+//
+// As with RoutePattern, w must be the writer passed to the route's handler or
+// middleware. Before a route has matched, as in middleware registered with
+// UsePre, the key falls back to the method, host and request URI.
+func CacheKey(w http.ResponseWriter, r *http.Request) string {
+	rw := findResponseWriter(w)
+	if rw == nil || rw.pattern == "" {
+		return r.Method + " " + strings.ToLower(r.Host) + r.URL.RequestURI()
+	}
+
+	_, path, _ := splitPattern(rw.pattern)
+	var b strings.Builder
+	b.WriteString(r.Method + " " + strings.ToLower(r.Host) + path)
+	for i := 0; i+1 < len(rw.pathValues); i += 2 {
+		b.WriteString(" " + rw.pathValues[i] + "=" + url.QueryEscape(rw.pathValues[i+1]))
+	}
+
+	query := r.URL.Query()
+	if rw.cacheQuery != nil {
+		significant := make(url.Values, len(rw.cacheQuery))
+		for _, name := range rw.cacheQuery {
+			if v, ok := query[name]; ok {
+				significant[name] = v
+			}
+		}
+		query = significant
+	}
+	if len(query) > 0 {
+		b.WriteString(" ?" + query.Encode())
+	}
+	return b.String()
+}
@@ -0,0 +1,104 @@
+package chain_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jpl-au/chain"
+)
+
+func TestCacheKey(t *testing.T) {
+	var key string
+	h := func(w http.ResponseWriter, r *http.Request) { key = chain.CacheKey(w, r) }
+
+	mux := chain.New()
+	mux.HandleFunc("GET /users/{id}", h)
+	mux.Group(func(g *chain.Mux) {
+		g.CacheKeyQuery("page", "sort")
+		g.HandleFunc("GET /search", h)
+	})
+	mux.Group(func(g *chain.Mux) {
+		g.CacheKeyQuery()
+		g.HandleFunc("GET /static/{path...}", h)
+	})
+
+	tests := []struct {
+		target string
+		want   string
+	}{
+		{"/users/42", "GET example.com/users/{id} id=42"},
+		{"/users/42?b=2&a=1", "GET example.com/users/{id} id=42 ?a=1&b=2"},
+		{"/users/a%20b", "GET example.com/users/{id} id=a+b"},
+		{"/search?utm_source=x&sort=name&page=2", "GET example.com/search ?page=2&sort=name"},
+		{"/search?utm_source=x", "GET example.com/search"},
+		{"/static/css/app.css?v=123", "GET example.com/static/{path...} path=css%2Fapp.css"},
+	}
+	for _, tt := range tests {
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", tt.target, nil))
+		if key != tt.want {
+			t.Errorf("%s: got key %q, want %q", tt.target, key, tt.want)
+		}
+	}
+
+	if routes := mux.RouteTable(); routes[0].CacheQuery != nil || len(routes[1].CacheQuery) != 2 || routes[2].CacheQuery == nil {
+		t.Errorf("Unexpected CacheQuery in route table %+v", routes)
+	}
+}
+
+func TestCacheKeyUnmatched(t *testing.T) {
+	var key string
+	mux := chain.New()
+	mux.UsePre(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key = chain.CacheKey(w, r)
+			next.ServeHTTP(w, r)
+		})
+	})
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {})
+
+	req := httptest.NewRequest("GET", "/users/42?b=2&a=1", nil)
+	req.Host = "Example.COM"
+	mux.ServeHTTP(httptest.NewRecorder(), req)
+	if want := "GET example.com/users/42?b=2&a=1"; key != want {
+		t.Errorf("Got key %q, want %q", key, want)
+	}
+}
+
+func TestResponseCacheSharesKeyAcrossQueryOrder(t *testing.T) {
+	calls := 0
+	mux := chain.New()
+	mux.Use(chain.ResponseCache(chain.CacheOptions{}))
+	mux.CacheKeyQuery("q")
+	mux.HandleFunc("GET /search", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Write([]byte("results"))
+	})
+
+	for _, target := range []string{"/search?q=go&utm=a", "/search?utm=b&q=go"} {
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", target, nil))
+	}
+	if calls != 1 {
+		t.Errorf("Expected one handler call, got %d", calls)
+	}
+}
+
+func TestCacheKeyAfterDerivedRequest(t *testing.T) {
+	var key string
+	mux := chain.New()
+	mux.UsePre(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Derive a new request, as Trace does, so ServeMux sets the path
+			// values on a request this middleware never sees
+			next.ServeHTTP(w, r.WithContext(context.WithoutCancel(r.Context())))
+			key = chain.CacheKey(w, r)
+		})
+	})
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {})
+
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/42", nil))
+	if want := "GET example.com/users/{id} id=42"; key != want {
+		t.Errorf("Got key %q, want %q", key, want)
+	}
+}
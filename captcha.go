@@ -0,0 +1,147 @@
+package chain
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// CaptchaResult is the outcome of verifying a captcha token.
+type CaptchaResult struct {
+	Success bool
+	// Score is the risk score reported by score-based providers such as reCAPTCHA
+	// v3, from 0 (likely a bot) to 1 (likely a human). Zero for other providers.
+	Score float64
+}
+
+// CaptchaVerifier checks captcha tokens with a provider.
+type CaptchaVerifier interface {
+	Verify(ctx context.Context, token, remoteIP string) (CaptchaResult, error)
+}
+
+// Verification endpoints of the common captcha providers.
+const (
+	HCaptchaVerifyURL  = "https://api.hcaptcha.com/siteverify"
+	ReCaptchaVerifyURL = "https://www.google.com/recaptcha/api/siteverify"
+)
+
+// HTTPCaptchaVerifier verifies tokens against a siteverify-style endpoint, as used
+// by hCaptcha, reCAPTCHA and compatible providers.
+type HTTPCaptchaVerifier struct {
+	// URL is the verification endpoint, such as HCaptchaVerifyURL.
+	URL string
+	// Secret is the site's secret key.
+	Secret string
+	// Client performs the request. Defaults to http.DefaultClient.
+	Client *http.Client
+}
+
+// HCaptcha returns a verifier for hCaptcha using secret.
+func HCaptcha(secret string) *HTTPCaptchaVerifier {
+	return &HTTPCaptchaVerifier{URL: HCaptchaVerifyURL, Secret: secret}
+}
+
+// ReCaptcha returns a verifier for Google reCAPTCHA using secret.
+func ReCaptcha(secret string) *HTTPCaptchaVerifier {
+	return &HTTPCaptchaVerifier{URL: ReCaptchaVerifyURL, Secret: secret}
+}
+
+// Verify implements CaptchaVerifier.
+func (v *HTTPCaptchaVerifier) Verify(ctx context.Context, token, remoteIP string) (CaptchaResult, error) {
+	form := url.Values{"secret": {v.Secret}, "response": {token}}
+	if remoteIP != "" {
+		form.Set("remoteip", remoteIP)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.URL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return CaptchaResult{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	client := v.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return CaptchaResult{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return CaptchaResult{}, fmt.Errorf("chain: captcha verification returned %s", resp.Status)
+	}
+
+	var result struct {
+		Success bool    `json:"success"`
+		Score   float64 `json:"score"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return CaptchaResult{}, err
+	}
+	return CaptchaResult{Success: result.Success, Score: result.Score}, nil
+}
+
+// CaptchaOptions configures the Captcha middleware.
+type CaptchaOptions struct {
+	// Fields are the form fields searched for the token, in order. Defaults to
+	// "h-captcha-response" and "g-recaptcha-response".
+	Fields []string
+	// MinScore rejects results from score-based providers below this threshold.
+	// Register separate middleware per group to apply different thresholds.
+	MinScore float64
+}
+
+// Captcha returns middleware that verifies a captcha token submitted with a form.
+// Requests without a token, or whose token fails verification or falls below
+// MinScore, receive 403 Forbidden. If the provider cannot be reached the request
+// receives 503 Service Unavailable. Safe methods (GET, HEAD, OPTIONS) pass through
+// so the same routes can render the form.
+func Captcha(v CaptchaVerifier, opts CaptchaOptions) func(http.Handler) http.Handler {
+	if v == nil {
+		panic("chain: nil verifier passed to Captcha")
+	}
+	fields := opts.Fields
+	if len(fields) == 0 {
+		fields = []string{"h-captcha-response", "g-recaptcha-response"}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			var token string
+			for _, field := range fields {
+				if token = r.PostFormValue(field); token != "" {
+					break
+				}
+			}
+			if token == "" {
+				WriteError(w, r, http.StatusForbidden, "")
+				return
+			}
+
+			remoteIP, _, err := net.SplitHostPort(r.RemoteAddr)
+			if err != nil {
+				remoteIP = r.RemoteAddr
+			}
+			result, err := v.Verify(r.Context(), token, remoteIP)
+			if err != nil {
+				WriteError(w, r, http.StatusServiceUnavailable, "")
+				return
+			}
+			if !result.Success || (opts.MinScore > 0 && result.Score < opts.MinScore) {
+				WriteError(w, r, http.StatusForbidden, "")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package chain_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/jpl-au/chain"
+)
+
+func TestCaptcha(t *testing.T) {
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.PostFormValue("secret") != "s3cret" {
+			t.Errorf("Expected secret to be sent, got '%s'", r.PostFormValue("secret"))
+		}
+		switch r.PostFormValue("response") {
+		case "human":
+			w.Write([]byte(`{"success": true, "score": 0.9}`))
+		case "bot":
+			w.Write([]byte(`{"success": true, "score": 0.1}`))
+		case "down":
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			w.Write([]byte(`{"success": false}`))
+		}
+	}))
+	defer provider.Close()
+
+	verifier := chain.ReCaptcha("s3cret")
+	verifier.URL = provider.URL
+
+	mux := chain.New().Use(chain.Captcha(verifier, chain.CaptchaOptions{MinScore: 0.5}))
+	mux.HandleFunc("/signup", func(w http.ResponseWriter, r *http.Request) {})
+
+	tests := []struct {
+		name   string
+		method string
+		token  string
+		status int
+	}{
+		{"form render", "GET", "", http.StatusOK},
+		{"human", "POST", "human", http.StatusOK},
+		{"low score", "POST", "bot", http.StatusForbidden},
+		{"failed", "POST", "invalid", http.StatusForbidden},
+		{"missing token", "POST", "", http.StatusForbidden},
+		{"provider down", "POST", "down", http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{"g-recaptcha-response": {tt.token}}
+			req := httptest.NewRequest(tt.method, "/signup", strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+			if rec.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, rec.Code)
+			}
+		})
+	}
+}
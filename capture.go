@@ -0,0 +1,132 @@
+package chain
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// CapturedRequest is a request recorded by Capture, complete enough to be
+// replayed with chaintest.Replay. It encodes to JSON, with the body in base64.
+type CapturedRequest struct {
+	Time       time.Time   `json:"time"`
+	Method     string      `json:"method"`
+	URL        string      `json:"url"` // the request URI, such as "/users?page=2"
+	Host       string      `json:"host"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body,omitempty"`
+	Truncated  bool        `json:"truncated,omitempty"` // Body was cut at MaxBody
+	RemoteAddr string      `json:"remote_addr"`
+	// Route and Status describe how the request was served.
+	Route  string `json:"route"`
+	Status int    `json:"status"`
+}
+
+// CaptureOptions configures the Capture middleware.
+type CaptureOptions struct {
+	// Sink receives each captured request once its response has been written. It
+	// runs on the request's goroutine, so slow sinks should hand off the work.
+	// Required.
+	Sink func(CapturedRequest)
+	// Sample is the fraction of requests captured, from 0 to 1. Defaults to 1.
+	Sample float64
+	// MaxBody is the most body bytes recorded per request. Defaults to 64 KiB.
+	MaxBody int64
+	// Redact lists headers left out of captures. Defaults to Authorization,
+	// Cookie and Proxy-Authorization.
+	Redact []string
+}
+
+// defaultCaptureRedact are the headers omitted unless CaptureOptions.Redact is set.
+var defaultCaptureRedact = []string{"Authorization", "Cookie", "Proxy-Authorization"}
+
+// Capture returns middleware that records a sample of full requests, headers and
+// body up to a limit, so that production-only failures can be reproduced locally
+// with chaintest.Replay. The handler still receives the whole body. Credentials
+// are redacted, but bodies are recorded as sent and may contain personal data;
+// capture sparingly and only where the sink's storage is suitably protected.
+//
+//	mux.Use(chain.Capture(chain.CaptureOptions{Sink: chain.CaptureTo(file), Sample: 0.01}))
+func Capture(opts CaptureOptions) func(http.Handler) http.Handler {
+	if opts.Sink == nil {
+		panic("chain: nil Sink passed to Capture")
+	}
+	if opts.Sample <= 0 || opts.Sample > 1 {
+		opts.Sample = 1
+	}
+	if opts.MaxBody <= 0 {
+		opts.MaxBody = 64 << 10
+	}
+	redact := opts.Redact
+	if redact == nil {
+		redact = defaultCaptureRedact
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if opts.Sample < 1 && rand.Float64() >= opts.Sample {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			c := CapturedRequest{
+				Time:       time.Now(),
+				Method:     r.Method,
+				URL:        r.URL.RequestURI(),
+				Host:       r.Host,
+				Header:     r.Header.Clone(),
+				RemoteAddr: r.RemoteAddr,
+			}
+			for _, name := range redact {
+				c.Header.Del(name)
+			}
+			if r.Body != nil && r.Body != http.NoBody {
+				body, err := io.ReadAll(io.LimitReader(r.Body, opts.MaxBody+1))
+				c.Truncated = int64(len(body)) > opts.MaxBody
+				c.Body = body[:min(int64(len(body)), opts.MaxBody)]
+				// Hand the handler what was read followed by the rest, including
+				// any read error
+				rest := io.Reader(r.Body)
+				if err != nil {
+					rest = errReader{err}
+				}
+				r.Body = readCloser{io.MultiReader(bytes.NewReader(body), rest), r.Body}
+			}
+
+			next.ServeHTTP(w, r)
+
+			if rw := findResponseWriter(w); rw != nil {
+				c.Route, c.Status = rw.pattern, rw.Status()
+			}
+			opts.Sink(c)
+		})
+	}
+}
+
+// CaptureTo returns a Capture sink that writes each request to w as a line of
+// JSON, the format read by chaintest.ReadCaptures. Writes are serialised, and
+// errors are ignored.
+func CaptureTo(w io.Writer) func(CapturedRequest) {
+	var mu sync.Mutex
+	enc := json.NewEncoder(w)
+	return func(c CapturedRequest) {
+		mu.Lock()
+		defer mu.Unlock()
+		enc.Encode(c)
+	}
+}
+
+// readCloser pairs a reader with the Close of the body it replaces.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// errReader returns err from every Read.
+type errReader struct{ err error }
+
+func (r errReader) Read([]byte) (int, error) { return 0, r.err }
@@ -0,0 +1,59 @@
+package chain_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jpl-au/chain"
+)
+
+func TestCapture(t *testing.T) {
+	var captured []chain.CapturedRequest
+	mux := chain.New()
+	mux.Use(chain.Capture(chain.CaptureOptions{
+		Sink:    func(c chain.CapturedRequest) { captured = append(captured, c) },
+		MaxBody: 5,
+	}))
+	mux.HandleFunc("POST /items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != "hello world" {
+			t.Errorf("handler body = %q, want the full body", body)
+		}
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	req := httptest.NewRequest("POST", "/items/7?x=1", strings.NewReader("hello world"))
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("X-Custom", "v")
+	mux.ServeHTTP(httptest.NewRecorder(), req)
+
+	if len(captured) != 1 {
+		t.Fatalf("captured %d requests, want 1", len(captured))
+	}
+	c := captured[0]
+	if c.Method != "POST" || c.URL != "/items/7?x=1" || c.Route != "POST /items/{id}" || c.Status != http.StatusCreated {
+		t.Errorf("capture = %s %s %s %d", c.Method, c.URL, c.Route, c.Status)
+	}
+	if string(c.Body) != "hello" || !c.Truncated {
+		t.Errorf("body = %q truncated %v, want hello truncated", c.Body, c.Truncated)
+	}
+	if c.Header.Get("Authorization") != "" || c.Header.Get("X-Custom") != "v" {
+		t.Errorf("header = %v, want Authorization redacted", c.Header)
+	}
+}
+
+func TestCaptureSample(t *testing.T) {
+	var n int
+	mux := chain.New()
+	mux.Use(chain.Capture(chain.CaptureOptions{Sink: func(chain.CapturedRequest) { n++ }, Sample: 0.000001}))
+	mux.HandleFunc("GET /", func(w http.ResponseWriter, r *http.Request) {})
+	for range 100 {
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	}
+	if n > 1 {
+		t.Errorf("captured %d of 100 requests at a tiny sample rate", n)
+	}
+}
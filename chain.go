@@ -1,7 +1,15 @@
 package chain
 
 import (
+	"context"
+	"log/slog"
 	"net/http"
+	"net/netip"
+	"os"
+	"runtime/pprof"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // ResponseWriter extends http.ResponseWriter with additional methods to inspect the response.
@@ -26,13 +34,100 @@ type Mux struct {
 	prefix           string
 	notFound         http.Handler
 	methodNotAllowed http.Handler
+
+	// root is the top-level Mux created by New. Groups and routes share it so
+	// that router-wide settings registered inside a group reach ServeHTTP.
+	root     *Mux
+	outer    []func(http.Handler) http.Handler
+	pre      []func(http.Handler) http.Handler
+	finalize []func(ResponseWriter, *http.Request)
+
+	// Strict method handling, enabled by WithAllowedMethods
+	allowedMethods map[string]bool
+	allowHeader    string
+
+	rewrites []rewriteRule
+
+	// Protocol handlers that bypass the router, set by WithGRPC, WithGRPCWeb and
+	// WithACME
+	grpc    http.Handler
+	grpcWeb http.Handler
+	acme    http.Handler
+
+	// Authorization requirements declared for routes registered on this Mux
+	authorizer Authorizer
+	forbidden  http.Handler
+	required   Requirements
+
+	// Deprecation declared for routes registered on this Mux, and usage counts
+	// per deprecated pattern kept on the root
+	deprecation *deprecation
+	deprecated  map[string]*atomic.Uint64
+
+	// Service level objective declared for routes registered on this Mux, and
+	// counters per pattern kept on the root
+	slo  *slo
+	slos map[string]*sloStats
+
+	// Networks allowed to reach routes registered on this Mux, one set per
+	// AllowCIDR call
+	allowNets [][]netip.Prefix
+
+	// Maintenance windows declared for routes registered on this Mux
+	maintenance *MaintenanceOptions
+
+	// Readiness checks gating routes registered on this Mux, set by ReadyWhen
+	ready []func() bool
+
+	// Load shedding priority of routes registered on this Mux
+	priority Priority
+
+	// Query parameters significant to CacheKey, set by CacheKeyQuery; nil means all
+	cacheQuery []string
+
+	// Decoy route behaviour set by WithHoneypot
+	honeypot *HoneypotOptions
+
+	// Cache policy middleware declared via CacheControl
+	cache func(http.Handler) http.Handler
+
+	// routes records every registration on the root, guarded by mu
+	mu        sync.RWMutex
+	routes    []route
+	lazy      []*lazyHandler
+	overrides atomic.Pointer[map[string]http.Handler]
+	disabled  atomic.Pointer[map[string]DisabledRoute]
+
+	// URL paths of content-hashed files served by Static, keyed by their name
+	// within the file system, guarded by mu
+	assets map[string]string
+
+	logger      *slog.Logger
+	reporter    Reporter
+	errorFormat ErrorFormatter
+	codecs      []Codec
+	events      eventBus
+	drain       drainer
+
+	// Requests in flight across the router and per pattern, and the cap set by
+	// MaxInFlight
+	inFlight      atomic.Int64
+	maxInFlight   atomic.Int64
+	routeInFlight map[string]*atomic.Int64
+
+	profile     Profile
+	pprofLabels bool
+	zeroAlloc   bool
+	startup     sync.Once
 }
 
 // New returns a new, initialized Mux instance.
 func New() *Mux {
-	return &Mux{
+	m := &Mux{
 		router: http.NewServeMux(),
 	}
+	m.root = m
+	return m
 }
 
 // WithNotFound sets a custom handler for 404 Not Found responses.
@@ -49,6 +144,45 @@ func (m *Mux) WithMethodNotAllowed(handler http.Handler) *Mux {
 	return m
 }
 
+// WithLogger sets the logger used for the router's own diagnostics, such as
+// reporting use of deprecated routes. Defaults to slog.Default().
+// Returns the Mux instance for chaining.
+func (m *Mux) WithLogger(logger *slog.Logger) *Mux {
+	m.root.logger = logger
+	return m
+}
+
+// WithPprofLabels labels the goroutine serving each route with the route's pattern
+// ("route") and the request method ("method") using pprof.Do, so CPU and goroutine
+// profiles can be broken down by endpoint. Labels are also available to handlers
+// through pprof.Label on the request context.
+// Returns the Mux instance for chaining.
+func (m *Mux) WithPprofLabels() *Mux {
+	m.root.pprofLabels = true
+	return m
+}
+
+// WithZeroAlloc serves requests without deriving a new request context, so that a
+// matched request through middleware that does not allocate, with no Finally
+// hooks, event subscribers or SLO, incurs no heap allocations in the router
+// beyond those of http.ServeMux itself. The cost is that Drain can no longer
+// cancel streaming requests that outlive its grace period; it still signals them
+// through Draining and closes hijacked connections. Features that store values in
+// the request context, such as Budget, allocate as usual when used.
+// Returns the Mux instance for chaining.
+func (m *Mux) WithZeroAlloc() *Mux {
+	m.root.zeroAlloc = true
+	return m
+}
+
+// log returns the configured logger, falling back to slog.Default().
+func (m *Mux) log() *slog.Logger {
+	if m.root.logger != nil {
+		return m.root.logger
+	}
+	return slog.Default()
+}
+
 // Use appends middleware to the Mux's middleware chain.
 // Middleware are executed in the order they are added.
 // Returns the Mux instance for method chaining.
@@ -62,6 +196,52 @@ func (m *Mux) Use(mw ...func(http.Handler) http.Handler) *Mux {
 	return m
 }
 
+// UsePre appends middleware that runs in ServeHTTP before the request is dispatched.
+// Unlike Use, pre-routing middleware wraps every request, including unmatched paths
+// and intercepted 404/405 responses, which makes it suitable for request IDs,
+// logging and CORS. Calling UsePre inside a group registers it on the root Mux.
+// Returns the Mux instance for method chaining.
+func (m *Mux) UsePre(mw ...func(http.Handler) http.Handler) *Mux {
+	for _, fn := range mw {
+		if fn == nil {
+			panic("chain: nil middleware passed to UsePre")
+		}
+	}
+	m.root.pre = append(m.root.pre, mw...)
+	return m
+}
+
+// Wrap appends middleware around the router's entire ServeHTTP. It is the outermost
+// layer: it sees every response the router produces, including redirects issued by
+// the underlying ServeMux (such as trailing-slash redirects), custom 404/405 responses
+// and requests served by WithGRPC. Unlike UsePre, wrapping middleware receives the
+// caller's http.ResponseWriter rather than the router's ResponseWriter. Calling Wrap
+// inside a group registers it on the root Mux.
+// Returns the Mux instance for method chaining.
+func (m *Mux) Wrap(mw ...func(http.Handler) http.Handler) *Mux {
+	for _, fn := range mw {
+		if fn == nil {
+			panic("chain: nil middleware passed to Wrap")
+		}
+	}
+	m.root.outer = append(m.root.outer, mw...)
+	return m
+}
+
+// Finally registers a hook that runs after the response completes, even if a handler
+// panics or hijacks the connection. Hooks run in the order they are registered and
+// receive the wrapped ResponseWriter, so they can read the final status and size.
+// They are suited to flushing logs, releasing request-scoped resources and emitting
+// metrics. Calling Finally inside a group registers it on the root Mux.
+// Returns the Mux instance for method chaining.
+func (m *Mux) Finally(fn func(ResponseWriter, *http.Request)) *Mux {
+	if fn == nil {
+		panic("chain: nil function passed to Finally")
+	}
+	m.root.finalize = append(m.root.finalize, fn)
+	return m
+}
+
 // Group creates a new routing group with isolated middleware.
 // Middleware registered within fn will only apply to routes defined within that group.
 // The group inherits the parent's route prefix if one was set via Route.
@@ -70,12 +250,7 @@ func (m *Mux) Group(fn func(*Mux)) *Mux {
 	if fn == nil {
 		panic("chain: nil function passed to Group")
 	}
-	groupMux := &Mux{
-		router:      m.router,
-		middlewares: append([]func(http.Handler) http.Handler{}, m.middlewares...),
-		prefix:      m.prefix,
-	}
-	fn(groupMux)
+	fn(m.group(m.prefix))
 	return m
 }
 
@@ -87,13 +262,42 @@ func (m *Mux) Route(prefix string, fn func(*Mux)) *Mux {
 	if fn == nil {
 		panic("chain: nil function passed to Route")
 	}
-	groupMux := &Mux{
-		router:      m.router,
+	fn(m.group(m.prefix + prefix))
+	return m
+}
+
+// Prefix returns a new routing group with a path prefix and isolated middleware,
+// like Route but without a callback. The returned Mux can be kept and passed to
+// other packages so that they register their own routes. Prefix("") returns a
+// group without an additional prefix, like Group.
+func (m *Mux) Prefix(prefix string) *Mux {
+	return m.group(m.prefix + prefix)
+}
+
+// group returns a child Mux sharing the router and root of m, with a copy of
+// m's middleware so that additions inside the group stay isolated.
+func (m *Mux) group(prefix string) *Mux {
+	return m.groupOn(m.root, prefix)
+}
+
+// groupOn returns a Mux with a copy of m's group settings that registers routes
+// on root, which need not be m's root.
+func (m *Mux) groupOn(root *Mux, prefix string) *Mux {
+	return &Mux{
+		router:      root.router,
 		middlewares: append([]func(http.Handler) http.Handler{}, m.middlewares...),
-		prefix:      m.prefix + prefix,
+		prefix:      prefix,
+		root:        root,
+		required:    m.required.clone(),
+		deprecation: m.deprecation,
+		slo:         m.slo,
+		ready:       m.ready,
+		maintenance: m.maintenance,
+		allowNets:   m.allowNets,
+		cache:       m.cache,
+		priority:    m.priority,
+		cacheQuery:  m.cacheQuery,
 	}
-	fn(groupMux)
-	return m
 }
 
 // Handle registers a handler for the given pattern with middleware applied.
@@ -103,7 +307,7 @@ func (m *Mux) Handle(pattern string, handler http.Handler) *Mux {
 	if handler == nil {
 		panic("chain: nil handler passed to Handle")
 	}
-	m.router.Handle(m.prefixPattern(pattern), m.wrap(handler))
+	m.register(m.prefixPattern(pattern), handler)
 	return m
 }
 
@@ -114,10 +318,36 @@ func (m *Mux) HandleFunc(pattern string, handlerFunc http.HandlerFunc) *Mux {
 	if handlerFunc == nil {
 		panic("chain: nil handler passed to HandleFunc")
 	}
-	m.router.Handle(m.prefixPattern(pattern), m.wrap(handlerFunc))
+	m.register(m.prefixPattern(pattern), handlerFunc)
 	return m
 }
 
+// register wraps handler and adds it to the router and the root's route table.
+func (m *Mux) register(pattern string, handler http.Handler) {
+	wrap := func(h http.Handler) http.Handler { return m.wrap(pattern, h) }
+	m.handle(route{
+		info:    m.routeInfo(pattern),
+		handler: wrap(handler),
+		wrap:    wrap,
+		raw:     handler,
+		group:   m.group(m.prefix),
+	})
+}
+
+// handle adds a route with an already wrapped handler to the router and the
+// root's route table. rt.wrap applies the route's middleware to a replacement
+// handler passed to Override, and may be nil for routes without middleware.
+func (m *Mux) handle(rt route) {
+	pattern := rt.info.Pattern
+	m.router.Handle(pattern, m.disableable(pattern, m.overridable(pattern, rt.handler)))
+
+	m.root.mu.Lock()
+	m.root.routes = append(m.root.routes, rt)
+	m.root.mu.Unlock()
+
+	m.root.events.emit(RouteRegistered{Route: rt.info.clone()})
+}
+
 // prefixPattern prepends the Mux's prefix to the pattern's path component.
 // Go 1.22 patterns can be "/path" or "METHOD /path", so we find the "/" to locate
 // where the path starts and insert the prefix there.
@@ -141,23 +371,158 @@ func (m *Mux) prefixPattern(pattern string) string {
 // ServeHTTP dispatches the request to the handler whose pattern most closely matches the request URL.
 // It also handles custom 404 and 405 logic if configured.
 func (m *Mux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h := m.acmeHandler(r); h != nil {
+		h.ServeHTTP(w, r)
+		return
+	}
+	if len(m.outer) == 0 {
+		m.serve(w, r)
+		return
+	}
+	var h http.Handler = http.HandlerFunc(m.serve)
+	for i := len(m.outer) - 1; i >= 0; i-- {
+		h = m.outer[i](h)
+	}
+	h.ServeHTTP(w, r)
+}
+
+// serve runs the pre-routing middleware and dispatches the request within the
+// response wrapper.
+func (m *Mux) serve(w http.ResponseWriter, r *http.Request) {
+	if g := m.grpcHandler(r); g != nil {
+		g.ServeHTTP(w, r)
+		return
+	}
+
+	if m.profile == Development {
+		m.startup.Do(func() { m.PrintRoutes(os.Stderr) })
+	}
+
+	// A cancellable context lets Drain stop streaming responses that outlive the
+	// grace period
+	var cancel context.CancelFunc
+	if !m.zeroAlloc {
+		var ctx context.Context
+		ctx, cancel = context.WithCancel(r.Context())
+		defer cancel()
+		r = r.WithContext(ctx)
+	}
+
+	rw := m.wrapWriter(w, r)
+	defer writerPool.Put(rw)
+	defer rw.reset()
+	if !m.admit() {
+		WriteRetryAfter(rw, r, http.StatusServiceUnavailable, defaultRetryAfter, "")
+		return
+	}
+	defer m.release()
+	rw.drain, rw.cancel = &m.drain, cancel
+	defer func() {
+		if rw.longLived {
+			m.drain.untrack(rw)
+		}
+	}()
+	if len(m.finalize) > 0 {
+		defer m.runFinalizers(rw, r)
+	}
+
+	if m.events.active() {
+		start := time.Now()
+		defer func() {
+			m.events.emit(RequestCompleted{
+				Request:  r,
+				Route:    rw.pattern,
+				Status:   rw.Status(),
+				Size:     rw.Size(),
+				Duration: time.Since(start),
+			})
+		}()
+	}
+
 	// Normal path with potential interception in the wrapper
-	m.router.ServeHTTP(m.wrapWriter(w, r), r)
+	if len(m.pre) == 0 {
+		m.dispatch(rw, r)
+	} else {
+		var h http.Handler = http.HandlerFunc(m.dispatch)
+		for i := len(m.pre) - 1; i >= 0; i-- {
+			h = m.pre[i](h)
+		}
+		h.ServeHTTP(rw, r)
+	}
+	rw.finish()
 }
 
-// wrapWriter wraps the http.ResponseWriter.
-func (m *Mux) wrapWriter(w http.ResponseWriter, r *http.Request) http.ResponseWriter {
-	return wrapResponseWriter(w, r, m.notFound, m.methodNotAllowed)
+// runFinalizers calls each Finally hook in order. Every hook is deferred so that a
+// panicking hook does not prevent the remaining hooks from running.
+func (m *Mux) runFinalizers(rw ResponseWriter, r *http.Request) {
+	for i := len(m.finalize) - 1; i >= 0; i-- {
+		defer m.finalize[i](rw, r)
+	}
+}
+
+// dispatch hands the request to the underlying router. Headers set before this
+// point (for example by pre-routing middleware) are recorded so that they survive
+// when a custom 404/405 handler takes over the response.
+func (m *Mux) dispatch(w http.ResponseWriter, r *http.Request) {
+	if rw, ok := w.(*responseWriter); ok && len(rw.Header()) > 0 {
+		rw.preserved = rw.Header().Clone()
+	}
+	if !m.checkMethod(w, r) {
+		return
+	}
+	m.router.ServeHTTP(w, m.rewrite(r))
 }
 
-// wrap applies the middleware chain to a http.Handler.
-func (m *Mux) wrap(handler http.Handler) http.Handler {
+// wrapWriter wraps the http.ResponseWriter. Without custom 404/405 handlers, those
+// responses are rendered through the error formatter if one is set.
+func (m *Mux) wrapWriter(w http.ResponseWriter, r *http.Request) *responseWriter {
+	notFound, methodNotAllowed := m.notFound, m.methodNotAllowed
+	if m.errorFormat != nil {
+		if notFound == nil {
+			notFound = errorHandler(m.errorFormat, http.StatusNotFound)
+		}
+		if methodNotAllowed == nil {
+			methodNotAllowed = errorHandler(m.errorFormat, http.StatusMethodNotAllowed)
+		}
+	}
+	rw := wrapResponseWriter(w, r, notFound, methodNotAllowed).(*responseWriter)
+	rw.errorFormat = m.errorFormat
+	rw.codecs = m.codecs
+	rw.events = &m.events
+	return rw
+}
+
+// wrap applies the middleware chain to a http.Handler registered under pattern.
+func (m *Mux) wrap(pattern string, handler http.Handler) http.Handler {
+	// Authorization runs innermost so that authentication middleware has
+	// already placed the principal in the request context
+	handler = m.authorize(handler)
+
 	// Apply middleware in reverse order so first-registered runs outermost
 	// (first to see request, last to see response)
 	for i := len(m.middlewares) - 1; i >= 0; i-- {
 		handler = m.middlewares[i](handler)
 	}
 
+	// Route-level headers wrap the middleware so that they are also applied to
+	// responses produced by middleware, such as authentication failures
+	if m.cache != nil {
+		handler = m.cache(handler)
+	}
+	handler = m.gateReady(handler)
+	handler = m.gateNetworks(handler)
+	handler = m.gateMaintenance(handler)
+	handler = m.deprecate(pattern, handler)
+	handler = m.trackSLO(pattern, handler)
+	handler = m.countInFlight(pattern, handler)
+
+	var wildcards []string
+	for _, s := range patternSegments(pattern) {
+		if s.param != "" {
+			wildcards = append(wildcards, s.param)
+		}
+	}
+
 	// Return a handler that provides the right ResponseWriter to middleware
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// If this is being called from ServeHTTP, w is already the wrapped writer
@@ -166,9 +531,24 @@ func (m *Mux) wrap(handler http.Handler) http.Handler {
 		// Check if w is already our ResponseWriter interface
 		if _, ok := w.(ResponseWriter); !ok {
 			// Not wrapped yet, wrap it now
-			w = wrapResponseWriter(w, r, m.notFound, m.methodNotAllowed)
+			w = m.root.wrapWriter(w, r)
+		}
+		if rw := findResponseWriter(w); rw != nil {
+			rw.pattern = pattern
+			rw.pathValues = rw.pathValues[:0]
+			for _, name := range wildcards {
+				rw.pathValues = append(rw.pathValues, name, r.PathValue(name))
+			}
+			rw.priority = m.priority
+			rw.cacheQuery = m.cacheQuery
 		}
 
+		if m.root.pprofLabels {
+			pprof.Do(r.Context(), pprof.Labels("route", pattern, "method", r.Method), func(ctx context.Context) {
+				handler.ServeHTTP(w, r.WithContext(ctx))
+			})
+			return
+		}
 		handler.ServeHTTP(w, r)
 	})
 }
@@ -1064,6 +1064,31 @@ func TestRoutePrefix(t *testing.T) {
 	}
 }
 
+func TestPrefix(t *testing.T) {
+	mux := chain.New()
+	api := mux.Prefix("/api").Use(tag("api"))
+	v1 := api.Prefix("/v1")
+	v1.HandleFunc("GET /users", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("users list"))
+	})
+	mux.HandleFunc("GET /plain", func(w http.ResponseWriter, r *http.Request) {})
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/api/v1/users", nil))
+	if rec.Body.String() != "users list" {
+		t.Errorf("Expected 'users list', got '%s'", rec.Body.String())
+	}
+	if got := rec.Header().Get("X-Order"); got != "api" {
+		t.Errorf("Expected prefix middleware 'api', got '%s'", got)
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/plain", nil))
+	if got := rec.Header().Get("X-Order"); got != "" {
+		t.Errorf("Expected middleware to be scoped to the prefix, got '%s'", got)
+	}
+}
+
 func TestNestedRoutePrefix(t *testing.T) {
 	mux := chain.New()
 
@@ -1330,3 +1355,136 @@ func TestNilRouteFuncPanics(t *testing.T) {
 
 	chain.New().Route("/api", nil)
 }
+
+func TestWrapCoversServeMuxRedirects(t *testing.T) {
+	var statuses []int
+	mux := chain.New()
+	mux.Wrap(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rec := httptest.NewRecorder()
+			next.ServeHTTP(rec, r)
+			statuses = append(statuses, rec.Code)
+			for k, v := range rec.Header() {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(rec.Code)
+			w.Write(rec.Body.Bytes())
+		})
+	})
+	mux.HandleFunc("GET /docs/", func(w http.ResponseWriter, r *http.Request) {})
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/docs", nil))
+	redirect := rec.Code
+	if redirect < 300 || redirect > 399 {
+		t.Fatalf("Expected ServeMux redirect, got %d", redirect)
+	}
+
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/missing", nil))
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/docs/", nil))
+
+	want := []int{redirect, http.StatusNotFound, http.StatusOK}
+	if !reflect.DeepEqual(statuses, want) {
+		t.Errorf("Expected wrapping middleware to see %v, got %v", want, statuses)
+	}
+}
+
+func TestWrapOrder(t *testing.T) {
+	mux := chain.New().Wrap(tag("outer"))
+	mux.UsePre(tag("pre"))
+	mux.Group(func(g *chain.Mux) {
+		g.Wrap(tag("outer2"))
+	})
+	mux.HandleFunc("GET /", func(w http.ResponseWriter, r *http.Request) {})
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if got := strings.Join(rec.Header().Values("X-Order"), ","); got != "outer,outer2,pre" {
+		t.Errorf("Expected order 'outer,outer2,pre', got '%s'", got)
+	}
+}
+
+func TestUsePreCoversNotFound(t *testing.T) {
+	var preStatus int
+	mux := chain.New().
+		WithNotFound(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("Custom 404"))
+		}))
+
+	mux.Group(func(g *chain.Mux) {
+		// Registered from a group, but still applies router-wide
+		g.UsePre(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Request-ID", "abc")
+				next.ServeHTTP(w, r)
+				if rw, ok := w.(chain.ResponseWriter); ok {
+					preStatus = rw.Status()
+				}
+			})
+		})
+	})
+	mux.HandleFunc("GET /exists", func(w http.ResponseWriter, r *http.Request) {})
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/missing", nil))
+
+	if rec.Header().Get("X-Request-ID") != "abc" {
+		t.Error("Pre-routing middleware did not run for unmatched path")
+	}
+	if preStatus != http.StatusNotFound {
+		t.Errorf("Expected pre-routing middleware to see 404, got %d", preStatus)
+	}
+	if rec.Body.String() != "Custom 404" {
+		t.Errorf("Expected body 'Custom 404', got '%s'", rec.Body.String())
+	}
+}
+
+func TestUsePreOrder(t *testing.T) {
+	var order []string
+	record := func(name string) func(http.Handler) http.Handler {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	mux := chain.New().UsePre(record("pre1"), record("pre2")).Use(record("use"))
+	mux.HandleFunc("GET /", func(w http.ResponseWriter, r *http.Request) {})
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	if !reflect.DeepEqual(order, []string{"pre1", "pre2", "use"}) {
+		t.Errorf("Expected order [pre1 pre2 use], got %v", order)
+	}
+}
+
+func TestRouteTableAndMatch(t *testing.T) {
+	mux := chain.New()
+	mux.Route("/api", func(api *chain.Mux) {
+		api.RequireRole("admin")
+		api.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	})
+	mux.HandleFunc("GET /", func(w http.ResponseWriter, r *http.Request) {})
+
+	table := mux.RouteTable()
+	if len(table) != 2 {
+		t.Fatalf("Expected 2 routes, got %d", len(table))
+	}
+	if table[0].Pattern != "GET /api/users/{id}" || table[0].Prefix != "/api" {
+		t.Errorf("Unexpected first route %+v", table[0])
+	}
+	if !reflect.DeepEqual(table[0].Requirements.Roles, []string{"admin"}) {
+		t.Errorf("Expected admin requirement, got %v", table[0].Requirements.Roles)
+	}
+
+	info, ok := mux.Match(httptest.NewRequest("GET", "/api/users/1", nil))
+	if !ok || info.Pattern != "GET /api/users/{id}" {
+		t.Errorf("Expected match 'GET /api/users/{id}', got '%s' (%v)", info.Pattern, ok)
+	}
+	info, ok = mux.Match(httptest.NewRequest("POST", "/api/users/1", nil))
+	if ok {
+		t.Errorf("Expected no match for POST, got '%s'", info.Pattern)
+	}
+}
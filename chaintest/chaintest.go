@@ -0,0 +1,106 @@
+// Package chaintest provides utilities for testing routers built with chain.
+//
+// Requests are served in-process with [Do], which avoids the cost of starting an
+// [httptest.Server] and making real TCP round-trips for every routing test:
+//
+//	res := chaintest.Do(mux, httptest.NewRequest("GET", "/users/42", nil))
+//	if res.Status != http.StatusOK || res.Pattern != "GET /users/{id}" {
+//		t.Errorf("unexpected result: %d %s", res.Status, res.Pattern)
+//	}
+//
+// Requests recorded in production by chain.Capture are re-run with [Replay]:
+//
+//	captures, err := chaintest.ReadCaptures(file)
+//	for _, c := range captures {
+//		res := chaintest.Replay(mux, c)
+//	}
+//
+// [Fuzz] turns a router into a fuzz target that checks routing and error
+// handling invariants for arbitrary methods, paths and headers.
+package chaintest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/jpl-au/chain"
+)
+
+// Result describes the outcome of a request served by Do.
+type Result struct {
+	// Status is the response status code, as reported by chain.ResponseWriter.Status.
+	Status int
+	// Header holds the response headers.
+	Header http.Header
+	// Body holds the response body.
+	Body []byte
+	// Size is the number of body bytes written, as reported by chain.ResponseWriter.Size.
+	Size int
+	// Written reports whether the response was written, as reported by
+	// chain.ResponseWriter.Written.
+	Written bool
+	// Pattern is the full pattern of the route that matched, or empty if none did.
+	Pattern string
+	// Middleware holds the names of the middleware applied to the matched route,
+	// outermost first.
+	Middleware []string
+}
+
+// Do serves r with mux in-process and returns the result. The status, size and
+// pattern are those of the request as the router served it, after any rewrites
+// and pre-routing middleware.
+func Do(mux *chain.Mux, r *http.Request) Result {
+	// Pick out this request's completion from any others served concurrently
+	tag := new(int)
+	r = r.WithContext(context.WithValue(r.Context(), doKey{}, tag))
+	var done chain.RequestCompleted
+	unsubscribe := mux.Subscribe(func(e chain.Event) {
+		if c, ok := e.(chain.RequestCompleted); ok && c.Request.Context().Value(doKey{}) == tag {
+			done = c
+		}
+	})
+	rec := &recorder{ResponseRecorder: httptest.NewRecorder()}
+	mux.ServeHTTP(rec, r)
+	unsubscribe()
+
+	res := Result{
+		Status:  done.Status,
+		Header:  rec.Header(),
+		Body:    rec.Body.Bytes(),
+		Size:    done.Size,
+		Written: rec.wrote,
+		Pattern: done.Route,
+	}
+	if done.Request == nil {
+		res.Status, res.Size = rec.Code, rec.Body.Len()
+	}
+	for _, info := range mux.RouteTable() {
+		if info.Pattern == res.Pattern && res.Pattern != "" {
+			res.Middleware = info.Middleware
+			break
+		}
+	}
+	return res
+}
+
+// doKey tags the context of requests served by Do.
+type doKey struct{}
+
+// recorder is an httptest.ResponseRecorder that records whether the header was sent.
+type recorder struct {
+	*httptest.ResponseRecorder
+	wrote bool
+}
+
+// WriteHeader records that the header was sent.
+func (r *recorder) WriteHeader(status int) {
+	r.wrote = true
+	r.ResponseRecorder.WriteHeader(status)
+}
+
+// Write records that the header was sent.
+func (r *recorder) Write(b []byte) (int, error) {
+	r.wrote = true
+	return r.ResponseRecorder.Write(b)
+}
@@ -0,0 +1,92 @@
+package chaintest_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/jpl-au/chain"
+	"github.com/jpl-au/chain/chaintest"
+)
+
+func auth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Auth", "ok")
+		next.ServeHTTP(w, r)
+	})
+}
+
+func TestDo(t *testing.T) {
+	mux := chain.New()
+	mux.Route("/api", func(api *chain.Mux) {
+		api.Use(auth)
+		api.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusAccepted)
+			w.Write([]byte("user " + r.PathValue("id")))
+		})
+	})
+
+	res := chaintest.Do(mux, httptest.NewRequest("GET", "/api/users/42", nil))
+
+	if res.Status != http.StatusAccepted {
+		t.Errorf("Expected status 202, got %d", res.Status)
+	}
+	if string(res.Body) != "user 42" || res.Size != 7 {
+		t.Errorf("Expected body 'user 42' of size 7, got '%s' of size %d", res.Body, res.Size)
+	}
+	if !res.Written {
+		t.Error("Expected Written to be true")
+	}
+	if res.Header.Get("X-Auth") != "ok" {
+		t.Error("Expected middleware header in result")
+	}
+	if res.Pattern != "GET /api/users/{id}" {
+		t.Errorf("Expected pattern 'GET /api/users/{id}', got '%s'", res.Pattern)
+	}
+	want := []string{"github.com/jpl-au/chain/chaintest_test.auth"}
+	if !reflect.DeepEqual(res.Middleware, want) {
+		t.Errorf("Expected middleware %v, got %v", want, res.Middleware)
+	}
+}
+
+func TestDoUnmatched(t *testing.T) {
+	res := chaintest.Do(chain.New(), httptest.NewRequest("GET", "/missing", nil))
+
+	if res.Status != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", res.Status)
+	}
+	if res.Pattern != "" || res.Middleware != nil {
+		t.Errorf("Expected no matched route, got '%s' %v", res.Pattern, res.Middleware)
+	}
+}
+
+func TestDoReportsServedRoute(t *testing.T) {
+	mux := chain.New()
+	// Pre-routing middleware that moves the request to another route, which
+	// Mux.Match on the original request cannot see
+	mux.UsePre(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r2 := r.Clone(r.Context())
+			r2.URL.Path = "/v2" + r.URL.Path
+			next.ServeHTTP(w, r2)
+		})
+	})
+	mux.HandleFunc("GET /users", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("GET /v2/users", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	res := chaintest.Do(mux, httptest.NewRequest("GET", "/users", nil))
+	if res.Pattern != "GET /v2/users" || res.Status != http.StatusCreated {
+		t.Errorf("Expected the served route 'GET /v2/users' with 201, got '%s' with %d", res.Pattern, res.Status)
+	}
+}
+
+func TestLint(t *testing.T) {
+	mux := chain.New()
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("DELETE /items/{id}", func(w http.ResponseWriter, r *http.Request) {})
+
+	chaintest.Lint(t, mux, chain.IssueMissingMethod)
+}
@@ -0,0 +1,117 @@
+package chaintest
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/jpl-au/chain"
+)
+
+// wildcard matches path wildcards in route patterns.
+var wildcard = regexp.MustCompile(`\{[^}]*\}`)
+
+// Fuzz fuzzes mux with generated methods, paths and a request header, seeded with
+// a request for every registered route and the methods most likely to reach
+// 404 and 405 handling. Each input is served in-process and checked for these
+// invariants:
+//
+//   - serving does not panic
+//   - the status is a valid HTTP status code
+//   - the response header is written at most once
+//   - 404 and 405 responses do not leak stack traces or source paths
+//
+// Call it from a fuzz target; go test runs the seeds, and go test -fuzz explores
+// further:
+//
+//	func FuzzRouter(f *testing.F) {
+//		chaintest.Fuzz(f, newRouter())
+//	}
+func Fuzz(f *testing.F, mux *chain.Mux) {
+	f.Helper()
+	for _, method := range []string{"GET", "HEAD", "POST", "PUT", "DELETE", "OPTIONS", "PATCH", "BREW"} {
+		f.Add(method, "/", "Accept", "*/*")
+	}
+	for _, info := range mux.RouteTable() {
+		method, path, _ := strings.Cut(info.Pattern, " ")
+		if path == "" {
+			method, path = "GET", method
+		}
+		if i := strings.Index(path, "/"); i > 0 {
+			path = path[i:] // drop a host
+		}
+		path = wildcard.ReplaceAllStringFunc(path, func(w string) string {
+			switch {
+			case w == "{$}":
+				return ""
+			case strings.HasSuffix(w, "...}"):
+				return "a/b"
+			default:
+				return "x"
+			}
+		})
+		f.Add(method, path, "Accept", "text/html")
+		f.Add("DELETE", path, "Content-Type", "application/json")
+	}
+
+	f.Fuzz(func(t *testing.T, method, path, name, value string) {
+		r, err := http.NewRequest(method, "http://example.com"+path, nil)
+		if err != nil || !validHeader(name, value) {
+			t.Skip()
+		}
+		r.Header.Set(name, value)
+
+		rec := &headerCounter{ResponseRecorder: httptest.NewRecorder()}
+		mux.ServeHTTP(rec, r)
+
+		if rec.Code < 100 || rec.Code > 599 {
+			t.Fatalf("%s %s: invalid status %d", method, path, rec.Code)
+		}
+		if rec.headers > 1 {
+			t.Fatalf("%s %s: response header written %d times", method, path, rec.headers)
+		}
+		if rec.Code == http.StatusNotFound || rec.Code == http.StatusMethodNotAllowed {
+			body := rec.Body.Bytes()
+			if bytes.Contains(body, []byte("goroutine ")) || bytes.Contains(body, []byte(".go:")) {
+				t.Fatalf("%s %s: %d response leaks a stack trace:\n%s", method, path, rec.Code, body)
+			}
+		}
+	})
+}
+
+// validHeader reports whether name and value can be sent in a request header.
+func validHeader(name, value string) bool {
+	if name == "" {
+		return false
+	}
+	for _, c := range name {
+		if c <= ' ' || c >= 0x7f || strings.ContainsRune(`"(),/:;<=>?@[\]{}`, c) {
+			return false
+		}
+	}
+	return !strings.ContainsAny(value, "\r\n\x00")
+}
+
+// headerCounter is an httptest.ResponseRecorder that counts how often the
+// response header is written.
+type headerCounter struct {
+	*httptest.ResponseRecorder
+	headers int
+}
+
+// WriteHeader counts the header being written.
+func (h *headerCounter) WriteHeader(status int) {
+	h.headers++
+	h.ResponseRecorder.WriteHeader(status)
+}
+
+// Write counts the implicit header written by the first Write.
+func (h *headerCounter) Write(b []byte) (int, error) {
+	if h.headers == 0 {
+		h.headers++
+	}
+	return h.ResponseRecorder.Write(b)
+}
@@ -0,0 +1,26 @@
+package chaintest_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/jpl-au/chain"
+	"github.com/jpl-au/chain/chaintest"
+)
+
+func FuzzRouter(f *testing.F) {
+	mux := chain.New().
+		WithNotFound(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "not here", http.StatusNotFound)
+		})).
+		WithErrorFormat(chain.ProblemErrors)
+	mux.Use(chain.Recoverer(chain.RecoverOptions{}))
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.PathValue("id")))
+	})
+	mux.HandleFunc("POST /files/{path...}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {})
+	chaintest.Fuzz(f, mux)
+}
@@ -0,0 +1,28 @@
+package chaintest
+
+import (
+	"testing"
+
+	"github.com/jpl-au/chain"
+)
+
+// Lint fails the test with one error per issue reported by mux.Lint. Kinds listed
+// in ignore are skipped, for routers that intentionally rely on them.
+func Lint(t testing.TB, mux *chain.Mux, ignore ...chain.IssueKind) {
+	t.Helper()
+	for _, issue := range mux.Lint() {
+		if !ignored(issue.Kind, ignore) {
+			t.Errorf("chaintest: %s: %s", issue.Kind, issue)
+		}
+	}
+}
+
+// ignored reports whether kind is in ignore.
+func ignored(kind chain.IssueKind, ignore []chain.IssueKind) bool {
+	for _, k := range ignore {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
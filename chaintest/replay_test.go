@@ -0,0 +1,43 @@
+package chaintest_test
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jpl-au/chain"
+	"github.com/jpl-au/chain/chaintest"
+)
+
+func TestReplay(t *testing.T) {
+	var log bytes.Buffer
+	var bodies []string
+	mux := chain.New()
+	mux.Use(chain.Capture(chain.CaptureOptions{Sink: chain.CaptureTo(&log)}))
+	mux.HandleFunc("POST /orders", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, r.Host+" "+r.Header.Get("X-Tenant")+" "+string(body))
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	req := httptest.NewRequest("POST", "/orders", strings.NewReader(`{"sku":"a1"}`))
+	req.Host = "shop.example.com"
+	req.Header.Set("X-Tenant", "acme")
+	mux.ServeHTTP(httptest.NewRecorder(), req)
+
+	captures, err := chaintest.ReadCaptures(&log)
+	if err != nil || len(captures) != 1 {
+		t.Fatalf("ReadCaptures = %d captures, %v", len(captures), err)
+	}
+
+	res := chaintest.Replay(mux, captures[0])
+	if res.Status != http.StatusAccepted || res.Pattern != "POST /orders" {
+		t.Errorf("replay = %d %s", res.Status, res.Pattern)
+	}
+	if len(bodies) != 2 || bodies[0] != bodies[1] {
+		t.Errorf("handler saw %q, want the replay to match the original", bodies)
+	}
+}
@@ -0,0 +1,117 @@
+package chaintest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/jpl-au/chain"
+)
+
+// UpdateEnv is the environment variable that, when set to a non-empty value, makes
+// Snapshot write the current route table to the golden file instead of comparing.
+const UpdateEnv = "CHAINTEST_UPDATE"
+
+// Snapshot compares the route table of mux against the golden file
+// testdata/<test name>.golden and fails the test with a diff if they differ.
+// Routes are sorted by pattern so that reordering registrations does not change the
+// snapshot. Run the tests with CHAINTEST_UPDATE=1 to create or update the file
+// after an intentional change.
+func Snapshot(t testing.TB, mux *chain.Mux) {
+	t.Helper()
+
+	got := FormatRoutes(mux.RouteTable())
+	path := filepath.Join("testdata", strings.ReplaceAll(t.Name(), "/", "_")+".golden")
+
+	if os.Getenv(UpdateEnv) != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("chaintest: creating golden directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatalf("chaintest: writing golden file: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("chaintest: reading golden file (run with %s=1 to create it): %v", UpdateEnv, err)
+	}
+	if d := diff(string(want), got); d != "" {
+		t.Errorf("chaintest: route table does not match %s (-want +got):\n%s", path, d)
+	}
+}
+
+// FormatRoutes renders routes as deterministic text, one block per route sorted by
+// pattern, as used by Snapshot.
+func FormatRoutes(routes []chain.RouteInfo) string {
+	sorted := append([]chain.RouteInfo(nil), routes...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Pattern < sorted[j].Pattern })
+
+	var b strings.Builder
+	for _, rt := range sorted {
+		fmt.Fprintln(&b, rt.Pattern)
+		if rt.Prefix != "" {
+			fmt.Fprintf(&b, "  prefix: %s\n", rt.Prefix)
+		}
+		if len(rt.Middleware) > 0 {
+			fmt.Fprintf(&b, "  middleware: %s\n", strings.Join(rt.Middleware, ", "))
+		}
+		if len(rt.Requirements.Roles) > 0 {
+			fmt.Fprintf(&b, "  roles: %s\n", strings.Join(rt.Requirements.Roles, ", "))
+		}
+		if len(rt.Requirements.Permissions) > 0 {
+			fmt.Fprintf(&b, "  permissions: %s\n", strings.Join(rt.Requirements.Permissions, ", "))
+		}
+		if rt.Deprecated {
+			fmt.Fprintln(&b, "  deprecated")
+		}
+	}
+	return b.String()
+}
+
+// diff returns a line diff of want and got, or an empty string if they are equal.
+// It is a longest-common-subsequence diff, which is ample for route tables.
+func diff(want, got string) string {
+	if want == got {
+		return ""
+	}
+	a := strings.Split(strings.TrimSuffix(want, "\n"), "\n")
+	b := strings.Split(strings.TrimSuffix(got, "\n"), "\n")
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var out strings.Builder
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			fmt.Fprintf(&out, "  %s\n", a[i])
+			i++
+			j++
+		case j < len(b) && (i == len(a) || lcs[i][j+1] >= lcs[i+1][j]):
+			fmt.Fprintf(&out, "+ %s\n", b[j])
+			j++
+		default:
+			fmt.Fprintf(&out, "- %s\n", a[i])
+			i++
+		}
+	}
+	return out.String()
+}
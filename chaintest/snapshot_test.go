@@ -0,0 +1,46 @@
+package chaintest
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/jpl-au/chain"
+)
+
+func TestFormatRoutes(t *testing.T) {
+	mux := chain.New()
+	mux.HandleFunc("GET /z", func(w http.ResponseWriter, r *http.Request) {})
+	mux.Route("/admin", func(admin *chain.Mux) {
+		admin.RequireRole("admin").RequirePermission("users:write")
+		admin.HandleFunc("DELETE /users/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	})
+
+	want := `DELETE /admin/users/{id}
+  prefix: /admin
+  roles: admin
+  permissions: users:write
+GET /z
+`
+	if got := FormatRoutes(mux.RouteTable()); got != want {
+		t.Errorf("Expected:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	mux := chain.New()
+	mux.HandleFunc("GET /users", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("POST /users", func(w http.ResponseWriter, r *http.Request) {})
+
+	Snapshot(t, mux)
+}
+
+func TestDiff(t *testing.T) {
+	got := diff("GET /a\nGET /b\nGET /c\n", "GET /a\nGET /c\nGET /d\n")
+	want := "  GET /a\n- GET /b\n  GET /c\n+ GET /d\n"
+	if got != want {
+		t.Errorf("Expected diff:\n%s\ngot:\n%s", want, got)
+	}
+	if diff("same\n", "same\n") != "" {
+		t.Error("Expected empty diff for equal input")
+	}
+}
@@ -0,0 +1,73 @@
+package chain_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jpl-au/chain"
+)
+
+func TestAllowCIDR(t *testing.T) {
+	mux := chain.New()
+	mux.Route("/admin", func(admin *chain.Mux) {
+		admin.AllowCIDR("10.0.0.0/8", "::1/128")
+		admin.HandleFunc("GET /stats", func(w http.ResponseWriter, r *http.Request) {})
+		admin.Route("/debug", func(debug *chain.Mux) {
+			debug.AllowCIDR("10.1.0.0/16")
+			debug.HandleFunc("GET /vars", func(w http.ResponseWriter, r *http.Request) {})
+		})
+	})
+	mux.HandleFunc("GET /public", func(w http.ResponseWriter, r *http.Request) {})
+
+	tests := []struct {
+		path, remote string
+		status       int
+	}{
+		{"/admin/stats", "10.2.3.4:1234", http.StatusOK},
+		{"/admin/stats", "[::1]:1234", http.StatusOK},
+		{"/admin/stats", "[::ffff:10.2.3.4]:1234", http.StatusOK},
+		{"/admin/stats", "192.168.1.1:1234", http.StatusForbidden},
+		{"/admin/stats", "garbage", http.StatusForbidden},
+		{"/admin/debug/vars", "10.1.2.3:1234", http.StatusOK},
+		{"/admin/debug/vars", "10.2.3.4:1234", http.StatusForbidden},
+		{"/public", "192.168.1.1:1234", http.StatusOK},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", tt.path, nil)
+		req.RemoteAddr = tt.remote
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		if rec.Code != tt.status {
+			t.Errorf("%s from %s: expected %d, got %d", tt.path, tt.remote, tt.status, rec.Code)
+		}
+	}
+}
+
+func TestAllowCIDRResolvedByUsePre(t *testing.T) {
+	mux := chain.New().UsePre(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r.RemoteAddr = r.Header.Get("X-Real-Ip")
+			next.ServeHTTP(w, r)
+		})
+	})
+	mux.AllowCIDR("10.0.0.0/8").HandleFunc("GET /internal", func(w http.ResponseWriter, r *http.Request) {})
+
+	req := httptest.NewRequest("GET", "/internal", nil)
+	req.RemoteAddr = "203.0.113.1:1234"
+	req.Header.Set("X-Real-Ip", "10.0.0.5")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected resolved address allowed, got %d", rec.Code)
+	}
+}
+
+func TestAllowCIDRInvalid(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected panic for invalid network")
+		}
+	}()
+	chain.New().AllowCIDR("10.0.0.0/33")
+}
@@ -0,0 +1,104 @@
+package chain
+
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
+// ClientOptions configures Client.
+type ClientOptions struct {
+	// Transport sends the outbound requests. Defaults to http.DefaultTransport.
+	Transport http.RoundTripper
+	// Headers names inbound request headers copied to outbound requests that do
+	// not already set them. Defaults to "X-Request-Id". Credentials such as
+	// "Authorization" are only forwarded when listed explicitly, and should only
+	// be listed for clients that call trusted services.
+	Headers []string
+	// TraceFormat selects the headers that carry the trace context started by
+	// Trace. Defaults to TraceB3.
+	TraceFormat TraceFormat
+	// Cost is charged to the request's Budget for each outbound request.
+	Cost int64
+}
+
+// Client returns an http.Client for calls made while serving r that propagate
+// r's context to the services called. Outbound requests receive:
+//
+//   - the inbound headers named in opts.Headers, such as the request ID
+//   - the trace context stored by Trace, as a child span of the inbound request
+//   - r's deadline, if the outbound request's context has none, so that calls do
+//     not outlive the caller's budget
+//
+// When r has a Budget, each outbound request is charged opts.Cost and its
+// deadline is the budget's Reserve ahead of r's; requests the budget cannot cover
+// fail with ErrBudgetExhausted.
+//
+// The returned client is cheap to create and should not be kept beyond the
+// handler serving r.
+func Client(r *http.Request, opts ClientOptions) *http.Client {
+	if r == nil {
+		panic("chain: nil request passed to Client")
+	}
+	base := opts.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	headers := opts.Headers
+	if headers == nil {
+		headers = []string{"X-Request-Id"}
+	}
+	trace := TraceTransport(base, opts.TraceFormat)
+
+	return &http.Client{Transport: roundTripperFunc(func(out *http.Request) (*http.Response, error) {
+		ctx := out.Context()
+		if _, ok := TraceFrom(ctx); !ok {
+			if tc, ok := TraceFrom(r.Context()); ok {
+				ctx = context.WithValue(ctx, traceKey{}, tc)
+			}
+		}
+		var cancel context.CancelFunc
+		if b, ok := BudgetFrom(r.Context()); ok {
+			var err error
+			if ctx, cancel, err = b.callContext(ctx, opts.Cost); err != nil {
+				return nil, err
+			}
+		} else if _, ok := ctx.Deadline(); !ok {
+			if deadline, ok := r.Context().Deadline(); ok {
+				ctx, cancel = context.WithDeadline(ctx, deadline)
+			}
+		}
+
+		// RoundTrippers must not modify the caller's request
+		out = out.Clone(ctx)
+		for _, name := range headers {
+			if v := r.Header.Values(name); len(v) > 0 && out.Header.Get(name) == "" {
+				out.Header[http.CanonicalHeaderKey(name)] = v
+			}
+		}
+
+		resp, err := trace.RoundTrip(out)
+		if cancel != nil {
+			if err != nil {
+				cancel()
+			} else {
+				// The deadline must cover reading the body, so it is released
+				// only once the body is closed
+				resp.Body = cancelBody{resp.Body, cancel}
+			}
+		}
+		return resp, err
+	})}
+}
+
+// cancelBody cancels a context when the response body is closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,87 @@
+package chain
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ClientConcurrencyOptions configures the ClientConcurrency middleware.
+type ClientConcurrencyOptions struct {
+	// Max is the number of requests a single client may have in flight at once.
+	// Defaults to 10.
+	Max int
+	// Key identifies the client, for example by API key. Defaults to the remote IP
+	// address. Requests for which Key returns "" are not limited.
+	Key func(r *http.Request) string
+	// RetryAfter is the delay suggested to rejected clients in the Retry-After
+	// header. Defaults to 1 second.
+	RetryAfter time.Duration
+}
+
+// clientSlots counts the requests in flight per client key.
+type clientSlots struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+// ClientConcurrency returns middleware that caps the number of concurrent requests
+// per client, responding with 429 Too Many Requests and a Retry-After header once
+// a client is at its limit. Unlike a rate limit it counts requests still being
+// served, so a client holding many slow requests open cannot occupy all handler
+// capacity. A slot is held until the handler returns, including for streaming
+// and hijacked connections.
+func ClientConcurrency(opts ClientConcurrencyOptions) func(http.Handler) http.Handler {
+	if opts.Max <= 0 {
+		opts.Max = 10
+	}
+	if opts.RetryAfter <= 0 {
+		opts.RetryAfter = defaultRetryAfter
+	}
+	if opts.Key == nil {
+		opts.Key = func(r *http.Request) string {
+			if addr, ok := remoteAddr(r); ok {
+				return addr.String()
+			}
+			return r.RemoteAddr
+		}
+	}
+	s := &clientSlots{counts: make(map[string]int)}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := opts.Key(r)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !s.acquire(key, opts.Max) {
+				WriteRetryAfter(w, r, http.StatusTooManyRequests, opts.RetryAfter, "")
+				return
+			}
+			defer s.release(key)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// acquire takes a slot for key, reporting false if it already holds max.
+func (s *clientSlots) acquire(key string, max int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.counts[key] >= max {
+		return false
+	}
+	s.counts[key]++
+	return true
+}
+
+// release returns a slot for key, forgetting clients with none in flight so the
+// map does not grow with every address seen.
+func (s *clientSlots) release(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.counts[key]--; s.counts[key] <= 0 {
+		delete(s.counts, key)
+	}
+}
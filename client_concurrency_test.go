@@ -0,0 +1,84 @@
+package chain_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jpl-au/chain"
+)
+
+func TestClientConcurrency(t *testing.T) {
+	mux := chain.New()
+	mux.Use(chain.ClientConcurrency(chain.ClientConcurrencyOptions{Max: 1}))
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	mux.HandleFunc("GET /slow", func(w http.ResponseWriter, r *http.Request) {
+		entered <- struct{}{}
+		<-release
+	})
+
+	request := func(addr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/slow", nil)
+		req.RemoteAddr = addr
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	done := make(chan struct{})
+	go func() {
+		request("192.0.2.1:1000")
+		close(done)
+	}()
+	<-entered
+
+	if rec := request("192.0.2.1:2000"); rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "1" {
+		t.Errorf("second request from same client = %d with Retry-After %q, want 429 with 1", rec.Code, rec.Header().Get("Retry-After"))
+	}
+
+	other := make(chan int)
+	go func() { other <- request("192.0.2.2:1000").Code }()
+	<-entered
+	release <- struct{}{}
+	release <- struct{}{}
+	if code := <-other; code != http.StatusOK {
+		t.Errorf("request from other client = %d, want 200", code)
+	}
+	<-done
+
+	// The slot is returned once the handler finishes
+	go func() { <-entered; release <- struct{}{} }()
+	if rec := request("192.0.2.1:3000"); rec.Code != http.StatusOK {
+		t.Errorf("request after release = %d, want 200", rec.Code)
+	}
+}
+
+func TestClientConcurrencyKey(t *testing.T) {
+	mux := chain.New()
+	mux.Use(chain.ClientConcurrency(chain.ClientConcurrencyOptions{
+		Max: 1,
+		Key: func(r *http.Request) string { return r.Header.Get("X-Api-Key") },
+	}))
+	mux.HandleFunc("GET /", func(w http.ResponseWriter, r *http.Request) {
+		// Nested request from the same key while the first is in flight
+		if r.Header.Get("X-Nested") == "" {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set("X-Api-Key", r.Header.Get("X-Api-Key"))
+			req.Header.Set("X-Nested", "1")
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+			w.WriteHeader(rec.Code)
+		}
+	})
+
+	for key, want := range map[string]int{"abc": http.StatusTooManyRequests, "": http.StatusOK} {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("X-Api-Key", key)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		if rec.Code != want {
+			t.Errorf("key %q: nested status = %d, want %d", key, rec.Code, want)
+		}
+	}
+}
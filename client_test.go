@@ -0,0 +1,97 @@
+package chain_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jpl-au/chain"
+)
+
+func TestClient(t *testing.T) {
+	var got http.Header
+	var deadline bool
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		io.WriteString(w, "ok")
+	}))
+	defer backend.Close()
+
+	mux := chain.New().Use(chain.Trace())
+	mux.HandleFunc("GET /proxy", func(w http.ResponseWriter, r *http.Request) {
+		client := chain.Client(r, chain.ClientOptions{
+			Transport: roundTripper(func(out *http.Request) (*http.Response, error) {
+				_, deadline = out.Context().Deadline()
+				return http.DefaultTransport.RoundTrip(out)
+			}),
+		})
+		resp, err := client.Get(backend.URL)
+		if err != nil {
+			t.Errorf("Outbound request failed: %v", err)
+			return
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	})
+
+	req := httptest.NewRequest("GET", "/proxy", nil)
+	req.Header.Set("X-Request-Id", "abc123")
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("X-B3-TraceId", "463ac35c9f6413ad")
+	req.Header.Set("X-B3-SpanId", "a2fb4a1d1a96d312")
+	ctx, cancel := context.WithTimeout(req.Context(), time.Minute)
+	defer cancel()
+	mux.ServeHTTP(httptest.NewRecorder(), req.WithContext(ctx))
+
+	if got.Get("X-Request-Id") != "abc123" {
+		t.Errorf("Expected request ID forwarded, got '%s'", got.Get("X-Request-Id"))
+	}
+	if got.Get("Authorization") != "" {
+		t.Error("Expected Authorization not forwarded by default")
+	}
+	if got.Get("X-B3-TraceId") != "463ac35c9f6413ad" {
+		t.Errorf("Expected trace ID forwarded, got '%s'", got.Get("X-B3-TraceId"))
+	}
+	if got.Get("X-B3-SpanId") == "a2fb4a1d1a96d312" || got.Get("X-B3-ParentSpanId") == "" {
+		t.Errorf("Expected a child span, got span '%s' parent '%s'", got.Get("X-B3-SpanId"), got.Get("X-B3-ParentSpanId"))
+	}
+	if !deadline {
+		t.Error("Expected inbound deadline on outbound request")
+	}
+}
+
+func TestClientHeaders(t *testing.T) {
+	var got http.Header
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}))
+	defer backend.Close()
+
+	in := httptest.NewRequest("GET", "/", nil)
+	in.Header.Set("Authorization", "Bearer secret")
+	in.Header.Set("X-Request-Id", "abc123")
+	client := chain.Client(in, chain.ClientOptions{Headers: []string{"Authorization"}})
+
+	out, _ := http.NewRequest("GET", backend.URL, nil)
+	out.Header.Set("Authorization", "Bearer other")
+	resp, err := client.Do(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if got.Get("Authorization") != "Bearer other" {
+		t.Errorf("Expected outbound header kept, got '%s'", got.Get("Authorization"))
+	}
+	if got.Get("X-Request-Id") != "" {
+		t.Error("Expected only listed headers forwarded")
+	}
+}
+
+// roundTripper adapts a function to http.RoundTripper.
+type roundTripper func(*http.Request) (*http.Response, error)
+
+func (f roundTripper) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
@@ -0,0 +1,118 @@
+package chain_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jpl-au/chain"
+)
+
+// newCert creates a certificate signed by parent (or self-signed when parent is nil).
+func newCert(t *testing.T, tmpl *x509.Certificate, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	if parent == nil {
+		parent, parentKey = tmpl, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("Failed to parse certificate: %v", err)
+	}
+	return cert, key
+}
+
+func TestClientCert(t *testing.T) {
+	ca, caKey := newCert(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, nil, nil)
+	leaf, _ := newCert(t, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "billing"},
+		DNSNames:     []string{"billing.internal"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, ca, caKey)
+	stranger, _ := newCert(t, &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		DNSNames:     []string{"billing.internal"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, nil, nil)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+
+	tests := []struct {
+		name   string
+		sans   []string
+		state  *tls.ConnectionState
+		status int
+	}{
+		{"no tls", nil, nil, http.StatusForbidden},
+		{"valid cert", nil, &tls.ConnectionState{PeerCertificates: []*x509.Certificate{leaf}}, http.StatusOK},
+		{"matching san", []string{"*.internal"}, &tls.ConnectionState{PeerCertificates: []*x509.Certificate{leaf}}, http.StatusOK},
+		{"mismatched san", []string{"*.example.com"}, &tls.ConnectionState{PeerCertificates: []*x509.Certificate{leaf}}, http.StatusForbidden},
+		{"untrusted cert", nil, &tls.ConnectionState{PeerCertificates: []*x509.Certificate{stranger}}, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var identity string
+			mux := chain.New()
+			mux.Route("/internal", func(internal *chain.Mux) {
+				internal.Use(chain.ClientCert(chain.ClientCertOptions{Roots: roots, SANs: tt.sans}))
+				internal.HandleFunc("GET /status", func(w http.ResponseWriter, r *http.Request) {
+					identity = chain.ClientCertificate(r).Subject.CommonName
+				})
+			})
+
+			req := httptest.NewRequest("GET", "/internal/status", nil)
+			req.TLS = tt.state
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+			if rec.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, rec.Code)
+			}
+			if tt.status == http.StatusOK && identity != "billing" {
+				t.Errorf("Expected identity 'billing', got '%s'", identity)
+			}
+		})
+	}
+}
+
+func TestClientCertRequiresVerifiedChainWithoutRoots(t *testing.T) {
+	mux := chain.New().Use(chain.ClientCert(chain.ClientCertOptions{}))
+	mux.HandleFunc("GET /", func(w http.ResponseWriter, r *http.Request) {})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{{}}}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403, got %d", rec.Code)
+	}
+}
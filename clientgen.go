@@ -0,0 +1,269 @@
+package chain
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// endpoint is a route prepared for client generation.
+type endpoint struct {
+	name       string
+	method     string
+	pattern    string
+	segments   []pathSegment
+	deprecated bool
+}
+
+// GenerateGoClient writes the source of a Go package named pkg containing a
+// Client type with one method per route, named from the route's method and path,
+// such as GetUsersByID for "GET /users/{id}". Each method takes the path
+// wildcards as strings, and a body for methods that carry one, and returns the
+// *http.Response, which the caller decodes. Routes registered without a method
+// are skipped, and deprecated routes are marked as such.
+//
+// Generation is typically run from a test or a go:generate command that builds
+// the router:
+//
+//	f, _ := os.Create("client/client.go")
+//	defer f.Close()
+//	err := newRouter().GenerateGoClient(f, "client")
+func (m *Mux) GenerateGoClient(w io.Writer, pkg string) error {
+	endpoints := clientEndpoints(m.RouteTable())
+	var b bytes.Buffer
+	b.WriteString("// Code generated by chain; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "package %s\n\n", pkg)
+	b.WriteString("import (\n\"context\"\n\"io\"\n\"net/http\"\n")
+	if hasParams(endpoints) {
+		b.WriteString("\"net/url\"\n")
+	}
+	b.WriteString(`"strings"
+)
+
+// Client calls the routes of the API.
+type Client struct {
+	// BaseURL is the scheme, host and any path prefix of the API, such as
+	// "https://api.example.com".
+	BaseURL string
+	// HTTPClient sends requests. Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// do sends a request for path.
+func (c *Client) do(ctx context.Context, method, path string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(c.BaseURL, "/")+path, body)
+	if err != nil {
+		return nil, err
+	}
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return client.Do(req)
+}
+`)
+
+	for _, e := range endpoints {
+		args := []string{"ctx context.Context"}
+		var path []string
+		for _, s := range e.segments {
+			if s.param == "" {
+				path = append(path, strconv.Quote(s.literal))
+				continue
+			}
+			arg := goIdent(s.param)
+			args = append(args, arg+" string")
+			if s.rest {
+				path = append(path, `strings.ReplaceAll(url.PathEscape(`+arg+`), "%2F", "/")`)
+			} else {
+				path = append(path, "url.PathEscape("+arg+")")
+			}
+		}
+		body := "nil"
+		if hasBody(e.method) {
+			args = append(args, "body io.Reader")
+			body = "body"
+		}
+		if len(path) == 0 {
+			path = []string{`"/"`}
+		}
+
+		fmt.Fprintf(&b, "\n// %s calls %s.\n", e.name, e.pattern)
+		if e.deprecated {
+			b.WriteString("//\n// Deprecated: the route is deprecated.\n")
+		}
+		fmt.Fprintf(&b, "func (c *Client) %s(%s) (*http.Response, error) {\n", e.name, strings.Join(args, ", "))
+		fmt.Fprintf(&b, "return c.do(ctx, %q, %s, %s)\n}\n", e.method, strings.Join(path, " + "), body)
+	}
+
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(src)
+	return err
+}
+
+// GenerateTSClient writes a TypeScript module exporting a Client class with one
+// method per route, as GenerateGoClient does for Go, built on fetch. Methods are
+// named in lower camel case, such as getUsersByID, take the path wildcards as
+// strings and an optional RequestInit for the body and headers, and return the
+// fetch Response.
+func (m *Mux) GenerateTSClient(w io.Writer) error {
+	var b bytes.Buffer
+	b.WriteString(`// Code generated by chain; DO NOT EDIT.
+
+export class Client {
+  constructor(
+    private readonly baseURL: string,
+    private readonly fetchFn: typeof fetch = fetch,
+  ) {}
+
+  private call(method: string, path: string, init?: RequestInit): Promise<Response> {
+    return this.fetchFn(this.baseURL.replace(/\/$/, "") + path, { ...init, method });
+  }
+`)
+	for _, e := range clientEndpoints(m.RouteTable()) {
+		var args, path []string
+		for _, s := range e.segments {
+			if s.param == "" {
+				path = append(path, strconv.Quote(s.literal))
+				continue
+			}
+			arg := lowerFirst(goIdent(s.param))
+			args = append(args, arg+": string")
+			if s.rest {
+				path = append(path, arg+`.split("/").map(encodeURIComponent).join("/")`)
+			} else {
+				path = append(path, "encodeURIComponent("+arg+")")
+			}
+		}
+		args = append(args, "init?: RequestInit")
+		if len(path) == 0 {
+			path = []string{`"/"`}
+		}
+
+		doc := "Calls " + e.pattern + "."
+		if e.deprecated {
+			doc += " @deprecated"
+		}
+		fmt.Fprintf(&b, "\n  /** %s */\n", doc)
+		fmt.Fprintf(&b, "  %s(%s): Promise<Response> {\n", lowerFirst(e.name), strings.Join(args, ", "))
+		fmt.Fprintf(&b, "    return this.call(%q, %s, init);\n  }\n", e.method, strings.Join(path, " + "))
+	}
+	b.WriteString("}\n")
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// clientEndpoints prepares the routes of table that have a method, giving each a
+// unique name.
+func clientEndpoints(table []RouteInfo) []endpoint {
+	var endpoints []endpoint
+	used := make(map[string]int)
+	for _, rt := range table {
+		_, path, method := splitPattern(rt.Pattern)
+		if method == "" || path == "" {
+			continue
+		}
+		e := endpoint{method: method, pattern: rt.Pattern, deprecated: rt.Deprecated}
+
+		name := methodName(method)
+		words := 0
+		e.segments = patternSegments(path)
+		for _, s := range e.segments {
+			if s.param == "" {
+				name += exportedWords(s.literal, &words)
+			} else {
+				name += "By" + exportedWords(s.param, &words)
+			}
+		}
+		if words == 0 {
+			name += "Root"
+		}
+		if n := used[name]; n > 0 {
+			used[name]++
+			name += strconv.Itoa(n + 1)
+		} else {
+			used[name] = 1
+		}
+		e.name = name
+		endpoints = append(endpoints, e)
+	}
+	return endpoints
+}
+
+// methodName returns an HTTP method in title case, such as "Get".
+func methodName(method string) string {
+	return strings.ToUpper(method[:1]) + strings.ToLower(method[1:])
+}
+
+// exportedWords converts the words of s, separated by any character that cannot
+// appear in an identifier, to title case, counting them in words. Initialisms
+// such as ID and URL are kept upper case, as is Go style.
+func exportedWords(s string, words *int) string {
+	var b strings.Builder
+	for _, w := range strings.FieldsFunc(s, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }) {
+		*words++
+		switch upper := strings.ToUpper(w); upper {
+		case "ID", "URL", "API", "HTTP", "UUID", "JSON":
+			b.WriteString(upper)
+		default:
+			b.WriteString(strings.ToUpper(w[:1]) + w[1:])
+		}
+	}
+	return b.String()
+}
+
+// goIdent returns a lower camel case Go identifier for a wildcard name that does
+// not clash with keywords or the generated methods' other parameters.
+func goIdent(name string) string {
+	var n int
+	id := lowerFirst(exportedWords(name, &n))
+	if id == "" || unicode.IsDigit(rune(id[0])) {
+		id = "p" + id
+	}
+	if token.IsKeyword(id) || id == "ctx" || id == "body" || id == "init" {
+		id += "Param"
+	}
+	return id
+}
+
+// lowerFirst lowers the first word of an identifier, including initialisms such
+// as "ID" in "IDs".
+func lowerFirst(s string) string {
+	i := 0
+	for i < len(s) && unicode.IsUpper(rune(s[i])) {
+		i++
+	}
+	if i > 1 && i < len(s) {
+		i-- // keep the start of the next word, as in "IDList" -> "idList"
+	}
+	return strings.ToLower(s[:i]) + s[i:]
+}
+
+// hasParams reports whether any endpoint has a path wildcard.
+func hasParams(endpoints []endpoint) bool {
+	for _, e := range endpoints {
+		for _, s := range e.segments {
+			if s.param != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hasBody reports whether requests with method usually carry a body.
+func hasBody(method string) bool {
+	switch method {
+	case "POST", "PUT", "PATCH":
+		return true
+	}
+	return false
+}
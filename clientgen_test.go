@@ -0,0 +1,109 @@
+package chain_test
+
+import (
+	"bytes"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jpl-au/chain"
+)
+
+func clientMux() *chain.Mux {
+	h := func(w http.ResponseWriter, r *http.Request) {}
+
+	mux := chain.New()
+	mux.HandleFunc("GET /{$}", h)
+	mux.HandleFunc("GET /users", h)
+	mux.HandleFunc("POST /users", h)
+	mux.HandleFunc("GET /users/{id}", h)
+	mux.HandleFunc("PUT /users/{user_id}/orders/{type}", h)
+	mux.HandleFunc("GET /files/{path...}", h)
+	mux.HandleFunc("/health", h)
+	mux.Route("/v1", func(v1 *chain.Mux) {
+		v1.Deprecated(time.Time{}, "")
+		v1.HandleFunc("GET /users", h)
+	})
+	return mux
+}
+
+func TestGenerateGoClient(t *testing.T) {
+	var buf bytes.Buffer
+	if err := clientMux().GenerateGoClient(&buf, "client"); err != nil {
+		t.Fatal(err)
+	}
+	src := buf.String()
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "client.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("Generated source does not parse: %v\n%s", err, src)
+	}
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	if _, err := conf.Check("client", fset, []*ast.File{f}, nil); err != nil {
+		t.Fatalf("Generated source does not type check: %v\n%s", err, src)
+	}
+
+	for _, want := range []string{
+		"// Code generated by chain; DO NOT EDIT.",
+		`func (c *Client) GetRoot(ctx context.Context) (*http.Response, error) {`,
+		`return c.do(ctx, "GET", "/", nil)`,
+		`func (c *Client) PostUsers(ctx context.Context, body io.Reader) (*http.Response, error) {`,
+		`func (c *Client) GetUsersByID(ctx context.Context, id string) (*http.Response, error) {`,
+		`return c.do(ctx, "GET", "/users/"+url.PathEscape(id), nil)`,
+		`func (c *Client) PutUsersByUserIDOrdersByType(ctx context.Context, userID string, typeParam string, body io.Reader) (*http.Response, error) {`,
+		`strings.ReplaceAll(url.PathEscape(path), "%2F", "/")`,
+		"// Deprecated: the route is deprecated.\nfunc (c *Client) GetV1Users(",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("Generated source lacks %q:\n%s", want, src)
+		}
+	}
+	if strings.Contains(src, "Health") {
+		t.Errorf("Route without a method should be skipped:\n%s", src)
+	}
+}
+
+func TestGenerateGoClientDuplicateNames(t *testing.T) {
+	h := func(w http.ResponseWriter, r *http.Request) {}
+
+	mux := chain.New()
+	mux.HandleFunc("GET /user-list", h)
+	mux.HandleFunc("GET /user_list", h)
+
+	var buf bytes.Buffer
+	if err := mux.GenerateGoClient(&buf, "client"); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"GetUserList(", "GetUserList2("} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Generated source lacks %q:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestGenerateTSClient(t *testing.T) {
+	var buf bytes.Buffer
+	if err := clientMux().GenerateTSClient(&buf); err != nil {
+		t.Fatal(err)
+	}
+	src := buf.String()
+	for _, want := range []string{
+		"export class Client {",
+		`getUsersByID(id: string, init?: RequestInit): Promise<Response> {`,
+		`return this.call("GET", "/users/" + encodeURIComponent(id), init);`,
+		`putUsersByUserIDOrdersByType(userID: string, typeParam: string, init?: RequestInit)`,
+		`path.split("/").map(encodeURIComponent).join("/")`,
+		"/** Calls GET /v1/users. @deprecated */",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("Generated source lacks %q:\n%s", want, src)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package chain
+
+import (
+	"maps"
+	"slices"
+)
+
+// Clone returns a new, independent router with a copy of m's configuration:
+// middleware, prefix, Wrap and UsePre middleware, Finally hooks, custom error
+// handlers, method restrictions, rewrites, protocol handlers, authorization,
+// network restrictions, cache, cache key, deprecation, SLO, readiness,
+// maintenance and priority policies, honeypot options, logger, reporter, error format, codecs,
+// in-flight limit, profile, pprof labelling and zero-allocation mode. This lets
+// a base router carrying shared setup such as logging, metrics and
+// authentication be stamped out for several services or listeners in one binary.
+//
+// If withRoutes is set, routes registered on m's router are also registered on the
+// clone, along with the asset paths of its Static mounts. Copied routes keep the
+// middleware they were registered with, so settings changed on the clone
+// afterwards only affect routes registered on the clone. Their middleware is
+// rebuilt on the clone, so usage counts of deprecated routes, SLO counters and
+// in-flight gauges start from zero and count only the clone's traffic. Active
+// overrides, disabled routes and event subscriptions are not copied.
+func (m *Mux) Clone(withRoutes bool) *Mux {
+	root := m.root
+	c := New()
+	c.middlewares = slices.Clone(m.middlewares)
+	c.prefix = m.prefix
+	c.required = m.required.clone()
+	c.deprecation = m.deprecation
+	c.slo = m.slo
+	c.ready = slices.Clone(m.ready)
+	c.maintenance = m.maintenance
+	c.allowNets = slices.Clone(m.allowNets)
+	c.cache = m.cache
+	c.priority = m.priority
+	c.cacheQuery = m.cacheQuery
+
+	c.notFound = root.notFound
+	c.methodNotAllowed = root.methodNotAllowed
+	c.outer = slices.Clone(root.outer)
+	c.pre = slices.Clone(root.pre)
+	c.finalize = slices.Clone(root.finalize)
+	c.allowedMethods = maps.Clone(root.allowedMethods)
+	c.allowHeader = root.allowHeader
+	c.rewrites = slices.Clone(root.rewrites)
+	c.grpc = root.grpc
+	c.grpcWeb = root.grpcWeb
+	c.acme = root.acme
+	c.honeypot = root.honeypot
+	c.authorizer = root.authorizer
+	c.forbidden = root.forbidden
+	c.logger = root.logger
+	c.reporter = root.reporter
+	c.errorFormat = root.errorFormat
+	c.codecs = root.codecs
+	c.maxInFlight.Store(root.maxInFlight.Load())
+	c.profile = root.profile
+	c.pprofLabels = root.pprofLabels
+	c.zeroAlloc = root.zeroAlloc
+
+	if withRoutes {
+		root.mu.RLock()
+		routes := slices.Clone(root.routes)
+		c.assets = maps.Clone(root.assets)
+		root.mu.RUnlock()
+		for _, rt := range routes {
+			rt.info = rt.info.clone()
+			switch {
+			case rt.alias != "":
+				// Serve the clone's copy of the canonical route, registered earlier
+				canonical, _ := c.lookup(rt.alias)
+				rt.handler, rt.wrap = canonical.handler, canonical.wrap
+				c.handle(rt)
+			case rt.group != nil:
+				// Rebuild the middleware so that it reports to the clone
+				rt.group.groupOn(c, rt.group.prefix).register(rt.info.Pattern, rt.raw)
+			default:
+				c.handle(rt)
+			}
+		}
+	}
+	return c
+}
@@ -0,0 +1,96 @@
+package chain_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jpl-au/chain"
+)
+
+func TestClone(t *testing.T) {
+	base := chain.New().Use(tag("base"))
+	base.HandleFunc("GET /health", func(w http.ResponseWriter, r *http.Request) {})
+
+	users := base.Clone(false).Use(tag("users"))
+	users.HandleFunc("GET /users", func(w http.ResponseWriter, r *http.Request) {})
+
+	rec := httptest.NewRecorder()
+	users.ServeHTTP(rec, httptest.NewRequest("GET", "/users", nil))
+	if got := strings.Join(rec.Header().Values("X-Order"), ","); got != "base,users" {
+		t.Errorf("Expected order 'base,users', got '%s'", got)
+	}
+
+	rec = httptest.NewRecorder()
+	users.ServeHTTP(rec, httptest.NewRequest("GET", "/health", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected routes not to be copied, got %d", rec.Code)
+	}
+
+	// Middleware added to the clone must not leak into the base
+	base.HandleFunc("GET /status", func(w http.ResponseWriter, r *http.Request) {})
+	rec = httptest.NewRecorder()
+	base.ServeHTTP(rec, httptest.NewRequest("GET", "/status", nil))
+	if got := strings.Join(rec.Header().Values("X-Order"), ","); got != "base" {
+		t.Errorf("Expected order 'base', got '%s'", got)
+	}
+}
+
+func TestCloneWithRoutes(t *testing.T) {
+	base := chain.New().WithNotFound(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "custom", http.StatusNotFound)
+	}))
+	base.HandleFunc("GET /health", func(w http.ResponseWriter, r *http.Request) {})
+
+	c := base.Clone(true)
+	c.HandleFunc("GET /extra", func(w http.ResponseWriter, r *http.Request) {})
+
+	rec := httptest.NewRecorder()
+	c.ServeHTTP(rec, httptest.NewRequest("GET", "/health", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected copied route to serve 200, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	c.ServeHTTP(rec, httptest.NewRequest("GET", "/missing", nil))
+	if !strings.Contains(rec.Body.String(), "custom") {
+		t.Errorf("Expected custom 404 handler to be copied, got '%s'", rec.Body.String())
+	}
+
+	if len(base.RouteTable()) != 1 || len(c.RouteTable()) != 2 {
+		t.Errorf("Expected route tables of 1 and 2, got %d and %d", len(base.RouteTable()), len(c.RouteTable()))
+	}
+}
+
+func TestCloneWithRoutesCountsOnClone(t *testing.T) {
+	orig := chain.New()
+	orig.Group(func(v1 *chain.Mux) {
+		v1.Deprecated(time.Now().Add(time.Hour), "")
+		v1.HandleFunc("GET /x", func(w http.ResponseWriter, r *http.Request) {})
+	})
+	orig.Alias("GET /y", "GET /x")
+
+	c := orig.Clone(true)
+	c.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/x", nil))
+	c.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/y", nil))
+
+	if got := c.DeprecatedUsage()["GET /x"]; got != 2 {
+		t.Errorf("Expected clone to count 2 requests, got %d", got)
+	}
+	if _, ok := c.InFlightByRoute()["GET /x"]; !ok {
+		t.Errorf("Expected clone to track in-flight requests for GET /x")
+	}
+	if got := orig.DeprecatedUsage()["GET /x"]; got != 0 {
+		t.Errorf("Expected original not to count the clone's requests, got %d", got)
+	}
+
+	orig.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/x", nil))
+	if got := orig.DeprecatedUsage()["GET /x"]; got != 1 {
+		t.Errorf("Expected original to count 1 request, got %d", got)
+	}
+	if got := c.DeprecatedUsage()["GET /x"]; got != 2 {
+		t.Errorf("Expected clone not to count the original's requests, got %d", got)
+	}
+}
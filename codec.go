@@ -0,0 +1,156 @@
+package chain
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Codec encodes and decodes one media type. Codecs registered with WithCodecs are
+// used by Bind, Respond and NegotiatedErrors, so formats such as MessagePack or
+// CBOR can be added without changing handlers.
+type Codec interface {
+	// ContentType returns the media type handled, such as "application/json". It
+	// is matched against request Content-Type and Accept headers, and sent as the
+	// Content-Type of encoded responses.
+	ContentType() string
+	Encode(w io.Writer, v any) error
+	Decode(r io.Reader, v any) error
+}
+
+// Built-in codecs, registered by default in this order.
+var (
+	// JSONCodec handles application/json.
+	JSONCodec Codec = jsonCodec{}
+	// XMLCodec handles application/xml, and decodes text/xml too. Documents
+	// containing a DOCTYPE are rejected with ErrXMLDoctype.
+	XMLCodec Codec = xmlCodec{}
+)
+
+// defaultCodecs are used when WithCodecs has not been called.
+var defaultCodecs = []Codec{JSONCodec, XMLCodec}
+
+// WithCodecs sets the codecs used by Bind, Respond and NegotiatedErrors, in order
+// of preference: the first is used when the client accepts any format. Calling
+// WithCodecs inside a group sets them on the root Mux. Returns the Mux instance
+// for chaining.
+//
+//	mux.WithCodecs(chain.JSONCodec, msgpackCodec{}, chain.XMLCodec)
+func (m *Mux) WithCodecs(codecs ...Codec) *Mux {
+	if len(codecs) == 0 {
+		panic("chain: no codecs passed to WithCodecs")
+	}
+	for _, c := range codecs {
+		if c == nil {
+			panic("chain: nil codec passed to WithCodecs")
+		}
+	}
+	m.root.codecs = codecs
+	return m
+}
+
+// codecsFor returns the codecs of the router serving w, or the defaults.
+func codecsFor(w http.ResponseWriter) []Codec {
+	if rw := findResponseWriter(w); rw != nil && rw.codecs != nil {
+		return rw.codecs
+	}
+	return defaultCodecs
+}
+
+// mediaTypeAliases maps alternative media types to those of the built-in codecs.
+var mediaTypeAliases = map[string]string{
+	"text/xml":             "application/xml",
+	"application/protobuf": "application/x-protobuf",
+}
+
+// codecForType returns the codec handling mediaType, resolving aliases such as
+// text/xml for application/xml.
+func codecForType(codecs []Codec, mediaType string) (Codec, bool) {
+	mediaType = strings.ToLower(mediaType)
+	if alias, ok := mediaTypeAliases[mediaType]; ok {
+		mediaType = alias
+	}
+	for _, c := range codecs {
+		if mediaTypeOf(c) == mediaType {
+			return c, true
+		}
+	}
+	return nil, false
+}
+
+// negotiateCodec returns the codec best matching the Accept header, defaulting to
+// the first codec when nothing matches.
+func negotiateCodec(codecs []Codec, accept string) Codec {
+	for _, mediaRange := range parseAccept(accept) {
+		mediaRange = strings.ToLower(mediaRange)
+		if c, ok := codecForType(codecs, mediaRange); ok {
+			return c
+		}
+		if mediaRange == "*/*" {
+			return codecs[0]
+		}
+		if prefix, ok := strings.CutSuffix(mediaRange, "*"); ok {
+			for _, c := range codecs {
+				if strings.HasPrefix(mediaTypeOf(c), prefix) {
+					return c
+				}
+			}
+		}
+	}
+	return codecs[0]
+}
+
+// mediaTypeOf returns the codec's content type without parameters.
+func mediaTypeOf(c Codec) string {
+	mediaType, _, _ := strings.Cut(c.ContentType(), ";")
+	return strings.ToLower(strings.TrimSpace(mediaType))
+}
+
+// jsonCodec implements JSONCodec.
+type jsonCodec struct{}
+
+func (jsonCodec) ContentType() string { return "application/json" }
+
+func (jsonCodec) Encode(w io.Writer, v any) error { return json.NewEncoder(w).Encode(v) }
+
+func (jsonCodec) Decode(r io.Reader, v any) error { return json.NewDecoder(r).Decode(v) }
+
+// xmlCodec implements XMLCodec.
+type xmlCodec struct{}
+
+func (xmlCodec) ContentType() string { return "application/xml; charset=utf-8" }
+
+func (xmlCodec) Encode(w io.Writer, v any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	return xml.NewEncoder(w).Encode(v)
+}
+
+// Decode rejects document type declarations before decoding, so entity
+// definitions cannot be used to inflate the document.
+func (xmlCodec) Decode(r io.Reader, v any) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.Directive:
+			if bytes.HasPrefix(bytes.TrimSpace(t), []byte("DOCTYPE")) {
+				return ErrXMLDoctype
+			}
+		case xml.StartElement:
+			// The prolog is clean; decode the document from the start
+			return xml.Unmarshal(data, v)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package chain_test
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jpl-au/chain"
+)
+
+// gobCodec is a Codec for encoding/gob, standing in for MessagePack or CBOR.
+type gobCodec struct{}
+
+func (gobCodec) ContentType() string             { return "application/x-gob" }
+func (gobCodec) Encode(w io.Writer, v any) error { return gob.NewEncoder(w).Encode(v) }
+func (gobCodec) Decode(r io.Reader, v any) error { return gob.NewDecoder(r).Decode(v) }
+
+func TestWithCodecs(t *testing.T) {
+	mux := chain.New().WithCodecs(gobCodec{}, chain.JSONCodec).WithErrorFormat(chain.NegotiatedErrors)
+	mux.HandleFunc("POST /orders", func(w http.ResponseWriter, r *http.Request) {
+		var o order
+		if err := chain.Bind(w, r, &o); err != nil {
+			chain.WriteError(w, r, http.StatusUnsupportedMediaType, "")
+			return
+		}
+		chain.Respond(w, r, http.StatusCreated, o)
+	})
+
+	var body bytes.Buffer
+	gob.NewEncoder(&body).Encode(order{1, "tea"})
+	req := httptest.NewRequest("POST", "/orders", &body)
+	req.Header.Set("Content-Type", "application/x-gob")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	if ct := rec.Header().Get("Content-Type"); ct != "application/x-gob" {
+		t.Fatalf("Expected gob response for any Accept, got '%s'", ct)
+	}
+	var got order
+	if err := gob.NewDecoder(rec.Body).Decode(&got); err != nil || got != (order{1, "tea"}) {
+		t.Errorf("Expected round-tripped order, got %+v (%v)", got, err)
+	}
+
+	// XML was not registered, so it is rejected and the error negotiated as JSON
+	req = httptest.NewRequest("POST", "/orders", strings.NewReader(`<order id="2"/>`))
+	req.Header.Set("Content-Type", "application/xml")
+	req.Header.Set("Accept", "application/json")
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnsupportedMediaType {
+		t.Fatalf("Expected 415, got %d", rec.Code)
+	}
+	var e chain.ErrorBody
+	if err := json.Unmarshal(rec.Body.Bytes(), &e); err != nil || e.Status != http.StatusUnsupportedMediaType {
+		t.Errorf("Expected JSON error body, got %q", rec.Body.String())
+	}
+}
+
+func TestNegotiatedErrorsXML(t *testing.T) {
+	mux := chain.New().WithErrorFormat(chain.NegotiatedErrors)
+	req := httptest.NewRequest("GET", "/missing", nil)
+	req.Header.Set("Accept", "text/xml")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("Expected 404, got %d", rec.Code)
+	}
+	if want := "<error><status>404</status><message>Not Found</message></error>"; !strings.Contains(rec.Body.String(), want) {
+		t.Errorf("Expected %q in %q", want, rec.Body.String())
+	}
+}
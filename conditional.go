@@ -0,0 +1,119 @@
+package chain
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Conditional evaluates the conditional request headers of r against the current
+// version of the resource, given as an entity tag and modification time, following
+// RFC 9110 section 13.2.2. Either validator may be zero when unknown. etag may be
+// given with or without quotes; a "W/" prefix marks it weak.
+//
+// The validators are set as the ETag and Last-Modified response headers. If the
+// preconditions fail, Conditional writes 412 Precondition Failed, or 304 Not
+// Modified for a GET or HEAD request whose cached copy is current, and reports
+// true: the handler should then return without producing a body. Otherwise it
+// reports false and the handler continues as normal:
+//
+//	if chain.Conditional(w, r, doc.Version, doc.Updated) {
+//		return
+//	}
+func Conditional(w http.ResponseWriter, r *http.Request, etag string, lastModified time.Time) bool {
+	etag = quoteETag(etag)
+	// HTTP dates have a resolution of one second
+	lastModified = lastModified.Truncate(time.Second)
+
+	h := w.Header()
+	if etag != "" {
+		h.Set("ETag", etag)
+	}
+	if !lastModified.IsZero() {
+		h.Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	safe := r.Method == http.MethodGet || r.Method == http.MethodHead
+
+	if im := r.Header.Get("If-Match"); im != "" {
+		if !etagListMatch(im, etag, true) {
+			WriteError(w, r, http.StatusPreconditionFailed, "")
+			return true
+		}
+	} else if ius := r.Header.Get("If-Unmodified-Since"); ius != "" && !lastModified.IsZero() {
+		if t, err := http.ParseTime(ius); err == nil && lastModified.After(t) {
+			WriteError(w, r, http.StatusPreconditionFailed, "")
+			return true
+		}
+	}
+
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		if !etagListMatch(inm, etag, false) {
+			return false
+		}
+		if safe {
+			writeNotModified(w)
+		} else {
+			WriteError(w, r, http.StatusPreconditionFailed, "")
+		}
+		return true
+	}
+
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" && safe && !lastModified.IsZero() {
+		if t, err := http.ParseTime(ims); err == nil && !lastModified.After(t) {
+			writeNotModified(w)
+			return true
+		}
+	}
+	return false
+}
+
+// writeNotModified writes a 304 response, dropping headers that describe a body.
+func writeNotModified(w http.ResponseWriter) {
+	h := w.Header()
+	h.Del("Content-Type")
+	h.Del("Content-Length")
+	h.Del("Content-Encoding")
+	w.WriteHeader(http.StatusNotModified)
+}
+
+// quoteETag returns etag as a quoted entity tag, or "" if it is empty.
+func quoteETag(etag string) string {
+	if etag == "" || strings.HasPrefix(etag, `"`) || strings.HasPrefix(etag, `W/"`) {
+		return etag
+	}
+	if weak, ok := strings.CutPrefix(etag, "W/"); ok {
+		return `W/"` + weak + `"`
+	}
+	return `"` + etag + `"`
+}
+
+// etagListMatch reports whether the comma-separated entity tags of header match
+// etag, using the strong comparison for If-Match and the weak comparison for
+// If-None-Match. "*" matches any current representation.
+func etagListMatch(header, etag string, strong bool) bool {
+	if strings.TrimSpace(header) == "*" {
+		return etag != ""
+	}
+	if etag == "" || strong && strings.HasPrefix(etag, "W/") {
+		return false
+	}
+	opaque := strings.TrimPrefix(etag, "W/")
+	for header != "" {
+		header = strings.TrimLeft(header, " \t,")
+		weak := strings.HasPrefix(header, "W/")
+		tag := strings.TrimPrefix(header, "W/")
+		if !strings.HasPrefix(tag, `"`) {
+			return false
+		}
+		end := strings.IndexByte(tag[1:], '"')
+		if end < 0 {
+			return false
+		}
+		if tag[:end+2] == opaque && !(strong && weak) {
+			return true
+		}
+		header = tag[end+2:]
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package chain_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jpl-au/chain"
+)
+
+func TestConditional(t *testing.T) {
+	modified := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	before := modified.Add(-time.Hour).Format(http.TimeFormat)
+	after := modified.Add(time.Hour).Format(http.TimeFormat)
+
+	tests := []struct {
+		name    string
+		method  string
+		etag    string
+		headers map[string]string
+		status  int
+	}{
+		{"no preconditions", "GET", "v1", nil, http.StatusOK},
+		{"if-none-match hit", "GET", "v1", map[string]string{"If-None-Match": `"v0", "v1"`}, http.StatusNotModified},
+		{"if-none-match weak hit", "GET", "W/v1", map[string]string{"If-None-Match": `"v1"`}, http.StatusNotModified},
+		{"if-none-match miss", "GET", "v2", map[string]string{"If-None-Match": `"v1"`}, http.StatusOK},
+		{"if-none-match star", "GET", "v1", map[string]string{"If-None-Match": "*"}, http.StatusNotModified},
+		{"if-none-match on write", "PUT", "v1", map[string]string{"If-None-Match": "*"}, http.StatusPreconditionFailed},
+		{"if-match hit", "PUT", "v1", map[string]string{"If-Match": `"v1"`}, http.StatusOK},
+		{"if-match miss", "PUT", "v2", map[string]string{"If-Match": `"v1"`}, http.StatusPreconditionFailed},
+		{"if-match weak", "PUT", "W/v1", map[string]string{"If-Match": `W/"v1"`}, http.StatusPreconditionFailed},
+		{"if-modified-since current", "GET", "", map[string]string{"If-Modified-Since": after}, http.StatusNotModified},
+		{"if-modified-since stale", "GET", "", map[string]string{"If-Modified-Since": before}, http.StatusOK},
+		{"if-modified-since ignored with etag", "GET", "v2", map[string]string{"If-None-Match": `"v1"`, "If-Modified-Since": after}, http.StatusOK},
+		{"if-unmodified-since failed", "DELETE", "", map[string]string{"If-Unmodified-Since": before}, http.StatusPreconditionFailed},
+		{"if-unmodified-since passed", "DELETE", "", map[string]string{"If-Unmodified-Since": after}, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, "/doc", nil)
+		for k, v := range tt.headers {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		generated := false
+		handler := func(w http.ResponseWriter, r *http.Request) {
+			if chain.Conditional(w, r, tt.etag, modified) {
+				return
+			}
+			generated = true
+			w.Write([]byte("body"))
+		}
+		handler(rec, req)
+
+		if rec.Code != tt.status {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.status, rec.Code)
+		}
+		if generated != (tt.status == http.StatusOK) {
+			t.Errorf("%s: unexpected body generation %v", tt.name, generated)
+		}
+		if got := rec.Header().Get("Last-Modified"); got != modified.Format(http.TimeFormat) {
+			t.Errorf("%s: expected Last-Modified, got '%s'", tt.name, got)
+		}
+	}
+}
+
+func TestConditionalQuotesETag(t *testing.T) {
+	rec := httptest.NewRecorder()
+	chain.Conditional(rec, httptest.NewRequest("GET", "/", nil), "abc", time.Time{})
+	if got := rec.Header().Get("ETag"); got != `"abc"` {
+		t.Errorf("Expected quoted ETag, got '%s'", got)
+	}
+	if got := rec.Header().Get("Last-Modified"); got != "" {
+		t.Errorf("Expected no Last-Modified, got '%s'", got)
+	}
+}
@@ -0,0 +1,114 @@
+package chain
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Config declaratively describes a Mux: a tree of groups, each with an optional
+// path prefix, named middleware stacks and routes mapped to named handlers.
+// Stacks refer to names registered with Stack. The struct carries json and yaml
+// tags so it can be decoded from either format; LoadConfig reads JSON directly,
+// and YAML documents can be unmarshalled into a Config and passed to FromConfig.
+// Middleware is only read from the top-level Config.
+type Config struct {
+	Middleware *MiddlewareConfig `json:"middleware,omitempty" yaml:"middleware,omitempty"`
+
+	Prefix string        `json:"prefix,omitempty" yaml:"prefix,omitempty"`
+	Stacks []string      `json:"stacks,omitempty" yaml:"stacks,omitempty"`
+	Routes []RouteConfig `json:"routes,omitempty" yaml:"routes,omitempty"`
+	Groups []Config      `json:"groups,omitempty" yaml:"groups,omitempty"`
+}
+
+// RouteConfig describes a single route within a Config.
+type RouteConfig struct {
+	// Pattern is a Go 1.22 pattern such as "GET /users/{id}", relative to the
+	// enclosing group's prefix.
+	Pattern string `json:"pattern" yaml:"pattern"`
+	// Handler is the name of the handler in the map passed to FromConfig.
+	Handler string `json:"handler" yaml:"handler"`
+	// Stacks are applied to this route only, after the group's stacks.
+	Stacks []string `json:"stacks,omitempty" yaml:"stacks,omitempty"`
+}
+
+// LoadConfig decodes a JSON Config from r and builds a Mux from it with FromConfig.
+// Unknown fields are rejected so that typos in the configuration are reported.
+func LoadConfig(r io.Reader, handlers map[string]http.Handler) (*Mux, error) {
+	var cfg Config
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("chain: decoding config: %w", err)
+	}
+	return FromConfig(cfg, handlers)
+}
+
+// FromConfig builds a new Mux from cfg, resolving handler names against handlers.
+// The built-in middleware selected in cfg.Middleware is installed first. Unlike the
+// registration methods, which panic on programmer error, FromConfig reports
+// unknown handlers, unknown stacks, unknown error formats and conflicting patterns
+// as errors since the configuration is usually supplied at runtime.
+func FromConfig(cfg Config, handlers map[string]http.Handler) (mux *Mux, err error) {
+	defer func() {
+		// http.ServeMux panics on invalid or conflicting patterns
+		if r := recover(); r != nil {
+			mux, err = nil, fmt.Errorf("chain: building config: %v", r)
+		}
+	}()
+
+	mux = New()
+	if cfg.Middleware != nil {
+		if err := mux.applyMiddlewareConfig(*cfg.Middleware); err != nil {
+			return nil, err
+		}
+	}
+	if err := mux.applyConfig(cfg, handlers); err != nil {
+		return nil, err
+	}
+	return mux, nil
+}
+
+// applyConfig registers the stacks, routes and groups of cfg on m.
+func (m *Mux) applyConfig(cfg Config, handlers map[string]http.Handler) error {
+	if err := checkStacks(cfg.Stacks); err != nil {
+		return err
+	}
+
+	var err error
+	m.Route(cfg.Prefix, func(g *Mux) {
+		g.UseStack(cfg.Stacks...)
+		for _, rc := range cfg.Routes {
+			handler, ok := handlers[rc.Handler]
+			if !ok {
+				err = fmt.Errorf("chain: unknown handler %q for pattern %q", rc.Handler, rc.Pattern)
+				return
+			}
+			if err = checkStacks(rc.Stacks); err != nil {
+				return
+			}
+			g.Group(func(r *Mux) {
+				r.UseStack(rc.Stacks...).Handle(rc.Pattern, handler)
+			})
+		}
+		for _, sub := range cfg.Groups {
+			if err = g.applyConfig(sub, handlers); err != nil {
+				return
+			}
+		}
+	})
+	return err
+}
+
+// checkStacks returns an error if any name has not been registered with Stack.
+func checkStacks(names []string) error {
+	stacks.RLock()
+	defer stacks.RUnlock()
+	for _, name := range names {
+		if _, ok := stacks.m[name]; !ok {
+			return fmt.Errorf("chain: unknown stack %q", name)
+		}
+	}
+	return nil
+}
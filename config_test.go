@@ -0,0 +1,117 @@
+package chain_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jpl-au/chain"
+)
+
+func TestLoadConfig(t *testing.T) {
+	chain.Stack("config-test-api", tag("api"))
+	chain.Stack("config-test-audit", tag("audit"))
+
+	handlers := map[string]http.Handler{
+		"home": http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("home"))
+		}),
+		"user": http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("user " + r.PathValue("id")))
+		}),
+	}
+
+	mux, err := chain.LoadConfig(strings.NewReader(`{
+		"routes": [{"pattern": "GET /{$}", "handler": "home"}],
+		"groups": [{
+			"prefix": "/api",
+			"stacks": ["config-test-api"],
+			"routes": [{"pattern": "GET /users/{id}", "handler": "user", "stacks": ["config-test-audit"]}]
+		}]
+	}`), handlers)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if rec.Body.String() != "home" || rec.Header().Get("X-Order") != "" {
+		t.Errorf("Unexpected root response: body '%s', order %v", rec.Body.String(), rec.Header().Values("X-Order"))
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/api/users/7", nil))
+	if rec.Body.String() != "user 7" {
+		t.Errorf("Expected body 'user 7', got '%s'", rec.Body.String())
+	}
+	if got := strings.Join(rec.Header().Values("X-Order"), ","); got != "api,audit" {
+		t.Errorf("Expected order 'api,audit', got '%s'", got)
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	handlers := map[string]http.Handler{
+		"home": http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+	}
+
+	tests := []struct {
+		name   string
+		config string
+	}{
+		{"malformed json", `{"routes": [`},
+		{"unknown field", `{"rutes": []}`},
+		{"unknown handler", `{"routes": [{"pattern": "GET /", "handler": "missing"}]}`},
+		{"unknown stack", `{"stacks": ["config-test-missing"]}`},
+		{"conflicting patterns", `{"routes": [{"pattern": "GET /", "handler": "home"}, {"pattern": "GET /", "handler": "home"}]}`},
+		{"unknown error format", `{"middleware": {"errorFormat": "yaml"}}`},
+		{"bad duration", `{"middleware": {"slowRequests": "soon"}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := chain.LoadConfig(strings.NewReader(tt.config), handlers); err == nil {
+				t.Error("Expected an error, got nil")
+			}
+		})
+	}
+}
+
+func TestLoadConfigMiddleware(t *testing.T) {
+	handlers := map[string]http.Handler{
+		"panic": http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { panic("boom") }),
+	}
+	config := `{
+		"middleware": {
+			"recover": true,
+			"trace": true,
+			"allowedMethods": [],
+			"headers": {"server": "chain", "static": {"X-Frame-Options": "DENY"}},
+			"slowRequests": "2s",
+			"errorFormat": "json"
+		},
+		"routes": [{"pattern": "GET /panic", "handler": "panic"}]
+	}`
+	mux, err := chain.LoadConfig(strings.NewReader(config), handlers)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/panic", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("Expected recovered 500, got %d", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Expected JSON error format, got '%s'", ct)
+	}
+	if rec.Header().Get("Server") != "chain" || rec.Header().Get("X-Frame-Options") != "DENY" {
+		t.Errorf("Expected configured headers, got %v", rec.Header())
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("TRACE", "/panic", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected TRACE rejected by default allowed methods, got %d", rec.Code)
+	}
+}
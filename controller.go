@@ -0,0 +1,152 @@
+package chain
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Routable is implemented by controllers that declare their routes explicitly.
+// Routes returns a map of patterns, such as "GET /users/{id}", to handlers.
+type Routable interface {
+	Routes() map[string]http.HandlerFunc
+}
+
+// Prefixed is implemented by controllers whose routes share a path prefix.
+type Prefixed interface {
+	Prefix() string
+}
+
+// Middlewared is implemented by controllers whose routes share middleware.
+type Middlewared interface {
+	Middleware() []func(http.Handler) http.Handler
+}
+
+// controllerVerbs maps method name prefixes to HTTP methods.
+var controllerVerbs = []struct{ prefix, method string }{
+	{"Get", http.MethodGet},
+	{"Post", http.MethodPost},
+	{"Put", http.MethodPut},
+	{"Patch", http.MethodPatch},
+	{"Delete", http.MethodDelete},
+	{"Head", http.MethodHead},
+	{"Options", http.MethodOptions},
+}
+
+// Register registers the routes of a controller in an isolated group.
+// If the controller implements Routable, its declared routes are used. Otherwise
+// every exported method with the signature func(http.ResponseWriter, *http.Request)
+// whose name starts with an HTTP verb is registered by convention: the rest of the
+// name is split into words which become lowercase path segments, and the word "ID"
+// becomes the wildcard {id}. For example GetUsersID registers "GET /users/{id}"
+// and Post registers "POST /". A later ID is named after the word before it, so
+// GetUsersIDPostsID registers "GET /users/{id}/posts/{postsID}". Controllers implementing Prefixed or Middlewared have
+// the prefix and middleware applied to all of their routes.
+// Returns the Mux instance for method chaining.
+func (m *Mux) Register(controller any) *Mux {
+	if controller == nil {
+		panic("chain: nil controller passed to Register")
+	}
+
+	routes := controllerRoutes(controller)
+	if len(routes) == 0 {
+		panic("chain: no routes found in controller passed to Register")
+	}
+
+	prefix := ""
+	if p, ok := controller.(Prefixed); ok {
+		prefix = p.Prefix()
+	}
+	return m.Route(prefix, func(g *Mux) {
+		if mw, ok := controller.(Middlewared); ok {
+			g.Use(mw.Middleware()...)
+		}
+
+		// Register in a stable order so conflicts are reported deterministically
+		patterns := make([]string, 0, len(routes))
+		for pattern := range routes {
+			patterns = append(patterns, pattern)
+		}
+		sort.Strings(patterns)
+		for _, pattern := range patterns {
+			g.HandleFunc(pattern, routes[pattern])
+		}
+	})
+}
+
+// controllerRoutes returns the routes declared by controller, either explicitly via
+// Routable or derived from its method names.
+func controllerRoutes(controller any) map[string]http.HandlerFunc {
+	if r, ok := controller.(Routable); ok {
+		return r.Routes()
+	}
+
+	routes := make(map[string]http.HandlerFunc)
+	v := reflect.ValueOf(controller)
+	t := v.Type()
+	for i := 0; i < t.NumMethod(); i++ {
+		fn, ok := v.Method(i).Interface().(func(http.ResponseWriter, *http.Request))
+		if !ok {
+			continue
+		}
+		if pattern, ok := conventionPattern(t.Method(i).Name); ok {
+			routes[pattern] = fn
+		}
+	}
+	return routes
+}
+
+// conventionPattern converts a method name such as GetUsersID into a pattern
+// such as "GET /users/{id}". Each ID after the first is named after the word
+// before it, so GetUsersIDPostsID becomes "GET /users/{id}/posts/{postsID}". It
+// panics if the names still collide, as in GetUsersIDUsersIDUsersID.
+func conventionPattern(name string) (string, bool) {
+	for _, verb := range controllerVerbs {
+		rest, ok := strings.CutPrefix(name, verb.prefix)
+		if !ok || (rest != "" && !unicode.IsUpper(rune(rest[0]))) {
+			continue
+		}
+		var segments []string
+		seen := make(map[string]bool)
+		for _, word := range splitWords(rest) {
+			if word != "ID" {
+				segments = append(segments, strings.ToLower(word))
+				continue
+			}
+			wildcard := "id"
+			if n := len(segments); seen[wildcard] && n > 0 && !strings.HasPrefix(segments[n-1], "{") {
+				wildcard = segments[n-1] + "ID"
+			}
+			if seen[wildcard] {
+				panic("chain: duplicate wildcard {" + wildcard + "} in controller method " + name + " passed to Register")
+			}
+			seen[wildcard] = true
+			segments = append(segments, "{"+wildcard+"}")
+		}
+		return verb.method + " /" + strings.Join(segments, "/"), true
+	}
+	return "", false
+}
+
+// splitWords splits a CamelCase identifier into words, keeping acronyms together:
+// "UsersID" becomes ["Users", "ID"] and "APIKeys" becomes ["API", "Keys"].
+func splitWords(s string) []string {
+	var words []string
+	runes := []rune(s)
+	start := 0
+	for i := 1; i < len(runes); i++ {
+		lowerToUpper := unicode.IsLower(runes[i-1]) && unicode.IsUpper(runes[i])
+		acronymEnd := unicode.IsUpper(runes[i-1]) && unicode.IsUpper(runes[i]) &&
+			i+1 < len(runes) && unicode.IsLower(runes[i+1])
+		if lowerToUpper || acronymEnd {
+			words = append(words, string(runes[start:i]))
+			start = i
+		}
+	}
+	if start < len(runes) {
+		words = append(words, string(runes[start:]))
+	}
+	return words
+}
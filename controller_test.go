@@ -0,0 +1,112 @@
+package chain_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jpl-au/chain"
+)
+
+type usersController struct{}
+
+func (usersController) Prefix() string { return "/v1" }
+
+func (usersController) Middleware() []func(http.Handler) http.Handler {
+	return []func(http.Handler) http.Handler{tag("users")}
+}
+
+func (usersController) GetUsers(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte("list"))
+}
+
+func (usersController) GetUsersID(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte("user " + r.PathValue("id")))
+}
+
+func (usersController) DeleteAPIKeysID(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte("deleted " + r.PathValue("id")))
+}
+
+func (usersController) GetUsersIDPostsID(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte("post " + r.PathValue("postsID") + " of " + r.PathValue("id")))
+}
+
+// Helper has the wrong signature and must be ignored
+func (usersController) Helper() string { return "" }
+
+type explicitController struct{}
+
+func (explicitController) Routes() map[string]http.HandlerFunc {
+	return map[string]http.HandlerFunc{
+		"GET /health": func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("ok")) },
+	}
+}
+
+func TestRegisterByConvention(t *testing.T) {
+	mux := chain.New().Register(usersController{})
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{"GET", "/v1/users", "list"},
+		{"GET", "/v1/users/42", "user 42"},
+		{"DELETE", "/v1/api/keys/9", "deleted 9"},
+		{"GET", "/v1/users/42/posts/7", "post 7 of 42"},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+		if rec.Body.String() != tt.body {
+			t.Errorf("%s %s: expected body '%s', got '%s'", tt.method, tt.path, tt.body, rec.Body.String())
+		}
+		if got := strings.Join(rec.Header().Values("X-Order"), ","); got != "users" {
+			t.Errorf("%s %s: expected controller middleware, got '%s'", tt.method, tt.path, got)
+		}
+	}
+}
+
+func TestRegisterRoutable(t *testing.T) {
+	mux := chain.New().Register(explicitController{})
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/health", nil))
+	if rec.Body.String() != "ok" {
+		t.Errorf("Expected body 'ok', got '%s'", rec.Body.String())
+	}
+}
+
+func TestRegisterWithoutRoutesPanics(t *testing.T) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			t.Fatal("Expected panic for controller without routes, got none")
+		}
+		msg, ok := r.(string)
+		if !ok || msg != "chain: no routes found in controller passed to Register" {
+			t.Fatalf("Unexpected panic message '%v'", r)
+		}
+	}()
+
+	chain.New().Register(struct{}{})
+}
+
+type duplicateIDController struct{}
+
+func (duplicateIDController) GetUsersIDUsersIDUsersID(w http.ResponseWriter, r *http.Request) {}
+
+func TestRegisterDuplicateWildcardPanics(t *testing.T) {
+	defer func() {
+		r := recover()
+		msg, ok := r.(string)
+		if !ok || msg != "chain: duplicate wildcard {usersID} in controller method GetUsersIDUsersIDUsersID passed to Register" {
+			t.Fatalf("Unexpected panic message '%v'", r)
+		}
+	}()
+
+	chain.New().Register(duplicateIDController{})
+}
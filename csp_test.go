@@ -0,0 +1,41 @@
+package chain_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jpl-au/chain"
+)
+
+func TestContentSecurityPolicy(t *testing.T) {
+	var nonces []string
+	mux := chain.New().Use(chain.ContentSecurityPolicy("script-src 'self' 'nonce-{nonce}'"))
+	mux.HandleFunc("GET /", func(w http.ResponseWriter, r *http.Request) {
+		nonces = append(nonces, chain.CSPNonce(r))
+	})
+
+	for i := 0; i < 2; i++ {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+
+		nonce := nonces[i]
+		if nonce == "" {
+			t.Fatal("Expected a nonce in the request context")
+		}
+		want := "script-src 'self' 'nonce-" + nonce + "'"
+		if got := rec.Header().Get("Content-Security-Policy"); got != want {
+			t.Errorf("Expected header '%s', got '%s'", want, got)
+		}
+	}
+
+	if nonces[0] == nonces[1] {
+		t.Error("Expected a different nonce for each request")
+	}
+}
+
+func TestCSPNonceWithoutMiddleware(t *testing.T) {
+	if nonce := chain.CSPNonce(httptest.NewRequest("GET", "/", nil)); nonce != "" {
+		t.Errorf("Expected empty nonce, got '%s'", nonce)
+	}
+}
@@ -0,0 +1,140 @@
+package chain
+
+import (
+	"encoding/csv"
+	"mime"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// CSVOptions configures CSV.
+type CSVOptions struct {
+	// Filename, when set, makes the response a download with that name via
+	// Content-Disposition.
+	Filename string
+	// BOM writes a UTF-8 byte order mark first, which Excel needs to detect the
+	// encoding of non-ASCII text.
+	BOM bool
+	// Comma is the field delimiter. Defaults to ','.
+	Comma rune
+}
+
+// CSVWriter streams rows as a text/csv response. It is not safe for concurrent
+// use.
+type CSVWriter struct {
+	w    http.ResponseWriter
+	csv  *csv.Writer
+	rows int
+
+	// mu guards the writer against the timer flushing pending rows
+	mu      sync.Mutex
+	flushed time.Time
+	timer   *time.Timer
+	closed  bool
+	err     error
+}
+
+// CSV returns a writer streaming a text/csv response to w, starting with the
+// header row if header is non-empty. Rows are buffered and reach the client
+// within 100ms of being written. Callers must call Close when done, and must not
+// use w again until they have. The number of data rows written is available from
+// Rows, and the bytes sent from the ResponseWriter's Size.
+func CSV(w http.ResponseWriter, header []string, opts CSVOptions) *CSVWriter {
+	if w == nil {
+		panic("chain: nil writer passed to CSV")
+	}
+	h := w.Header()
+	h.Set("Content-Type", "text/csv; charset=utf-8")
+	h.Set("X-Content-Type-Options", "nosniff")
+	if opts.Filename != "" {
+		h.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": opts.Filename}))
+	}
+
+	c := &CSVWriter{w: w, csv: csv.NewWriter(w), flushed: time.Now()}
+	if opts.Comma != 0 {
+		c.csv.Comma = opts.Comma
+	}
+	if opts.BOM {
+		_, c.err = w.Write([]byte("\xef\xbb\xbf"))
+	}
+	if len(header) > 0 && c.err == nil {
+		c.err = c.csv.Write(header)
+	}
+	return c
+}
+
+// Write writes one row. Errors are sticky: after one, Write does nothing and
+// returns it again.
+func (c *CSVWriter) Write(row []string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return c.err
+	}
+	if c.err = c.csv.Write(row); c.err != nil {
+		return c.err
+	}
+	c.rows++
+	since := time.Since(c.flushed)
+	if since >= streamFlushInterval {
+		return c.flush()
+	}
+	if c.timer == nil {
+		c.timer = time.AfterFunc(streamFlushInterval-since, c.flushPending)
+	}
+	return nil
+}
+
+// flushPending flushes rows left buffered by Write, once the flush interval has
+// passed without another Write doing so.
+func (c *CSVWriter) flushPending() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.timer = nil
+	if !c.closed && c.err == nil {
+		c.flush()
+	}
+}
+
+// Flush sends buffered rows to the client.
+func (c *CSVWriter) Flush() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.flush()
+}
+
+// flush is Flush with mu held.
+func (c *CSVWriter) flush() error {
+	if c.err != nil {
+		return c.err
+	}
+	c.csv.Flush()
+	if c.err = c.csv.Error(); c.err != nil {
+		return c.err
+	}
+	c.flushed = time.Now()
+	if err := http.NewResponseController(c.w).Flush(); err != nil && err != http.ErrNotSupported {
+		c.err = err
+	}
+	return c.err
+}
+
+// Rows returns the number of data rows written, excluding the header.
+func (c *CSVWriter) Rows() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.rows
+}
+
+// Close flushes the remaining rows and returns the first error encountered.
+func (c *CSVWriter) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	if c.timer != nil {
+		c.timer.Stop()
+		c.timer = nil
+	}
+	return c.flush()
+}
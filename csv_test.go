@@ -0,0 +1,96 @@
+package chain_test
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jpl-au/chain"
+)
+
+func TestCSV(t *testing.T) {
+	var rows, size int
+	mux := chain.New()
+	mux.HandleFunc("GET /export", func(w http.ResponseWriter, r *http.Request) {
+		out := chain.CSV(w, []string{"id", "name"}, chain.CSVOptions{Filename: "users.csv", BOM: true})
+		out.Write([]string{"1", "Zoë"})
+		out.Write([]string{"2", "Smith, Jan"})
+		if err := out.Close(); err != nil {
+			t.Error(err)
+		}
+		rows = out.Rows()
+		size = w.(chain.ResponseWriter).Size()
+	})
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/export", nil))
+
+	if ct := rec.Header().Get("Content-Type"); ct != "text/csv; charset=utf-8" {
+		t.Errorf("Unexpected Content-Type '%s'", ct)
+	}
+	if cd := rec.Header().Get("Content-Disposition"); cd != `attachment; filename=users.csv` {
+		t.Errorf("Unexpected Content-Disposition '%s'", cd)
+	}
+	want := "\xef\xbb\xbfid,name\n1,Zoë\n2,\"Smith, Jan\"\n"
+	if rec.Body.String() != want {
+		t.Errorf("Expected %q, got %q", want, rec.Body.String())
+	}
+	if rows != 2 {
+		t.Errorf("Expected 2 rows, got %d", rows)
+	}
+	if size != len(want) {
+		t.Errorf("Expected size %d, got %d", len(want), size)
+	}
+}
+
+func TestCSVComma(t *testing.T) {
+	rec := httptest.NewRecorder()
+	out := chain.CSV(rec, nil, chain.CSVOptions{Comma: ';'})
+	out.Write([]string{"a", "b"})
+	out.Close()
+	if got := strings.TrimSpace(rec.Body.String()); got != "a;b" {
+		t.Errorf("Expected semicolon delimited row, got %q", got)
+	}
+	if rec.Header().Get("Content-Disposition") != "" {
+		t.Error("Expected no Content-Disposition without a filename")
+	}
+}
+
+func TestCSVSlowProducer(t *testing.T) {
+	// The second row is only written once the client has read the first, which
+	// must therefore be flushed without waiting for another Write
+	read := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c := chain.CSV(w, nil, chain.CSVOptions{})
+		c.Write([]string{"1", "a"})
+		select {
+		case <-read:
+		case <-time.After(2 * time.Second):
+		}
+		c.Write([]string{"2", "b"})
+		c.Close()
+	}))
+	defer srv.Close()
+
+	start := time.Now()
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	lines := bufio.NewScanner(resp.Body)
+	if !lines.Scan() || lines.Text() != "1,a" {
+		t.Fatalf("Expected first row, got %q", lines.Text())
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected first row within the flush interval, took %v", elapsed)
+	}
+	close(read)
+	if !lines.Scan() || lines.Text() != "2,b" {
+		t.Errorf("Expected second row, got %q", lines.Text())
+	}
+}
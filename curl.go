@@ -0,0 +1,118 @@
+package chain
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// CurlOptions configures CurlExamples.
+type CurlOptions struct {
+	// BaseURL is the scheme, host and any path prefix requests are sent to.
+	// Defaults to "http://localhost:8080". Routes registered for a host use that
+	// host with the same scheme.
+	BaseURL string
+	// Auth is the header sent to routes that declare roles or permissions.
+	// Defaults to "Authorization: Bearer $TOKEN", which the shell expands.
+	Auth string
+	// ContentType is the Content-Type of example request bodies. Defaults to
+	// "application/json".
+	ContentType string
+	// Body is the example request body. Defaults to "{}".
+	Body string
+}
+
+// CurlExample is a curl command line for one route.
+type CurlExample struct {
+	Pattern string `json:"pattern"`
+	Command string `json:"command"`
+}
+
+// CurlExamples returns a copy-pastable curl command for each route, in
+// registration order, for onboarding and support documentation. Path wildcards
+// are replaced with placeholders such as <id>, routes that declare requirements
+// send the Auth header, and methods that carry a body send an example body with
+// its Content-Type.
+func (m *Mux) CurlExamples(opts CurlOptions) []CurlExample {
+	if opts.BaseURL == "" {
+		opts.BaseURL = "http://localhost:8080"
+	}
+	if opts.Auth == "" {
+		opts.Auth = "Authorization: Bearer $TOKEN"
+	}
+	if opts.ContentType == "" {
+		opts.ContentType = "application/json"
+	}
+	if opts.Body == "" {
+		opts.Body = "{}"
+	}
+	base := strings.TrimSuffix(opts.BaseURL, "/")
+
+	var examples []CurlExample
+	for _, info := range m.RouteTable() {
+		host, path, method := splitPattern(info.Pattern)
+		url := base
+		if host != "" {
+			scheme, _, _ := strings.Cut(base, "://")
+			url = scheme + "://" + host
+		}
+		url += curlPath(path)
+
+		args := []string{"curl"}
+		switch method {
+		case "", http.MethodGet:
+		case http.MethodHead:
+			args = append(args, "-I")
+		default:
+			args = append(args, "-X", method)
+		}
+		if len(info.Requirements.Roles) > 0 || len(info.Requirements.Permissions) > 0 {
+			args = append(args, "-H", shellDoubleQuote(opts.Auth))
+		}
+		if hasBody(method) {
+			args = append(args, "-H", shellQuote("Content-Type: "+opts.ContentType), "-d", shellQuote(opts.Body))
+		}
+		args = append(args, shellQuote(url))
+		examples = append(examples, CurlExample{Pattern: info.Pattern, Command: strings.Join(args, " ")})
+	}
+	return examples
+}
+
+// WriteCurlExamples writes the commands returned by CurlExamples to w, each
+// preceded by a comment naming its route.
+func (m *Mux) WriteCurlExamples(w io.Writer, opts CurlOptions) error {
+	for i, e := range m.CurlExamples(opts) {
+		sep := "\n"
+		if i == 0 {
+			sep = ""
+		}
+		if _, err := fmt.Fprintf(w, "%s# %s\n%s\n", sep, e.Pattern, e.Command); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// curlPath replaces the wildcards of a route path with placeholders.
+func curlPath(path string) string {
+	var b strings.Builder
+	for _, s := range patternSegments(path) {
+		if s.param == "" {
+			b.WriteString(s.literal)
+		} else {
+			b.WriteString("<" + s.param + ">")
+		}
+	}
+	return b.String()
+}
+
+// shellQuote quotes s for a POSIX shell, without expansion.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// shellDoubleQuote quotes s for a POSIX shell, expanding variables such as $TOKEN.
+func shellDoubleQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "`", "\\`").Replace(s) + `"`
+}
@@ -0,0 +1,61 @@
+package chain_test
+
+import (
+	"bytes"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/jpl-au/chain"
+)
+
+func TestCurlExamples(t *testing.T) {
+	h := func(w http.ResponseWriter, r *http.Request) {}
+
+	mux := chain.New()
+	mux.HandleFunc("GET /users/{id}", h)
+	mux.HandleFunc("HEAD /health", h)
+	mux.HandleFunc("/files/{path...}", h)
+	mux.HandleFunc("api.example.com/{$}", h)
+	mux.Group(func(admin *chain.Mux) {
+		admin.RequireRole("admin")
+		admin.HandleFunc("POST /orders", h)
+	})
+
+	var got []string
+	for _, e := range mux.CurlExamples(chain.CurlOptions{BaseURL: "https://example.com/"}) {
+		got = append(got, e.Command)
+	}
+	want := []string{
+		"curl 'https://example.com/users/<id>'",
+		"curl -I 'https://example.com/health'",
+		"curl 'https://example.com/files/<path>'",
+		"curl 'https://api.example.com/'",
+		`curl -X POST -H "Authorization: Bearer $TOKEN" -H 'Content-Type: application/json' -d '{}' 'https://example.com/orders'`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Unexpected examples:\n got %q\nwant %q", got, want)
+	}
+
+	examples := mux.CurlExamples(chain.CurlOptions{Body: `{"name":"O'Brien"}`})
+	if want := `curl -X POST -H "Authorization: Bearer $TOKEN" -H 'Content-Type: application/json' -d '{"name":"O'\''Brien"}' 'http://localhost:8080/orders'`; examples[4].Command != want {
+		t.Errorf("Expected body quoted for the shell\n got %s\nwant %s", examples[4].Command, want)
+	}
+}
+
+func TestWriteCurlExamples(t *testing.T) {
+	h := func(w http.ResponseWriter, r *http.Request) {}
+
+	mux := chain.New()
+	mux.HandleFunc("GET /a", h)
+	mux.HandleFunc("DELETE /b", h)
+
+	var buf bytes.Buffer
+	if err := mux.WriteCurlExamples(&buf, chain.CurlOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	want := "# GET /a\ncurl 'http://localhost:8080/a'\n\n# DELETE /b\ncurl -X DELETE 'http://localhost:8080/b'\n"
+	if buf.String() != want {
+		t.Errorf("Unexpected output:\n%s", buf.String())
+	}
+}
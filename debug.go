@@ -0,0 +1,134 @@
+package chain
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// The development and inspection features Dump, RequestLog and SchemaRecorder
+// can be compiled out of production binaries by building with the chain_nodebug
+// tag:
+//
+//	go build -tags chain_nodebug ./cmd/server
+//
+// Their API is unchanged, so code using them still compiles, but Dump returns
+// middleware that calls the next handler directly, RequestLog and SchemaRecorder
+// record nothing and serve 404 Not Found, and RecordError does nothing. Debug
+// reports which build is in use.
+
+// DumpOptions configures the Dump middleware.
+type DumpOptions struct {
+	// MaxBody is the maximum number of body bytes printed for requests and
+	// responses. Defaults to 4096.
+	MaxBody int
+	// ResponseBody enables printing the response body in addition to the request.
+	ResponseBody bool
+	// RedactHeaders lists headers whose values are replaced with "[REDACTED]".
+	// Defaults to Authorization, Cookie, Set-Cookie and Proxy-Authorization.
+	RedactHeaders []string
+	// Redact, if set, is applied to request and response bodies before printing,
+	// for example to mask passwords or tokens.
+	Redact func(body []byte) []byte
+}
+
+// RequestLogOptions configures NewRequestLog.
+type RequestLogOptions struct {
+	// Size is the number of requests kept. Defaults to 100.
+	Size int
+	// RequestIDHeader names the request header holding the request ID. Defaults
+	// to "X-Request-Id".
+	RequestIDHeader string
+}
+
+// RequestEntry records one completed request.
+type RequestEntry struct {
+	Time      time.Time     `json:"time"`
+	Method    string        `json:"method"`
+	Path      string        `json:"path"`
+	Route     string        `json:"route,omitempty"`
+	Status    int           `json:"status"`
+	Duration  time.Duration `json:"duration"`
+	RequestID string        `json:"requestId,omitempty"`
+	// Error is the error recorded with RecordError, including panics caught by
+	// Recoverer, if any.
+	Error string `json:"error,omitempty"`
+}
+
+// RequestLog keeps the most recent requests in memory, for inspecting a service
+// without external observability infrastructure. Register its Middleware with
+// UsePre, and mount the RequestLog itself as a handler to view the entries as
+// JSON, or as an HTML table in a browser:
+//
+//	requests := chain.NewRequestLog(chain.RequestLogOptions{})
+//	mux.UsePre(requests.Middleware())
+//	mux.Handle("GET /debug/requests", requests)
+type RequestLog struct {
+	opts    RequestLogOptions
+	mu      sync.Mutex
+	entries []RequestEntry
+	next    int
+	full    bool
+}
+
+// SchemaOptions configures NewSchemaRecorder.
+type SchemaOptions struct {
+	// MaxBody is the largest JSON body examined, in bytes. Larger bodies are not
+	// recorded. Defaults to 64 KiB.
+	MaxBody int
+	// Redact lists substrings of JSON object keys whose values are replaced with
+	// "[REDACTED]" in examples, matched ignoring case. Defaults to password,
+	// secret, token, key, authorization and card.
+	Redact []string
+}
+
+// RouteSchema describes the requests and responses observed for one route.
+type RouteSchema struct {
+	Route string `json:"route"`
+	// Count is the number of requests observed.
+	Count int `json:"count"`
+	// Query lists the query parameter names seen, sorted.
+	Query []string `json:"query,omitempty"`
+	// Request describes the request bodies, if any were seen.
+	Request *BodySchema `json:"request,omitempty"`
+	// Responses describes the response bodies by status code.
+	Responses map[int]*BodySchema `json:"responses,omitempty"`
+}
+
+// BodySchema describes the bodies observed for a request or a response status.
+type BodySchema struct {
+	ContentType string `json:"contentType,omitempty"`
+	// Shape merges the structure of every JSON body seen.
+	Shape *Shape `json:"shape,omitempty"`
+	// Example is the first JSON body seen, with sensitive values redacted.
+	Example json.RawMessage `json:"example,omitempty"`
+}
+
+// Shape describes the structure of the JSON values seen at one position, merged
+// across requests.
+type Shape struct {
+	// Types lists the JSON types seen: "array", "boolean", "null", "number",
+	// "object" or "string", sorted.
+	Types []string `json:"types"`
+	// Count is the number of values seen. A property seen in fewer objects than
+	// its parent is optional.
+	Count int `json:"count"`
+	// Properties describes the members of objects.
+	Properties map[string]*Shape `json:"properties,omitempty"`
+	// Items describes the elements of arrays.
+	Items *Shape `json:"items,omitempty"`
+}
+
+// SchemaRecorder observes live traffic in development and accumulates the shape
+// of each route's JSON requests and responses, with redacted examples, to seed
+// API documentation for routes written code-first. Register its Middleware with
+// UsePre, and mount the SchemaRecorder itself to export the schemas as JSON:
+//
+//	schemas := chain.NewSchemaRecorder(chain.SchemaOptions{})
+//	mux.UsePre(schemas.Middleware())
+//	mux.Handle("GET /debug/schemas", schemas)
+type SchemaRecorder struct {
+	opts   SchemaOptions
+	mu     sync.Mutex
+	routes map[string]*RouteSchema
+}
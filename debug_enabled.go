@@ -0,0 +1,7 @@
+//go:build !chain_nodebug
+
+package chain
+
+// Debug reports whether the debug features are compiled in, which they are
+// unless the chain_nodebug build tag is set.
+const Debug = true
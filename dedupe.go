@@ -0,0 +1,82 @@
+package chain
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// DedupeStore records the request IDs seen by Dedupe.
+type DedupeStore interface {
+	NonceStore
+	// Remove forgets nonce, so that a request carrying it is accepted again.
+	Remove(ctx context.Context, nonce string) error
+}
+
+// DedupeOptions configures the Dedupe middleware.
+type DedupeOptions struct {
+	// Store records the request IDs seen within the window. Defaults to a
+	// MemoryNonceStore, which only deduplicates within one instance.
+	Store DedupeStore
+	// Header carries the client-generated request ID. Defaults to "X-Request-Id".
+	Header string
+	// Window is how long a request ID is remembered. Defaults to 10 seconds.
+	Window time.Duration
+}
+
+// Dedupe returns middleware that rejects duplicate non-idempotent requests with
+// 409 Conflict, giving POST and PATCH endpoints at-most-once semantics against
+// client retries and double submissions without storing responses. A request is
+// a duplicate if another with the same request ID and CacheKey arrived within the
+// window, including one still being served. An original that fails with a 5xx
+// status or panics is forgotten, so that the client can retry it. Requests
+// without a request ID, and those with idempotent methods, pass through.
+//
+// The request ID must come from the client, so Dedupe must run before any
+// middleware that fills in missing request IDs. Requests rejected as duplicates
+// are not told how the original was answered; endpoints that must replay the
+// original response need full idempotency key storage.
+func Dedupe(opts DedupeOptions) func(http.Handler) http.Handler {
+	if opts.Store == nil {
+		opts.Store = NewMemoryNonceStore()
+	}
+	if opts.Header == "" {
+		opts.Header = "X-Request-Id"
+	}
+	if opts.Window <= 0 {
+		opts.Window = 10 * time.Second
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(opts.Header)
+			if id == "" || idempotent(r.Method) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			key := CacheKey(w, r) + " " + id
+			fresh, err := opts.Store.Add(r.Context(), key, time.Now().Add(opts.Window))
+			if err != nil {
+				WriteError(w, r, http.StatusInternalServerError, "")
+				return
+			}
+			if !fresh {
+				WriteError(w, r, http.StatusConflict, "duplicate request "+id)
+				return
+			}
+
+			completed := false
+			defer func() {
+				status := http.StatusOK
+				if rw, ok := w.(ResponseWriter); ok {
+					status = rw.Status()
+				}
+				if !completed || status >= 500 {
+					opts.Store.Remove(context.WithoutCancel(r.Context()), key)
+				}
+			}()
+			next.ServeHTTP(w, r)
+			completed = true
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package chain_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jpl-au/chain"
+)
+
+func TestDedupe(t *testing.T) {
+	calls := 0
+	mux := chain.New().Use(chain.Dedupe(chain.DedupeOptions{Window: 50 * time.Millisecond}))
+	mux.HandleFunc("/orders", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusCreated)
+	})
+	mux.HandleFunc("/payments", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	do := func(method, path, id string) int {
+		req := httptest.NewRequest(method, path, nil)
+		if id != "" {
+			req.Header.Set("X-Request-Id", id)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		id     string
+		want   int
+	}{
+		{"first", "POST", "/orders", "a", http.StatusCreated},
+		{"duplicate", "POST", "/orders", "a", http.StatusConflict},
+		{"other id", "POST", "/orders", "b", http.StatusCreated},
+		{"other path", "POST", "/payments", "a", http.StatusCreated},
+		{"other method", "PATCH", "/orders", "a", http.StatusCreated},
+		{"idempotent method", "PUT", "/orders", "a", http.StatusCreated},
+		{"idempotent repeat", "PUT", "/orders", "a", http.StatusCreated},
+		{"no id", "POST", "/orders", "", http.StatusCreated},
+		{"no id repeat", "POST", "/orders", "", http.StatusCreated},
+	}
+	for _, tt := range tests {
+		if got := do(tt.method, tt.path, tt.id); got != tt.want {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.want, got)
+		}
+	}
+	if calls != 8 {
+		t.Errorf("Expected 8 handler calls, got %d", calls)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if got := do("POST", "/orders", "a"); got != http.StatusCreated {
+		t.Errorf("Expected request ID accepted after the window, got %d", got)
+	}
+}
+
+func TestDedupeForgetsFailures(t *testing.T) {
+	status := http.StatusServiceUnavailable
+	mux := chain.New().Use(chain.Dedupe(chain.DedupeOptions{}))
+	mux.HandleFunc("POST /orders", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	})
+
+	do := func() int {
+		req := httptest.NewRequest("POST", "/orders", nil)
+		req.Header.Set("X-Request-Id", "a")
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if got := do(); got != http.StatusServiceUnavailable {
+		t.Fatalf("Expected 503, got %d", got)
+	}
+	status = http.StatusCreated
+	if got := do(); got != http.StatusCreated {
+		t.Errorf("Expected retry after a 5xx accepted, got %d", got)
+	}
+	if got := do(); got != http.StatusConflict {
+		t.Errorf("Expected duplicate of a success rejected, got %d", got)
+	}
+}
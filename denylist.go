@@ -0,0 +1,78 @@
+package chain
+
+import (
+	"net/http"
+	"net/netip"
+	"sync"
+	"time"
+)
+
+// Denylist blocks client IP addresses for a time, such as those caught by a
+// Honeypot. The zero value is not usable; create one with NewDenylist.
+type Denylist struct {
+	mu      sync.Mutex
+	entries map[netip.Addr]time.Time // address -> expiry
+	pruned  time.Time
+}
+
+// NewDenylist returns an empty Denylist.
+func NewDenylist() *Denylist {
+	return &Denylist{entries: make(map[netip.Addr]time.Time), pruned: time.Now()}
+}
+
+// Add blocks addr for ttl, extending any existing block that would end sooner.
+// Expired blocks are pruned at most once a minute.
+func (d *Denylist) Add(addr netip.Addr, ttl time.Duration) {
+	addr = addr.Unmap()
+	now := time.Now()
+	expires := now.Add(ttl)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if now.Sub(d.pruned) >= memoryPruneInterval {
+		for a, exp := range d.entries {
+			if now.After(exp) {
+				delete(d.entries, a)
+			}
+		}
+		d.pruned = now
+	}
+	if expires.After(d.entries[addr]) {
+		d.entries[addr] = expires
+	}
+}
+
+// Remove lifts any block on addr.
+func (d *Denylist) Remove(addr netip.Addr) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.entries, addr.Unmap())
+}
+
+// Contains reports whether addr is currently blocked. Expired blocks are removed
+// as they are found.
+func (d *Denylist) Contains(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	expires, ok := d.entries[addr]
+	if ok && time.Now().After(expires) {
+		delete(d.entries, addr)
+		return false
+	}
+	return ok
+}
+
+// Middleware returns middleware that rejects requests from blocked addresses with
+// 403 Forbidden through the error format. Like AllowCIDR it checks r.RemoteAddr,
+// so it should run after any middleware that resolves the real client IP.
+func (d *Denylist) Middleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if addr, ok := remoteAddr(r); ok && d.Contains(addr) {
+				WriteError(w, r, http.StatusForbidden, "")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package chain
+
+import (
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// deprecation holds the retirement details of deprecated routes.
+type deprecation struct {
+	sunset time.Time
+	link   string
+}
+
+// Deprecated marks routes registered afterwards on this Mux as deprecated. Responses
+// carry a Deprecation header, a Sunset header when sunset is non-zero (RFC 8594),
+// and a Link header pointing at link when it is non-empty. Each use is logged with
+// the caller's address and user agent, and counted in DeprecatedUsage.
+// Returns the Mux instance for method chaining.
+func (m *Mux) Deprecated(sunset time.Time, link string) *Mux {
+	m.deprecation = &deprecation{sunset: sunset, link: link}
+	return m
+}
+
+// DeprecatedUsage returns the number of requests served by each deprecated route,
+// keyed by pattern.
+func (m *Mux) DeprecatedUsage() map[string]uint64 {
+	root := m.root
+	root.mu.RLock()
+	defer root.mu.RUnlock()
+	usage := make(map[string]uint64, len(root.deprecated))
+	for pattern, n := range root.deprecated {
+		usage[pattern] = n.Load()
+	}
+	return usage
+}
+
+// deprecate wraps handler with deprecation headers, logging and counting if the Mux
+// has been marked deprecated. Other handlers are returned unchanged.
+func (m *Mux) deprecate(pattern string, handler http.Handler) http.Handler {
+	if m.deprecation == nil {
+		return handler
+	}
+	d := m.deprecation
+	var sunset string
+	if !d.sunset.IsZero() {
+		sunset = d.sunset.UTC().Format(http.TimeFormat)
+	}
+
+	counter := new(atomic.Uint64)
+	root := m.root
+	root.mu.Lock()
+	if root.deprecated == nil {
+		root.deprecated = make(map[string]*atomic.Uint64)
+	}
+	root.deprecated[pattern] = counter
+	root.mu.Unlock()
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		counter.Add(1)
+		m.log().Warn("chain: deprecated route used",
+			"pattern", pattern,
+			"remote_addr", r.RemoteAddr,
+			"user_agent", r.UserAgent(),
+		)
+
+		h := w.Header()
+		h.Set("Deprecation", "true")
+		if sunset != "" {
+			h.Set("Sunset", sunset)
+		}
+		if d.link != "" {
+			h.Add("Link", "<"+d.link+`>; rel="deprecation"`)
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,56 @@
+package chain_test
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jpl-au/chain"
+)
+
+func TestDeprecated(t *testing.T) {
+	var logs bytes.Buffer
+	sunset := time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	mux := chain.New().WithLogger(slog.New(slog.NewTextHandler(&logs, nil)))
+	mux.HandleFunc("GET /v2/users", func(w http.ResponseWriter, r *http.Request) {})
+	mux.Route("/v1", func(v1 *chain.Mux) {
+		v1.Deprecated(sunset, "https://example.com/migrate")
+		v1.HandleFunc("GET /users", func(w http.ResponseWriter, r *http.Request) {})
+	})
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest("GET", "/v1/users", nil)
+		req.Header.Set("User-Agent", "legacy-client")
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+
+		if rec.Header().Get("Deprecation") != "true" {
+			t.Errorf("Expected Deprecation header, got '%s'", rec.Header().Get("Deprecation"))
+		}
+		if got := rec.Header().Get("Sunset"); got != "Tue, 01 Jan 2030 00:00:00 GMT" {
+			t.Errorf("Unexpected Sunset header '%s'", got)
+		}
+		if got := rec.Header().Get("Link"); got != `<https://example.com/migrate>; rel="deprecation"` {
+			t.Errorf("Unexpected Link header '%s'", got)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/v2/users", nil))
+	if rec.Header().Get("Deprecation") != "" {
+		t.Error("Deprecation header set on a current route")
+	}
+
+	usage := mux.DeprecatedUsage()
+	if len(usage) != 1 || usage["GET /v1/users"] != 2 {
+		t.Errorf("Expected usage map[GET /v1/users:2], got %v", usage)
+	}
+	if !strings.Contains(logs.String(), "user_agent=legacy-client") {
+		t.Errorf("Expected deprecated use to be logged with caller, got '%s'", logs.String())
+	}
+}
@@ -0,0 +1,142 @@
+package chain
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// RouteDiff is the difference between two route tables, as returned by Diff.
+type RouteDiff struct {
+	// Added holds the routes only in the new table.
+	Added []RouteInfo
+	// Removed holds the routes only in the old table.
+	Removed []RouteInfo
+	// Changed holds the routes in both tables whose middleware or metadata differ.
+	Changed []RouteChange
+}
+
+// RouteChange describes a route present in both tables with different details.
+type RouteChange struct {
+	Old RouteInfo
+	New RouteInfo
+	// Fields names the RouteInfo fields that differ, such as "Middleware".
+	Fields []string
+}
+
+// Diff compares two route tables, such as the RouteTable of the previous and
+// current release, matching routes by pattern. Each list in the result is sorted
+// by pattern, so the output is stable for CI gates and release notes. Tables
+// saved with WriteRouteTable can be loaded with ReadRouteTable for comparison
+// with the current build.
+//
+// Middleware are compared by function name. Closures are named after their
+// position in the enclosing function, such as "main.main.func1", so adding a
+// closure before another can show as a change.
+func Diff(oldRoutes, newRoutes []RouteInfo) RouteDiff {
+	before := make(map[string]RouteInfo, len(oldRoutes))
+	for _, info := range oldRoutes {
+		before[info.Pattern] = info
+	}
+	after := make(map[string]RouteInfo, len(newRoutes))
+	for _, info := range newRoutes {
+		after[info.Pattern] = info
+	}
+
+	var d RouteDiff
+	for _, info := range sortedRoutes(newRoutes) {
+		old, ok := before[info.Pattern]
+		if !ok {
+			d.Added = append(d.Added, info.clone())
+			continue
+		}
+		if fields := changedFields(old, info); len(fields) > 0 {
+			d.Changed = append(d.Changed, RouteChange{Old: old.clone(), New: info.clone(), Fields: fields})
+		}
+	}
+	for _, info := range sortedRoutes(oldRoutes) {
+		if _, ok := after[info.Pattern]; !ok {
+			d.Removed = append(d.Removed, info.clone())
+		}
+	}
+	return d
+}
+
+// Empty reports whether the tables compared were equivalent.
+func (d RouteDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// String formats the diff one route per line, prefixed with "+" for added, "-"
+// for removed and "~" for changed routes, followed by the fields that changed.
+func (d RouteDiff) String() string {
+	var b strings.Builder
+	for _, info := range d.Added {
+		fmt.Fprintf(&b, "+ %s\n", info.Pattern)
+	}
+	for _, info := range d.Removed {
+		fmt.Fprintf(&b, "- %s\n", info.Pattern)
+	}
+	for _, c := range d.Changed {
+		fmt.Fprintf(&b, "~ %s (%s)\n", c.New.Pattern, strings.Join(c.Fields, ", "))
+	}
+	return b.String()
+}
+
+// WriteRouteTable writes routes to w as indented JSON sorted by pattern, a stable
+// form suitable for committing alongside the code and reading back with
+// ReadRouteTable.
+func WriteRouteTable(w io.Writer, routes []RouteInfo) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(sortedRoutes(routes))
+}
+
+// ReadRouteTable reads a route table written by WriteRouteTable.
+func ReadRouteTable(r io.Reader) ([]RouteInfo, error) {
+	var routes []RouteInfo
+	if err := json.NewDecoder(r).Decode(&routes); err != nil {
+		return nil, err
+	}
+	return routes, nil
+}
+
+// sortedRoutes returns a copy of routes sorted by pattern.
+func sortedRoutes(routes []RouteInfo) []RouteInfo {
+	sorted := slices.Clone(routes)
+	slices.SortStableFunc(sorted, func(a, b RouteInfo) int { return cmp.Compare(a.Pattern, b.Pattern) })
+	return sorted
+}
+
+// changedFields returns the names of the fields that differ between a and b.
+// Nil and empty slices are treated as equal, since they do not survive a round
+// trip through JSON alike.
+func changedFields(a, b RouteInfo) []string {
+	var fields []string
+	if a.Prefix != b.Prefix {
+		fields = append(fields, "Prefix")
+	}
+	if !slices.Equal(a.Middleware, b.Middleware) {
+		fields = append(fields, "Middleware")
+	}
+	if !slices.Equal(a.Requirements.Roles, b.Requirements.Roles) ||
+		!slices.Equal(a.Requirements.Permissions, b.Requirements.Permissions) {
+		fields = append(fields, "Requirements")
+	}
+	if a.Deprecated != b.Deprecated {
+		fields = append(fields, "Deprecated")
+	}
+	if a.SLOLatency != b.SLOLatency || a.SLOObjective != b.SLOObjective {
+		fields = append(fields, "SLO")
+	}
+	if a.Priority != b.Priority {
+		fields = append(fields, "Priority")
+	}
+	if (a.CacheQuery == nil) != (b.CacheQuery == nil) || !slices.Equal(a.CacheQuery, b.CacheQuery) {
+		fields = append(fields, "CacheQuery")
+	}
+	return fields
+}
@@ -0,0 +1,73 @@
+package chain_test
+
+import (
+	"bytes"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jpl-au/chain"
+)
+
+func logging(next http.Handler) http.Handler { return next }
+
+func TestDiff(t *testing.T) {
+	h := func(w http.ResponseWriter, r *http.Request) {}
+
+	before := chain.New()
+	before.HandleFunc("GET /users", h)
+	before.HandleFunc("GET /orders", h)
+	before.HandleFunc("GET /legacy", h)
+
+	after := chain.New()
+	after.HandleFunc("GET /users", h)
+	after.Group(func(g *chain.Mux) {
+		g.Use(logging).RequireRole("staff").SLO(time.Second, 0.99)
+		g.HandleFunc("GET /orders", h)
+	})
+	after.HandleFunc("POST /orders", h)
+
+	d := chain.Diff(before.RouteTable(), after.RouteTable())
+	want := "+ POST /orders\n- GET /legacy\n~ GET /orders (Middleware, Requirements, SLO)\n"
+	if got := d.String(); got != want {
+		t.Errorf("Unexpected diff:\n%s\nwant:\n%s", got, want)
+	}
+	if d.Empty() {
+		t.Error("Expected diff to be non-empty")
+	}
+	if !chain.Diff(after.RouteTable(), after.RouteTable()).Empty() {
+		t.Error("Expected no difference between identical tables")
+	}
+}
+
+func TestRouteTableRoundTrip(t *testing.T) {
+	h := func(w http.ResponseWriter, r *http.Request) {}
+
+	mux := chain.New()
+	mux.HandleFunc("GET /b", h)
+	mux.Group(func(g *chain.Mux) {
+		g.Use(logging).RequirePermission("a:read").Deprecated(time.Time{}, "").Priority(chain.PriorityHigh)
+		g.HandleFunc("GET /a", h)
+	})
+
+	var first, second bytes.Buffer
+	if err := chain.WriteRouteTable(&first, mux.RouteTable()); err != nil {
+		t.Fatal(err)
+	}
+	routes, err := chain.ReadRouteTable(bytes.NewReader(first.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(routes) != 2 || routes[0].Pattern != "GET /a" {
+		t.Fatalf("Expected routes sorted by pattern, got %+v", routes)
+	}
+	if d := chain.Diff(routes, mux.RouteTable()); !d.Empty() {
+		t.Errorf("Expected no difference after round trip, got:\n%s", d)
+	}
+
+	chain.WriteRouteTable(&second, routes)
+	if !reflect.DeepEqual(first.Bytes(), second.Bytes()) {
+		t.Errorf("Expected stable serialization:\n%s\n%s", first.String(), second.String())
+	}
+}
@@ -0,0 +1,73 @@
+package chain_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jpl-au/chain"
+)
+
+func TestDisable(t *testing.T) {
+	var middlewareRan bool
+	mux := chain.New()
+	mux.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			middlewareRan = true
+			next.ServeHTTP(w, r)
+		})
+	})
+	mux.HandleFunc("GET /exports", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("export"))
+	})
+	mux.HandleFunc("GET /legacy", func(w http.ResponseWriter, r *http.Request) {})
+
+	get := func(path string) *httptest.ResponseRecorder {
+		middlewareRan = false
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		return rec
+	}
+
+	mux.Disable("GET /exports", 0, "exports are paused").Disable("GET /legacy", http.StatusGone, "use /v2")
+
+	rec := get("/exports")
+	if rec.Code != http.StatusServiceUnavailable || !strings.Contains(rec.Body.String(), "exports are paused") {
+		t.Errorf("Expected 503 with reason, got %d %q", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("Retry-After"); got != "1" {
+		t.Errorf("Expected Retry-After 1, got %q", got)
+	}
+	if middlewareRan {
+		t.Error("Expected middleware skipped for a disabled route")
+	}
+	if rec := get("/legacy"); rec.Code != http.StatusGone || rec.Header().Get("Retry-After") != "" {
+		t.Errorf("Expected 410 without Retry-After, got %d", rec.Code)
+	}
+
+	routes := mux.RouteTable()
+	if routes[0].Disabled == nil || routes[0].Disabled.Reason != "exports are paused" {
+		t.Errorf("Expected route table to report the disabled route, got %+v", routes[0])
+	}
+	if len(mux.DisabledRoutes()) != 2 {
+		t.Errorf("Expected 2 disabled routes, got %v", mux.DisabledRoutes())
+	}
+
+	mux.Enable("GET /exports")
+	if rec := get("/exports"); rec.Code != http.StatusOK || rec.Body.String() != "export" {
+		t.Errorf("Expected route enabled, got %d %q", rec.Code, rec.Body.String())
+	}
+	if mux.RouteTable()[0].Disabled != nil {
+		t.Error("Expected route table to report the route enabled")
+	}
+}
+
+func TestDisableUnknownPattern(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected panic for unknown pattern")
+		}
+	}()
+	chain.New().Disable("GET /missing", 0, "")
+}
@@ -21,6 +21,23 @@
 //	mux.Use(firstMiddleware)   // Runs first (outermost)
 //	mux.Use(secondMiddleware)  // Runs second (innermost)
 //
+// Middleware registered with [Mux.UsePre] runs before routing, so it also wraps
+// unmatched paths and custom 404/405 responses:
+//
+//	mux.UsePre(requestIDMiddleware)
+//
+// Middleware registered with [Mux.Wrap] wraps the whole router, including redirects
+// issued by the underlying [http.ServeMux] such as trailing-slash redirects:
+//
+//	mux.Wrap(accessLogMiddleware)
+//
+// Hooks registered with [Mux.Finally] run after the response completes, even when
+// a handler panics:
+//
+//	mux.Finally(func(rw chain.ResponseWriter, r *http.Request) {
+//		metrics.Observe(r.URL.Path, rw.Status())
+//	})
+//
 // # Route Groups
 //
 // Groups allow middleware to be scoped to a subset of routes:
@@ -30,6 +47,25 @@
 //		api.HandleFunc("GET /api/users", listUsersHandler)
 //	})
 //
+// [Mux.Lazy] mounts a subtree that is constructed on its first request, keeping
+// expensive setup for rarely used sections out of startup. [Mux.Prewarm] builds
+// them ahead of time:
+//
+//	mux.Lazy("/reports", newReportsRouter)
+//	go mux.Prewarm()
+//
+// # Named Stacks
+//
+// Middleware bundles can be defined once with [Stack] and applied by name:
+//
+//	chain.Stack("api", requestID, logger, auth)
+//	chain.Extend("api", rateLimit)
+//
+//	mux.Group(func(api *chain.Mux) {
+//		api.UseStack("api")
+//		api.HandleFunc("GET /api/users", listUsersHandler)
+//	})
+//
 // # Route Prefixes
 //
 // Use [Mux.Route] to create groups with a path prefix. All routes registered within
@@ -49,6 +85,280 @@
 //		})
 //	})
 //
+// [Mux.Prefix] returns the same kind of group without a callback, so subrouters can
+// be passed to feature packages for self-registration:
+//
+//	billing.Routes(mux.Prefix("/billing"))
+//
+// [Mux.Clone] copies a router's middleware and configuration, optionally with its
+// routes, so a base router can be reused for several services in one binary:
+//
+//	users := platform.Clone(false)
+//	users.HandleFunc("GET /users", listUsersHandler)
+//
+// # Authorization
+//
+// Groups can declare the roles and permissions their routes require. An [Authorizer]
+// checks them against the authenticated principal and failures receive a 403:
+//
+//	mux.WithAuthorizer(authz)
+//	mux.Group(func(admin *chain.Mux) {
+//		admin.RequireRole("admin").RequirePermission("orders:write")
+//		admin.HandleFunc("DELETE /orders/{id}", deleteOrderHandler)
+//	})
+//
+// Browser routes can send unauthenticated users to a login page with
+// [LoginRedirect], which records where they were going. The login handler sends
+// them back with [ReturnURL], which only accepts local paths, preventing open
+// redirects:
+//
+//	http.Redirect(w, r, chain.ReturnURL(r, "/"), http.StatusSeeOther)
+//
+// [Remember] keeps users logged in across browser sessions with rotating
+// remember-me tokens, revoking all of a user's tokens if a copied cookie is used:
+//
+//	remember := chain.NewRemember(chain.RememberOptions{Store: store, Load: startSession, Secure: true})
+//	mux.Use(remember.Middleware)
+//
+// [LoginGuard] protects login handlers from brute force attacks, delaying and
+// then locking out a client and username pair after repeated 401 responses. Its
+// [LoginGuard.Stats] and [LoginLocked] events feed alerting:
+//
+//	guard := chain.NewLoginGuard(chain.LoginGuardOptions{Delay: time.Second})
+//	mux.Group(func(m *chain.Mux) {
+//		m.Use(guard.Middleware())
+//		m.HandleFunc("POST /login", loginHandler)
+//	})
+//
+// [Mux.AllowCIDR] restricts a group to client networks, evaluated after UsePre
+// middleware so that the real client IP has been resolved:
+//
+//	mux.Route("/debug", func(debug *chain.Mux) {
+//		debug.AllowCIDR("10.0.0.0/8")
+//		debug.Handle("GET /vars", expvar.Handler())
+//	})
+//
+// [Mux.Honeypot] registers decoy routes at paths scanners probe. Clients that
+// request them can be tarpitted and added to a [Denylist] that blocks them from
+// the rest of the application:
+//
+//	blocked := chain.NewDenylist()
+//	mux.UsePre(blocked.Middleware())
+//	mux.WithHoneypot(chain.HoneypotOptions{Denylist: blocked}).Honeypot("/wp-admin", "/.env")
+//
+// Behind a TCP load balancer such as an AWS NLB or HAProxy, [ProxyProtocolListener]
+// reads the PROXY protocol header so that r.RemoteAddr is the original client:
+//
+//	http.Serve(chain.ProxyProtocolListener(l, chain.ProxyProtocolOptions{}), mux)
+//
+// # Controllers
+//
+// [Mux.Register] registers a controller's methods by naming convention, so a method
+// GetUsersID becomes "GET /users/{id}". Controllers can instead implement [Routable]
+// and add a shared prefix or middleware via [Prefixed] and [Middlewared]:
+//
+//	mux.Register(&UsersController{db: db})
+//
+// # Declarative Configuration
+//
+// [LoadConfig] builds a Mux from a JSON description of prefixes, named stacks and
+// routes mapped to named handlers, so routing can change without recompiling:
+//
+//	mux, err := chain.LoadConfig(file, map[string]http.Handler{
+//		"listUsers": http.HandlerFunc(listUsersHandler),
+//	})
+//
+// The "middleware" section ([MiddlewareConfig]) selects and tunes the built-in
+// middleware, such as standard headers, tracing, slow request logging and the
+// error format:
+//
+//	{"middleware": {"recover": true, "slowRequests": "500ms", "errorFormat": "problem"}}
+//
+// # Rewrites
+//
+// [Mux.Rewrite] and [Mux.RewriteRegexp] rewrite request paths before routing, which
+// is useful for absorbing legacy URLs without touching handlers:
+//
+//	mux.Rewrite("/index.php/{page}", "/pages/{page}")
+//
+// # Aliases
+//
+// [Mux.Alias] serves an alternative pattern with an existing route's handler, and
+// [Mux.AliasRedirect] redirects it to the canonical path:
+//
+//	mux.HandleFunc("GET /users/{id}", getUserHandler)
+//	mux.AliasRedirect("GET /v1/people/{id}", "GET /users/{id}")
+//
+// # Cache Policies
+//
+// [CacheControl] builds Cache-Control values, and [Mux.CacheControl] applies a policy
+// to a group's routes:
+//
+//	mux.Route("/static", func(static *chain.Mux) {
+//		static.CacheControl(chain.CacheControl().Public().MaxAge(24 * time.Hour))
+//		static.Handle("GET /", fileServer)
+//	})
+//
+// Handlers that know the version of their resource can call [Conditional] to answer
+// If-None-Match, If-Modified-Since and If-Match before generating a body:
+//
+//	if chain.Conditional(w, r, doc.Version, doc.Updated) {
+//		return
+//	}
+//
+// [ServeRange] and [ServeRangeAt] answer Range requests over content the handler
+// produces, reading only the requested bytes:
+//
+//	obj := bucket.Object(key)
+//	chain.ServeRangeAt(w, r, obj, obj.Size, chain.RangeOptions{ETag: obj.ETag})
+//
+// # Static Assets
+//
+// [Mux.Static] serves a file system, typically an embed.FS, under both original and
+// content-hashed names. Hashed names are cached as immutable, and [Mux.AssetPath] gives
+// templates the current hashed URL:
+//
+//	mux.Static("/static", assetsFS, chain.StaticOptions{})
+//	tmpl.Funcs(template.FuncMap{"asset": mux.AssetPath})
+//
+// Setting [StaticOptions].Listing adds sortable directory listings rendered with a
+// customizable template.
+//
+// # Deprecation
+//
+// [Mux.Deprecated] marks a group's routes for retirement. Responses carry Deprecation,
+// Sunset and Link headers, and usage is logged and counted in [Mux.DeprecatedUsage]:
+//
+//	mux.Route("/v1", func(v1 *chain.Mux) {
+//		v1.Deprecated(sunset, "https://example.com/docs/v2-migration")
+//		v1.HandleFunc("GET /users", listUsersV1)
+//	})
+//
+// # Readiness
+//
+// [Mux.ReadyWhen] gates a group on readiness checks. While a check fails, the
+// group's routes respond with 503 and other routes keep serving:
+//
+//	mux.Route("/api", func(api *chain.Mux) {
+//		api.ReadyWhen(dbReady.Load)
+//		api.HandleFunc("GET /users", listUsers)
+//	})
+//
+// [Mux.Maintenance] takes a group offline during scheduled windows, responding with
+// 503 and a Retry-After header until the window ends:
+//
+//	api.Maintenance(chain.MaintenanceOptions{
+//		Windows: []chain.MaintenanceWindow{
+//			chain.DailyWindow{Start: 2 * time.Hour, Duration: time.Hour},
+//		},
+//	})
+//
+// [Mux.InFlight] and [Mux.InFlightByRoute] report the requests currently being
+// served, and [Mux.MaxInFlight] sheds load with 503 once the router is at capacity:
+//
+//	mux.MaxInFlight(1000)
+//
+// [Mux.Priority] ranks a group's routes for [LoadShed], which serves queued
+// high-priority requests first and sheds low-priority ones first once overloaded:
+//
+//	mux.Use(chain.LoadShed(chain.LoadShedOptions{}))
+//	mux.Group(func(ops *chain.Mux) {
+//		ops.Priority(chain.PriorityHigh)
+//		ops.HandleFunc("GET /healthz", healthz)
+//	})
+//
+// # Request Log
+//
+// [RequestLog] keeps the most recent requests in memory, with their route, status,
+// latency, request ID and any error recorded with [RecordError], and serves them as
+// JSON or an HTML table:
+//
+//	requests := chain.NewRequestLog(chain.RequestLogOptions{Size: 200})
+//	mux.UsePre(requests.Middleware())
+//	mux.Handle("GET /debug/requests", requests)
+//
+// [SchemaRecorder] watches development traffic and accumulates the shape of each
+// route's JSON requests and responses, with redacted examples, as a starting
+// point for API documentation:
+//
+//	schemas := chain.NewSchemaRecorder(chain.SchemaOptions{})
+//	mux.UsePre(schemas.Middleware())
+//	mux.Handle("GET /debug/schemas", schemas)
+//
+// Building with the chain_nodebug tag compiles [Dump], [RequestLog] and
+// [SchemaRecorder] down to no-ops with the same API, keeping them out of
+// production binaries.
+//
+// [Capture] records a sample of full requests, including bodies up to a limit, so
+// that production-only failures can be replayed locally with chaintest.Replay:
+//
+//	mux.Use(chain.Capture(chain.CaptureOptions{Sink: chain.CaptureTo(file), Sample: 0.01}))
+//
+// # Events
+//
+// [Mux.Subscribe] delivers typed lifecycle events, such as [RouteRegistered],
+// [RequestCompleted], [PanicRecovered] and [ConfigReloaded], so integrations like
+// auditing or error tracking can be built without middleware:
+//
+//	mux.Subscribe(func(e chain.Event) {
+//		if p, ok := e.(chain.PanicRecovered); ok {
+//			tracker.Capture(p.Err)
+//		}
+//	})
+//
+// # Admin API
+//
+// [Mux.Admin] mounts JSON endpoints behind their own authentication for viewing the
+// route table, SLO status, deprecated route usage and curl examples, disabling and
+// enabling routes, changing the log level, switching maintenance on and off with a
+// [MaintenanceSwitch], and reloading configuration:
+//
+//	mux.Admin("/admin", chain.AdminOptions{Auth: opsAuth, LogLevel: &level, Maintenance: &sw})
+//
+// [Mux.Disable] is a kill switch for a single route during an incident: the route
+// stays registered and listed, but answers with 503 or another status and a reason
+// until [Mux.Enable] switches it back on:
+//
+//	mux.Disable("POST /exports", http.StatusServiceUnavailable, "exports are paused")
+//
+// # Service Level Objectives
+//
+// [Mux.SLO] declares a latency and success objective for a group's routes. Requests
+// that are too slow or fail with a 5xx status are counted in [Mux.SLOReport] and
+// by [StatsD.Middleware] as http.slo.breach, and a warning is logged when a route
+// falls below its objective:
+//
+//	mux.Route("/api", func(api *chain.Mux) {
+//		api.SLO(200*time.Millisecond, 0.999)
+//		api.HandleFunc("GET /users", listUsers)
+//	})
+//
+// # Route Table
+//
+// [Mux.RouteTable] lists every registered route with its prefix, middleware and
+// requirements, and [Mux.Match] reports which route would serve a request.
+// [Mux.Lint] checks the table for shadowed patterns and prefix mistakes. The
+// chaintest subpackage builds on these to test routers without a network round-trip.
+//
+// [Mux.GenerateGoClient] and [Mux.GenerateTSClient] write client stubs with one
+// method per route, taking the path wildcards as parameters, so API clients need not
+// repeat the router's paths by hand.
+//
+// [Diff] compares two route tables, reporting added and removed routes and those
+// whose middleware or metadata changed. [WriteRouteTable] saves a table in a stable
+// form that [ReadRouteTable] loads, so CI can compare a build with the last release:
+//
+//	old, _ := chain.ReadRouteTable(f)
+//	if d := chain.Diff(old, mux.RouteTable()); !d.Empty() {
+//		fmt.Print(d)
+//	}
+//
+// [Mux.CurlExamples] renders a curl command for each route, with placeholders for
+// path wildcards and the headers its requirements and method call for.
+//
+// [Mux.WithPprofLabels] labels each route's goroutines with its pattern and method,
+// so CPU and goroutine profiles can be broken down by endpoint.
+//
 // # Response Wrapper
 //
 // Chain wraps all responses with a [ResponseWriter] that tracks the status code and
@@ -63,6 +373,12 @@
 //		})
 //	}
 //
+// [RoutePattern] reports the pattern of the route that served a request, for
+// middleware registered with [Mux.UsePre] such as metrics.
+//
+// [OnWriteHeader] registers a hook that runs just before the status is sent, the last
+// point at which response headers can be changed.
+//
 // The response wrapper also implements [http.Flusher], [http.Hijacker], and [http.Pusher]
 // for compatibility with SSE, WebSockets, and HTTP/2 server push.
 //
@@ -74,6 +390,273 @@
 //		WithNotFound(notFoundHandler).
 //		WithMethodNotAllowed(methodNotAllowedHandler)
 //
+// [Mux.WithAllowedMethods] rejects unknown HTTP methods with 501 and methods outside
+// the allowlist (such as TRACE) with 405 before routing:
+//
+//	mux := chain.New().WithAllowedMethods() // DefaultAllowedMethods
+//
+// [Mux.WithErrorFormat] renders every built-in error response through one
+// [ErrorFormatter]: 404 and 405 without a custom handler, method and header
+// rejections, authorization failures, panics and the like. [TextErrors] is the
+// default; [ProblemErrors], [JSONErrors] and [HTMLErrors] are also provided.
+// Handlers can use [WriteError] so their own errors match:
+//
+//	mux := chain.New().WithErrorFormat(chain.JSONErrors)
+//
+//	chain.WriteError(w, r, http.StatusConflict, "email already registered")
+//
+// Backpressure responses (429 and 503) should be written with [WriteRetryAfter],
+// which sets the Retry-After header and passes the delay to the formatter.
+// [RetryAfterRefill] and [RetryAfterUntil] compute the delay from a token bucket
+// or a deadline:
+//
+//	chain.WriteRetryAfter(w, r, http.StatusTooManyRequests,
+//		chain.RetryAfterRefill(tokens, 1, rate), "")
+//
+// # Profiles
+//
+// [NewDev] and [NewProd] apply environment presets. Development returns verbose
+// problem-detail errors, dumps requests to stderr and prints the route table on the
+// first request. Production returns terse problem-detail errors, rejects unsafe
+// methods and malformed headers, and sets security headers:
+//
+//	mux := chain.NewProd()
+//
+// # Built-in Middleware
+//
+// The package provides middleware for common concerns, registered like any other:
+//
+//   - [Recoverer] turns panics into 500 responses and reports them
+//   - [Transaction] runs each request in a transaction committed or rolled back by status
+//   - [ExpectContinue] accepts or rejects "Expect: 100-continue" uploads before the body is sent
+//   - [Trace] propagates B3 and Jaeger trace IDs, with [TraceTransport] and [TraceLogHandler]
+//   - [StatsD.Middleware] sends per-route request counts and timings to StatsD or DogStatsD
+//   - [SlowRequests] logs requests over a latency threshold and profiles sustained slowness
+//   - [ValidateHeaders] rejects malformed or conflicting request headers
+//   - [ValidateHost] rejects requests for hosts outside an allowlist
+//   - [ClientCert] authenticates requests with TLS client certificates
+//   - [ReplayProtection] rejects signed requests with stale timestamps or reused nonces
+//   - [Dedupe] rejects repeated POST and PATCH requests carrying the same client request ID with 409
+//   - [ClientConcurrency] caps the requests each client may have in flight at once
+//   - [LoadShed] adapts a concurrency limit to latency and sheds queued excess with 503
+//   - [ContentSecurityPolicy] sets a CSP header with a per-request nonce
+//   - [Localize] negotiates the response language from Accept-Language
+//   - [Transform] rewrites requests and buffered responses
+//   - [Minify] minifies HTML, JSON and other configured response types
+//   - [StandardHeaders] adds Server, request ID, timing and static headers
+//   - [Captcha] verifies hCaptcha and reCAPTCHA tokens on form submissions
+//   - [Dump] prints requests and responses during development
+//   - [ResponseCache] caches responses in memory with optional stale-while-revalidate
+//   - [MicroCache] caches nearly static responses for a short TTL, refreshing in the background
+//
+// Both caches key responses with [CacheKey], built from the method, host, matched
+// route pattern, path wildcard values and the query parameters the route declares
+// significant with [Mux.CacheKeyQuery]:
+//
+//	mux.Group(func(search *chain.Mux) {
+//		search.CacheKeyQuery("q", "page").Use(chain.ResponseCache(chain.CacheOptions{}))
+//		search.HandleFunc("GET /search", searchHandler)
+//	})
+//
+// [CacheOptions].Vary and Partition store a response per negotiated language,
+// encoding, tenant or authentication state, with MaxVariants bounding the variants
+// kept for each key.
+//
+// # Background Work
+//
+// [AfterResponse] schedules work to run after the response, on a bounded pool
+// enabled with [Mux.WithWorkerPool]:
+//
+//	pool := chain.NewWorkerPool(chain.WorkerOptions{Workers: 8})
+//	defer pool.Close(ctx)
+//	mux.WithWorkerPool(pool)
+//
+//	chain.AfterResponse(r, func(ctx context.Context) { mailer.SendWelcome(ctx, user) })
+//
+// # Binding and Rendering
+//
+// [Bind] decodes JSON, XML and form request bodies by Content-Type with a size
+// limit, and [Respond] writes JSON or XML according to the Accept header. Forms may
+// address nested structs and slices, as in "items[0].name". [JSON] and [XML]
+// write a specific format:
+//
+//	var o Order
+//	if err := chain.Bind(w, r, &o); err != nil {
+//		chain.WriteError(w, r, http.StatusBadRequest, err.Error())
+//		return
+//	}
+//	chain.Respond(w, r, http.StatusCreated, o)
+//
+// Struct fields tagged path, query or header are filled from r.PathValue, the
+// URL query and the request headers in the same call, so a handler binds its
+// whole input at once:
+//
+//	type UpdateOrder struct {
+//		ID    int      `path:"id"`
+//		Items []string `json:"items"`
+//	}
+//
+// Both are built on [Codec], and [Mux.WithCodecs] registers additional formats
+// such as MessagePack or CBOR, in order of preference. [NegotiatedErrors] renders
+// error responses with the same codecs:
+//
+//	mux.WithCodecs(chain.JSONCodec, msgpackCodec{}).WithErrorFormat(chain.NegotiatedErrors)
+//
+// [ProtobufCodec] adapts a protobuf runtime without adding a dependency:
+//
+//	mux.WithCodecs(chain.JSONCodec, chain.ProtobufCodec(proto.Marshal, proto.Unmarshal))
+//
+// [BufferBody] reads the body into memory and makes it re-readable, so that
+// middleware such as signature verification can inspect it before Bind, and
+// [RetryTransport] can replay it upstream.
+//
+// # Validation Errors
+//
+// [ValidationErrors] collects every failed field rule, and [WriteValidationErrors]
+// renders them as a single 422 response, localized for the locale negotiated by
+// [Localize] using messages from [RegisterValidationMessages]:
+//
+//	var errs chain.ValidationErrors
+//	errs.Check(req.Email != "", "email", "required", "", "email is required")
+//	if err := errs.Err(); err != nil {
+//		chain.WriteValidationErrors(w, r, err)
+//		return
+//	}
+//
+// # Uploads
+//
+// [Upload] streams multipart file parts to caller-provided writers, enforcing size
+// and count limits and checking sniffed content types as the body is read:
+//
+//	res, err := chain.Upload(r, chain.UploadOptions{
+//		Sink:         func(p chain.UploadPart) (io.Writer, error) { return os.CreateTemp("", "upload-*") },
+//		AllowedTypes: []string{"image/png", "image/jpeg"},
+//	})
+//
+// A [Scanner] set in UploadOptions, such as a clamd or ICAP client, receives each
+// file as it streams and can veto it; [ScanQuarantine] keeps flagged files and
+// marks them instead of failing the upload.
+//
+// File types are detected from their leading bytes by [SniffContentType], which
+// also recognises SVG, so HTML or SVG cannot be uploaded as an image whatever
+// Content-Type the client declares. [SniffUploads] applies the same check to raw
+// request bodies:
+//
+//	mux.Group(func(m *chain.Mux) {
+//		m.Use(chain.SniffUploads(chain.SniffOptions{AllowedTypes: []string{"image/png"}}))
+//		m.HandleFunc("PUT /avatar", putAvatar)
+//	})
+//
+// [Mux.Resumable] mounts a tus-compatible resumable upload endpoint backed by an
+// [UploadStorage], so clients can continue interrupted uploads:
+//
+//	mux.Resumable("/uploads", chain.ResumableOptions{Storage: storage, MaxSize: 1 << 30})
+//
+// # Streaming Responses
+//
+// [NDJSON] streams newline-delimited JSON with periodic flushes, stopping when the
+// client goes away:
+//
+//	stream := chain.NDJSON(w, r)
+//	for rows.Next() {
+//		if err := stream.Send(row); err != nil {
+//			break
+//		}
+//	}
+//	stream.Close()
+//
+// [CSV] streams a text/csv download, optionally with a byte order mark for Excel:
+//
+//	out := chain.CSV(w, []string{"id", "name"}, chain.CSVOptions{Filename: "users.csv", BOM: true})
+//	for _, u := range users {
+//		out.Write([]string{u.ID, u.Name})
+//	}
+//	out.Close()
+//
+// Long-lived connections, hijacked WebSockets and responses that have been
+// flushed, are tracked so that [Mux.Drain] can end them at shutdown. Handlers
+// select on [Draining] to send a close frame or final event; any still running
+// when the grace period expires are closed or have their context cancelled:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+//	defer cancel()
+//	go mux.Drain(ctx)
+//	server.Shutdown(ctx)
+//
+// # Error Reporting
+//
+// [Recoverer] recovers panics and passes them to a [Reporter], the single integration
+// point for error tracking services. [NewAsyncReporter] delivers reports on a
+// background goroutine so they do not delay responses:
+//
+//	reporter := chain.NewAsyncReporter(sentryReporter, 100)
+//	defer reporter.Close()
+//	mux.Use(chain.Recoverer(chain.RecoverOptions{Reporter: reporter}))
+//
+// The Development and Production profiles install a Recoverer reporting to the
+// Reporter set with [Mux.WithReporter]. Under Development, panics render an error
+// page with the stack trace, request details and the route's middleware.
+//
+// # Outbound Requests
+//
+// [Client] returns an http.Client for calls made inside a handler. It forwards the
+// request ID, the trace context started by [Trace] and the inbound deadline:
+//
+//	resp, err := chain.Client(r, chain.ClientOptions{}).Get(inventoryURL)
+//
+// [Budget] gives each request a deadline and a number of cost units. Downstream
+// calls made through [Call] or [Client] are charged against it and expire ahead of
+// the route's deadline, failing with [ErrBudgetExhausted] once it is spent:
+//
+//	mux.Use(chain.Budget(chain.BudgetOptions{Latency: time.Second, Cost: 100, Reserve: 50 * time.Millisecond}))
+//
+//	err := chain.Call(r.Context(), 10, func(ctx context.Context) error {
+//		return db.QueryRowContext(ctx, query, id).Scan(&user)
+//	})
+//
+// [RevalidatingTransport] caches upstream responses that carry an ETag or
+// Last-Modified validator and revalidates them with the origin, serving the
+// cached body when it answers 304 Not Modified. As the Transport of an
+// httputil.ReverseProxy it makes the proxy a small caching gateway:
+//
+//	proxy := httputil.NewSingleHostReverseProxy(origin)
+//	proxy.Transport = chain.RevalidatingTransport(nil, chain.RevalidateOptions{})
+//	mux.Handle("/assets/", proxy)
+//
+// [RetryTransport] retries failed idempotent requests with backoff, within a
+// budget that stops retries from multiplying load on a failing upstream, and can
+// hedge slow requests by sending a second copy:
+//
+//	proxy.Transport = chain.RetryTransport(nil, chain.RetryOptions{HedgeAfter: 200 * time.Millisecond})
+//
+// [Split] divides traffic between weighted [Variant] handlers, such as proxies to
+// stable and canary upstreams, and keeps each visitor on one variant with an
+// affinity cookie:
+//
+//	mux.Handle("/api/", chain.Split(chain.SplitOptions{Secure: true},
+//		chain.Variant{Name: "stable", Weight: 95, Handler: stableProxy},
+//		chain.Variant{Name: "canary", Weight: 5, Handler: canaryProxy},
+//	))
+//
+// # gRPC
+//
+// [Mux.WithGRPC] and [Mux.WithGRPCWeb] serve gRPC and gRPC-Web on the same listener as
+// regular routes. Matching requests bypass the router and response wrapper:
+//
+//	mux := chain.New().WithGRPC(grpcServer)
+//
+// [WebTransport] accepts WebTransport sessions on a CONNECT route through an
+// HTTP/3 implementation's upgrade function. Unlike gRPC, the CONNECT request passes
+// through the route's middleware first:
+//
+//	mux.Handle("CONNECT /live", chain.WebTransport(wt.Upgrade, serveSession))
+//
+// [Mux.WithACME] serves ACME HTTP-01 challenges, such as those of an
+// autocert.Manager, ahead of all middleware so certificates can be issued
+// automatically:
+//
+//	go http.ListenAndServe(":80", chain.New().WithACME(manager.HTTPHandler(nil)))
+//
 // # Path Parameters
 //
 // Path parameters use Go 1.22's syntax and are accessed via [http.Request.PathValue]:
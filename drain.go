@@ -0,0 +1,142 @@
+package chain
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+)
+
+// drainer tracks long-lived connections, those that have been hijacked or have
+// streamed a response with Flush, so that Drain can signal and close them.
+type drainer struct {
+	mu       sync.Mutex
+	active   map[*responseWriter]struct{}
+	draining chan struct{}
+	empty    chan struct{}
+	started  bool
+}
+
+// init prepares the channels on first use.
+func (d *drainer) init() {
+	if d.draining == nil {
+		d.draining = make(chan struct{})
+		d.empty = make(chan struct{})
+		d.active = make(map[*responseWriter]struct{})
+	}
+}
+
+// track registers rw as a long-lived connection.
+func (d *drainer) track(rw *responseWriter) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.init()
+	d.active[rw] = struct{}{}
+}
+
+// untrack removes rw once its handler has returned.
+func (d *drainer) untrack(rw *responseWriter) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, ok := d.active[rw]; !ok {
+		return
+	}
+	delete(d.active, rw)
+	if d.started && len(d.active) == 0 {
+		close(d.empty)
+	}
+}
+
+// signal returns the channel closed when draining starts.
+func (d *drainer) signal() <-chan struct{} {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.init()
+	return d.draining
+}
+
+// Drain signals long-lived connections served by the router, WebSockets and other
+// hijacked connections as well as streaming responses such as server-sent events,
+// to finish, and waits for their handlers to return. Handlers learn of the drain
+// through Draining and should send a close frame or final event and return. When
+// ctx is done before they have, hijacked connections are closed and the contexts
+// of streaming requests are cancelled, unless the router was configured with
+// WithZeroAlloc, and Drain returns ctx.Err().
+//
+// Call Drain alongside http.Server.Shutdown, which does not wait for hijacked
+// connections and may otherwise wait indefinitely for streams:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+//	defer cancel()
+//	go mux.Drain(ctx)
+//	server.Shutdown(ctx)
+//
+// Drain can only be called once per router.
+func (m *Mux) Drain(ctx context.Context) error {
+	d := &m.root.drain
+	d.mu.Lock()
+	d.init()
+	if d.started {
+		d.mu.Unlock()
+		panic("chain: Drain called twice")
+	}
+	d.started = true
+	close(d.draining)
+	if len(d.active) == 0 {
+		close(d.empty)
+	}
+	d.mu.Unlock()
+
+	select {
+	case <-d.empty:
+		return nil
+	case <-ctx.Done():
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for rw := range d.active {
+		if rw.conn != nil {
+			rw.conn.Close()
+		}
+		if rw.cancel != nil {
+			rw.cancel()
+		}
+	}
+	return ctx.Err()
+}
+
+// Draining returns a channel that is closed when Drain is called on the router
+// serving w. Long-lived handlers select on it to finish gracefully:
+//
+//	for {
+//		select {
+//		case ev := <-events:
+//			fmt.Fprintf(w, "data: %s\n\n", ev)
+//			http.NewResponseController(w).Flush()
+//		case <-chain.Draining(w):
+//			fmt.Fprint(w, "event: shutdown\ndata: reconnect\n\n")
+//			return
+//		case <-r.Context().Done():
+//			return
+//		}
+//	}
+//
+// It returns nil, which blocks forever in a select, if w was not wrapped by chain.
+func Draining(w http.ResponseWriter) <-chan struct{} {
+	if rw := findResponseWriter(w); rw != nil && rw.drain != nil {
+		return rw.drain.signal()
+	}
+	return nil
+}
+
+// trackLongLived registers rw with its router's drainer, once.
+func (rw *responseWriter) trackLongLived(conn net.Conn) {
+	if conn != nil {
+		rw.conn = conn
+	}
+	if rw.drain != nil && !rw.longLived {
+		rw.longLived = true
+		rw.drain.track(rw)
+	}
+}
@@ -0,0 +1,167 @@
+package chain_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jpl-au/chain"
+)
+
+func TestDrainSignalsStreams(t *testing.T) {
+	mux := chain.New()
+	started := make(chan struct{})
+	mux.HandleFunc("GET /events", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "data: hello\n\n")
+		http.NewResponseController(w).Flush()
+		close(started)
+		<-chain.Draining(w)
+		fmt.Fprint(w, "event: shutdown\n\n")
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := mux.Drain(ctx); err != nil {
+		t.Fatalf("Drain = %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.HasSuffix(string(body), "event: shutdown\n\n") {
+		t.Errorf("body = %q, want final event", body)
+	}
+}
+
+func TestDrainCancelsAfterGrace(t *testing.T) {
+	mux := chain.New()
+	started := make(chan struct{})
+	done := make(chan error, 1)
+	mux.HandleFunc("GET /events", func(w http.ResponseWriter, r *http.Request) {
+		http.NewResponseController(w).Flush()
+		close(started)
+		<-r.Context().Done()
+		done <- r.Context().Err()
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := mux.Drain(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Drain = %v, want deadline exceeded", err)
+	}
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("handler context = %v, want canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("handler context was not cancelled")
+	}
+}
+
+func TestDrainClosesHijacked(t *testing.T) {
+	mux := chain.New()
+	started := make(chan struct{})
+	mux.HandleFunc("GET /ws", func(w http.ResponseWriter, r *http.Request) {
+		conn, buf, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		buf.WriteString("HTTP/1.1 101 Switching Protocols\r\n\r\n")
+		buf.Flush()
+		close(started)
+		io.Copy(io.Discard, conn) // returns once Drain closes the connection
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	fmt.Fprint(conn, "GET /ws HTTP/1.1\r\nHost: example.com\r\n\r\n")
+	if _, err := bufio.NewReader(conn).ReadString('\n'); err != nil {
+		t.Fatal(err)
+	}
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	mux.Drain(ctx)
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := conn.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("read after drain = %v, want EOF", err)
+	}
+}
+
+func TestDrainIdle(t *testing.T) {
+	mux := chain.New()
+	mux.HandleFunc("GET /", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-chain.Draining(w):
+			fmt.Fprint(w, "draining")
+		default:
+			fmt.Fprint(w, "ok")
+		}
+	})
+	if err := mux.Drain(context.Background()); err != nil {
+		t.Fatalf("Drain = %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if rec.Body.String() != "draining" {
+		t.Errorf("body = %q, want draining", rec.Body)
+	}
+}
+
+func TestDrainZeroAlloc(t *testing.T) {
+	mux := chain.New().WithZeroAlloc()
+	started := make(chan struct{})
+	mux.HandleFunc("GET /events", func(w http.ResponseWriter, r *http.Request) {
+		http.NewResponseController(w).Flush()
+		close(started)
+		<-chain.Draining(w)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := mux.Drain(ctx); err != nil {
+		t.Fatalf("Drain = %v", err)
+	}
+}
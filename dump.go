@@ -0,0 +1,146 @@
+//go:build !chain_nodebug
+
+package chain
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Dump returns middleware that pretty-prints each request and its response to out.
+// Request bodies are printed up to MaxBody bytes and remain fully readable by the
+// handler. Response bodies are captured as they are written when ResponseBody is
+// set. Binary bodies are summarised rather than printed. Dump is intended for
+// development and should not be enabled in production.
+func Dump(out io.Writer, opts DumpOptions) func(http.Handler) http.Handler {
+	if opts.MaxBody <= 0 {
+		opts.MaxBody = 4096
+	}
+	if opts.RedactHeaders == nil {
+		opts.RedactHeaders = []string{"Authorization", "Cookie", "Set-Cookie", "Proxy-Authorization"}
+	}
+	redact := make(map[string]bool, len(opts.RedactHeaders))
+	for _, name := range opts.RedactHeaders {
+		redact[http.CanonicalHeaderKey(name)] = true
+	}
+	var mu sync.Mutex
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			var b bytes.Buffer
+
+			fmt.Fprintf(&b, "--> %s %s %s\n", r.Method, r.URL.RequestURI(), r.Proto)
+			fmt.Fprintf(&b, "Host: %s\n", r.Host)
+			writeDumpHeaders(&b, r.Header, redact)
+			if r.Body != nil && r.Body != http.NoBody {
+				head, _ := io.ReadAll(io.LimitReader(r.Body, int64(opts.MaxBody)+1))
+				r.Body = struct {
+					io.Reader
+					io.Closer
+				}{io.MultiReader(bytes.NewReader(head), r.Body), r.Body}
+				writeDumpBody(&b, head, opts)
+			}
+
+			rw, ok := w.(ResponseWriter)
+			if !ok {
+				rw = wrapResponseWriter(w, r, nil, nil)
+			}
+			var body *bodyCapture
+			if opts.ResponseBody {
+				body = captureBody(rw, opts.MaxBody)
+			}
+
+			defer func() {
+				status := rw.Status()
+				fmt.Fprintf(&b, "\n<-- %d %s (%s)\n", status, http.StatusText(status), time.Since(start))
+				writeDumpHeaders(&b, rw.Header(), redact)
+				if opts.ResponseBody {
+					writeDumpBody(&b, body.buf.Bytes(), opts)
+				}
+				b.WriteString("\n")
+
+				mu.Lock()
+				out.Write(b.Bytes())
+				mu.Unlock()
+			}()
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+// writeDumpHeaders prints headers in sorted order, redacting sensitive values.
+func writeDumpHeaders(b *bytes.Buffer, h http.Header, redact map[string]bool) {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range h[k] {
+			if redact[k] {
+				v = "[REDACTED]"
+			}
+			fmt.Fprintf(b, "%s: %s\n", k, v)
+		}
+	}
+}
+
+// writeDumpBody prints up to opts.MaxBody bytes of body, noting any truncation.
+func writeDumpBody(b *bytes.Buffer, body []byte, opts DumpOptions) {
+	if len(body) == 0 {
+		return
+	}
+	truncated := len(body) > opts.MaxBody
+	if truncated {
+		body = body[:opts.MaxBody]
+	}
+	if opts.Redact != nil {
+		body = opts.Redact(body)
+	}
+
+	b.WriteString("\n")
+	if !utf8.Valid(body) || bytes.IndexByte(body, 0) >= 0 {
+		fmt.Fprintf(b, "[%d bytes of binary data]\n", len(body))
+		return
+	}
+	b.Write(body)
+	if !strings.HasSuffix(string(body), "\n") {
+		b.WriteString("\n")
+	}
+	if truncated {
+		b.WriteString("[truncated]\n")
+	}
+}
+
+// bodyCapture holds up to limit bytes of a response body, and one more to
+// detect truncation.
+type bodyCapture struct {
+	buf   bytes.Buffer
+	limit int
+}
+
+// captureBody records the body sent through w. It hooks the router's writer
+// rather than wrapping w, so that it sees the body written by a custom 404 or 405
+// handler during interception and not the default body ServeMux writes after it.
+func captureBody(w http.ResponseWriter, limit int) *bodyCapture {
+	c := &bodyCapture{limit: limit}
+	if rw := findResponseWriter(w); rw != nil {
+		rw.onWrite = append(rw.onWrite, c.write)
+	}
+	return c
+}
+
+// write captures as much of b as fits.
+func (c *bodyCapture) write(b []byte) {
+	if room := c.limit + 1 - c.buf.Len(); room > 0 {
+		c.buf.Write(b[:min(room, len(b))])
+	}
+}
@@ -0,0 +1,89 @@
+//go:build !chain_nodebug
+
+package chain_test
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jpl-au/chain"
+)
+
+func TestDump(t *testing.T) {
+	var out bytes.Buffer
+	var handlerBody string
+	mux := chain.New().Use(chain.Dump(&out, chain.DumpOptions{
+		MaxBody:      10,
+		ResponseBody: true,
+		Redact: func(body []byte) []byte {
+			return bytes.ReplaceAll(body, []byte("hunter2"), []byte("*******"))
+		},
+	}))
+	mux.HandleFunc("POST /login", func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		handlerBody = string(b)
+		w.Header().Set("Set-Cookie", "session=abc")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("welcome"))
+	})
+
+	req := httptest.NewRequest("POST", "/login?next=/", strings.NewReader("pw=hunter2&remember=1"))
+	req.Header.Set("Authorization", "Bearer secret")
+	mux.ServeHTTP(httptest.NewRecorder(), req)
+
+	if handlerBody != "pw=hunter2&remember=1" {
+		t.Errorf("Handler should read the full body, got '%s'", handlerBody)
+	}
+
+	dump := out.String()
+	for _, want := range []string{
+		"--> POST /login?next=/ HTTP/1.1\n",
+		"Authorization: [REDACTED]\n",
+		"pw=*******\n[truncated]\n",
+		"<-- 201 Created (",
+		"Set-Cookie: [REDACTED]\n",
+		"\nwelcome\n",
+	} {
+		if !strings.Contains(dump, want) {
+			t.Errorf("Expected dump to contain %q, got:\n%s", want, dump)
+		}
+	}
+	if strings.Contains(dump, "secret") || strings.Contains(dump, "hunter2") {
+		t.Errorf("Dump leaked a redacted value:\n%s", dump)
+	}
+}
+
+func TestDumpInterceptedResponses(t *testing.T) {
+	var out bytes.Buffer
+	mux := chain.New().
+		UsePre(chain.Dump(&out, chain.DumpOptions{ResponseBody: true})).
+		WithNotFound(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("custom not found"))
+		})).
+		WithMethodNotAllowed(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			w.Write([]byte("custom not allowed"))
+		}))
+	mux.HandleFunc("GET /users", func(w http.ResponseWriter, r *http.Request) {})
+
+	for _, tt := range []struct{ method, path, body string }{
+		{"GET", "/missing", "custom not found"},
+		{"POST", "/users", "custom not allowed"},
+	} {
+		out.Reset()
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+		if rec.Body.String() != tt.body {
+			t.Fatalf("%s %s: expected client body %q, got %q", tt.method, tt.path, tt.body, rec.Body.String())
+		}
+		dump := out.String()
+		if !strings.Contains(dump, "\n"+tt.body+"\n") || strings.Contains(dump, "\n404 page not found\n") || strings.Contains(dump, "\nMethod Not Allowed\n") {
+			t.Errorf("%s %s: expected dump of the custom body, got:\n%s", tt.method, tt.path, dump)
+		}
+	}
+}
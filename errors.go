@@ -0,0 +1,200 @@
+package chain
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"html/template"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// HTTPError describes an error response produced by the router or its built-in
+// middleware.
+type HTTPError struct {
+	// Status is the HTTP status code.
+	Status int
+	// Detail optionally explains the error. It is empty for most built-in errors,
+	// in which case formatters use the status text.
+	Detail string
+	// RetryAfter is how long the client should wait before retrying, set for
+	// backpressure responses written with WriteRetryAfter. The Retry-After header
+	// has already been set when it is non-zero.
+	RetryAfter time.Duration
+}
+
+// Message returns Detail, or the status text if Detail is empty.
+func (e HTTPError) Message() string {
+	if e.Detail != "" {
+		return e.Detail
+	}
+	return http.StatusText(e.Status)
+}
+
+// ErrorFormatter renders error responses, so that every built-in error path
+// produces the same shape.
+type ErrorFormatter interface {
+	FormatError(w http.ResponseWriter, r *http.Request, e HTTPError)
+}
+
+// ErrorFormatterFunc adapts a function to the ErrorFormatter interface.
+type ErrorFormatterFunc func(w http.ResponseWriter, r *http.Request, e HTTPError)
+
+// FormatError calls f(w, r, e).
+func (f ErrorFormatterFunc) FormatError(w http.ResponseWriter, r *http.Request, e HTTPError) {
+	f(w, r, e)
+}
+
+// Built-in error formatters.
+var (
+	// TextErrors writes the message as text/plain, like http.Error. It is the
+	// default when no formatter is configured.
+	TextErrors ErrorFormatter = ErrorFormatterFunc(func(w http.ResponseWriter, r *http.Request, e HTTPError) {
+		http.Error(w, e.Message(), e.Status)
+	})
+
+	// ProblemErrors writes an RFC 9457 application/problem+json response.
+	ProblemErrors ErrorFormatter = ErrorFormatterFunc(func(w http.ResponseWriter, r *http.Request, e HTTPError) {
+		WriteProblem(w, Problem{Status: e.Status, Detail: e.Detail})
+	})
+
+	// JSONErrors writes {"error": {"status": 404, "message": "Not Found"}}, with
+	// "retry_after" in whole seconds for backpressure responses.
+	JSONErrors ErrorFormatter = ErrorFormatterFunc(func(w http.ResponseWriter, r *http.Request, e HTTPError) {
+		type body struct {
+			Status     int    `json:"status"`
+			Message    string `json:"message"`
+			RetryAfter int    `json:"retry_after,omitempty"`
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.WriteHeader(e.Status)
+		json.NewEncoder(w).Encode(map[string]body{"error": {
+			Status:     e.Status,
+			Message:    e.Message(),
+			RetryAfter: retryAfterSeconds(e.RetryAfter),
+		}})
+	})
+
+	// HTMLErrors writes a minimal HTML page.
+	HTMLErrors ErrorFormatter = ErrorFormatterFunc(func(w http.ResponseWriter, r *http.Request, e HTTPError) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.WriteHeader(e.Status)
+		errorPage.Execute(w, e)
+	})
+)
+
+// errorPage renders HTMLErrors.
+var errorPage = template.Must(template.New("error").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{.Status}} {{.Message}}</title></head>
+<body><h1>{{.Status}}</h1><p>{{.Message}}</p>{{if .RetryAfter}}<p>Please try again in {{.RetryAfter}}.</p>{{end}}</body>
+</html>
+`))
+
+// NegotiatedErrors encodes errors with the codec matching the request's Accept
+// header among those set with WithCodecs, as ErrorBody.
+var NegotiatedErrors ErrorFormatter = ErrorFormatterFunc(func(w http.ResponseWriter, r *http.Request, e HTTPError) {
+	w.Header().Add("Vary", "Accept")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	Render(w, e.Status, negotiateCodec(codecsFor(w), r.Header.Get("Accept")), ErrorBody{
+		Status:     e.Status,
+		Message:    e.Message(),
+		RetryAfter: retryAfterSeconds(e.RetryAfter),
+	})
+})
+
+// ErrorBody is the error document written by NegotiatedErrors. Codecs encode it
+// like any other value, so it appears as {"status": 404, "message": "Not Found"}
+// in JSON and as an <error> element in XML.
+type ErrorBody struct {
+	XMLName    xml.Name `json:"-" xml:"error"`
+	Status     int      `json:"status" xml:"status"`
+	Message    string   `json:"message" xml:"message"`
+	RetryAfter int      `json:"retry_after,omitempty" xml:"retry_after,omitempty"`
+}
+
+// verboseProblemErrors is ProblemErrors with the request method and path as the
+// detail when none is given, used by the Development profile.
+var verboseProblemErrors ErrorFormatter = ErrorFormatterFunc(func(w http.ResponseWriter, r *http.Request, e HTTPError) {
+	if e.Detail == "" {
+		e.Detail = r.Method + " " + r.URL.Path
+	}
+	ProblemErrors.FormatError(w, r, e)
+})
+
+// WithErrorFormat sets the formatter for error responses produced by the router
+// and the package's middleware: 404 and 405 responses without a custom handler,
+// method and header rejections, authorization failures, panics and the like.
+// Calling WithErrorFormat inside a group sets it on the root Mux.
+// Returns the Mux instance for chaining.
+func (m *Mux) WithErrorFormat(f ErrorFormatter) *Mux {
+	m.root.errorFormat = f
+	return m
+}
+
+// WriteError writes an error response through the formatter configured with
+// WithErrorFormat on the router serving the request, or TextErrors if there is
+// none. Handlers can use it so their errors match the router's.
+func WriteError(w http.ResponseWriter, r *http.Request, status int, detail string) {
+	errorFormatFor(w).FormatError(w, r, HTTPError{Status: status, Detail: detail})
+}
+
+// WriteRetryAfter writes a backpressure response, typically 429 Too Many Requests
+// or 503 Service Unavailable, telling the client to retry after d. It sets the
+// Retry-After header in whole seconds, rounded up, and passes d to the error
+// formatter so it can be included in the body. A non-positive d omits the header.
+//
+// Producers compute d from their own state, such as the time until a rate limit
+// bucket refills (see RetryAfterRefill) or until a drain or maintenance window
+// ends (see RetryAfterUntil).
+func WriteRetryAfter(w http.ResponseWriter, r *http.Request, status int, d time.Duration, detail string) {
+	if d > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfterSeconds(d)))
+	} else {
+		d = 0
+	}
+	errorFormatFor(w).FormatError(w, r, HTTPError{Status: status, Detail: detail, RetryAfter: d})
+}
+
+// RetryAfterRefill returns how long a token bucket refilling at rate tokens per
+// second takes to go from have tokens to need tokens, or zero if it already has
+// enough or rate is not positive.
+func RetryAfterRefill(have, need, rate float64) time.Duration {
+	if have >= need || rate <= 0 {
+		return 0
+	}
+	return time.Duration((need - have) / rate * float64(time.Second))
+}
+
+// RetryAfterUntil returns the time remaining until t, such as the deadline of a
+// drain or the end of a maintenance window, or zero if t has passed.
+func RetryAfterUntil(t time.Time) time.Duration {
+	return max(time.Until(t), 0)
+}
+
+// defaultRetryAfter is the retry delay suggested by backpressure responses whose
+// cause has no known end, such as a concurrency limit being reached.
+const defaultRetryAfter = time.Second
+
+// errorFormatFor returns the formatter for the router serving w, or TextErrors.
+func errorFormatFor(w http.ResponseWriter) ErrorFormatter {
+	if rw := findResponseWriter(w); rw != nil && rw.errorFormat != nil {
+		return rw.errorFormat
+	}
+	return TextErrors
+}
+
+// retryAfterSeconds converts d to whole seconds, rounding up so clients never
+// retry early.
+func retryAfterSeconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
+
+// errorHandler returns a handler that writes status through f.
+func errorHandler(f ErrorFormatter, status int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.FormatError(w, r, HTTPError{Status: status})
+	})
+}
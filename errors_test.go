@@ -0,0 +1,140 @@
+package chain_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jpl-au/chain"
+)
+
+func TestWithErrorFormat(t *testing.T) {
+	mux := chain.New().WithErrorFormat(chain.JSONErrors).WithAllowedMethods()
+	mux.HandleFunc("GET /users", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("GET /conflict", func(w http.ResponseWriter, r *http.Request) {
+		chain.WriteError(w, r, http.StatusConflict, "already exists")
+	})
+
+	tests := []struct {
+		method, path string
+		status       int
+		message      string
+	}{
+		{"GET", "/missing", http.StatusNotFound, "Not Found"},
+		{"POST", "/users", http.StatusMethodNotAllowed, "Method Not Allowed"},
+		{"BREW", "/users", http.StatusNotImplemented, "Not Implemented"},
+		{"GET", "/conflict", http.StatusConflict, "already exists"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+		if rec.Code != tt.status {
+			t.Errorf("%s %s: expected status %d, got %d", tt.method, tt.path, tt.status, rec.Code)
+			continue
+		}
+		if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+			t.Errorf("%s %s: expected application/json, got '%s'", tt.method, tt.path, ct)
+		}
+		var body struct {
+			Error struct {
+				Status  int    `json:"status"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("%s %s: invalid body %q: %v", tt.method, tt.path, rec.Body.String(), err)
+		}
+		if body.Error.Status != tt.status || body.Error.Message != tt.message {
+			t.Errorf("%s %s: unexpected body %+v", tt.method, tt.path, body.Error)
+		}
+	}
+}
+
+func TestWithErrorFormatKeepsAllow(t *testing.T) {
+	mux := chain.New().WithErrorFormat(chain.ProblemErrors)
+	mux.HandleFunc("GET /users", func(w http.ResponseWriter, r *http.Request) {})
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("DELETE", "/users", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("Expected status 405, got %d", rec.Code)
+	}
+	if allow := rec.Header().Get("Allow"); !strings.Contains(allow, "GET") {
+		t.Errorf("Expected Allow header listing GET, got '%s'", allow)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/problem+json" {
+		t.Errorf("Expected problem+json, got '%s'", ct)
+	}
+}
+
+func TestWithErrorFormatPanic(t *testing.T) {
+	mux := chain.New().WithErrorFormat(chain.HTMLErrors)
+	mux.Use(chain.Recoverer(chain.RecoverOptions{}))
+	mux.HandleFunc("GET /panic", func(w http.ResponseWriter, r *http.Request) { panic("boom") })
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/panic", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("Expected status 500, got %d", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+		t.Errorf("Expected text/html, got '%s'", ct)
+	}
+	if !strings.Contains(rec.Body.String(), "Internal Server Error") {
+		t.Errorf("Expected status text in page, got %q", rec.Body.String())
+	}
+}
+
+func TestWriteErrorDefault(t *testing.T) {
+	rec := httptest.NewRecorder()
+	chain.WriteError(rec, httptest.NewRequest("GET", "/", nil), http.StatusTeapot, "")
+	if rec.Code != http.StatusTeapot {
+		t.Fatalf("Expected status 418, got %d", rec.Code)
+	}
+	if got := strings.TrimSpace(rec.Body.String()); got != "I'm a teapot" {
+		t.Errorf("Expected status text body, got %q", got)
+	}
+}
+
+func TestWriteRetryAfter(t *testing.T) {
+	mux := chain.New().WithErrorFormat(chain.JSONErrors)
+	mux.HandleFunc("GET /busy", func(w http.ResponseWriter, r *http.Request) {
+		chain.WriteRetryAfter(w, r, http.StatusTooManyRequests, 1500*time.Millisecond, "")
+	})
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/busy", nil))
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected status 429, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "2" {
+		t.Errorf("Expected Retry-After 2, got '%s'", got)
+	}
+	if !strings.Contains(rec.Body.String(), `"retry_after":2`) {
+		t.Errorf("Expected retry_after in body, got %q", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	chain.WriteRetryAfter(rec, httptest.NewRequest("GET", "/", nil), http.StatusServiceUnavailable, 0, "")
+	if got := rec.Header().Get("Retry-After"); got != "" {
+		t.Errorf("Expected no Retry-After, got '%s'", got)
+	}
+}
+
+func TestRetryAfterHelpers(t *testing.T) {
+	if got := chain.RetryAfterRefill(0.5, 1, 2); got != 250*time.Millisecond {
+		t.Errorf("Expected 250ms refill, got %v", got)
+	}
+	if got := chain.RetryAfterRefill(3, 1, 2); got != 0 {
+		t.Errorf("Expected no wait with tokens available, got %v", got)
+	}
+	if got := chain.RetryAfterUntil(time.Now().Add(-time.Second)); got != 0 {
+		t.Errorf("Expected zero for past deadline, got %v", got)
+	}
+	if got := chain.RetryAfterUntil(time.Now().Add(time.Minute)); got <= 59*time.Second {
+		t.Errorf("Expected about a minute, got %v", got)
+	}
+}
@@ -0,0 +1,140 @@
+package chain
+
+import (
+	"net/http"
+	"net/netip"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Event is a router lifecycle event delivered to subscribers registered with
+// Subscribe. Subscribers switch on the concrete type:
+//
+//	mux.Subscribe(func(e chain.Event) {
+//		switch e := e.(type) {
+//		case chain.RequestCompleted:
+//			metrics.Observe(e.Route, e.Duration)
+//		case chain.PanicRecovered:
+//			sentry.CaptureException(e.Err)
+//		}
+//	})
+type Event interface {
+	event()
+}
+
+// RouteRegistered is emitted when a route is added to the router.
+type RouteRegistered struct {
+	Route RouteInfo
+}
+
+// RequestCompleted is emitted once the response to a request has been written.
+type RequestCompleted struct {
+	Request  *http.Request
+	Route    string
+	Status   int
+	Size     int
+	Duration time.Duration
+}
+
+// PanicRecovered is emitted when Recoverer catches a panic.
+type PanicRecovered struct {
+	Request *http.Request
+	Err     error
+	Stack   []byte
+}
+
+// ConfigReloaded is emitted after the admin API's reload endpoint has run, with
+// the error returned by the reload function, if any.
+type ConfigReloaded struct {
+	Err error
+}
+
+// LoginLocked is emitted when a LoginGuard locks a key out after repeated
+// failed logins.
+type LoginLocked struct {
+	Request  *http.Request
+	Key      string
+	Failures int
+	Until    time.Time
+}
+
+// HoneypotHit is emitted when a client requests a route registered with
+// Honeypot. Addr is the client's address, or the zero Addr if r.RemoteAddr could
+// not be parsed.
+type HoneypotHit struct {
+	Request *http.Request
+	Addr    netip.Addr
+}
+
+func (RouteRegistered) event()  {}
+func (RequestCompleted) event() {}
+func (PanicRecovered) event()   {}
+func (ConfigReloaded) event()   {}
+func (LoginLocked) event()      {}
+func (HoneypotHit) event()      {}
+
+// eventBus delivers events to subscribers. Emitting reads the subscriber list
+// without locking, so that requests do not contend when nobody subscribes.
+type eventBus struct {
+	mu   sync.Mutex
+	subs atomic.Pointer[[]*func(Event)]
+}
+
+// Subscribe registers fn to receive every event emitted by the router from now
+// on, and returns a function that unsubscribes it. Events are delivered
+// synchronously on the goroutine that caused them, for example the request's own
+// goroutine for RequestCompleted, so fn must be fast and safe for concurrent use;
+// slow integrations should hand events to a queue. Calling Subscribe inside a group
+// subscribes to the root Mux.
+func (m *Mux) Subscribe(fn func(Event)) (unsubscribe func()) {
+	if fn == nil {
+		panic("chain: nil function passed to Subscribe")
+	}
+	bus := &m.root.events
+	sub := &fn
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+	var subs []*func(Event)
+	if p := bus.subs.Load(); p != nil {
+		subs = slices.Clone(*p)
+	}
+	subs = append(subs, sub)
+	bus.subs.Store(&subs)
+
+	return func() {
+		bus.mu.Lock()
+		defer bus.mu.Unlock()
+		p := bus.subs.Load()
+		if p == nil {
+			return
+		}
+		subs := slices.DeleteFunc(slices.Clone(*p), func(s *func(Event)) bool { return s == sub })
+		bus.subs.Store(&subs)
+	}
+}
+
+// active reports whether anyone is subscribed.
+func (b *eventBus) active() bool {
+	p := b.subs.Load()
+	return p != nil && len(*p) > 0
+}
+
+// emit delivers e to every subscriber.
+func (b *eventBus) emit(e Event) {
+	p := b.subs.Load()
+	if p == nil {
+		return
+	}
+	for _, fn := range *p {
+		(*fn)(e)
+	}
+}
+
+// emitEvent delivers e through the bus of the router serving w, if any.
+func emitEvent(w http.ResponseWriter, e Event) {
+	if rw := findResponseWriter(w); rw != nil && rw.events != nil {
+		rw.events.emit(e)
+	}
+}
@@ -0,0 +1,79 @@
+package chain_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/jpl-au/chain"
+)
+
+func TestSubscribe(t *testing.T) {
+	var mu sync.Mutex
+	var events []chain.Event
+	mux := chain.New()
+	unsubscribe := mux.Subscribe(func(e chain.Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, e)
+	})
+
+	mux.Use(chain.Recoverer(chain.RecoverOptions{}))
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+	mux.HandleFunc("GET /panic", func(w http.ResponseWriter, r *http.Request) { panic("boom") })
+
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/1", nil))
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/panic", nil))
+
+	var registered, completed, panics int
+	for _, e := range events {
+		switch e := e.(type) {
+		case chain.RouteRegistered:
+			registered++
+		case chain.RequestCompleted:
+			completed++
+			if e.Route == "GET /users/{id}" && (e.Status != http.StatusOK || e.Size != 2 || e.Request.URL.Path != "/users/1") {
+				t.Errorf("Unexpected completion %+v", e)
+			}
+			if e.Route == "GET /panic" && e.Status != http.StatusInternalServerError {
+				t.Errorf("Expected panic request completed with 500, got %d", e.Status)
+			}
+		case chain.PanicRecovered:
+			panics++
+			if e.Err == nil || len(e.Stack) == 0 {
+				t.Errorf("Expected panic details, got %+v", e)
+			}
+		}
+	}
+	if registered != 2 || completed != 2 || panics != 1 {
+		t.Errorf("Expected 2 registered, 2 completed and 1 panic, got %d, %d and %d", registered, completed, panics)
+	}
+
+	unsubscribe()
+	n := len(events)
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/2", nil))
+	if len(events) != n {
+		t.Error("Expected no events after unsubscribing")
+	}
+}
+
+func TestSubscribeConfigReloaded(t *testing.T) {
+	mux := chain.New()
+	reloaded := false
+	mux.Subscribe(func(e chain.Event) {
+		if _, ok := e.(chain.ConfigReloaded); ok {
+			reloaded = true
+		}
+	})
+	mux.Admin("/admin", chain.AdminOptions{
+		Auth:   func(next http.Handler) http.Handler { return next },
+		Reload: func(r *http.Request) error { return nil },
+	})
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/admin/reload", nil))
+	if !reloaded {
+		t.Error("Expected ConfigReloaded event")
+	}
+}
@@ -0,0 +1,35 @@
+package chain
+
+import (
+	"net/http"
+	"strings"
+)
+
+// ExpectContinue returns middleware that inspects requests carrying
+// "Expect: 100-continue" before the client sends the body. check receives the
+// request with its headers and returns 0 to accept it, in which case 100 Continue
+// is sent and the handler runs, or an error status such as 401 or 413 to reject it.
+// Rejected requests are answered without reading the body, so a client uploading a
+// large file learns of the failure before sending it. Requests without the Expect
+// header pass through unchecked.
+func ExpectContinue(check func(r *http.Request) int) func(http.Handler) http.Handler {
+	if check == nil {
+		panic("chain: nil function passed to ExpectContinue")
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !strings.EqualFold(r.Header.Get("Expect"), "100-continue") {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if status := check(r); status != 0 {
+				w.Header().Set("Connection", "close")
+				WriteError(w, r, status, "")
+				return
+			}
+			w.WriteHeader(http.StatusContinue)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package chain_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jpl-au/chain"
+)
+
+func TestExpectContinue(t *testing.T) {
+	mux := chain.New().Use(chain.ExpectContinue(func(r *http.Request) int {
+		if r.ContentLength > 10 {
+			return http.StatusRequestEntityTooLarge
+		}
+		return 0
+	}))
+	mux.HandleFunc("POST /upload", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if rw, ok := w.(chain.ResponseWriter); ok && rw.Written() {
+			t.Error("Expected 100 Continue not to mark the response as written")
+		}
+		w.Write(body)
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	client := &http.Client{Transport: &http.Transport{ExpectContinueTimeout: 5 * time.Second}}
+
+	tests := []struct {
+		body   string
+		status int
+	}{
+		{"small", http.StatusOK},
+		{strings.Repeat("x", 100), http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest("POST", server.URL+"/upload", strings.NewReader(tt.body))
+		req.Header.Set("Expect", "100-continue")
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != tt.status {
+			t.Errorf("Expected status %d, got %d", tt.status, resp.StatusCode)
+		}
+		if tt.status == http.StatusOK && string(body) != tt.body {
+			t.Errorf("Expected echoed body '%s', got '%s'", tt.body, body)
+		}
+	}
+}
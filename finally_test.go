@@ -0,0 +1,81 @@
+package chain_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/jpl-au/chain"
+)
+
+func TestFinallyRunsAfterResponse(t *testing.T) {
+	var order []string
+	var status, size int
+
+	mux := chain.New()
+	mux.Finally(func(rw chain.ResponseWriter, r *http.Request) {
+		order = append(order, "first")
+		status = rw.Status()
+		size = rw.Size()
+	})
+	mux.Group(func(g *chain.Mux) {
+		g.Finally(func(rw chain.ResponseWriter, r *http.Request) {
+			order = append(order, "second")
+		})
+	})
+	mux.HandleFunc("GET /", func(w http.ResponseWriter, r *http.Request) {
+		order = append(order, "handler")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("done"))
+	})
+
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	if !reflect.DeepEqual(order, []string{"handler", "first", "second"}) {
+		t.Errorf("Expected order [handler first second], got %v", order)
+	}
+	if status != http.StatusCreated || size != 4 {
+		t.Errorf("Expected finalizer to see 201/4, got %d/%d", status, size)
+	}
+}
+
+func TestFinallyRunsOnPanic(t *testing.T) {
+	called := false
+	mux := chain.New().Finally(func(rw chain.ResponseWriter, r *http.Request) {
+		called = true
+	})
+	mux.HandleFunc("GET /", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("Expected panic to propagate")
+			}
+		}()
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	}()
+
+	if !called {
+		t.Error("Finalizer did not run after panic")
+	}
+}
+
+func TestFinallyContinuesAfterPanickingHook(t *testing.T) {
+	called := false
+	mux := chain.New().
+		Finally(func(rw chain.ResponseWriter, r *http.Request) { panic("hook") }).
+		Finally(func(rw chain.ResponseWriter, r *http.Request) { called = true })
+	mux.HandleFunc("GET /", func(w http.ResponseWriter, r *http.Request) {})
+
+	func() {
+		defer func() { recover() }()
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	}()
+
+	if !called {
+		t.Error("Second finalizer did not run after the first panicked")
+	}
+}
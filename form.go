@@ -0,0 +1,236 @@
+package chain
+
+import (
+	"encoding"
+	"errors"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxFormIndex bounds slice indices in form keys, so that a key such as
+// "items[999999999]" cannot allocate a huge slice.
+const maxFormIndex = 1000
+
+// timeType is the reflect.Type of time.Time, which is decoded with a layout
+// rather than as a struct.
+var timeType = reflect.TypeOf(time.Time{})
+
+// formSegment is one step of a form key path: a field name or a slice index.
+type formSegment struct {
+	name  string
+	index int
+}
+
+// decodeForm decodes form values into v, which must be a pointer to a struct.
+// Keys address nested fields with dots and slice elements with brackets, as in
+// "items[0].name"; repeated keys fill slices of scalars. Fields are matched by
+// their form tag, or their name ignoring case, and unknown keys are ignored.
+// time.Time fields are parsed with the layout in their layout tag, defaulting to
+// RFC 3339. Values that cannot be converted are reported as ValidationErrors
+// with the "type" rule, keyed by the full path.
+func decodeForm(form url.Values, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return errors.New("chain: non-pointer passed to Bind")
+	}
+
+	// Sorted keys give a stable error order
+	keys := make([]string, 0, len(form))
+	for k := range form {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var errs ValidationErrors
+	for _, key := range keys {
+		segs, ok := parseFormKey(key)
+		if !ok {
+			errs.Add(key, "invalid", "", "malformed field name")
+			continue
+		}
+		if msg := setFormValue(rv, segs, form[key], ""); msg != "" {
+			errs.Add(strings.TrimSuffix(key, "[]"), "type", "", msg)
+		}
+	}
+	return errs.Err()
+}
+
+// parseFormKey splits a key such as "items[0].name" into segments. A trailing
+// "[]" is dropped, so "tags[]" addresses the tags field.
+func parseFormKey(key string) ([]formSegment, bool) {
+	key = strings.TrimSuffix(key, "[]")
+	var segs []formSegment
+	for key != "" {
+		switch {
+		case key[0] == '[':
+			end := strings.IndexByte(key, ']')
+			if end < 0 {
+				return nil, false
+			}
+			i, err := strconv.Atoi(key[1:end])
+			if err != nil || i < 0 {
+				return nil, false
+			}
+			segs = append(segs, formSegment{index: i})
+			key = key[end+1:]
+		case key[0] == '.':
+			if len(segs) == 0 {
+				return nil, false
+			}
+			key = key[1:]
+		default:
+			end := strings.IndexAny(key, ".[")
+			if end < 0 {
+				end = len(key)
+			}
+			segs = append(segs, formSegment{name: key[:end], index: -1})
+			key = key[end:]
+		}
+	}
+	return segs, len(segs) > 0 && segs[0].name != ""
+}
+
+// setFormValue assigns values to the element of v addressed by segs, allocating
+// pointers and growing slices on the way. It returns a message describing why the
+// value could not be set, or "" on success. Unknown fields are skipped.
+func setFormValue(v reflect.Value, segs []formSegment, values []string, layout string) string {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	if len(segs) == 0 {
+		return setFormScalars(v, values, layout)
+	}
+
+	seg := segs[0]
+	if seg.name != "" {
+		if v.Kind() != reflect.Struct || v.Type() == timeType {
+			return "unexpected nested field"
+		}
+		field, tag, ok := formField(v, seg.name)
+		if !ok {
+			return ""
+		}
+		return setFormValue(field, segs[1:], values, tag)
+	}
+
+	if v.Kind() != reflect.Slice {
+		return "unexpected index"
+	}
+	if seg.index >= maxFormIndex {
+		return "index too large"
+	}
+	if seg.index >= v.Len() {
+		grown := reflect.MakeSlice(v.Type(), seg.index+1, seg.index+1)
+		reflect.Copy(grown, v)
+		v.Set(grown)
+	}
+	return setFormValue(v.Index(seg.index), segs[1:], values, layout)
+}
+
+// formField returns the exported field of struct v named name, by form tag or by
+// field name ignoring case, and its layout tag.
+func formField(v reflect.Value, name string) (reflect.Value, string, bool) {
+	t := v.Type()
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		tag, _, _ := strings.Cut(f.Tag.Get("form"), ",")
+		if tag == "-" {
+			continue
+		}
+		if tag == name || tag == "" && strings.EqualFold(f.Name, name) {
+			return v.Field(i), f.Tag.Get("layout"), true
+		}
+	}
+	return reflect.Value{}, "", false
+}
+
+// setFormScalars assigns values to v: every value for a slice of scalars, the
+// first otherwise.
+func setFormScalars(v reflect.Value, values []string, layout string) string {
+	if v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8 {
+		s := reflect.MakeSlice(v.Type(), len(values), len(values))
+		for i, value := range values {
+			if msg := setFormScalar(s.Index(i), value, layout); msg != "" {
+				return msg
+			}
+		}
+		v.Set(s)
+		return ""
+	}
+	if len(values) == 0 {
+		return ""
+	}
+	return setFormScalar(v, values[0], layout)
+}
+
+// setFormScalar converts one value into v.
+func setFormScalar(v reflect.Value, value, layout string) string {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	if v.Type() == timeType {
+		if layout == "" {
+			layout = time.RFC3339
+		}
+		t, err := time.Parse(layout, value)
+		if err != nil {
+			return "must be a time in the format " + layout
+		}
+		v.Set(reflect.ValueOf(t))
+		return ""
+	}
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		if err := u.UnmarshalText([]byte(value)); err != nil {
+			return err.Error()
+		}
+		return ""
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if value == "on" {
+			b, err = true, nil
+		}
+		if err != nil {
+			return "must be true or false"
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, v.Type().Bits())
+		if err != nil {
+			return "must be an integer"
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, v.Type().Bits())
+		if err != nil {
+			return "must be a non-negative integer"
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, v.Type().Bits())
+		if err != nil {
+			return "must be a number"
+		}
+		v.SetFloat(f)
+	default:
+		return "unsupported field type " + v.Type().String()
+	}
+	return ""
+}
@@ -0,0 +1,120 @@
+package chain_test
+
+import (
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jpl-au/chain"
+)
+
+type formItem struct {
+	Name string `form:"name"`
+	Qty  *int   `form:"qty"`
+}
+
+type formOrder struct {
+	Customer string    `form:"customer"`
+	Due      time.Time `form:"due" layout:"2006-01-02"`
+	Created  time.Time
+	Express  bool
+	Tags     []string `form:"tags"`
+	Items    []formItem
+	Address  *struct {
+		City string
+	}
+	Secret string `form:"-"`
+}
+
+func TestBindForm(t *testing.T) {
+	form := url.Values{
+		"customer":         {"Ada"},
+		"due":              {"2024-05-01"},
+		"created":          {"2024-04-01T10:00:00Z"},
+		"express":          {"on"},
+		"tags[]":           {"gift", "fragile"},
+		"items[1].name":    {"cake"},
+		"items[0].name":    {"tea"},
+		"items[0].qty":     {"2"},
+		"address.city":     {"London"},
+		"Secret":           {"ignored"},
+		"unknown.field[3]": {"ignored"},
+	}
+	req := httptest.NewRequest("POST", "/orders", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var got formOrder
+	if err := chain.Bind(httptest.NewRecorder(), req, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Customer != "Ada" || !got.Express || got.Secret != "" {
+		t.Errorf("Unexpected scalars %+v", got)
+	}
+	if !got.Due.Equal(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)) || got.Created.Hour() != 10 {
+		t.Errorf("Unexpected times %v %v", got.Due, got.Created)
+	}
+	if strings.Join(got.Tags, ",") != "gift,fragile" {
+		t.Errorf("Unexpected tags %v", got.Tags)
+	}
+	if len(got.Items) != 2 || got.Items[0].Name != "tea" || *got.Items[0].Qty != 2 || got.Items[1].Name != "cake" || got.Items[1].Qty != nil {
+		t.Errorf("Unexpected items %+v", got.Items)
+	}
+	if got.Address == nil || got.Address.City != "London" {
+		t.Errorf("Unexpected address %+v", got.Address)
+	}
+}
+
+func TestBindMultipartForm(t *testing.T) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	mw.WriteField("customer", "Grace")
+	mw.WriteField("items[0].name", "scone")
+	mw.Close()
+	req := httptest.NewRequest("POST", "/orders", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+
+	var got formOrder
+	if err := chain.Bind(httptest.NewRecorder(), req, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Customer != "Grace" || len(got.Items) != 1 || got.Items[0].Name != "scone" {
+		t.Errorf("Unexpected order %+v", got)
+	}
+}
+
+func TestBindFormErrors(t *testing.T) {
+	form := url.Values{
+		"due":             {"tomorrow"},
+		"items[0].qty":    {"lots"},
+		"items[5000].qty": {"1"},
+		"customer.name":   {"x"},
+	}
+	req := httptest.NewRequest("POST", "/orders", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var got formOrder
+	err := chain.Bind(httptest.NewRecorder(), req, &got)
+	var errs chain.ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("Expected ValidationErrors, got %v", err)
+	}
+	want := map[string]string{
+		"customer.name":   "unexpected nested field",
+		"due":             "must be a time in the format 2006-01-02",
+		"items[0].qty":    "must be an integer",
+		"items[5000].qty": "index too large",
+	}
+	if len(errs) != len(want) {
+		t.Errorf("Expected %d errors, got %v", len(want), errs)
+	}
+	for _, fe := range errs {
+		if want[fe.Field] != fe.Message || fe.Rule != "type" {
+			t.Errorf("Unexpected error %+v", fe)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package chain
+
+import (
+	"net/http"
+	"strings"
+)
+
+// WithGRPC routes gRPC requests (HTTP/2 with a Content-Type of application/grpc)
+// to handler, typically a *grpc.Server, so that gRPC and regular routes can share a
+// listener. gRPC requests bypass the router entirely: pre-routing middleware,
+// Finally hooks and the response wrapper are not applied, leaving gRPC streams
+// untouched. Serving gRPC over cleartext additionally requires the server to accept
+// HTTP/2 without TLS (h2c). Returns the Mux instance for chaining.
+func (m *Mux) WithGRPC(handler http.Handler) *Mux {
+	m.root.grpc = handler
+	return m
+}
+
+// WithGRPCWeb routes gRPC-Web requests (Content-Type application/grpc-web or
+// application/grpc-web-text, over any HTTP version) to handler, typically a
+// gRPC-Web proxy wrapping the gRPC server. Like WithGRPC, these requests bypass
+// the router. Returns the Mux instance for chaining.
+func (m *Mux) WithGRPCWeb(handler http.Handler) *Mux {
+	m.root.grpcWeb = handler
+	return m
+}
+
+// grpcHandler returns the handler for r if it is a gRPC or gRPC-Web request that
+// has a configured handler, or nil otherwise.
+func (m *Mux) grpcHandler(r *http.Request) http.Handler {
+	if m.grpc == nil && m.grpcWeb == nil {
+		return nil
+	}
+	ct := r.Header.Get("Content-Type")
+	if !strings.HasPrefix(ct, "application/grpc") {
+		return nil
+	}
+	if strings.HasPrefix(ct, "application/grpc-web") {
+		return m.grpcWeb
+	}
+	if r.ProtoMajor == 2 {
+		return m.grpc
+	}
+	return nil
+}
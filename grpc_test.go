@@ -0,0 +1,59 @@
+package chain_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jpl-au/chain"
+)
+
+func TestWithGRPC(t *testing.T) {
+	var grpcWriter, webWriter http.ResponseWriter
+	grpcServer := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		grpcWriter = w
+		w.Write([]byte("grpc"))
+	})
+	webProxy := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		webWriter = w
+		w.Write([]byte("grpc-web"))
+	})
+
+	mux := chain.New().WithGRPC(grpcServer).WithGRPCWeb(webProxy)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("http"))
+	})
+
+	tests := []struct {
+		name        string
+		proto       int
+		contentType string
+		body        string
+	}{
+		{"grpc over h2", 2, "application/grpc+proto", "grpc"},
+		{"grpc over http1", 1, "application/grpc", "http"},
+		{"grpc-web", 1, "application/grpc-web+proto", "grpc-web"},
+		{"plain h2", 2, "application/json", "http"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/pkg.Service/Method", nil)
+			req.ProtoMajor = tt.proto
+			req.Header.Set("Content-Type", tt.contentType)
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+			if rec.Body.String() != tt.body {
+				t.Errorf("Expected body '%s', got '%s'", tt.body, rec.Body.String())
+			}
+		})
+	}
+
+	// gRPC handlers must receive the original writer, not the chain wrapper
+	if _, ok := grpcWriter.(chain.ResponseWriter); ok {
+		t.Error("gRPC handler received a wrapped ResponseWriter")
+	}
+	if _, ok := webWriter.(chain.ResponseWriter); ok {
+		t.Error("gRPC-Web handler received a wrapped ResponseWriter")
+	}
+}
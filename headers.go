@@ -0,0 +1,114 @@
+package chain
+
+import (
+	"net/http"
+)
+
+// HeaderRules configures the ValidateHeaders middleware. The zero value applies
+// safe defaults.
+type HeaderRules struct {
+	// MaxValueLength is the maximum length of a single header value in bytes.
+	// Defaults to 8192.
+	MaxValueLength int
+	// Unique lists headers that may appear at most once.
+	// Defaults to Content-Length, Content-Type and Authorization. Host need not
+	// be listed: net/http moves it out of the header map into Request.Host and
+	// rejects requests that repeat it before they reach the handler.
+	Unique []string
+	// AllowTransferEncodingWithContentLength permits requests that carry both
+	// Transfer-Encoding and Content-Length. Such requests are a classic request
+	// smuggling vector and are rejected by default.
+	AllowTransferEncodingWithContentLength bool
+}
+
+// defaultUniqueHeaders are the headers that may appear only once unless
+// HeaderRules.Unique overrides them.
+var defaultUniqueHeaders = []string{"Content-Length", "Content-Type", "Authorization"}
+
+// ValidateHeaders returns middleware that rejects requests with malformed or
+// conflicting headers with 400 Bad Request. It checks for repeated singleton
+// headers (such as multiple Content-Length), Transfer-Encoding combined with
+// Content-Length, oversized values, and invalid characters in names or values.
+// This adds a defensive layer when the router sits behind proxies that may
+// interpret ambiguous requests differently.
+func ValidateHeaders(rules HeaderRules) func(http.Handler) http.Handler {
+	if rules.MaxValueLength <= 0 {
+		rules.MaxValueLength = 8192
+	}
+	names := rules.Unique
+	if names == nil {
+		names = defaultUniqueHeaders
+	}
+	unique := make([]string, len(names))
+	for i, name := range names {
+		unique[i] = http.CanonicalHeaderKey(name)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !rules.valid(r, unique) {
+				WriteError(w, r, http.StatusBadRequest, "")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// valid reports whether the request headers satisfy the rules.
+func (rules HeaderRules) valid(r *http.Request, unique []string) bool {
+	for _, name := range unique {
+		if len(r.Header[name]) > 1 {
+			return false
+		}
+	}
+
+	// net/http strips Content-Length from chunked requests, so the parsed
+	// TransferEncoding field is checked alongside the raw header
+	chunked := len(r.TransferEncoding) > 0 || len(r.Header["Transfer-Encoding"]) > 0
+	if chunked && len(r.Header["Content-Length"]) > 0 && !rules.AllowTransferEncodingWithContentLength {
+		return false
+	}
+
+	for name, values := range r.Header {
+		if !validHeaderName(name) {
+			return false
+		}
+		for _, v := range values {
+			if len(v) > rules.MaxValueLength || !validHeaderValue(v) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// validHeaderName reports whether name is a non-empty RFC 9110 token.
+func validHeaderName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '!' || c == '#' || c == '$' || c == '%' || c == '&' || c == '\'' || c == '*' ||
+			c == '+' || c == '-' || c == '.' || c == '^' || c == '_' || c == '`' || c == '|' || c == '~':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// validHeaderValue reports whether v contains no control characters other than
+// horizontal tab.
+func validHeaderValue(v string) bool {
+	for i := 0; i < len(v); i++ {
+		c := v[i]
+		if (c < ' ' && c != '\t') || c == 0x7f {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,62 @@
+package chain_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jpl-au/chain"
+)
+
+func TestValidateHeaders(t *testing.T) {
+	tests := []struct {
+		name   string
+		rules  chain.HeaderRules
+		setup  func(r *http.Request)
+		status int
+	}{
+		{"clean request", chain.HeaderRules{}, func(r *http.Request) {
+			r.Header.Set("Content-Type", "text/plain")
+		}, http.StatusOK},
+		{"duplicate content-length", chain.HeaderRules{}, func(r *http.Request) {
+			r.Header["Content-Length"] = []string{"5", "6"}
+		}, http.StatusBadRequest},
+		{"te and cl", chain.HeaderRules{}, func(r *http.Request) {
+			r.Header.Set("Content-Length", "5")
+			r.TransferEncoding = []string{"chunked"}
+		}, http.StatusBadRequest},
+		{"te and cl allowed", chain.HeaderRules{AllowTransferEncodingWithContentLength: true}, func(r *http.Request) {
+			r.Header.Set("Content-Length", "5")
+			r.TransferEncoding = []string{"chunked"}
+		}, http.StatusOK},
+		{"oversized value", chain.HeaderRules{MaxValueLength: 10}, func(r *http.Request) {
+			r.Header.Set("X-Big", strings.Repeat("a", 11))
+		}, http.StatusBadRequest},
+		{"control character", chain.HeaderRules{}, func(r *http.Request) {
+			r.Header.Set("X-Bad", "a\x00b")
+		}, http.StatusBadRequest},
+		{"invalid name", chain.HeaderRules{}, func(r *http.Request) {
+			r.Header["Bad Name"] = []string{"x"}
+		}, http.StatusBadRequest},
+		{"custom unique", chain.HeaderRules{Unique: []string{"x-tenant"}}, func(r *http.Request) {
+			r.Header.Add("X-Tenant", "a")
+			r.Header.Add("X-Tenant", "b")
+		}, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := chain.New().Use(chain.ValidateHeaders(tt.rules))
+			mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {})
+
+			req := httptest.NewRequest("POST", "/", nil)
+			tt.setup(req)
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+			if rec.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, rec.Code)
+			}
+		})
+	}
+}
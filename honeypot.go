@@ -0,0 +1,130 @@
+package chain
+
+import (
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// HoneypotOptions configures the routes registered with Mux.Honeypot.
+type HoneypotOptions struct {
+	// Decoy serves honeypot requests. Defaults to a 404 Not Found through the
+	// error format, so scanners learn nothing.
+	Decoy http.Handler
+	// Tarpit, if set, holds each honeypot request open for this long, sending a
+	// byte of the response each second, to slow scanners down. The decoy is not
+	// served.
+	Tarpit time.Duration
+	// MaxTarpits caps the requests held in the tarpit at once, so that a
+	// scanner cannot tie up the server's connections. Requests beyond it are
+	// answered immediately, as if Tarpit were not set. Defaults to 100.
+	MaxTarpits int
+	// Denylist, if set, receives the address of every client requesting a
+	// honeypot, blocking it from the rest of the application wherever the
+	// Denylist's middleware is installed.
+	Denylist *Denylist
+	// Ban is how long offending addresses are blocked. Defaults to 1 hour.
+	Ban time.Duration
+
+	tarpits *atomic.Int64 // requests currently held in the tarpit
+}
+
+// tarpitInterval is the time between bytes sent by a tarpit.
+const tarpitInterval = time.Second
+
+// WithHoneypot configures the routes registered with Honeypot. It is shared by
+// the whole router and may be called before or after the routes are registered.
+// Returns the Mux instance for chaining.
+func (m *Mux) WithHoneypot(opts HoneypotOptions) *Mux {
+	if opts.Ban <= 0 {
+		opts.Ban = time.Hour
+	}
+	if opts.MaxTarpits <= 0 {
+		opts.MaxTarpits = 100
+	}
+	opts.tarpits = new(atomic.Int64)
+	m.root.honeypot = &opts
+	return m
+}
+
+// Honeypot registers decoy routes at paths that the application does not serve
+// but that scanners probe, such as "/wp-admin" or "/.env", including everything
+// beneath them. Requests to them emit a HoneypotHit event, as an early warning
+// of scanning, and are handled as configured with WithHoneypot. Returns the Mux
+// instance for method chaining.
+//
+//	blocked := chain.NewDenylist()
+//	mux.UsePre(blocked.Middleware())
+//	mux.WithHoneypot(chain.HoneypotOptions{Denylist: blocked, Tarpit: time.Minute}).
+//		Honeypot("/wp-admin", "/phpmyadmin", "/.env")
+func (m *Mux) Honeypot(paths ...string) *Mux {
+	root := m.root
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The options are looked up per request so WithHoneypot may be called
+		// after the routes are registered
+		opts := HoneypotOptions{Ban: time.Hour}
+		if root.honeypot != nil {
+			opts = *root.honeypot
+		}
+		addr, _ := remoteAddr(r)
+		if opts.Denylist != nil && addr.IsValid() {
+			opts.Denylist.Add(addr, opts.Ban)
+		}
+		emitEvent(w, HoneypotHit{Request: r, Addr: addr})
+
+		if opts.Tarpit > 0 {
+			held := opts.tarpits.Add(1)
+			defer opts.tarpits.Add(-1)
+			if held <= int64(opts.MaxTarpits) {
+				tarpit(w, r, opts.Tarpit)
+				return
+			}
+		}
+		switch {
+		case opts.Decoy != nil:
+			opts.Decoy.ServeHTTP(w, r)
+		default:
+			WriteError(w, r, http.StatusNotFound, "")
+		}
+	})
+
+	for _, path := range paths {
+		if !strings.HasPrefix(path, "/") {
+			panic("chain: invalid path " + path + " passed to Honeypot")
+		}
+		m.Handle(path, handler)
+		if !strings.HasSuffix(path, "/") {
+			m.Handle(path+"/", handler)
+		}
+	}
+	return m
+}
+
+// tarpit drips a response to w, one byte per tarpitInterval, until d has passed
+// or the client goes away.
+func tarpit(w http.ResponseWriter, r *http.Request, d time.Duration) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	rc := http.NewResponseController(w)
+	interval := min(tarpitInterval, d)
+	deadline := time.NewTimer(d)
+	defer deadline.Stop()
+	tick := time.NewTicker(interval)
+	defer tick.Stop()
+	for {
+		if _, err := w.Write([]byte(" ")); err != nil {
+			return
+		}
+		if rc.Flush() != nil {
+			return
+		}
+		select {
+		case <-tick.C:
+		case <-deadline.C:
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
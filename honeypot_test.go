@@ -0,0 +1,124 @@
+package chain_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/jpl-au/chain"
+)
+
+func TestHoneypot(t *testing.T) {
+	blocked := chain.NewDenylist()
+	mux := chain.New()
+	mux.UsePre(blocked.Middleware())
+	mux.Honeypot("/wp-admin", "/.env")
+	mux.WithHoneypot(chain.HoneypotOptions{Denylist: blocked, Decoy: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<form>Log In</form>"))
+	})})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {})
+
+	var hits []chain.HoneypotHit
+	mux.Subscribe(func(e chain.Event) {
+		if e, ok := e.(chain.HoneypotHit); ok {
+			hits = append(hits, e)
+		}
+	})
+
+	get := func(path, ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req.RemoteAddr = ip + ":1234"
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := get("/", "192.0.2.1"); rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200 before the honeypot, got %d", rec.Code)
+	}
+	if rec := get("/wp-admin/setup.php", "192.0.2.1"); rec.Body.String() != "<form>Log In</form>" {
+		t.Errorf("Expected the decoy, got %d '%s'", rec.Code, rec.Body.String())
+	}
+	if rec := get("/", "192.0.2.1"); rec.Code != http.StatusForbidden {
+		t.Errorf("Expected the client to be blocked, got %d", rec.Code)
+	}
+	if rec := get("/", "192.0.2.2"); rec.Code != http.StatusOK {
+		t.Errorf("Expected other clients to be unaffected, got %d", rec.Code)
+	}
+	if len(hits) != 1 || hits[0].Addr != netip.MustParseAddr("192.0.2.1") || hits[0].Request.URL.Path != "/wp-admin/setup.php" {
+		t.Errorf("Expected one HoneypotHit for 192.0.2.1, got %+v", hits)
+	}
+}
+
+func TestHoneypotDefaults(t *testing.T) {
+	mux := chain.New().Honeypot("/phpmyadmin")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/phpmyadmin", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404, got %d", rec.Code)
+	}
+}
+
+func TestHoneypotTarpit(t *testing.T) {
+	mux := chain.New().WithHoneypot(chain.HoneypotOptions{Tarpit: 30 * time.Millisecond}).Honeypot("/.git/config")
+
+	start := time.Now()
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/.git/config", nil))
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Errorf("Expected the request to be held for the tarpit, took %v", elapsed)
+	}
+	if rec.Code != http.StatusOK || rec.Body.Len() == 0 || !rec.Flushed {
+		t.Errorf("Expected a dripped response, got %d with %d bytes", rec.Code, rec.Body.Len())
+	}
+}
+
+func TestHoneypotTarpitLimit(t *testing.T) {
+	mux := chain.New().WithHoneypot(chain.HoneypotOptions{Tarpit: time.Minute, MaxTarpits: 1}).Honeypot("/.env")
+
+	// Of two concurrent requests one is held, and the other is answered at once
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan *httptest.ResponseRecorder, 2)
+	for range 2 {
+		go func() {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest("GET", "/.env", nil).WithContext(ctx))
+			done <- rec
+		}()
+	}
+
+	select {
+	case rec := <-done:
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("Expected 404 beyond the tarpit limit, got %d", rec.Code)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected a request beyond the tarpit limit answered immediately")
+	}
+	cancel()
+	if rec := <-done; rec.Code != http.StatusOK {
+		t.Errorf("Expected the held request to be tarpitted, got %d", rec.Code)
+	}
+}
+
+func TestDenylistExpiry(t *testing.T) {
+	d := chain.NewDenylist()
+	addr := netip.MustParseAddr("::ffff:192.0.2.1")
+	d.Add(addr, 10*time.Millisecond)
+	if !d.Contains(netip.MustParseAddr("192.0.2.1")) {
+		t.Fatal("Expected the mapped address to be blocked")
+	}
+	time.Sleep(15 * time.Millisecond)
+	if d.Contains(addr) {
+		t.Error("Expected the block to expire")
+	}
+	d.Add(addr, time.Hour)
+	d.Remove(addr)
+	if d.Contains(addr) {
+		t.Error("Expected Remove to lift the block")
+	}
+}
@@ -0,0 +1,72 @@
+package chain
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ValidateHost returns middleware that accepts only requests whose Host header
+// names one of hosts, defending against DNS rebinding and Host header cache
+// poisoning. Entries match case-insensitively and ignore the port unless they
+// include one: "example.com" matches "example.com:8443" but "example.com:443" does
+// not. A leading "*." matches any subdomain, so "*.example.com" matches
+// "api.example.com" but not "example.com" itself.
+//
+// Requests without a Host or with a malformed one receive 400 Bad Request and
+// requests for other hosts 421 Misdirected Request. Register it with UsePre or
+// Wrap so it runs before routing, including for requests that match no route:
+//
+//	mux.Wrap(chain.ValidateHost("example.com", "*.example.com"))
+func ValidateHost(hosts ...string) func(http.Handler) http.Handler {
+	if len(hosts) == 0 {
+		panic("chain: no hosts passed to ValidateHost")
+	}
+	allowed := make([]string, len(hosts))
+	for i, h := range hosts {
+		allowed[i] = normalizeHost(h)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			host := normalizeHost(r.Host)
+			hostname, _, err := net.SplitHostPort(host)
+			if err != nil {
+				hostname = host
+			}
+			if hostname == "" || strings.ContainsAny(host, "/\\@ ") {
+				WriteError(w, r, http.StatusBadRequest, "")
+				return
+			}
+			for _, pattern := range allowed {
+				if hostMatches(pattern, host, hostname) {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+			WriteError(w, r, http.StatusMisdirectedRequest, "")
+		})
+	}
+}
+
+// normalizeHost lower-cases h and removes a trailing dot from the name.
+func normalizeHost(h string) string {
+	h = strings.ToLower(h)
+	if name, port, err := net.SplitHostPort(h); err == nil {
+		return net.JoinHostPort(strings.TrimSuffix(name, "."), port)
+	}
+	return strings.TrimSuffix(h, ".")
+}
+
+// hostMatches reports whether the request host (with port) or hostname (without)
+// matches pattern.
+func hostMatches(pattern, host, hostname string) bool {
+	name := hostname
+	if _, _, err := net.SplitHostPort(pattern); err == nil {
+		name = host
+	}
+	if suffix, ok := strings.CutPrefix(pattern, "*"); ok {
+		return strings.HasSuffix(name, suffix) && len(name) > len(suffix)
+	}
+	return name == pattern
+}
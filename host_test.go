@@ -0,0 +1,55 @@
+package chain_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jpl-au/chain"
+)
+
+func TestValidateHost(t *testing.T) {
+	mux := chain.New()
+	mux.Wrap(chain.ValidateHost("example.com", "*.example.net", "admin.example.org:8443"))
+	mux.HandleFunc("GET /", func(w http.ResponseWriter, r *http.Request) {})
+
+	tests := []struct {
+		host string
+		want int
+	}{
+		{"example.com", http.StatusOK},
+		{"EXAMPLE.com.", http.StatusOK},
+		{"example.com:8080", http.StatusOK},
+		{"api.example.net", http.StatusOK},
+		{"a.b.example.net:443", http.StatusOK},
+		{"example.net", http.StatusMisdirectedRequest},
+		{"evilexample.com", http.StatusMisdirectedRequest},
+		{"admin.example.org:8443", http.StatusOK},
+		{"admin.example.org", http.StatusMisdirectedRequest},
+		{"127.0.0.1", http.StatusMisdirectedRequest},
+		{"", http.StatusBadRequest},
+		{"example.com@evil", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Host = tt.host
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("Host %q: status = %d, want %d", tt.host, rec.Code, tt.want)
+		}
+	}
+}
+
+func TestValidateHostBeforeRouting(t *testing.T) {
+	mux := chain.New()
+	mux.Wrap(chain.ValidateHost("example.com"))
+
+	req := httptest.NewRequest("GET", "/missing", nil)
+	req.Host = "attacker.test"
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusMisdirectedRequest {
+		t.Errorf("status = %d, want 421 before routing", rec.Code)
+	}
+}
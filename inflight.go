@@ -0,0 +1,72 @@
+package chain
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+// InFlight returns the number of requests the router is currently serving.
+func (m *Mux) InFlight() int64 {
+	return m.root.inFlight.Load()
+}
+
+// InFlightByRoute returns the number of requests each route is currently serving,
+// keyed by pattern. Routes with no requests in flight are reported as zero.
+func (m *Mux) InFlightByRoute() map[string]int64 {
+	root := m.root
+	root.mu.RLock()
+	defer root.mu.RUnlock()
+	gauges := make(map[string]int64, len(root.routeInFlight))
+	for pattern, n := range root.routeInFlight {
+		gauges[pattern] = n.Load()
+	}
+	return gauges
+}
+
+// MaxInFlight caps the number of requests the router serves at once. Requests
+// beyond the cap receive 503 Service Unavailable, with a Retry-After of one
+// second, without reaching pre-routing middleware or any route. Zero, the
+// default, removes the cap. The limit applies to the whole router regardless of
+// the group it is called on.
+// Returns the Mux instance for method chaining.
+func (m *Mux) MaxInFlight(n int) *Mux {
+	if n < 0 {
+		panic("chain: negative limit passed to MaxInFlight")
+	}
+	m.root.maxInFlight.Store(int64(n))
+	return m
+}
+
+// admit counts a request in, reporting false if it would exceed MaxInFlight. The
+// caller must call release when admit returns true.
+func (m *Mux) admit() bool {
+	n := m.inFlight.Add(1)
+	if limit := m.maxInFlight.Load(); limit > 0 && n > limit {
+		m.inFlight.Add(-1)
+		return false
+	}
+	return true
+}
+
+// release counts a request out.
+func (m *Mux) release() {
+	m.inFlight.Add(-1)
+}
+
+// countInFlight keeps the in-flight gauge for the route registered under pattern.
+func (m *Mux) countInFlight(pattern string, handler http.Handler) http.Handler {
+	root := m.root
+	n := new(atomic.Int64)
+	root.mu.Lock()
+	if root.routeInFlight == nil {
+		root.routeInFlight = make(map[string]*atomic.Int64)
+	}
+	root.routeInFlight[pattern] = n
+	root.mu.Unlock()
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n.Add(1)
+		defer n.Add(-1)
+		handler.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,74 @@
+package chain_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/jpl-au/chain"
+)
+
+func TestInFlight(t *testing.T) {
+	mux := chain.New()
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	mux.HandleFunc("GET /slow", func(w http.ResponseWriter, r *http.Request) {
+		entered <- struct{}{}
+		<-release
+	})
+	mux.HandleFunc("GET /fast", func(w http.ResponseWriter, r *http.Request) {})
+
+	var wg sync.WaitGroup
+	for range 2 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/slow", nil))
+		}()
+		<-entered
+	}
+
+	if got := mux.InFlight(); got != 2 {
+		t.Errorf("InFlight = %d, want 2", got)
+	}
+	gauges := mux.InFlightByRoute()
+	if gauges["GET /slow"] != 2 || gauges["GET /fast"] != 0 {
+		t.Errorf("InFlightByRoute = %v", gauges)
+	}
+
+	close(release)
+	wg.Wait()
+	if got := mux.InFlight(); got != 0 {
+		t.Errorf("InFlight after completion = %d, want 0", got)
+	}
+}
+
+func TestMaxInFlight(t *testing.T) {
+	mux := chain.New().MaxInFlight(1)
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	mux.HandleFunc("GET /", func(w http.ResponseWriter, r *http.Request) {
+		entered <- struct{}{}
+		<-release
+	})
+
+	done := make(chan struct{})
+	go func() {
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+		close(done)
+	}()
+	<-entered
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") != "1" {
+		t.Errorf("status over limit = %d with Retry-After %q, want 503 with 1", rec.Code, rec.Header().Get("Retry-After"))
+	}
+
+	close(release)
+	<-done
+	if got := mux.InFlight(); got != 0 {
+		t.Errorf("InFlight = %d, want 0", got)
+	}
+}
@@ -0,0 +1,91 @@
+package chain
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+)
+
+// lazyHandler builds its handler on first use.
+type lazyHandler struct {
+	prefix  string
+	build   func() http.Handler
+	mu      sync.Mutex
+	handler atomic.Pointer[http.Handler]
+}
+
+// Lazy mounts a subtree under prefix whose handler is constructed by build on the
+// first request, so that expensive setup such as parsing templates or warming
+// caches for rarely used sections does not delay startup. Requests arriving while
+// build runs wait for it rather than starting their own, and if build panics the
+// next request tries again. The handler receives the full request path, as with
+// Route, and runs behind this Mux's middleware:
+//
+//	mux.Lazy("/reports", func() http.Handler {
+//		reports := chain.New()
+//		reports.HandleFunc("GET /reports/{id}", newReportHandler(loadTemplates()))
+//		return reports
+//	})
+//
+// Call Prewarm to construct lazy subtrees ahead of their first request.
+// Returns the Mux instance for method chaining.
+func (m *Mux) Lazy(prefix string, build func() http.Handler) *Mux {
+	if build == nil {
+		panic("chain: nil function passed to Lazy")
+	}
+	lazy := &lazyHandler{prefix: m.prefix + prefix, build: build}
+	m.root.mu.Lock()
+	m.root.lazy = append(m.root.lazy, lazy)
+	m.root.mu.Unlock()
+
+	m.Handle(prefix+"/", lazy)
+	return m
+}
+
+// Prewarm constructs every subtree registered with Lazy that has not been built
+// yet, one after another, returning once all are ready. Run it on its own
+// goroutine to warm them in the background after the server starts:
+//
+//	go mux.Prewarm()
+//
+// A subtree whose build panics is logged and left to be built on its first
+// request.
+func (m *Mux) Prewarm() {
+	m.root.mu.RLock()
+	lazy := m.root.lazy
+	m.root.mu.RUnlock()
+	for _, l := range lazy {
+		func() {
+			defer func() {
+				if v := recover(); v != nil {
+					m.log().Error("chain: lazy subtree failed to build", "prefix", l.prefix, "error", fmt.Sprint(v))
+				}
+			}()
+			l.get()
+		}()
+	}
+}
+
+// get returns the handler, building it if this is the first call.
+func (l *lazyHandler) get() http.Handler {
+	if h := l.handler.Load(); h != nil {
+		return *h
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if h := l.handler.Load(); h != nil {
+		return *h
+	}
+	h := l.build()
+	if h == nil {
+		panic("chain: nil handler returned by Lazy build for " + l.prefix)
+	}
+	l.handler.Store(&h)
+	return h
+}
+
+// ServeHTTP implements http.Handler.
+func (l *lazyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	l.get().ServeHTTP(w, r)
+}
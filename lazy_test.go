@@ -0,0 +1,104 @@
+package chain_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jpl-au/chain"
+)
+
+func reportsTree(builds *atomic.Int32) func() http.Handler {
+	return func() http.Handler {
+		builds.Add(1)
+		time.Sleep(10 * time.Millisecond)
+		sub := chain.New()
+		sub.HandleFunc("GET /reports/{id}", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("report " + r.PathValue("id")))
+		})
+		return sub
+	}
+}
+
+func TestLazy(t *testing.T) {
+	var builds atomic.Int32
+	mux := chain.New()
+	mux.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Outer", "1")
+			next.ServeHTTP(w, r)
+		})
+	})
+	mux.Lazy("/reports", reportsTree(&builds))
+
+	if builds.Load() != 0 {
+		t.Fatal("subtree built before the first request")
+	}
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest("GET", "/reports/7", nil))
+			if rec.Body.String() != "report 7" || rec.Header().Get("X-Outer") != "1" {
+				t.Errorf("response = %q %v", rec.Body, rec.Header())
+			}
+		}()
+	}
+	wg.Wait()
+	if n := builds.Load(); n != 1 {
+		t.Errorf("built %d times, want 1", n)
+	}
+}
+
+func TestLazyPrewarm(t *testing.T) {
+	var builds atomic.Int32
+	mux := chain.New()
+	mux.Route("/admin", func(admin *chain.Mux) {
+		admin.Lazy("/reports", func() http.Handler {
+			builds.Add(1)
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(r.URL.Path))
+			})
+		})
+	})
+	mux.Prewarm()
+	if n := builds.Load(); n != 1 {
+		t.Fatalf("Prewarm built %d subtrees, want 1", n)
+	}
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/admin/reports/x", nil))
+	if rec.Body.String() != "/admin/reports/x" || builds.Load() != 1 {
+		t.Errorf("body = %q after %d builds", rec.Body, builds.Load())
+	}
+}
+
+func TestLazyRetriesAfterPanic(t *testing.T) {
+	var attempts int
+	mux := chain.New()
+	mux.Use(chain.Recoverer(chain.RecoverOptions{}))
+	mux.Lazy("/flaky", func() http.Handler {
+		attempts++
+		if attempts == 1 {
+			panic("cache unavailable")
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	})
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/flaky/", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("first request = %d, want 500", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/flaky/", nil))
+	if rec.Code != http.StatusOK || attempts != 2 {
+		t.Errorf("second request = %d after %d attempts, want 200 after 2", rec.Code, attempts)
+	}
+}
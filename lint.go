@@ -0,0 +1,220 @@
+package chain
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// IssueKind classifies a problem reported by Lint.
+type IssueKind string
+
+// Kinds of issues reported by Lint.
+const (
+	// IssueShadowed means requests matching the pattern are served by another route.
+	IssueShadowed IssueKind = "shadowed"
+	// IssueMissingMethod means a path accepts modifying methods but has no GET route.
+	IssueMissingMethod IssueKind = "missing-method"
+	// IssuePrefixSubtree means "/" was registered inside Route, which joins to a
+	// pattern such as "/api/" that matches every path under the prefix rather than
+	// the prefix alone. Use "/{$}" to match only the prefix.
+	IssuePrefixSubtree IssueKind = "prefix-subtree"
+	// IssueBadPrefix means a prefix does not start with "/", so the router parses
+	// the start of the prefix as a host name.
+	IssueBadPrefix IssueKind = "bad-prefix"
+)
+
+// Issue is a problem found in the route table by Lint.
+type Issue struct {
+	Kind    IssueKind
+	Pattern string
+	Message string
+}
+
+// String returns the issue formatted as "pattern: message".
+func (i Issue) String() string {
+	return i.Pattern + ": " + i.Message
+}
+
+// Lint analyses the registered routes for common mistakes: routes that can never
+// be reached because another pattern wins, including host-specific patterns which
+// take precedence over every host-less pattern; resource paths with PUT, PATCH or
+// DELETE routes but no GET; and mistakes from joining Route prefixes to patterns,
+// such as a missing leading slash or "/" unintentionally matching a whole subtree.
+func (m *Mux) Lint() []Issue {
+	table := m.RouteTable()
+	var issues []Issue
+
+	hosts := map[string]bool{"": true}
+	for _, rt := range table {
+		if host, _, _ := splitPattern(rt.Pattern); host != "" {
+			hosts[host] = true
+		}
+	}
+
+	for _, rt := range table {
+		host, path, _ := splitPattern(rt.Pattern)
+		if rt.Prefix != "" && !strings.HasPrefix(rt.Prefix, "/") {
+			issues = append(issues, Issue{IssueBadPrefix, rt.Pattern,
+				"prefix " + rt.Prefix + " does not start with \"/\" and is parsed as a host"})
+		}
+		if rt.Prefix != "" && path == strings.TrimSuffix(rt.Prefix, "/")+"/" {
+			issues = append(issues, Issue{IssuePrefixSubtree, rt.Pattern,
+				"matches every path under " + rt.Prefix + "; use \"/{$}\" to match only the prefix"})
+		}
+
+		// Probe the router with a request built from the pattern for each known host
+		for _, h := range sortedKeys(hosts) {
+			if host != "" && h != host {
+				continue
+			}
+			req := samplePattern(rt.Pattern, h)
+			if req == nil {
+				continue
+			}
+			if _, matched := m.router.Handler(req); matched != "" && matched != rt.Pattern {
+				msg := "requests are served by " + matched
+				if h != "" && host == "" {
+					msg += " for host " + h
+				}
+				issues = append(issues, Issue{IssueShadowed, rt.Pattern, msg})
+			}
+		}
+	}
+
+	return append(issues, missingMethods(table)...)
+}
+
+// missingMethods reports host and path combinations that have PUT, PATCH or DELETE
+// routes without a matching GET route.
+func missingMethods(table []RouteInfo) []Issue {
+	methods := make(map[string]map[string]bool)
+	var order []string
+	for _, rt := range table {
+		host, path, method := splitPattern(rt.Pattern)
+		key := host + path
+		if methods[key] == nil {
+			methods[key] = make(map[string]bool)
+			order = append(order, key)
+		}
+		if method == "" {
+			method = "*"
+		}
+		methods[key][method] = true
+	}
+
+	var issues []Issue
+	for _, key := range order {
+		set := methods[key]
+		if set[http.MethodGet] || set["*"] {
+			continue
+		}
+		var writes []string
+		for _, method := range []string{http.MethodPut, http.MethodPatch, http.MethodDelete} {
+			if set[method] {
+				writes = append(writes, method)
+			}
+		}
+		if len(writes) > 0 {
+			issues = append(issues, Issue{IssueMissingMethod, key,
+				"has " + strings.Join(writes, ", ") + " but no GET route"})
+		}
+	}
+	return issues
+}
+
+// splitPattern splits a pattern into its host, path and method.
+func splitPattern(pattern string) (host, path, method string) {
+	if m, rest, ok := strings.Cut(pattern, " "); ok {
+		method, pattern = m, strings.TrimLeft(rest, " \t")
+	}
+	i := strings.IndexByte(pattern, '/')
+	if i < 0 {
+		return pattern, "", method
+	}
+	return pattern[:i], pattern[i:], method
+}
+
+// pathSegment is a literal run of a route path or one of its wildcards.
+type pathSegment struct {
+	literal string
+	param   string // wildcard name, or empty for a literal
+	rest    bool   // the wildcard matches the remainder of the path
+}
+
+// patternSegments splits the path of a pattern into literal runs and wildcards.
+// The "{$}" anchor matches no text and is left out. Every scan of pattern
+// wildcards goes through here, so that they agree on the syntax.
+func patternSegments(path string) []pathSegment {
+	var segments []pathSegment
+	for path != "" {
+		start := strings.IndexByte(path, '{')
+		end := strings.IndexByte(path[max(start, 0):], '}')
+		if start < 0 || end < 0 {
+			segments = append(segments, pathSegment{literal: path})
+			break
+		}
+		end += start
+		if start > 0 {
+			segments = append(segments, pathSegment{literal: path[:start]})
+		}
+		if wildcard := path[start+1 : end]; wildcard != "$" {
+			param, rest := strings.CutSuffix(wildcard, "...")
+			segments = append(segments, pathSegment{param: param, rest: rest})
+		}
+		path = path[end+1:]
+	}
+	return segments
+}
+
+// escapeWildcard escapes value for the path position of wildcard s. Slashes are
+// kept in values of a remainder wildcard, separating the segments they matched.
+func escapeWildcard(s pathSegment, value string) string {
+	if !s.rest {
+		return url.PathEscape(value)
+	}
+	segments := strings.Split(value, "/")
+	for i, seg := range segments {
+		segments[i] = url.PathEscape(seg)
+	}
+	return strings.Join(segments, "/")
+}
+
+// samplePattern builds a request that pattern matches, replacing each wildcard with
+// a placeholder segment. It returns nil if the pattern has no path.
+func samplePattern(pattern, defaultHost string) *http.Request {
+	host, path, method := splitPattern(pattern)
+	if path == "" {
+		return nil
+	}
+	if host == "" {
+		host = defaultHost
+	}
+	if host == "" {
+		host = "lint.invalid"
+	}
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	var b strings.Builder
+	for _, s := range patternSegments(path) {
+		if s.param == "" {
+			b.WriteString(s.literal)
+		} else {
+			b.WriteString("x")
+		}
+	}
+	return &http.Request{Method: method, Host: host, URL: &url.URL{Path: b.String()}}
+}
+
+// sortedKeys returns the keys of set in ascending order.
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
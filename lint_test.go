@@ -0,0 +1,62 @@
+package chain_test
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/jpl-au/chain"
+)
+
+func TestLint(t *testing.T) {
+	h := func(w http.ResponseWriter, r *http.Request) {}
+
+	mux := chain.New()
+	mux.HandleFunc("GET /users", h)
+	mux.HandleFunc("api.example.com/", h)
+	mux.HandleFunc("PUT /items/{id}", h)
+	mux.HandleFunc("GET /orders/{id}", h)
+	mux.HandleFunc("DELETE /orders/{id}", h)
+	mux.Route("/v1", func(v1 *chain.Mux) {
+		v1.HandleFunc("GET /", h)
+	})
+	mux.Route("v2", func(v2 *chain.Mux) {
+		v2.HandleFunc("GET /status", h)
+	})
+
+	var got []string
+	for _, issue := range mux.Lint() {
+		got = append(got, string(issue.Kind)+" "+issue.String())
+	}
+	want := []string{
+		"shadowed GET /users: requests are served by api.example.com/ for host api.example.com",
+		"shadowed PUT /items/{id}: requests are served by api.example.com/ for host api.example.com",
+		"shadowed GET /orders/{id}: requests are served by api.example.com/ for host api.example.com",
+		"shadowed DELETE /orders/{id}: requests are served by api.example.com/ for host api.example.com",
+		"prefix-subtree GET /v1/: matches every path under /v1; use \"/{$}\" to match only the prefix",
+		"shadowed GET /v1/: requests are served by api.example.com/ for host api.example.com",
+		"bad-prefix GET v2/status: prefix v2 does not start with \"/\" and is parsed as a host",
+		"missing-method /items/{id}: has PUT but no GET route",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Unexpected issues:\n got %q\nwant %q", got, want)
+	}
+}
+
+func TestLintClean(t *testing.T) {
+	h := func(w http.ResponseWriter, r *http.Request) {}
+
+	mux := chain.New()
+	mux.HandleFunc("GET /posts/{$}", h)
+	mux.HandleFunc("GET /posts/{id}", h)
+	mux.HandleFunc("PUT /posts/{id}", h)
+	mux.HandleFunc("/static/", h)
+	mux.Route("/api", func(api *chain.Mux) {
+		api.HandleFunc("GET /{$}", h)
+		api.HandleFunc("POST /login", h)
+	})
+
+	if issues := mux.Lint(); len(issues) != 0 {
+		t.Errorf("Expected no issues, got %v", issues)
+	}
+}
@@ -0,0 +1,141 @@
+package chain
+
+import (
+	"cmp"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ListingData is passed to the directory listing template.
+type ListingData struct {
+	// Path is the URL path of the directory, ending in "/".
+	Path string
+	// Parent is the escaped URL path of the parent directory, or empty at the root.
+	Parent string
+	// Entries are the directory's contents in the requested order.
+	Entries []ListingEntry
+	// Sort is the column the entries are sorted by: "name", "size" or "modtime".
+	Sort string
+	// Desc reports whether the entries are in descending order.
+	Desc bool
+}
+
+// ListingEntry describes one file or subdirectory in a directory listing.
+type ListingEntry struct {
+	Name    string
+	Path    string // escaped URL path, ending in "/" for directories
+	Size    int64
+	ModTime time.Time
+	IsDir   bool
+}
+
+// SortLink returns the query string that sorts the listing by column, toggling the
+// direction if the listing is already sorted by it.
+func (d ListingData) SortLink(column string) string {
+	if column == d.Sort && !d.Desc {
+		return "?sort=" + column + "&order=desc"
+	}
+	return "?sort=" + column
+}
+
+// DefaultListingTemplate renders a sortable HTML table of name, size and
+// modification time.
+var DefaultListingTemplate = template.Must(template.New("listing").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Index of {{.Path}}</title></head>
+<body>
+<h1>Index of {{.Path}}</h1>
+<table>
+<thead><tr>
+<th><a href="{{.SortLink "name"}}">Name</a></th>
+<th><a href="{{.SortLink "size"}}">Size</a></th>
+<th><a href="{{.SortLink "modtime"}}">Modified</a></th>
+</tr></thead>
+<tbody>
+{{- if .Parent}}
+<tr><td><a href="{{.Parent}}">../</a></td><td></td><td></td></tr>
+{{- end}}
+{{- range .Entries}}
+<tr><td><a href="{{.Path}}">{{.Name}}{{if .IsDir}}/{{end}}</a></td><td>{{if not .IsDir}}{{.Size}}{{end}}</td><td>{{.ModTime.UTC.Format "2006-01-02 15:04:05"}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+</body>
+</html>
+`))
+
+// serveListing renders the directory dir, a valid fs path, for r. Entries whose
+// names begin with a dot are hidden.
+func (s *staticFiles) serveListing(w http.ResponseWriter, r *http.Request, dir string) {
+	if !strings.HasSuffix(r.URL.Path, "/") {
+		http.Redirect(w, r, r.URL.EscapedPath()+"/", http.StatusMovedPermanently)
+		return
+	}
+
+	entries, err := fs.ReadDir(s.fsys, dir)
+	if err != nil {
+		WriteError(w, r, http.StatusNotFound, "")
+		return
+	}
+
+	base := r.URL.EscapedPath()
+	data := ListingData{Path: r.URL.Path, Sort: "name", Desc: r.URL.Query().Get("order") == "desc"}
+	if dir != "." {
+		data.Parent = path.Dir(strings.TrimSuffix(base, "/")) + "/"
+	}
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		entry := ListingEntry{Name: e.Name(), Path: base + url.PathEscape(e.Name()), ModTime: info.ModTime(), IsDir: e.IsDir()}
+		if e.IsDir() {
+			entry.Path += "/"
+		} else {
+			entry.Size = info.Size()
+		}
+		data.Entries = append(data.Entries, entry)
+	}
+
+	switch r.URL.Query().Get("sort") {
+	case "size":
+		data.Sort = "size"
+		slices.SortStableFunc(data.Entries, func(a, b ListingEntry) int { return cmp.Compare(a.Size, b.Size) })
+	case "modtime":
+		data.Sort = "modtime"
+		slices.SortStableFunc(data.Entries, func(a, b ListingEntry) int { return a.ModTime.Compare(b.ModTime) })
+	}
+	if data.Desc {
+		slices.Reverse(data.Entries)
+	}
+
+	tmpl := s.opts.ListingTemplate
+	if tmpl == nil {
+		tmpl = DefaultListingTemplate
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	if err := tmpl.Execute(w, data); err != nil {
+		WriteError(w, r, http.StatusInternalServerError, "")
+	}
+}
+
+// hidden reports whether any element of the slash-separated name begins with a
+// dot.
+func hidden(name string) bool {
+	for _, elem := range strings.Split(name, "/") {
+		if strings.HasPrefix(elem, ".") {
+			return true
+		}
+	}
+	return false
+}
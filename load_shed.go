@@ -0,0 +1,208 @@
+package chain
+
+import (
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+)
+
+// LoadShedOptions configures the LoadShed middleware. The zero value applies
+// defaults suited to handlers taking milliseconds to a few hundred milliseconds.
+type LoadShedOptions struct {
+	// Target is the queueing delay tolerated while the queue has been backed up for
+	// longer than Interval. Defaults to 5ms.
+	Target time.Duration
+	// Interval is how long requests may queue while load is transient, and how
+	// often the concurrency limit may be cut. Defaults to 100ms.
+	Interval time.Duration
+	// InitialLimit, MinLimit and MaxLimit bound the adaptive concurrency limit.
+	// They default to 20, 1 and 1000.
+	InitialLimit, MinLimit, MaxLimit int
+	// Tolerance is the multiple of the baseline latency beyond which requests are
+	// taken as a sign of congestion. Defaults to 2.
+	Tolerance float64
+	// RetryAfter is the delay suggested to shed requests in the Retry-After
+	// header. Defaults to 1 second.
+	RetryAfter time.Duration
+}
+
+// shedder holds the state shared by all requests through one LoadShed middleware.
+type shedder struct {
+	opts LoadShedOptions
+
+	mu        sync.Mutex
+	limit     float64
+	inFlight  int
+	queue     []*shedWaiter
+	lastEmpty time.Time
+
+	// Latency baseline: the minimum latency of the previous window
+	baseline     time.Duration
+	windowMin    time.Duration
+	windowEnd    time.Time
+	lastDecrease time.Time
+}
+
+// shedWaiter is a queued request, signalled through ready when given a slot.
+type shedWaiter struct {
+	ready    chan struct{}
+	priority Priority
+}
+
+// LoadShed returns middleware that keeps latency bounded under overload by
+// limiting the requests its routes serve at once and rejecting the excess with
+// 503 Service Unavailable and a Retry-After header before it reaches the
+// handlers.
+//
+// The concurrency limit adapts to the handlers: it grows slowly while requests
+// complete near the baseline latency, the fastest seen recently, and is cut by
+// 10% at most once per Interval when they take more than Tolerance times as
+// long. Requests over the limit wait in a queue managed with CoDel: while the
+// queue drains regularly they may wait up to Interval, absorbing bursts, but
+// once it has stayed non-empty for longer than Interval they are only allowed
+// Target, so a standing queue is shed rather than adding to every request's
+// latency. Requests whose client goes away while queued are dropped.
+//
+// Routes assigned a priority with Mux.Priority are queued ahead of lower-priority
+// requests. Once the queue is backed up, requests below PriorityNormal are shed
+// without queueing and those above it may still wait up to Interval, so health
+// checks and critical work keep being served while exports are turned away. Use
+// LoadShed with Use rather than UsePre so that the route's priority is known.
+//
+// Each call returns an independent limiter; register it once on the group whose
+// handlers share the capacity being protected.
+func LoadShed(opts LoadShedOptions) func(http.Handler) http.Handler {
+	if opts.Target <= 0 {
+		opts.Target = 5 * time.Millisecond
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = 100 * time.Millisecond
+	}
+	if opts.MinLimit <= 0 {
+		opts.MinLimit = 1
+	}
+	if opts.MaxLimit <= 0 {
+		opts.MaxLimit = 1000
+	}
+	if opts.InitialLimit <= 0 {
+		opts.InitialLimit = 20
+	}
+	opts.InitialLimit = min(max(opts.InitialLimit, opts.MinLimit), opts.MaxLimit)
+	if opts.Tolerance <= 1 {
+		opts.Tolerance = 2
+	}
+	if opts.RetryAfter <= 0 {
+		opts.RetryAfter = defaultRetryAfter
+	}
+	s := &shedder{opts: opts, limit: float64(opts.InitialLimit), lastEmpty: time.Now()}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !s.acquire(w, r) {
+				WriteRetryAfter(w, r, http.StatusServiceUnavailable, opts.RetryAfter, "")
+				return
+			}
+			start := time.Now()
+			defer func() { s.release(time.Since(start)) }()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// acquire takes a slot, queueing for one if the limit is reached. It reports
+// false if the request was shed.
+func (s *shedder) acquire(w http.ResponseWriter, r *http.Request) bool {
+	s.mu.Lock()
+	now := time.Now()
+	if len(s.queue) == 0 && s.inFlight < int(s.limit) {
+		s.inFlight++
+		s.lastEmpty = now
+		s.mu.Unlock()
+		return true
+	}
+	priority := RequestPriority(w)
+	timeout := s.opts.Interval
+	if now.Sub(s.lastEmpty) > s.opts.Interval {
+		if priority < PriorityNormal {
+			s.mu.Unlock()
+			return false
+		}
+		if priority == PriorityNormal {
+			timeout = s.opts.Target
+		}
+	}
+	waiter := &shedWaiter{ready: make(chan struct{}), priority: priority}
+	i := slices.IndexFunc(s.queue, func(q *shedWaiter) bool { return q.priority < priority })
+	if i < 0 {
+		i = len(s.queue)
+	}
+	s.queue = slices.Insert(s.queue, i, waiter)
+	s.mu.Unlock()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-waiter.ready:
+		return true
+	case <-timer.C:
+	case <-r.Context().Done():
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i = slices.Index(s.queue, waiter)
+	if i < 0 {
+		// A slot was handed over as the wait ended
+		return true
+	}
+	s.queue = slices.Delete(s.queue, i, i+1)
+	return false
+}
+
+// release returns a slot, adapting the limit to the request's latency.
+func (s *shedder) release(latency time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	s.adapt(now, latency)
+	s.inFlight--
+	s.dispatch()
+}
+
+// dispatch hands free slots to queued requests in order. Callers hold s.mu.
+func (s *shedder) dispatch() {
+	for len(s.queue) > 0 && s.inFlight < int(s.limit) {
+		close(s.queue[0].ready)
+		s.queue = s.queue[1:]
+		s.inFlight++
+	}
+	if len(s.queue) == 0 {
+		s.lastEmpty = time.Now()
+	}
+}
+
+// adapt updates the baseline and the concurrency limit. Callers hold s.mu.
+func (s *shedder) adapt(now time.Time, latency time.Duration) {
+	if s.windowMin == 0 || latency < s.windowMin {
+		s.windowMin = latency
+	}
+	if s.baseline == 0 || latency < s.baseline {
+		s.baseline = latency
+	}
+	// The baseline is refreshed every ten intervals so that it follows handlers
+	// that become permanently slower, rather than shedding against a stale best case
+	if now.After(s.windowEnd) {
+		s.baseline, s.windowMin = s.windowMin, 0
+		s.windowEnd = now.Add(10 * s.opts.Interval)
+	}
+
+	if float64(latency) > float64(s.baseline)*s.opts.Tolerance {
+		if now.Sub(s.lastDecrease) >= s.opts.Interval {
+			s.limit = max(float64(s.opts.MinLimit), s.limit*0.9)
+			s.lastDecrease = now
+		}
+		return
+	}
+	s.limit = min(float64(s.opts.MaxLimit), s.limit+1/s.limit)
+}
@@ -0,0 +1,94 @@
+package chain_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jpl-au/chain"
+)
+
+func TestLoadShed(t *testing.T) {
+	mux := chain.New()
+	mux.Use(chain.LoadShed(chain.LoadShedOptions{
+		Interval:     50 * time.Millisecond,
+		InitialLimit: 1,
+		MaxLimit:     1,
+	}))
+	entered := make(chan struct{}, 2)
+	release := make(chan struct{})
+	mux.HandleFunc("GET /", func(w http.ResponseWriter, r *http.Request) {
+		entered <- struct{}{}
+		<-release
+	})
+
+	serve := func() chan int {
+		code := make(chan int, 1)
+		go func() {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+			code <- rec.Code
+		}()
+		return code
+	}
+
+	first := serve()
+	<-entered
+
+	// A request queued while a slot frees up is served
+	second := serve()
+	time.Sleep(10 * time.Millisecond)
+	release <- struct{}{}
+	<-entered
+	if code := <-first; code != http.StatusOK {
+		t.Errorf("first request = %d, want 200", code)
+	}
+
+	// Over the limit, a request queues for up to Interval and is then shed
+	start := time.Now()
+	if code := <-serve(); code != http.StatusServiceUnavailable {
+		t.Errorf("queued request = %d, want 503", code)
+	}
+	if waited := time.Since(start); waited < 40*time.Millisecond {
+		t.Errorf("shed after %v, want about Interval", waited)
+	}
+
+	release <- struct{}{}
+	if code := <-second; code != http.StatusOK {
+		t.Errorf("second request = %d, want 200", code)
+	}
+}
+
+func TestLoadShedStandingQueue(t *testing.T) {
+	mux := chain.New()
+	mux.Use(chain.LoadShed(chain.LoadShedOptions{
+		Target:       time.Millisecond,
+		Interval:     20 * time.Millisecond,
+		InitialLimit: 1,
+		MaxLimit:     1,
+	}))
+	release := make(chan struct{})
+	entered := make(chan struct{})
+	mux.HandleFunc("GET /", func(w http.ResponseWriter, r *http.Request) {
+		entered <- struct{}{}
+		<-release
+	})
+	go mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	<-entered
+	defer close(release)
+
+	// Keep the queue occupied past Interval
+	go mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	time.Sleep(30 * time.Millisecond)
+
+	start := time.Now()
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") != "1" {
+		t.Errorf("status = %d with Retry-After %q, want 503 with 1", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	if waited := time.Since(start); waited >= 15*time.Millisecond {
+		t.Errorf("shed after %v, want about Target", waited)
+	}
+}
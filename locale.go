@@ -0,0 +1,136 @@
+package chain
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// LocaleOptions configures the Localize middleware.
+type LocaleOptions struct {
+	// Supported lists the language tags the application can serve, such as "en-GB".
+	// The first entry is the default when nothing matches. Required.
+	Supported []string
+	// Cookie names a cookie whose value, if supported, overrides Accept-Language.
+	Cookie string
+	// Query names a query parameter whose value, if supported, overrides both the
+	// cookie and Accept-Language.
+	Query string
+}
+
+// localeKey is the context key under which the negotiated locale is stored.
+type localeKey struct{}
+
+// Localize returns middleware that negotiates the response language. It honours
+// the query and cookie overrides when configured, then matches Accept-Language
+// entries in q-value order against the supported tags. A request for "en-AU"
+// matches a supported "en" and vice versa when no exact match exists. The chosen
+// tag is stored in the request context, available via Locale, and written to the
+// Content-Language header.
+func Localize(opts LocaleOptions) func(http.Handler) http.Handler {
+	if len(opts.Supported) == 0 {
+		panic("chain: no supported locales passed to Localize")
+	}
+	vary := "Accept-Language"
+	if opts.Cookie != "" {
+		vary += ", Cookie"
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tag := opts.negotiate(r)
+			w.Header().Set("Content-Language", tag)
+			w.Header().Add("Vary", vary)
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), localeKey{}, tag)))
+		})
+	}
+}
+
+// Locale returns the language tag negotiated by Localize, or an empty string if the
+// middleware did not run.
+func Locale(r *http.Request) string {
+	tag, _ := r.Context().Value(localeKey{}).(string)
+	return tag
+}
+
+// negotiate picks the best supported tag for r.
+func (opts LocaleOptions) negotiate(r *http.Request) string {
+	if opts.Query != "" {
+		if tag, ok := opts.exact(r.URL.Query().Get(opts.Query)); ok {
+			return tag
+		}
+	}
+	if opts.Cookie != "" {
+		if c, err := r.Cookie(opts.Cookie); err == nil {
+			if tag, ok := opts.exact(c.Value); ok {
+				return tag
+			}
+		}
+	}
+
+	for _, want := range parseAccept(r.Header.Get("Accept-Language")) {
+		if want == "*" {
+			return opts.Supported[0]
+		}
+		if tag, ok := opts.exact(want); ok {
+			return tag
+		}
+		base, _, _ := strings.Cut(want, "-")
+		for _, tag := range opts.Supported {
+			supportedBase, _, _ := strings.Cut(tag, "-")
+			if strings.EqualFold(base, supportedBase) {
+				return tag
+			}
+		}
+	}
+	return opts.Supported[0]
+}
+
+// exact returns the supported tag equal to want, ignoring case.
+func (opts LocaleOptions) exact(want string) (string, bool) {
+	if want == "" {
+		return "", false
+	}
+	for _, tag := range opts.Supported {
+		if strings.EqualFold(tag, want) {
+			return tag, true
+		}
+	}
+	return "", false
+}
+
+// parseAccept returns the values of an Accept-style header, such as language
+// ranges or media ranges, ordered by descending q-value. Values with q=0 are
+// dropped, and ties keep their original order.
+func parseAccept(header string) []string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+	var ranges []weighted
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+		q, ok := 1.0, true
+		for _, param := range strings.Split(params, ";") {
+			if v, found := strings.CutPrefix(strings.TrimSpace(param), "q="); found {
+				parsed, err := strconv.ParseFloat(v, 64)
+				q, ok = parsed, err == nil
+			}
+		}
+		if ok && q > 0 {
+			ranges = append(ranges, weighted{tag, q})
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+	tags := make([]string, len(ranges))
+	for i, r := range ranges {
+		tags[i] = r.tag
+	}
+	return tags
+}
@@ -0,0 +1,63 @@
+package chain_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jpl-au/chain"
+)
+
+func TestLocalize(t *testing.T) {
+	tests := []struct {
+		name   string
+		url    string
+		accept string
+		cookie string
+		want   string
+	}{
+		{"default", "/", "", "", "en-AU"},
+		{"exact match", "/", "fr-FR", "", "fr-FR"},
+		{"q-value order", "/", "de;q=0.5, fr-FR;q=0.9, ja;q=0.1", "", "fr-FR"},
+		{"base language match", "/", "fr-CA", "", "fr-FR"},
+		{"unsupported falls back", "/", "ja", "", "en-AU"},
+		{"q zero excluded", "/", "fr-FR;q=0, de", "", "de"},
+		{"cookie override", "/", "fr-FR", "de", "de"},
+		{"query override", "/?lang=fr-fr", "de", "de", "fr-FR"},
+		{"unsupported override ignored", "/?lang=ja", "de", "", "de"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			mux := chain.New().Use(chain.Localize(chain.LocaleOptions{
+				Supported: []string{"en-AU", "fr-FR", "de"},
+				Cookie:    "lang",
+				Query:     "lang",
+			}))
+			mux.HandleFunc("GET /", func(w http.ResponseWriter, r *http.Request) {
+				got = chain.Locale(r)
+			})
+
+			req := httptest.NewRequest("GET", tt.url, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept-Language", tt.accept)
+			}
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: "lang", Value: tt.cookie})
+			}
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+
+			if got != tt.want {
+				t.Errorf("Expected locale '%s', got '%s'", tt.want, got)
+			}
+			if cl := rec.Header().Get("Content-Language"); cl != tt.want {
+				t.Errorf("Expected Content-Language '%s', got '%s'", tt.want, cl)
+			}
+			if vary := rec.Header().Get("Vary"); vary != "Accept-Language, Cookie" {
+				t.Errorf("Expected Vary 'Accept-Language, Cookie', got '%s'", vary)
+			}
+		})
+	}
+}
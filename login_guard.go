@@ -0,0 +1,250 @@
+package chain
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// LoginFailures is the failed login history of one key. Attempts are recorded
+// as failures when they start and forgotten if they succeed, so the history
+// includes attempts still being handled.
+type LoginFailures struct {
+	// Count is the number of failures since the last success.
+	Count int
+	// Last is the time of the most recent failure.
+	Last time.Time
+}
+
+// LoginFailureStore records failed login attempts for LoginGuard. Stores backed
+// by shared storage such as Redis let lockouts apply across instances.
+type LoginFailureStore interface {
+	// Get returns the failures recorded for key, or the zero value if there are
+	// none or they have expired.
+	Get(ctx context.Context, key string) (LoginFailures, error)
+	// Attempt reserves a login attempt for key. It calls allow with the key's
+	// history and, if allow returns true, records the attempt as a failure,
+	// keeping the history until expires. It returns the history after the call
+	// and whether the attempt was allowed. The check and the update must be
+	// atomic, so that concurrent attempts cannot all pass the check before any
+	// of them is recorded.
+	Attempt(ctx context.Context, key string, expires time.Time, allow func(LoginFailures) bool) (LoginFailures, bool, error)
+	// Reset forgets the failures recorded for key.
+	Reset(ctx context.Context, key string) error
+}
+
+// LoginGuardOptions configures NewLoginGuard.
+type LoginGuardOptions struct {
+	// Store records failures. Defaults to a MemoryLoginFailureStore.
+	Store LoginFailureStore
+	// Key identifies the party attempting to log in. Defaults to the client IP
+	// and the username, from Basic authentication or the "username" form value,
+	// so that one client cannot lock every account and one account cannot be
+	// locked from everywhere.
+	Key func(r *http.Request) string
+	// Failed reports whether the login handler's response status is a failed
+	// attempt. Defaults to 401 Unauthorized.
+	Failed func(status int) bool
+	// Delay, if set, makes a key wait before its next attempt after each failure,
+	// doubling with every consecutive failure up to the lockout.
+	Delay time.Duration
+	// Threshold is the number of consecutive failures that locks a key out.
+	// Defaults to 5.
+	Threshold int
+	// Lockout is how long a key stays locked out. Defaults to 15 minutes.
+	Lockout time.Duration
+	// Window is how long failures are remembered after the most recent one.
+	// Defaults to 1 hour, and is never shorter than Lockout.
+	Window time.Duration
+}
+
+// LoginStats holds the counters of a LoginGuard, for alerting on credential
+// stuffing and brute force attacks.
+type LoginStats struct {
+	// Failures is the number of failed attempts.
+	Failures int64
+	// Lockouts is the number of times a key reached the threshold.
+	Lockouts int64
+	// Rejected is the number of attempts refused while a key was waiting or locked.
+	Rejected int64
+}
+
+// LoginGuard protects login handlers against brute force attacks by tracking
+// failed attempts per key, delaying further attempts and locking keys out.
+type LoginGuard struct {
+	opts     LoginGuardOptions
+	failures atomic.Int64
+	lockouts atomic.Int64
+	rejected atomic.Int64
+}
+
+// NewLoginGuard returns a LoginGuard configured by opts.
+func NewLoginGuard(opts LoginGuardOptions) *LoginGuard {
+	if opts.Store == nil {
+		opts.Store = NewMemoryLoginFailureStore()
+	}
+	if opts.Key == nil {
+		opts.Key = loginKey
+	}
+	if opts.Failed == nil {
+		opts.Failed = func(status int) bool { return status == http.StatusUnauthorized }
+	}
+	if opts.Threshold <= 0 {
+		opts.Threshold = 5
+	}
+	if opts.Lockout <= 0 {
+		opts.Lockout = 15 * time.Minute
+	}
+	if opts.Window <= 0 {
+		opts.Window = time.Hour
+	}
+	// Failures must be remembered for as long as they lock the key out
+	opts.Window = max(opts.Window, opts.Lockout)
+	return &LoginGuard{opts: opts}
+}
+
+// Middleware returns middleware for login routes. Attempts from a key that is
+// waiting out a delay or locked out are refused with 429 Too Many Requests and a
+// Retry-After header, without reaching the handler. Each attempt is recorded
+// against the key as a failure before the handler runs, so that attempts made in
+// parallel count towards the delay and lockout, and a response the Failed
+// function does not report as a failure resets the key. The handler therefore
+// signals failure through its status alone.
+func (g *LoginGuard) Middleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := g.opts.Key(r)
+			ctx := r.Context()
+			f, ok, err := g.opts.Store.Attempt(ctx, key, time.Now().Add(g.opts.Window), func(f LoginFailures) bool {
+				return !g.blockedUntil(f).After(time.Now())
+			})
+			if err != nil {
+				WriteError(w, r, http.StatusInternalServerError, "")
+				return
+			}
+			if !ok {
+				g.rejected.Add(1)
+				WriteRetryAfter(w, r, http.StatusTooManyRequests, time.Until(g.blockedUntil(f)), "")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+
+			status := http.StatusOK
+			if rw, ok := w.(ResponseWriter); ok {
+				status = rw.Status()
+			}
+			if !g.opts.Failed(status) {
+				g.opts.Store.Reset(ctx, key)
+				return
+			}
+			g.failures.Add(1)
+			if f.Count == g.opts.Threshold {
+				g.lockouts.Add(1)
+				emitEvent(w, LoginLocked{Request: r, Key: key, Failures: f.Count, Until: g.blockedUntil(f)})
+			}
+		})
+	}
+}
+
+// Stats returns the guard's counters.
+func (g *LoginGuard) Stats() LoginStats {
+	return LoginStats{Failures: g.failures.Load(), Lockouts: g.lockouts.Load(), Rejected: g.rejected.Load()}
+}
+
+// blockedUntil returns the earliest time a key with failures f may try again.
+func (g *LoginGuard) blockedUntil(f LoginFailures) time.Time {
+	if f.Count >= g.opts.Threshold {
+		return f.Last.Add(g.opts.Lockout)
+	}
+	if f.Count == 0 || g.opts.Delay <= 0 {
+		return time.Time{}
+	}
+	delay := g.opts.Delay << min(f.Count-1, 30)
+	if delay <= 0 || delay > g.opts.Lockout {
+		delay = g.opts.Lockout
+	}
+	return f.Last.Add(delay)
+}
+
+// loginKey is the default LoginGuardOptions.Key.
+func loginKey(r *http.Request) string {
+	ip := r.RemoteAddr
+	if addr, ok := remoteAddr(r); ok {
+		ip = addr.String()
+	}
+	user, _, ok := r.BasicAuth()
+	if !ok {
+		user = r.PostFormValue("username")
+	}
+	return ip + "|" + user
+}
+
+// MemoryLoginFailureStore is an in-process LoginFailureStore suitable for
+// single-instance deployments.
+type MemoryLoginFailureStore struct {
+	mu      sync.Mutex
+	entries map[string]memoryLoginFailures
+	pruned  time.Time
+}
+
+// memoryLoginFailures is a history held by MemoryLoginFailureStore.
+type memoryLoginFailures struct {
+	LoginFailures
+	expires time.Time
+}
+
+// NewMemoryLoginFailureStore returns an empty MemoryLoginFailureStore.
+func NewMemoryLoginFailureStore() *MemoryLoginFailureStore {
+	return &MemoryLoginFailureStore{entries: make(map[string]memoryLoginFailures), pruned: time.Now()}
+}
+
+// Get implements LoginFailureStore.
+func (s *MemoryLoginFailureStore) Get(_ context.Context, key string) (LoginFailures, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[key]
+	if !ok || time.Now().After(e.expires) {
+		return LoginFailures{}, nil
+	}
+	return e.LoginFailures, nil
+}
+
+// Attempt implements LoginFailureStore. Expired histories are pruned at most
+// once a minute.
+func (s *MemoryLoginFailureStore) Attempt(_ context.Context, key string, expires time.Time, allow func(LoginFailures) bool) (LoginFailures, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.pruned) >= memoryPruneInterval {
+		for k, e := range s.entries {
+			if now.After(e.expires) {
+				delete(s.entries, k)
+			}
+		}
+		s.pruned = now
+	}
+	e := s.entries[key]
+	if now.After(e.expires) {
+		e = memoryLoginFailures{}
+	}
+	if !allow(e.LoginFailures) {
+		return e.LoginFailures, false, nil
+	}
+	e.Count++
+	e.Last = now
+	e.expires = expires
+	s.entries[key] = e
+	return e.LoginFailures, true, nil
+}
+
+// Reset implements LoginFailureStore.
+func (s *MemoryLoginFailureStore) Reset(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+	return nil
+}
@@ -0,0 +1,130 @@
+package chain_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jpl-au/chain"
+)
+
+// loginMux serves a login form accepting only alice's password "secret".
+func loginMux(g *chain.LoginGuard) *chain.Mux {
+	mux := chain.New()
+	mux.Group(func(m *chain.Mux) {
+		m.Use(g.Middleware())
+		m.HandleFunc("POST /login", func(w http.ResponseWriter, r *http.Request) {
+			if r.FormValue("username") != "alice" || r.FormValue("password") != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+			}
+		})
+	})
+	return mux
+}
+
+func login(mux http.Handler, ip, user, password string) *httptest.ResponseRecorder {
+	form := url.Values{"username": {user}, "password": {password}}
+	req := httptest.NewRequest("POST", "/login", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.RemoteAddr = ip + ":1234"
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestLoginGuardLockout(t *testing.T) {
+	g := chain.NewLoginGuard(chain.LoginGuardOptions{Threshold: 3})
+	mux := loginMux(g)
+	var locked []chain.LoginLocked
+	mux.Subscribe(func(e chain.Event) {
+		if e, ok := e.(chain.LoginLocked); ok {
+			locked = append(locked, e)
+		}
+	})
+
+	for i := range 3 {
+		if rec := login(mux, "192.0.2.1", "alice", "wrong"); rec.Code != http.StatusUnauthorized {
+			t.Fatalf("Attempt %d: expected 401, got %d", i+1, rec.Code)
+		}
+	}
+
+	// The correct password is refused while locked out
+	rec := login(mux, "192.0.2.1", "alice", "secret")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected 429 while locked out, got %d", rec.Code)
+	}
+	if rec.Header().Get("Retry-After") != "900" {
+		t.Errorf("Expected Retry-After 900, got '%s'", rec.Header().Get("Retry-After"))
+	}
+
+	// Other clients and other accounts are unaffected
+	if rec := login(mux, "192.0.2.2", "alice", "secret"); rec.Code != http.StatusOK {
+		t.Errorf("Expected another client to log in, got %d", rec.Code)
+	}
+	if rec := login(mux, "192.0.2.1", "bob", "wrong"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected another account to be checked, got %d", rec.Code)
+	}
+
+	if len(locked) != 1 || locked[0].Key != "192.0.2.1|alice" || locked[0].Failures != 3 {
+		t.Errorf("Expected one LoginLocked event for 192.0.2.1|alice, got %+v", locked)
+	}
+	stats := g.Stats()
+	if stats.Failures != 4 || stats.Lockouts != 1 || stats.Rejected != 1 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
+
+func TestLoginGuardDelay(t *testing.T) {
+	g := chain.NewLoginGuard(chain.LoginGuardOptions{Delay: 20 * time.Millisecond})
+	mux := loginMux(g)
+
+	login(mux, "192.0.2.1", "alice", "wrong")
+	if rec := login(mux, "192.0.2.1", "alice", "secret"); rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected 429 during the delay, got %d", rec.Code)
+	}
+	time.Sleep(25 * time.Millisecond)
+	if rec := login(mux, "192.0.2.1", "alice", "secret"); rec.Code != http.StatusOK {
+		t.Fatalf("Expected login after the delay, got %d", rec.Code)
+	}
+
+	// Success resets the failures, so the next failure waits only the base delay
+	login(mux, "192.0.2.1", "alice", "wrong")
+	time.Sleep(25 * time.Millisecond)
+	if rec := login(mux, "192.0.2.1", "alice", "secret"); rec.Code != http.StatusOK {
+		t.Errorf("Expected the delay to restart after success, got %d", rec.Code)
+	}
+}
+
+func TestLoginGuardParallelAttempts(t *testing.T) {
+	g := chain.NewLoginGuard(chain.LoginGuardOptions{Threshold: 3})
+	var handled atomic.Int32
+	mux := chain.New()
+	mux.Use(g.Middleware())
+	mux.HandleFunc("POST /login", func(w http.ResponseWriter, r *http.Request) {
+		handled.Add(1)
+		time.Sleep(20 * time.Millisecond)
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			login(mux, "192.0.2.1", "alice", "wrong")
+		}()
+	}
+	wg.Wait()
+
+	if n := handled.Load(); n != 3 {
+		t.Errorf("Expected parallel attempts capped at the threshold of 3, got %d", n)
+	}
+	if stats := g.Stats(); stats.Rejected != 17 || stats.Lockouts != 1 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
@@ -0,0 +1,141 @@
+package chain
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaintenanceWindow is a period during which routes are taken offline.
+type MaintenanceWindow interface {
+	// Active reports whether t falls within the window and, if so, when the
+	// window ends.
+	Active(t time.Time) (end time.Time, ok bool)
+}
+
+// FixedWindow is a one-off maintenance window from Start until End.
+type FixedWindow struct {
+	Start, End time.Time
+}
+
+// Active implements MaintenanceWindow.
+func (fw FixedWindow) Active(t time.Time) (time.Time, bool) {
+	return fw.End, !t.Before(fw.Start) && t.Before(fw.End)
+}
+
+// DailyWindow is a maintenance window recurring every day, or on the listed
+// weekdays, at a fixed time of day. A window may extend past midnight, in which
+// case Weekdays refers to the day it starts.
+type DailyWindow struct {
+	// Start is the offset from midnight at which the window opens, such as
+	// 2*time.Hour for 02:00.
+	Start time.Duration
+	// Duration is how long the window stays open.
+	Duration time.Duration
+	// Weekdays restricts the window to the days listed. Defaults to every day.
+	Weekdays []time.Weekday
+	// Location is the time zone of Start. Defaults to time.Local.
+	Location *time.Location
+}
+
+// Active implements MaintenanceWindow.
+func (dw DailyWindow) Active(t time.Time) (time.Time, bool) {
+	loc := dw.Location
+	if loc == nil {
+		loc = time.Local
+	}
+	t = t.In(loc)
+	// A window that opened yesterday may still be open
+	for _, days := range []int{0, -1} {
+		day := t.AddDate(0, 0, days)
+		if len(dw.Weekdays) > 0 && !slices.Contains(dw.Weekdays, day.Weekday()) {
+			continue
+		}
+		start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, loc).Add(dw.Start)
+		end := start.Add(dw.Duration)
+		if !t.Before(start) && t.Before(end) {
+			return end, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// MaintenanceOptions configures Maintenance.
+type MaintenanceOptions struct {
+	// Windows lists the periods during which the routes are offline.
+	Windows []MaintenanceWindow
+	// Template renders the 503 page for clients accepting HTML, executed with
+	// MaintenanceData. Other clients, or all clients when Template is nil, receive
+	// the error format's 503 response.
+	Template *template.Template
+}
+
+// MaintenanceData is passed to MaintenanceOptions.Template.
+type MaintenanceData struct {
+	// Until is the time the current window ends.
+	Until time.Time
+}
+
+// Maintenance takes routes registered afterwards on this Mux offline during the
+// configured windows. Requests in a window receive 503 Service Unavailable with a
+// Retry-After header pointing at the end of the window, without running the
+// routes' middleware. Routes come back automatically when the window ends, and
+// routes outside the group are unaffected. Returns the Mux instance for method
+// chaining.
+func (m *Mux) Maintenance(opts MaintenanceOptions) *Mux {
+	for _, w := range opts.Windows {
+		if w == nil {
+			panic("chain: nil window passed to Maintenance")
+		}
+	}
+	opts.Windows = slices.Clone(opts.Windows)
+	m.maintenance = &opts
+	return m
+}
+
+// gateMaintenance wraps handler with the Mux's maintenance windows, if any.
+// Other handlers are returned unchanged.
+func (m *Mux) gateMaintenance(handler http.Handler) http.Handler {
+	if m.maintenance == nil {
+		return handler
+	}
+	opts := m.maintenance
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		now := time.Now()
+		for _, window := range opts.Windows {
+			if end, ok := window.Active(now); ok {
+				writeMaintenance(w, r, opts.Template, end)
+				return
+			}
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// writeMaintenance responds with 503 until end, using tmpl for HTML clients.
+func writeMaintenance(w http.ResponseWriter, r *http.Request, tmpl *template.Template, end time.Time) {
+	retry := RetryAfterUntil(end)
+	if tmpl == nil || !strings.Contains(r.Header.Get("Accept"), "text/html") {
+		WriteRetryAfter(w, r, http.StatusServiceUnavailable, retry, "")
+		return
+	}
+
+	// Render first so that a template error can still produce a 503
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, MaintenanceData{Until: end}); err != nil {
+		WriteRetryAfter(w, r, http.StatusServiceUnavailable, retry, "")
+		return
+	}
+	h := w.Header()
+	h.Set("Content-Type", "text/html; charset=utf-8")
+	h.Set("Cache-Control", "no-store")
+	if retry > 0 {
+		h.Set("Retry-After", strconv.Itoa(retryAfterSeconds(retry)))
+	}
+	w.WriteHeader(http.StatusServiceUnavailable)
+	w.Write(buf.Bytes())
+}
@@ -0,0 +1,95 @@
+package chain_test
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jpl-au/chain"
+)
+
+func TestMaintenance(t *testing.T) {
+	now := time.Now()
+	page := template.Must(template.New("maintenance").Parse(`<p>Back at {{.Until.Format "15:04"}}</p>`))
+
+	mux := chain.New()
+	mux.Route("/api", func(api *chain.Mux) {
+		api.Maintenance(chain.MaintenanceOptions{
+			Windows:  []chain.MaintenanceWindow{chain.FixedWindow{Start: now.Add(-time.Minute), End: now.Add(time.Hour)}},
+			Template: page,
+		})
+		api.HandleFunc("POST /import", func(w http.ResponseWriter, r *http.Request) {})
+	})
+	mux.Route("/later", func(later *chain.Mux) {
+		later.Maintenance(chain.MaintenanceOptions{
+			Windows: []chain.MaintenanceWindow{chain.FixedWindow{Start: now.Add(time.Hour), End: now.Add(2 * time.Hour)}},
+		})
+		later.HandleFunc("GET /", func(w http.ResponseWriter, r *http.Request) {})
+	})
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {})
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("POST", "/api/import", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected 503 during window, got %d", rec.Code)
+	}
+	retry, _ := strconv.Atoi(rec.Header().Get("Retry-After"))
+	if retry < 3590 || retry > 3600 {
+		t.Errorf("Expected Retry-After near an hour, got '%s'", rec.Header().Get("Retry-After"))
+	}
+	if strings.Contains(rec.Body.String(), "Back at") {
+		t.Error("Expected the page only for HTML clients")
+	}
+
+	req := httptest.NewRequest("POST", "/api/import", nil)
+	req.Header.Set("Accept", "text/html")
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if want := "Back at " + now.Add(time.Hour).Format("15:04"); !strings.Contains(rec.Body.String(), want) {
+		t.Errorf("Expected %q in page, got %q", want, rec.Body.String())
+	}
+
+	for _, path := range []string{"/later/", "/healthz"} {
+		rec = httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		if rec.Code != http.StatusOK {
+			t.Errorf("%s: expected 200 outside a window, got %d", path, rec.Code)
+		}
+	}
+}
+
+func TestDailyWindow(t *testing.T) {
+	utc := time.UTC
+	nightly := chain.DailyWindow{Start: 23 * time.Hour, Duration: 2 * time.Hour, Location: utc}
+
+	tests := []struct {
+		at     time.Time
+		active bool
+		end    time.Time
+	}{
+		{time.Date(2024, 5, 1, 22, 59, 0, 0, utc), false, time.Time{}},
+		{time.Date(2024, 5, 1, 23, 30, 0, 0, utc), true, time.Date(2024, 5, 2, 1, 0, 0, 0, utc)},
+		{time.Date(2024, 5, 2, 0, 30, 0, 0, utc), true, time.Date(2024, 5, 2, 1, 0, 0, 0, utc)},
+		{time.Date(2024, 5, 2, 1, 0, 0, 0, utc), false, time.Time{}},
+	}
+	for _, tt := range tests {
+		end, ok := nightly.Active(tt.at)
+		if ok != tt.active || !end.Equal(tt.end) {
+			t.Errorf("%v: expected (%v, %v), got (%v, %v)", tt.at, tt.end, tt.active, end, ok)
+		}
+	}
+
+	// 2024-05-01 is a Wednesday
+	weekly := chain.DailyWindow{Start: 23 * time.Hour, Duration: 2 * time.Hour, Weekdays: []time.Weekday{time.Wednesday}, Location: utc}
+	if _, ok := weekly.Active(time.Date(2024, 5, 2, 0, 30, 0, 0, utc)); !ok {
+		t.Error("Expected window opened on Wednesday to stay open past midnight")
+	}
+	weekly.Weekdays = []time.Weekday{time.Tuesday}
+	if _, ok := weekly.Active(time.Date(2024, 5, 1, 23, 30, 0, 0, utc)); ok {
+		t.Error("Expected window closed on other weekdays")
+	}
+}
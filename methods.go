@@ -0,0 +1,64 @@
+package chain
+
+import (
+	"net/http"
+	"strings"
+)
+
+// DefaultAllowedMethods is the allowlist used by WithAllowedMethods when called
+// without arguments. TRACE and CONNECT are deliberately excluded.
+var DefaultAllowedMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+	http.MethodOptions,
+}
+
+// standardMethods are the methods defined by RFC 9110 and RFC 5789.
+var standardMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodPatch:   true,
+	http.MethodDelete:  true,
+	http.MethodConnect: true,
+	http.MethodOptions: true,
+	http.MethodTrace:   true,
+}
+
+// WithAllowedMethods enables strict method handling. Requests are checked before
+// routing: methods outside the standard set receive 501 Not Implemented, and standard
+// methods missing from the allowlist receive 405 Method Not Allowed (through the
+// handler set by WithMethodNotAllowed, if any). With no arguments the allowlist is
+// DefaultAllowedMethods. Returns the Mux instance for chaining.
+func (m *Mux) WithAllowedMethods(methods ...string) *Mux {
+	if len(methods) == 0 {
+		methods = DefaultAllowedMethods
+	}
+	allowed := make(map[string]bool, len(methods))
+	for _, method := range methods {
+		allowed[method] = true
+	}
+	m.root.allowedMethods = allowed
+	m.root.allowHeader = strings.Join(methods, ", ")
+	return m
+}
+
+// checkMethod reports whether the request method may be routed. If not, it writes
+// the rejection response.
+func (m *Mux) checkMethod(w http.ResponseWriter, r *http.Request) bool {
+	if m.allowedMethods == nil || m.allowedMethods[r.Method] {
+		return true
+	}
+	if !standardMethods[r.Method] {
+		WriteError(w, r, http.StatusNotImplemented, "")
+		return false
+	}
+	w.Header().Set("Allow", m.allowHeader)
+	WriteError(w, r, http.StatusMethodNotAllowed, "")
+	return false
+}
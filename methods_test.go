@@ -0,0 +1,64 @@
+package chain_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jpl-au/chain"
+)
+
+func TestWithAllowedMethods(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		status int
+	}{
+		{"allowed", "GET", http.StatusOK},
+		{"trace rejected", "TRACE", http.StatusMethodNotAllowed},
+		{"unknown verb", "BREW", http.StatusNotImplemented},
+	}
+
+	mux := chain.New().WithAllowedMethods()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(tt.method, "/", nil))
+			if rec.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, rec.Code)
+			}
+		})
+	}
+}
+
+func TestWithAllowedMethodsCustomList(t *testing.T) {
+	mux := chain.New().
+		WithAllowedMethods("GET", "HEAD").
+		WithMethodNotAllowed(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			w.Write([]byte("Custom 405"))
+		}))
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {})
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("POST", "/", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status 405, got %d", rec.Code)
+	}
+	if rec.Body.String() != "Custom 405" {
+		t.Errorf("Expected body 'Custom 405', got '%s'", rec.Body.String())
+	}
+}
+
+func TestMethodsUnrestrictedByDefault(t *testing.T) {
+	mux := chain.New()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {})
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("BREW", "/", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", rec.Code)
+	}
+}
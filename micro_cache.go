@@ -0,0 +1,128 @@
+package chain
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// microCacheEntries bounds the number of responses MicroCache stores.
+const microCacheEntries = 100
+
+// microEntry is a response cached by MicroCache.
+type microEntry struct {
+	header  http.Header
+	body    []byte
+	expires time.Time
+}
+
+// microSlot holds the latest response for one cache key.
+type microSlot struct {
+	entry      atomic.Pointer[microEntry]
+	refreshing atomic.Bool
+	fill       chan struct{} // closed once the first fill completes
+}
+
+// MicroCache returns middleware that caches successful GET and HEAD responses for
+// ttl (defaulting to one second), for nearly static endpoints such as
+// configuration and feature flags that many clients poll. It is a lighter
+// alternative to ResponseCache: a hit is a lock-free lookup, and once an entry
+// expires the next request refreshes it while concurrent requests keep
+// receiving the previous copy. Requests arriving before the first response is
+// cached wait for it rather than all reaching the handler.
+//
+// Responses are keyed by CacheKey only, so MicroCache must not be used for
+// responses that vary by user or by request header. At most 100 keys are cached:
+// once full, expired entries are evicted to make room for new keys, and while
+// every entry is fresh requests for other keys pass through. Responses are marked
+// with an X-Cache header of HIT, STALE or MISS, and those ResponseCache would not
+// store are not cached.
+func MicroCache(ttl time.Duration) func(http.Handler) http.Handler {
+	if ttl <= 0 {
+		ttl = time.Second
+	}
+	var (
+		slots sync.Map // cache key -> *microSlot
+		count atomic.Int32
+	)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+			key := CacheKey(w, r)
+			v, ok := slots.Load(key)
+			if !ok {
+				if count.Load() >= microCacheEntries && evictMicroSlots(&slots, &count) == 0 {
+					next.ServeHTTP(w, r)
+					return
+				}
+				slot := &microSlot{fill: make(chan struct{})}
+				if v, ok = slots.LoadOrStore(key, slot); !ok {
+					// This request fills the new slot while others wait for it
+					count.Add(1)
+					defer func() {
+						if slot.entry.Load() == nil {
+							slots.Delete(key)
+							count.Add(-1)
+						}
+						close(slot.fill)
+					}()
+					slot.refresh(w, r, next, ttl)
+					return
+				}
+			}
+
+			slot := v.(*microSlot)
+			<-slot.fill
+			e := slot.entry.Load()
+			switch {
+			case e == nil:
+				// The first response was not cacheable
+				next.ServeHTTP(w, r)
+			case time.Now().Before(e.expires):
+				writeResponse(w, http.StatusOK, e.header, e.body, "HIT")
+			case slot.refreshing.CompareAndSwap(false, true):
+				defer slot.refreshing.Store(false)
+				slot.refresh(w, r, next, ttl)
+			default:
+				writeResponse(w, http.StatusOK, e.header, e.body, "STALE")
+			}
+		})
+	}
+}
+
+// evictMicroSlots removes the slots whose entries have expired and are not being
+// refreshed, returning the number removed. Slots still waiting for their first
+// fill are kept.
+func evictMicroSlots(slots *sync.Map, count *atomic.Int32) int {
+	now := time.Now()
+	removed := 0
+	slots.Range(func(key, v any) bool {
+		slot := v.(*microSlot)
+		if e := slot.entry.Load(); e != nil && !now.Before(e.expires) && !slot.refreshing.Load() {
+			if slots.CompareAndDelete(key, slot) {
+				count.Add(-1)
+				removed++
+			}
+		}
+		return true
+	})
+	return removed
+}
+
+// refresh serves r from next, storing the response if it is cacheable. An
+// uncacheable response leaves the previous entry in place.
+func (s *microSlot) refresh(w http.ResponseWriter, r *http.Request, next http.Handler, ttl time.Duration) {
+	// The buffer starts without the headers set by earlier middleware so that
+	// request-specific values are not stored with the response
+	buf := newBufferedResponse(http.Header{})
+	next.ServeHTTP(buf, r)
+	if cacheable(buf) {
+		s.entry.Store(&microEntry{header: buf.header.Clone(), body: buf.body.Bytes(), expires: time.Now().Add(ttl)})
+	}
+	writeResponse(w, buf.Status(), buf.header, buf.body.Bytes(), "MISS")
+}
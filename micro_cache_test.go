@@ -0,0 +1,152 @@
+package chain_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jpl-au/chain"
+)
+
+func TestMicroCache(t *testing.T) {
+	var calls atomic.Int32
+	mux := chain.New().Use(chain.MicroCache(time.Hour))
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		w.Write([]byte("v" + strconv.Itoa(int(n))))
+	})
+	mux.HandleFunc("/private", func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Cache-Control", "no-store")
+	})
+
+	tests := []struct {
+		method string
+		path   string
+		xcache string
+		body   string
+	}{
+		{"GET", "/", "MISS", "v1"},
+		{"GET", "/", "HIT", "v1"},
+		{"GET", "/?page=2", "MISS", "v2"},
+		{"POST", "/", "", "v3"},
+		{"GET", "/private", "MISS", ""},
+		{"GET", "/private", "MISS", ""},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+		if got := rec.Header().Get("X-Cache"); got != tt.xcache {
+			t.Errorf("%s %s: expected X-Cache '%s', got '%s'", tt.method, tt.path, tt.xcache, got)
+		}
+		if rec.Body.String() != tt.body {
+			t.Errorf("%s %s: expected body '%s', got '%s'", tt.method, tt.path, tt.body, rec.Body.String())
+		}
+	}
+	if calls.Load() != 5 {
+		t.Errorf("Expected 5 handler calls, got %d", calls.Load())
+	}
+}
+
+func TestMicroCacheStale(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	mux := chain.New().Use(chain.MicroCache(10 * time.Millisecond))
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		if n == 2 {
+			<-release
+		}
+		w.Write([]byte("v" + strconv.Itoa(int(n))))
+	})
+
+	get := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+		return rec
+	}
+	get()
+	time.Sleep(20 * time.Millisecond)
+
+	// The first request after expiry refreshes while others get the old copy
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- get() }()
+	for calls.Load() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	if rec := get(); rec.Header().Get("X-Cache") != "STALE" || rec.Body.String() != "v1" {
+		t.Errorf("Expected STALE v1, got %s %s", rec.Header().Get("X-Cache"), rec.Body.String())
+	}
+	close(release)
+	if rec := <-done; rec.Header().Get("X-Cache") != "MISS" || rec.Body.String() != "v2" {
+		t.Errorf("Expected MISS v2, got %s %s", rec.Header().Get("X-Cache"), rec.Body.String())
+	}
+	if rec := get(); rec.Header().Get("X-Cache") != "HIT" || rec.Body.String() != "v2" {
+		t.Errorf("Expected HIT v2, got %s %s", rec.Header().Get("X-Cache"), rec.Body.String())
+	}
+}
+
+func TestMicroCacheFirstFill(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	mux := chain.New().Use(chain.MicroCache(time.Hour))
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		<-release
+		w.Write([]byte("config"))
+	})
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+			if rec.Body.String() != "config" {
+				t.Errorf("Expected 'config', got '%s'", rec.Body.String())
+			}
+		}()
+	}
+	for calls.Load() < 1 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if calls.Load() != 1 {
+		t.Errorf("Expected 1 handler call, got %d", calls.Load())
+	}
+}
+
+func TestMicroCacheEviction(t *testing.T) {
+	mux := chain.New().Use(chain.MicroCache(20 * time.Millisecond))
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {})
+
+	get := func(target string) string {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest("GET", target, nil))
+		return rec.Header().Get("X-Cache")
+	}
+
+	// Fill every slot with junk query strings
+	for i := range 100 {
+		get("/?junk=" + strconv.Itoa(i))
+	}
+	if got := get("/?page=1"); got != "" {
+		t.Errorf("Expected a new key to pass through while every entry is fresh, got X-Cache %q", got)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if got := get("/?page=1"); got != "MISS" {
+		t.Errorf("Expected expired entries evicted for a new key, got X-Cache %q", got)
+	}
+	if got := get("/?page=1"); got != "HIT" {
+		t.Errorf("Expected the new key cached, got X-Cache %q", got)
+	}
+}
@@ -0,0 +1,107 @@
+package chain
+
+import (
+	"fmt"
+	"time"
+)
+
+// MiddlewareConfig selects and tunes the built-in middleware from configuration,
+// so that operators can adjust them without code changes. It is the Middleware
+// section of a Config and is applied by FromConfig to the whole router. Zero
+// values leave the corresponding middleware out.
+type MiddlewareConfig struct {
+	// Recover installs Recoverer, reporting to the router's Reporter.
+	Recover bool `json:"recover,omitempty" yaml:"recover,omitempty"`
+	// Trace installs Trace.
+	Trace bool `json:"trace,omitempty" yaml:"trace,omitempty"`
+	// AllowedMethods enables strict method handling with WithAllowedMethods. An
+	// empty list in the configuration, as opposed to an absent one, selects
+	// DefaultAllowedMethods.
+	AllowedMethods *[]string `json:"allowedMethods,omitempty" yaml:"allowedMethods,omitempty"`
+	// Headers installs StandardHeaders.
+	Headers *HeadersConfig `json:"headers,omitempty" yaml:"headers,omitempty"`
+	// SlowRequests installs SlowRequests with this threshold, such as "500ms".
+	SlowRequests Duration `json:"slowRequests,omitempty" yaml:"slowRequests,omitempty"`
+	// Minify installs Minify with the default minifiers.
+	Minify bool `json:"minify,omitempty" yaml:"minify,omitempty"`
+	// ErrorFormat selects the error format by name: "text", "problem", "json",
+	// "html" or "negotiated".
+	ErrorFormat string `json:"errorFormat,omitempty" yaml:"errorFormat,omitempty"`
+}
+
+// HeadersConfig configures StandardHeaders from a MiddlewareConfig.
+type HeadersConfig struct {
+	Server          string            `json:"server,omitempty" yaml:"server,omitempty"`
+	RequestIDHeader string            `json:"requestIdHeader,omitempty" yaml:"requestIdHeader,omitempty"`
+	ResponseTime    bool              `json:"responseTime,omitempty" yaml:"responseTime,omitempty"`
+	Static          map[string]string `json:"static,omitempty" yaml:"static,omitempty"`
+}
+
+// Duration is a time.Duration written in configuration as a string such as "1.5s"
+// or "200ms".
+type Duration time.Duration
+
+// MarshalText implements encoding.TextMarshaler.
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// errorFormats maps MiddlewareConfig.ErrorFormat names to formatters.
+var errorFormats = map[string]ErrorFormatter{
+	"text":       TextErrors,
+	"problem":    ProblemErrors,
+	"json":       JSONErrors,
+	"html":       HTMLErrors,
+	"negotiated": NegotiatedErrors,
+}
+
+// applyMiddlewareConfig installs the middleware selected by cfg on m's root.
+// Pre-routing middleware is added in a fixed order: headers, tracing, then slow
+// request logging, so that every response carries the headers and the trace.
+func (m *Mux) applyMiddlewareConfig(cfg MiddlewareConfig) error {
+	root := m.root
+	if cfg.ErrorFormat != "" {
+		f, ok := errorFormats[cfg.ErrorFormat]
+		if !ok {
+			return fmt.Errorf("chain: unknown error format %q", cfg.ErrorFormat)
+		}
+		root.WithErrorFormat(f)
+	}
+	if cfg.AllowedMethods != nil {
+		root.WithAllowedMethods(*cfg.AllowedMethods...)
+	}
+	if h := cfg.Headers; h != nil {
+		root.UsePre(StandardHeaders(StandardHeaderOptions{
+			Server:          h.Server,
+			RequestIDHeader: h.RequestIDHeader,
+			ResponseTime:    h.ResponseTime,
+			Static:          h.Static,
+		}))
+	}
+	if cfg.Trace {
+		root.UsePre(Trace())
+	}
+	if cfg.SlowRequests > 0 {
+		root.UsePre(SlowRequests(SlowRequestOptions{
+			Threshold: time.Duration(cfg.SlowRequests),
+			Logger:    root.log(),
+		}))
+	}
+	if cfg.Recover {
+		root.Use(recoverer{mux: root}.middleware)
+	}
+	if cfg.Minify {
+		root.Use(Minify(MinifyOptions{}))
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package chain
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+)
+
+// Minifier shrinks a response body of a given media type. Implementations may wrap
+// a full minification library to cover CSS and JavaScript.
+type Minifier interface {
+	Minify(mediaType string, body []byte) ([]byte, error)
+}
+
+// MinifierFunc adapts a function to the Minifier interface.
+type MinifierFunc func(mediaType string, body []byte) ([]byte, error)
+
+// Minify calls f(mediaType, body).
+func (f MinifierFunc) Minify(mediaType string, body []byte) ([]byte, error) {
+	return f(mediaType, body)
+}
+
+// MinifyOptions configures the Minify middleware.
+type MinifyOptions struct {
+	// Minifiers maps media types, such as "text/css", to the minifier used for them.
+	// Defaults to JSONMinifier for application/json and HTMLMinifier for text/html.
+	Minifiers map[string]Minifier
+	// MinSize is the smallest body, in bytes, worth minifying. Defaults to 512.
+	MinSize int
+	// MaxSize is the largest body, in bytes, that will be minified. Larger bodies are
+	// sent unchanged. Defaults to 4 MiB.
+	MaxSize int
+}
+
+// Minify returns middleware that minifies responses whose Content-Type has a
+// configured minifier. The response is buffered in memory, so streaming handlers
+// should not be wrapped. Bodies outside the size thresholds, responses that already
+// carry a Content-Encoding, and bodies a minifier fails on are sent unchanged.
+func Minify(opts MinifyOptions) func(http.Handler) http.Handler {
+	if opts.Minifiers == nil {
+		opts.Minifiers = map[string]Minifier{
+			"application/json": JSONMinifier,
+			"text/html":        HTMLMinifier,
+		}
+	}
+	if opts.MinSize <= 0 {
+		opts.MinSize = 512
+	}
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = 4 << 20
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			buf := newBufferedResponse(w.Header())
+			next.ServeHTTP(buf, r)
+
+			body := buf.body.Bytes()
+			if len(body) >= opts.MinSize && len(body) <= opts.MaxSize && buf.header.Get("Content-Encoding") == "" {
+				mediaType, _, _ := mime.ParseMediaType(buf.header.Get("Content-Type"))
+				if m, ok := opts.Minifiers[mediaType]; ok {
+					if out, err := m.Minify(mediaType, body); err == nil {
+						body = out
+					}
+				}
+			}
+			buf.writeTo(w, body)
+		})
+	}
+}
+
+// JSONMinifier removes insignificant whitespace from JSON.
+var JSONMinifier Minifier = MinifierFunc(func(_ string, body []byte) ([]byte, error) {
+	var b bytes.Buffer
+	if err := json.Compact(&b, body); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+})
+
+// HTMLMinifier is a conservative HTML minifier. It removes comments and collapses
+// runs of whitespace to a single space, or a single newline if the run contained
+// one, leaving the contents of pre, textarea, script and style elements untouched.
+// Conditional comments ("<!--[if") are kept. Whitespace inside attribute values is
+// collapsed as well.
+var HTMLMinifier Minifier = MinifierFunc(func(_ string, body []byte) ([]byte, error) {
+	return minifyHTML(body), nil
+})
+
+// rawTextElements are the elements whose contents HTMLMinifier leaves untouched.
+var rawTextElements = []string{"pre", "textarea", "script", "style"}
+
+// minifyHTML implements HTMLMinifier.
+func minifyHTML(src []byte) []byte {
+	out := make([]byte, 0, len(src))
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '<' && bytes.HasPrefix(src[i:], []byte("<!--")) && !bytes.HasPrefix(src[i:], []byte("<!--[if")):
+			end := bytes.Index(src[i+4:], []byte("-->"))
+			if end < 0 {
+				return append(out, src[i:]...)
+			}
+			i += 4 + end + 3
+
+		case c == '<':
+			if name := rawTextElement(src[i:]); name != "" {
+				end := indexFold(src[i:], "</"+name)
+				if end < 0 {
+					return append(out, src[i:]...)
+				}
+				out = append(out, src[i:i+end]...)
+				i += end
+				continue
+			}
+			out = append(out, c)
+			i++
+
+		case isHTMLSpace(c):
+			newline := false
+			for i < len(src) && isHTMLSpace(src[i]) {
+				newline = newline || src[i] == '\n'
+				i++
+			}
+			// A removed comment can leave two runs adjacent, so merge with the last one
+			if n := len(out); n > 0 && (out[n-1] == ' ' || out[n-1] == '\n') {
+				if newline {
+					out[n-1] = '\n'
+				}
+			} else if newline {
+				out = append(out, '\n')
+			} else {
+				out = append(out, ' ')
+			}
+
+		default:
+			out = append(out, c)
+			i++
+		}
+	}
+	return out
+}
+
+// rawTextElement returns the name of the raw text element opened at the start of
+// src, or an empty string.
+func rawTextElement(src []byte) string {
+	for _, name := range rawTextElements {
+		tag := []byte("<" + name)
+		if len(src) > len(tag) && bytes.EqualFold(src[:len(tag)], tag) {
+			switch src[len(tag)] {
+			case '>', ' ', '\t', '\n', '\r', '\f', '/':
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+// indexFold returns the index of the first case-insensitive occurrence of substr
+// in s, or -1.
+func indexFold(s []byte, substr string) int {
+	sub := []byte(substr)
+	for i := 0; i+len(sub) <= len(s); i++ {
+		if bytes.EqualFold(s[i:i+len(sub)], sub) {
+			return i
+		}
+	}
+	return -1
+}
+
+// isHTMLSpace reports whether c is HTML whitespace.
+func isHTMLSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
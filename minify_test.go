@@ -0,0 +1,85 @@
+package chain_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/jpl-au/chain"
+)
+
+func TestMinify(t *testing.T) {
+	html := "<html>\n  <body>\n    <!-- note -->\n    <p>Hello,   world</p>\n" +
+		"    <pre>  keep\n   this </pre>\n  </body>\n</html>\n"
+	wantHTML := "<html>\n<body>\n<p>Hello, world</p>\n<pre>  keep\n   this </pre>\n</body>\n</html>\n"
+
+	mux := chain.New().Use(chain.Minify(chain.MinifyOptions{MinSize: 1}))
+	mux.HandleFunc("GET /page", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(html))
+	})
+	mux.HandleFunc("GET /data", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte("{\n  \"name\": \"a b\",\n  \"n\": [1, 2]\n}\n"))
+	})
+	mux.HandleFunc("GET /text", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("a   b"))
+	})
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/page", wantHTML},
+		{"/data", `{"name":"a b","n":[1,2]}`},
+		{"/text", "a   b"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest("GET", tt.path, nil))
+		if rec.Body.String() != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.path, tt.want, rec.Body.String())
+		}
+		if cl := rec.Header().Get("Content-Length"); cl != strconv.Itoa(len(tt.want)) {
+			t.Errorf("%s: expected Content-Length %d, got %s", tt.path, len(tt.want), cl)
+		}
+	}
+}
+
+func TestMinifyThresholds(t *testing.T) {
+	body := "{ \"a\": 1 }"
+	mux := chain.New().Use(chain.Minify(chain.MinifyOptions{MinSize: 100}))
+	mux.HandleFunc("GET /", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	})
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if rec.Body.String() != body {
+		t.Errorf("Expected small body unchanged, got %q", rec.Body.String())
+	}
+}
+
+func TestMinifyCustomMinifier(t *testing.T) {
+	css := chain.MinifierFunc(func(mediaType string, body []byte) ([]byte, error) {
+		return []byte(strings.Join(strings.Fields(string(body)), "")), nil
+	})
+	mux := chain.New().Use(chain.Minify(chain.MinifyOptions{
+		MinSize:   1,
+		Minifiers: map[string]chain.Minifier{"text/css": css},
+	}))
+	mux.HandleFunc("GET /app.css", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/css")
+		w.Write([]byte("body {\n  margin: 0;\n}\n"))
+	})
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/app.css", nil))
+	if rec.Body.String() != "body{margin:0;}" {
+		t.Errorf("Expected custom minifier output, got %q", rec.Body.String())
+	}
+}
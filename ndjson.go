@@ -0,0 +1,148 @@
+package chain
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// streamFlushInterval is how often streaming encoders push buffered data to the
+// client.
+const streamFlushInterval = 100 * time.Millisecond
+
+// NDJSONEncoder streams values as newline-delimited JSON. It is not safe for
+// concurrent use.
+type NDJSONEncoder struct {
+	w   http.ResponseWriter
+	r   *http.Request
+	buf *bufio.Writer
+	enc *json.Encoder
+
+	// mu guards the encoder against the timer flushing pending lines
+	mu      sync.Mutex
+	flushed time.Time
+	timer   *time.Timer
+	closed  bool
+	sent    bool
+	err     error
+}
+
+// NDJSON returns an encoder writing an application/x-ndjson response to w. Lines
+// are buffered and reach the client within 100ms of being sent, so a slow producer
+// still delivers rows promptly while a fast one avoids a flush per row. Callers
+// must call Close when done, and must not use w again until they have:
+//
+//	stream := chain.NDJSON(w, r)
+//	for rows.Next() {
+//		if err := stream.Send(row); err != nil {
+//			return stream.Close()
+//		}
+//	}
+//	return stream.Close()
+func NDJSON(w http.ResponseWriter, r *http.Request) *NDJSONEncoder {
+	if w == nil || r == nil {
+		panic("chain: nil writer or request passed to NDJSON")
+	}
+	e := &NDJSONEncoder{w: w, r: r, flushed: time.Now()}
+	e.buf = bufio.NewWriter(ndjsonWriter{e})
+	e.enc = json.NewEncoder(e.buf)
+	return e
+}
+
+// ndjsonWriter sets the response headers before the first bytes are sent.
+type ndjsonWriter struct{ e *NDJSONEncoder }
+
+func (nw ndjsonWriter) Write(p []byte) (int, error) {
+	if !nw.e.sent {
+		nw.e.sent = true
+		h := nw.e.w.Header()
+		h.Set("Content-Type", "application/x-ndjson")
+		h.Set("X-Content-Type-Options", "nosniff")
+	}
+	return nw.e.w.Write(p)
+}
+
+// Send writes v as one line. It returns the request context's error once the
+// client has gone away, so that producers stop early, and any encoding or write
+// error. Errors are sticky: after one, Send does nothing and returns it again.
+func (e *NDJSONEncoder) Send(v any) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.err != nil {
+		return e.err
+	}
+	if err := e.r.Context().Err(); err != nil {
+		e.err = err
+		return err
+	}
+	if err := e.enc.Encode(v); err != nil {
+		e.err = err
+		return err
+	}
+	since := time.Since(e.flushed)
+	if since >= streamFlushInterval {
+		return e.flush()
+	}
+	if e.timer == nil {
+		e.timer = time.AfterFunc(streamFlushInterval-since, e.flushPending)
+	}
+	return nil
+}
+
+// flushPending flushes lines left buffered by Send, once the flush interval has
+// passed without another Send doing so.
+func (e *NDJSONEncoder) flushPending() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.timer = nil
+	if !e.closed && e.err == nil && e.buf.Buffered() > 0 {
+		e.flush()
+	}
+}
+
+// Flush sends buffered lines to the client.
+func (e *NDJSONEncoder) Flush() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.flush()
+}
+
+// flush is Flush with mu held.
+func (e *NDJSONEncoder) flush() error {
+	if e.err != nil {
+		return e.err
+	}
+	if err := e.buf.Flush(); err != nil {
+		e.err = err
+		return err
+	}
+	e.flushed = time.Now()
+	if err := http.NewResponseController(e.w).Flush(); err != nil && err != http.ErrNotSupported {
+		e.err = err
+	}
+	return e.err
+}
+
+// Close flushes the remaining lines and returns the first error encountered. If
+// the stream failed before anything reached the client, for example because the
+// first value could not be encoded, Close writes a 500 response through the error
+// format instead, so the failure is not mistaken for an empty result. Errors
+// caused by the client going away are returned but produce no response.
+func (e *NDJSONEncoder) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.closed = true
+	if e.timer != nil {
+		e.timer.Stop()
+		e.timer = nil
+	}
+	if e.err == nil {
+		e.flush()
+	}
+	if e.err != nil && !e.sent && e.r.Context().Err() == nil {
+		WriteError(e.w, e.r, http.StatusInternalServerError, "")
+	}
+	return e.err
+}
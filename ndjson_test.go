@@ -0,0 +1,117 @@
+package chain_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jpl-au/chain"
+)
+
+func TestNDJSON(t *testing.T) {
+	rec := httptest.NewRecorder()
+	stream := chain.NDJSON(rec, httptest.NewRequest("GET", "/export", nil))
+	for i := range 3 {
+		if err := stream.Send(map[string]int{"id": i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := stream.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if ct := rec.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Errorf("Expected application/x-ndjson, got '%s'", ct)
+	}
+	if !rec.Flushed {
+		t.Error("Expected response flushed")
+	}
+	scanner := bufio.NewScanner(rec.Body)
+	n := 0
+	for scanner.Scan() {
+		var row map[string]int
+		if err := json.Unmarshal(scanner.Bytes(), &row); err != nil || row["id"] != n {
+			t.Errorf("Unexpected line %q", scanner.Text())
+		}
+		n++
+	}
+	if n != 3 {
+		t.Errorf("Expected 3 lines, got %d", n)
+	}
+}
+
+func TestNDJSONCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest("GET", "/export", nil).WithContext(ctx)
+	stream := chain.NDJSON(httptest.NewRecorder(), req)
+	if err := stream.Send(1); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	if err := stream.Send(2); err != context.Canceled {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if err := stream.Close(); err != context.Canceled {
+		t.Errorf("Expected Close to return context.Canceled, got %v", err)
+	}
+}
+
+func TestNDJSONEncodeError(t *testing.T) {
+	mux := chain.New().WithErrorFormat(chain.ProblemErrors)
+	mux.HandleFunc("GET /export", func(w http.ResponseWriter, r *http.Request) {
+		stream := chain.NDJSON(w, r)
+		if err := stream.Send(func() {}); err == nil {
+			t.Error("Expected encoding error")
+		}
+		stream.Close()
+	})
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/export", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("Expected 500, got %d", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/problem+json" {
+		t.Errorf("Expected error through the error format, got '%s'", ct)
+	}
+}
+
+func TestNDJSONSlowProducer(t *testing.T) {
+	// The second row is only sent once the client has read the first, which
+	// must therefore be flushed without waiting for another Send
+	read := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		stream := chain.NDJSON(w, r)
+		stream.Send(map[string]int{"id": 1})
+		select {
+		case <-read:
+		case <-time.After(2 * time.Second):
+		}
+		stream.Send(map[string]int{"id": 2})
+		stream.Close()
+	}))
+	defer srv.Close()
+
+	start := time.Now()
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	lines := bufio.NewScanner(resp.Body)
+	if !lines.Scan() || lines.Text() != `{"id":1}` {
+		t.Fatalf("Expected first row, got %q", lines.Text())
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected first row within the flush interval, took %v", elapsed)
+	}
+	close(read)
+	if !lines.Scan() || lines.Text() != `{"id":2}` {
+		t.Errorf("Expected second row, got %q", lines.Text())
+	}
+}
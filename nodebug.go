@@ -0,0 +1,63 @@
+//go:build chain_nodebug
+
+package chain
+
+import (
+	"io"
+	"net/http"
+)
+
+// Debug reports whether the debug features are compiled in, which they are
+// unless the chain_nodebug build tag is set.
+const Debug = false
+
+// Dump is compiled out by the chain_nodebug tag and returns middleware that calls
+// the next handler directly.
+func Dump(out io.Writer, opts DumpOptions) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler { return next }
+}
+
+// NewRequestLog is compiled out by the chain_nodebug tag and returns a RequestLog
+// that records nothing.
+func NewRequestLog(opts RequestLogOptions) *RequestLog {
+	return &RequestLog{opts: opts}
+}
+
+// Middleware returns middleware that calls the next handler directly.
+func (l *RequestLog) Middleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler { return next }
+}
+
+// RecordError does nothing.
+func RecordError(r *http.Request, err error) {}
+
+// Entries returns nil.
+func (l *RequestLog) Entries() []RequestEntry {
+	return nil
+}
+
+// ServeHTTP responds with 404 Not Found.
+func (l *RequestLog) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	http.NotFound(w, r)
+}
+
+// NewSchemaRecorder is compiled out by the chain_nodebug tag and returns a
+// SchemaRecorder that records nothing.
+func NewSchemaRecorder(opts SchemaOptions) *SchemaRecorder {
+	return &SchemaRecorder{opts: opts}
+}
+
+// Middleware returns middleware that calls the next handler directly.
+func (s *SchemaRecorder) Middleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler { return next }
+}
+
+// Schemas returns nil.
+func (s *SchemaRecorder) Schemas() []RouteSchema {
+	return nil
+}
+
+// ServeHTTP responds with 404 Not Found.
+func (s *SchemaRecorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	http.NotFound(w, r)
+}
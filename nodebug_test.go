@@ -0,0 +1,38 @@
+//go:build chain_nodebug
+
+package chain_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jpl-au/chain"
+)
+
+func TestNoDebug(t *testing.T) {
+	if chain.Debug {
+		t.Fatal("Debug = true with chain_nodebug")
+	}
+	var out bytes.Buffer
+	requests := chain.NewRequestLog(chain.RequestLogOptions{})
+	schemas := chain.NewSchemaRecorder(chain.SchemaOptions{})
+	mux := chain.New()
+	mux.UsePre(requests.Middleware(), schemas.Middleware())
+	mux.Use(chain.Dump(&out, chain.DumpOptions{}))
+	mux.HandleFunc("GET /", func(w http.ResponseWriter, r *http.Request) {
+		chain.RecordError(r, http.ErrAbortHandler)
+	})
+	mux.Handle("GET /debug/requests", requests)
+
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	if out.Len() != 0 || len(requests.Entries()) != 0 || len(schemas.Schemas()) != 0 {
+		t.Errorf("debug features ran: dump %q, %d entries, %d schemas", out.String(), len(requests.Entries()), len(schemas.Schemas()))
+	}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/debug/requests", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("request log status = %d, want 404", rec.Code)
+	}
+}
@@ -0,0 +1,81 @@
+package chain
+
+import (
+	"maps"
+	"net/http"
+	"testing"
+)
+
+// Override temporarily replaces the handler of the route registered under pattern,
+// the full pattern as reported by RouteTable, and returns a function that restores
+// the previous handler. The route's middleware still applies to the replacement, so
+// integration tests can stub individual endpoints of a fully built router:
+//
+//	restore := mux.Override("GET /payments/{id}", stubPayment)
+//	t.Cleanup(restore)
+//
+// Override is only available while running tests or under the Development profile
+// and panics otherwise. It also panics if no route is registered under pattern.
+func (m *Mux) Override(pattern string, handler http.Handler) (restore func()) {
+	if !testing.Testing() && m.root.profile != Development {
+		panic("chain: Override is only available in tests and development")
+	}
+	if handler == nil {
+		panic("chain: nil handler passed to Override")
+	}
+	rt, ok := m.lookup(pattern)
+	if !ok {
+		panic("chain: unknown pattern " + pattern + " passed to Override")
+	}
+	if rt.wrap != nil {
+		handler = rt.wrap(handler)
+	}
+
+	root := m.root
+	root.mu.Lock()
+	defer root.mu.Unlock()
+	prev, hadPrev := root.overrideSet(pattern, handler, true)
+
+	return func() {
+		root.mu.Lock()
+		defer root.mu.Unlock()
+		root.overrideSet(pattern, prev, hadPrev)
+	}
+}
+
+// overrideSet installs handler for pattern, or removes the override if set is
+// false, returning the previous override. The map is replaced rather than mutated
+// so that requests can read it without locking. m.mu must be held.
+func (m *Mux) overrideSet(pattern string, handler http.Handler, set bool) (http.Handler, bool) {
+	next := make(map[string]http.Handler)
+	if cur := m.overrides.Load(); cur != nil {
+		maps.Copy(next, *cur)
+	}
+	prev, hadPrev := next[pattern]
+	if set {
+		next[pattern] = handler
+	} else {
+		delete(next, pattern)
+	}
+	if len(next) == 0 {
+		m.overrides.Store(nil)
+	} else {
+		m.overrides.Store(&next)
+	}
+	return prev, hadPrev
+}
+
+// overridable returns a handler that serves the override for pattern when one is
+// installed, and handler otherwise.
+func (m *Mux) overridable(pattern string, handler http.Handler) http.Handler {
+	root := m.root
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ov := root.overrides.Load(); ov != nil {
+			if h, ok := (*ov)[pattern]; ok {
+				h.ServeHTTP(w, r)
+				return
+			}
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
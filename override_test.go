@@ -0,0 +1,54 @@
+package chain_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jpl-au/chain"
+)
+
+func TestOverride(t *testing.T) {
+	mux := chain.New()
+	mux.Route("/payments", func(p *chain.Mux) {
+		p.Use(tag("auth"))
+		p.HandleFunc("GET /{id}", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("real " + r.PathValue("id")))
+		})
+	})
+
+	get := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest("GET", "/payments/7", nil))
+		return rec
+	}
+
+	restore := mux.Override("GET /payments/{id}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("stub " + r.PathValue("id")))
+	}))
+
+	rec := get()
+	if rec.Body.String() != "stub 7" {
+		t.Errorf("Expected body 'stub 7', got '%s'", rec.Body.String())
+	}
+	if rec.Header().Get("X-Order") != "auth" {
+		t.Error("Expected route middleware to apply to the override")
+	}
+
+	restore()
+	if rec := get(); rec.Body.String() != "real 7" {
+		t.Errorf("Expected restored body 'real 7', got '%s'", rec.Body.String())
+	}
+}
+
+func TestOverrideUnknownPatternPanics(t *testing.T) {
+	defer func() {
+		r := recover()
+		msg, ok := r.(string)
+		if !ok || msg != "chain: unknown pattern GET /missing passed to Override" {
+			t.Fatalf("Unexpected panic '%v'", r)
+		}
+	}()
+
+	chain.New().Override("GET /missing", http.NotFoundHandler())
+}
@@ -0,0 +1,37 @@
+package chain_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"runtime/pprof"
+	"testing"
+
+	"github.com/jpl-au/chain"
+)
+
+func TestWithPprofLabels(t *testing.T) {
+	var route, method string
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		route, _ = pprof.Label(r.Context(), "route")
+		method, _ = pprof.Label(r.Context(), "method")
+	}
+
+	mux := chain.New()
+	mux.Route("/api", func(api *chain.Mux) {
+		api.HandleFunc("GET /users/{id}", handler)
+	})
+	mux.WithPprofLabels()
+
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/users/1", nil))
+	if route != "GET /api/users/{id}" || method != "GET" {
+		t.Errorf("Expected labels route='GET /api/users/{id}' method='GET', got '%s' '%s'", route, method)
+	}
+
+	route = ""
+	plain := chain.New()
+	plain.HandleFunc("GET /", handler)
+	plain.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	if route != "" {
+		t.Errorf("Expected no labels by default, got '%s'", route)
+	}
+}
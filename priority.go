@@ -0,0 +1,33 @@
+package chain
+
+import "net/http"
+
+// Priority ranks routes for load shedding. Higher values are preferred; any int
+// may be used, with the constants below as conventional levels.
+type Priority int
+
+// Conventional priorities. Routes default to PriorityNormal.
+const (
+	PriorityLow    Priority = -1
+	PriorityNormal Priority = 0
+	PriorityHigh   Priority = 1
+)
+
+// Priority assigns a priority to routes registered afterwards on this Mux, such
+// as PriorityHigh for health checks and payments or PriorityLow for exports.
+// LoadShed serves queued requests in priority order and, once overloaded, sheds
+// low-priority requests first. Returns the Mux instance for method chaining.
+func (m *Mux) Priority(p Priority) *Mux {
+	m.priority = p
+	return m
+}
+
+// RequestPriority returns the priority of the route serving the request written
+// to w, or PriorityNormal if no route has matched yet or w was not wrapped by
+// chain. Custom limiters registered with Use can call it to rank requests.
+func RequestPriority(w http.ResponseWriter) Priority {
+	if rw := findResponseWriter(w); rw != nil {
+		return rw.priority
+	}
+	return PriorityNormal
+}
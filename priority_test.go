@@ -0,0 +1,126 @@
+package chain_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/jpl-au/chain"
+)
+
+func TestPriorityRouteInfo(t *testing.T) {
+	mux := chain.New()
+	mux.HandleFunc("GET /a", func(w http.ResponseWriter, r *http.Request) {})
+	mux.Group(func(g *chain.Mux) {
+		g.Priority(chain.PriorityHigh)
+		g.HandleFunc("GET /health", func(w http.ResponseWriter, r *http.Request) {
+			if p := chain.RequestPriority(w); p != chain.PriorityHigh {
+				t.Errorf("RequestPriority = %d, want high", p)
+			}
+		})
+	})
+
+	want := map[string]chain.Priority{"GET /a": chain.PriorityNormal, "GET /health": chain.PriorityHigh}
+	for _, info := range mux.RouteTable() {
+		if info.Priority != want[info.Pattern] {
+			t.Errorf("%s: Priority = %d, want %d", info.Pattern, info.Priority, want[info.Pattern])
+		}
+	}
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/health", nil))
+}
+
+func TestLoadShedPriorityOrder(t *testing.T) {
+	mux := chain.New()
+	mux.Use(chain.LoadShed(chain.LoadShedOptions{Interval: time.Second, InitialLimit: 1, MaxLimit: 1}))
+	order := make(chan string, 4)
+	release := make(chan struct{})
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		order <- r.URL.Path
+		<-release
+	}
+	mux.HandleFunc("GET /normal", handler)
+	mux.Group(func(g *chain.Mux) {
+		g.Priority(chain.PriorityLow).HandleFunc("GET /low", handler)
+	})
+	mux.Group(func(g *chain.Mux) {
+		g.Priority(chain.PriorityHigh).HandleFunc("GET /high", handler)
+	})
+
+	done := make(chan struct{}, 4)
+	serve := func(path string) {
+		go func() {
+			mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+			done <- struct{}{}
+		}()
+	}
+	serve("/normal")
+	<-order
+	for _, path := range []string{"/low", "/normal", "/high"} {
+		serve(path)
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	var got []string
+	for range 3 {
+		release <- struct{}{}
+		got = append(got, <-order)
+	}
+	close(release)
+	for range 4 {
+		<-done
+	}
+	if want := []string{"/high", "/normal", "/low"}; !slices.Equal(got, want) {
+		t.Errorf("served %v, want %v", got, want)
+	}
+}
+
+func TestLoadShedLowPriorityFirst(t *testing.T) {
+	mux := chain.New()
+	mux.Use(chain.LoadShed(chain.LoadShedOptions{
+		Target:       time.Millisecond,
+		Interval:     20 * time.Millisecond,
+		InitialLimit: 1,
+		MaxLimit:     1,
+	}))
+	entered := make(chan struct{}, 1)
+	release := make(chan struct{})
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		entered <- struct{}{}
+		<-release
+	}
+	mux.HandleFunc("GET /normal", handler)
+	mux.Group(func(g *chain.Mux) {
+		g.Priority(chain.PriorityLow).HandleFunc("GET /low", handler)
+	})
+	mux.Group(func(g *chain.Mux) {
+		g.Priority(chain.PriorityHigh).HandleFunc("GET /high", handler)
+	})
+
+	go mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/normal", nil))
+	<-entered
+	time.Sleep(30 * time.Millisecond) // the queue is now considered backed up
+
+	start := time.Now()
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/low", nil))
+	if rec.Code != http.StatusServiceUnavailable || time.Since(start) > 5*time.Millisecond {
+		t.Errorf("low priority = %d after %v, want immediate 503", rec.Code, time.Since(start))
+	}
+
+	// A high-priority request still waits up to Interval for a slot
+	code := make(chan int)
+	go func() {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest("GET", "/high", nil))
+		code <- rec.Code
+	}()
+	time.Sleep(5 * time.Millisecond)
+	release <- struct{}{}
+	<-entered
+	release <- struct{}{}
+	if c := <-code; c != http.StatusOK {
+		t.Errorf("high priority = %d, want 200", c)
+	}
+}
@@ -0,0 +1,84 @@
+package chain
+
+import "os"
+
+// Profile selects a set of defaults suited to an environment.
+type Profile int
+
+// Profiles supported by WithProfile.
+const (
+	// NoProfile applies no presets. It is the profile of a Mux created by New.
+	NoProfile Profile = iota
+	// Development favours visibility: verbose error bodies, panic pages with the
+	// stack trace, request dumping to stderr and the route table printed on the
+	// first request.
+	Development
+	// Production favours safety: terse problem-detail errors including for
+	// panics, strict method handling, header validation and security headers.
+	Production
+)
+
+// String returns the profile name.
+func (p Profile) String() string {
+	switch p {
+	case Development:
+		return "development"
+	case Production:
+		return "production"
+	default:
+		return "none"
+	}
+}
+
+// NewDev returns a new Mux with the Development profile applied.
+func NewDev() *Mux {
+	return New().WithProfile(Development)
+}
+
+// NewProd returns a new Mux with the Production profile applied.
+func NewProd() *Mux {
+	return New().WithProfile(Production)
+}
+
+// WithProfile applies the presets of p to the router and records the profile so
+// that built-in features can adapt their behaviour. Error formats and custom
+// 404/405 handlers that have already been set are kept. It should be called
+// once, before registering routes. Returns the Mux instance for chaining.
+func (m *Mux) WithProfile(p Profile) *Mux {
+	root := m.root
+	root.profile = p
+
+	switch p {
+	case Development:
+		root.setDefaultErrorFormat(verboseProblemErrors)
+		root.UsePre(Dump(os.Stderr, DumpOptions{ResponseBody: true}))
+		root.Use(recoverer{opts: RecoverOptions{Verbose: true}, mux: root}.middleware)
+	case Production:
+		root.setDefaultErrorFormat(ProblemErrors)
+		root.Use(recoverer{mux: root}.middleware)
+		root.WithAllowedMethods()
+		root.UsePre(
+			ValidateHeaders(HeaderRules{}),
+			StandardHeaders(StandardHeaderOptions{
+				Static: map[string]string{
+					"X-Content-Type-Options": "nosniff",
+					"X-Frame-Options":        "DENY",
+					"Referrer-Policy":        "strict-origin-when-cross-origin",
+				},
+			}),
+		)
+	}
+	return m
+}
+
+// Profile returns the profile applied with WithProfile.
+func (m *Mux) Profile() Profile {
+	return m.root.profile
+}
+
+// setDefaultErrorFormat sets the error formatter unless one has already been set.
+func (m *Mux) setDefaultErrorFormat(f ErrorFormatter) {
+	if m.errorFormat == nil {
+		m.errorFormat = f
+	}
+}
@@ -0,0 +1,86 @@
+package chain_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jpl-au/chain"
+)
+
+func TestProdProfile(t *testing.T) {
+	mux := chain.NewProd()
+	mux.HandleFunc("GET /users", func(w http.ResponseWriter, r *http.Request) {})
+
+	if mux.Profile() != chain.Production {
+		t.Errorf("Expected profile production, got %s", mux.Profile())
+	}
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/users", nil))
+	if got := rec.Header().Get("X-Frame-Options"); got != "DENY" {
+		t.Errorf("Expected X-Frame-Options DENY, got '%s'", got)
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/missing", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("Expected status 404, got %d", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/problem+json" {
+		t.Errorf("Expected problem+json, got '%s'", ct)
+	}
+	var p chain.Problem
+	if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil {
+		t.Fatalf("Invalid problem body: %v", err)
+	}
+	if p.Status != http.StatusNotFound || p.Detail != "" {
+		t.Errorf("Expected terse 404 problem, got %+v", p)
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("TRACE", "/users", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected TRACE to be rejected with 405, got %d", rec.Code)
+	}
+}
+
+func TestDevProfile(t *testing.T) {
+	mux := chain.New()
+	notFound := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "custom", http.StatusNotFound)
+	})
+	mux.WithNotFound(notFound).WithProfile(chain.Development)
+	mux.HandleFunc("POST /users", func(w http.ResponseWriter, r *http.Request) {})
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/missing", nil))
+	if !strings.Contains(rec.Body.String(), "custom") {
+		t.Errorf("Expected custom 404 handler to be kept, got '%s'", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/users", nil))
+	var p chain.Problem
+	if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil {
+		t.Fatalf("Invalid problem body: %v", err)
+	}
+	if p.Status != http.StatusMethodNotAllowed || p.Detail != "GET /users" {
+		t.Errorf("Expected verbose 405 problem, got %+v", p)
+	}
+}
+
+func TestPrintRoutes(t *testing.T) {
+	mux := chain.New()
+	mux.HandleFunc("GET /users", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("POST /users", func(w http.ResponseWriter, r *http.Request) {})
+
+	var b strings.Builder
+	mux.PrintRoutes(&b)
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "GET /users") || !strings.HasPrefix(lines[1], "POST /users") {
+		t.Errorf("Unexpected route listing:\n%s", b.String())
+	}
+}
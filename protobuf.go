@@ -0,0 +1,55 @@
+package chain
+
+import (
+	"fmt"
+	"io"
+)
+
+// ProtobufCodec returns a Codec for application/x-protobuf built from the
+// marshalling functions of a protobuf runtime, keeping chain free of the
+// dependency. With google.golang.org/protobuf:
+//
+//	mux.WithCodecs(chain.JSONCodec, chain.ProtobufCodec(proto.Marshal, proto.Unmarshal))
+//
+// Bind then decodes application/x-protobuf (or application/protobuf) bodies into
+// a proto.Message, and Respond encodes messages for clients accepting protobuf.
+// Values that are not an M fail to encode or decode with an error.
+func ProtobufCodec[M any](marshal func(M) ([]byte, error), unmarshal func([]byte, M) error) Codec {
+	if marshal == nil || unmarshal == nil {
+		panic("chain: nil function passed to ProtobufCodec")
+	}
+	return protobufCodec[M]{marshal: marshal, unmarshal: unmarshal}
+}
+
+// protobufCodec implements ProtobufCodec.
+type protobufCodec[M any] struct {
+	marshal   func(M) ([]byte, error)
+	unmarshal func([]byte, M) error
+}
+
+func (protobufCodec[M]) ContentType() string { return "application/x-protobuf" }
+
+func (c protobufCodec[M]) Encode(w io.Writer, v any) error {
+	m, ok := v.(M)
+	if !ok {
+		return fmt.Errorf("chain: cannot encode %T as protobuf", v)
+	}
+	data, err := c.marshal(m)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+func (c protobufCodec[M]) Decode(r io.Reader, v any) error {
+	m, ok := v.(M)
+	if !ok {
+		return fmt.Errorf("chain: cannot decode protobuf into %T", v)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	return c.unmarshal(data, m)
+}
@@ -0,0 +1,78 @@
+package chain_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jpl-au/chain"
+)
+
+// message stands in for proto.Message.
+type message interface{ Reset() }
+
+type point struct{ X, Y int32 }
+
+func (p *point) Reset() { *p = point{} }
+
+func marshalPoint(m message) ([]byte, error) {
+	p, ok := m.(*point)
+	if !ok {
+		return nil, errors.New("not a point")
+	}
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.BigEndian, p)
+	return buf.Bytes(), nil
+}
+
+func unmarshalPoint(data []byte, m message) error {
+	return binary.Read(bytes.NewReader(data), binary.BigEndian, m.(*point))
+}
+
+func TestProtobufCodec(t *testing.T) {
+	mux := chain.New().WithCodecs(chain.JSONCodec, chain.ProtobufCodec(marshalPoint, unmarshalPoint))
+	mux.HandleFunc("POST /points", func(w http.ResponseWriter, r *http.Request) {
+		var p point
+		if err := chain.Bind(w, r, &p); err != nil {
+			t.Errorf("Bind failed: %v", err)
+			return
+		}
+		p.X++
+		chain.Respond(w, r, http.StatusOK, &p)
+	})
+
+	body, _ := marshalPoint(&point{1, 2})
+	req := httptest.NewRequest("POST", "/points", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/protobuf")
+	req.Header.Set("Accept", "application/x-protobuf")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	if ct := rec.Header().Get("Content-Type"); ct != "application/x-protobuf" {
+		t.Fatalf("Expected protobuf response, got '%s'", ct)
+	}
+	var got point
+	unmarshalPoint(rec.Body.Bytes(), &got)
+	if got != (point{2, 2}) {
+		t.Errorf("Expected {2 2}, got %+v", got)
+	}
+
+	// Clients not asking for protobuf receive the first codec
+	req = httptest.NewRequest("POST", "/points", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Expected JSON response, got '%s'", ct)
+	}
+}
+
+func TestProtobufCodecWrongType(t *testing.T) {
+	codec := chain.ProtobufCodec(marshalPoint, unmarshalPoint)
+	if err := codec.Encode(&bytes.Buffer{}, struct{}{}); err == nil {
+		t.Error("Expected error encoding a non-message")
+	}
+}
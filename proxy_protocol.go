@@ -0,0 +1,228 @@
+package chain
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ProxyProtocolOptions configures ProxyProtocolListener.
+type ProxyProtocolOptions struct {
+	// Trusted lists the networks of the load balancers allowed to send a PROXY
+	// header, such as "10.0.0.0/8". Connections from other addresses are served with
+	// their own address and no header is read. Defaults to trusting every peer,
+	// which is only safe when the listener cannot be reached except through the
+	// load balancer.
+	Trusted []string
+	// HeaderTimeout bounds how long reading the header may take. Defaults to 5
+	// seconds.
+	HeaderTimeout time.Duration
+}
+
+// errProxyHeader reports a missing or malformed PROXY protocol header.
+var errProxyHeader = errors.New("chain: invalid PROXY protocol header")
+
+// proxySignature starts every PROXY protocol v2 header.
+var proxySignature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+
+// ProxyProtocolListener wraps l to accept connections prefixed with a PROXY
+// protocol v1 or v2 header, as sent by AWS Network Load Balancers and HAProxy in
+// TCP mode, and report the original client address from the connection's
+// RemoteAddr. net/http copies it to r.RemoteAddr, so logging, AllowCIDR and other
+// middleware see the client rather than the load balancer:
+//
+//	l, err := net.Listen("tcp", ":8080")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	http.Serve(chain.ProxyProtocolListener(l, chain.ProxyProtocolOptions{
+//		Trusted: []string{"10.0.0.0/8"},
+//	}), mux)
+//
+// The header is read on the connection's own goroutine, on first use, so a slow
+// peer does not hold up Accept. Connections from trusted peers without a valid
+// header are closed. Health checks sent with the LOCAL command, and headers for
+// UNKNOWN or Unix socket addresses, keep the peer's address.
+func ProxyProtocolListener(l net.Listener, opts ProxyProtocolOptions) net.Listener {
+	if l == nil {
+		panic("chain: nil listener passed to ProxyProtocolListener")
+	}
+	if opts.HeaderTimeout <= 0 {
+		opts.HeaderTimeout = 5 * time.Second
+	}
+	trusted := make([]netip.Prefix, len(opts.Trusted))
+	for i, cidr := range opts.Trusted {
+		p, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			panic("chain: invalid network passed to ProxyProtocolListener: " + cidr)
+		}
+		trusted[i] = p.Masked()
+	}
+	return &proxyListener{Listener: l, trusted: trusted, timeout: opts.HeaderTimeout}
+}
+
+// proxyListener implements ProxyProtocolListener.
+type proxyListener struct {
+	net.Listener
+	trusted []netip.Prefix
+	timeout time.Duration
+}
+
+// Accept implements net.Listener.
+func (l *proxyListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	if !l.trust(conn.RemoteAddr()) {
+		return conn, nil
+	}
+	return &proxyConn{Conn: conn, reader: bufio.NewReader(conn), timeout: l.timeout}, nil
+}
+
+// trust reports whether addr may send a PROXY header.
+func (l *proxyListener) trust(addr net.Addr) bool {
+	if len(l.trusted) == 0 {
+		return true
+	}
+	ap, err := netip.ParseAddrPort(addr.String())
+	if err != nil {
+		return false
+	}
+	ip := ap.Addr().Unmap()
+	return slices.ContainsFunc(l.trusted, func(p netip.Prefix) bool { return p.Contains(ip) })
+}
+
+// proxyConn reads the PROXY header before the first Read or RemoteAddr.
+type proxyConn struct {
+	net.Conn
+	reader  *bufio.Reader
+	timeout time.Duration
+	once    sync.Once
+	remote  net.Addr
+	err     error
+}
+
+// Read implements net.Conn.
+func (c *proxyConn) Read(p []byte) (int, error) {
+	c.once.Do(c.readHeader)
+	if c.err != nil {
+		return 0, c.err
+	}
+	return c.reader.Read(p)
+}
+
+// RemoteAddr returns the client address from the header, or the peer's address
+// if the header did not carry one.
+func (c *proxyConn) RemoteAddr() net.Addr {
+	c.once.Do(c.readHeader)
+	if c.remote != nil {
+		return c.remote
+	}
+	return c.Conn.RemoteAddr()
+}
+
+// readHeader parses the header, closing the connection if it is invalid.
+func (c *proxyConn) readHeader() {
+	c.Conn.SetReadDeadline(time.Now().Add(c.timeout))
+	defer c.Conn.SetReadDeadline(time.Time{})
+
+	if sig, err := c.reader.Peek(len(proxySignature)); err == nil && bytes.Equal(sig, proxySignature) {
+		c.remote, c.err = readProxyV2(c.reader)
+	} else {
+		c.remote, c.err = readProxyV1(c.reader)
+	}
+	if c.err != nil {
+		c.Conn.Close()
+	}
+}
+
+// readProxyV1 parses a text header such as "PROXY TCP4 192.0.2.1 198.51.100.1
+// 56324 443\r\n". It returns a nil address for UNKNOWN.
+func readProxyV1(r *bufio.Reader) (net.Addr, error) {
+	// The longest valid v1 header is 107 bytes
+	var line []byte
+	for len(line) < 107 {
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, errProxyHeader
+		}
+		line = append(line, b)
+		if b == '\n' {
+			break
+		}
+	}
+	header, ok := strings.CutSuffix(string(line), "\r\n")
+	if !ok {
+		return nil, errProxyHeader
+	}
+	fields := strings.Split(header, " ")
+	if fields[0] != "PROXY" || len(fields) < 2 {
+		return nil, errProxyHeader
+	}
+	if fields[1] == "UNKNOWN" {
+		return nil, nil
+	}
+	if len(fields) != 6 || (fields[1] != "TCP4" && fields[1] != "TCP6") {
+		return nil, errProxyHeader
+	}
+	ip, err := netip.ParseAddr(fields[2])
+	if err != nil || ip.Is4() != (fields[1] == "TCP4") {
+		return nil, errProxyHeader
+	}
+	port, err := strconv.ParseUint(fields[4], 10, 16)
+	if err != nil {
+		return nil, errProxyHeader
+	}
+	return net.TCPAddrFromAddrPort(netip.AddrPortFrom(ip, uint16(port))), nil
+}
+
+// readProxyV2 parses a binary header. It returns a nil address for the LOCAL
+// command and for address families other than TCP or UDP over IPv4 and IPv6.
+func readProxyV2(r *bufio.Reader) (net.Addr, error) {
+	var fixed [16]byte
+	if _, err := io.ReadFull(r, fixed[:]); err != nil {
+		return nil, errProxyHeader
+	}
+	version, command := fixed[12]>>4, fixed[12]&0x0f
+	family := fixed[13]
+	payload := make([]byte, binary.BigEndian.Uint16(fixed[14:16]))
+	if version != 2 || command > 1 {
+		return nil, errProxyHeader
+	}
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, errProxyHeader
+	}
+	if command == 0 {
+		return nil, nil
+	}
+
+	var ip netip.Addr
+	var port []byte
+	switch family >> 4 {
+	case 1: // IPv4: source, destination, source port, destination port
+		if len(payload) < 12 {
+			return nil, errProxyHeader
+		}
+		ip = netip.AddrFrom4([4]byte(payload[0:4]))
+		port = payload[8:10]
+	case 2: // IPv6
+		if len(payload) < 36 {
+			return nil, errProxyHeader
+		}
+		ip = netip.AddrFrom16([16]byte(payload[0:16]))
+		port = payload[32:34]
+	default:
+		return nil, nil
+	}
+	return net.TCPAddrFromAddrPort(netip.AddrPortFrom(ip, binary.BigEndian.Uint16(port))), nil
+}
@@ -0,0 +1,106 @@
+package chain_test
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/jpl-au/chain"
+)
+
+// serveProxy starts a server on a PROXY protocol listener whose handler echoes
+// r.RemoteAddr, returning its address.
+func serveProxy(t *testing.T, opts chain.ProxyProtocolOptions) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := chain.New()
+	mux.HandleFunc("GET /", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.RemoteAddr)
+	})
+	srv := &http.Server{Handler: mux}
+	go srv.Serve(chain.ProxyProtocolListener(l, opts))
+	t.Cleanup(func() { srv.Close() })
+	return l.Addr().String()
+}
+
+// proxyRequest sends header followed by a GET request and returns the body, or ""
+// if the connection was closed without a response.
+func proxyRequest(t *testing.T, addr string, header []byte) string {
+	t.Helper()
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.Write(header)
+	io.WriteString(conn, "GET / HTTP/1.1\r\nHost: example.com\r\nConnection: close\r\n\r\n")
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		return ""
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return string(body)
+}
+
+// proxyV2 builds a binary header for command and family with payload.
+func proxyV2(command, family byte, payload []byte) []byte {
+	h := []byte("\r\n\r\n\x00\r\nQUIT\n")
+	h = append(h, 0x20|command, family)
+	h = binary.BigEndian.AppendUint16(h, uint16(len(payload)))
+	return append(h, payload...)
+}
+
+func TestProxyProtocolListener(t *testing.T) {
+	addr := serveProxy(t, chain.ProxyProtocolOptions{})
+
+	v4 := []byte{192, 0, 2, 1, 198, 51, 100, 1}
+	v4 = binary.BigEndian.AppendUint16(v4, 56324)
+	v4 = binary.BigEndian.AppendUint16(v4, 443)
+	v6 := make([]byte, 36)
+	v6[0], v6[1], v6[15] = 0x20, 0x01, 0x07
+	binary.BigEndian.PutUint16(v6[32:], 4000)
+
+	tests := []struct {
+		name   string
+		header []byte
+		want   string
+	}{
+		{"v1 tcp4", []byte("PROXY TCP4 192.0.2.1 198.51.100.1 56324 443\r\n"), "192.0.2.1:56324"},
+		{"v1 tcp6", []byte("PROXY TCP6 2001:db8::1 2001:db8::2 4000 443\r\n"), "[2001:db8::1]:4000"},
+		{"v2 ipv4", proxyV2(1, 0x11, v4), "192.0.2.1:56324"},
+		{"v2 ipv6 with TLV", proxyV2(1, 0x21, append(v6, 0x04, 0x00, 0x00)), "[2001::7]:4000"},
+		{"invalid", []byte("HELLO\r\n"), ""},
+		{"missing", nil, ""},
+	}
+	for _, tt := range tests {
+		if got := proxyRequest(t, addr, tt.header); got != tt.want {
+			t.Errorf("%s: RemoteAddr = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	// LOCAL and UNKNOWN keep the peer address
+	for _, header := range [][]byte{proxyV2(0, 0x00, nil), []byte("PROXY UNKNOWN\r\n")} {
+		if got := proxyRequest(t, addr, header); !strings.HasPrefix(got, "127.0.0.1:") {
+			t.Errorf("header %q: RemoteAddr = %q, want peer address", header, got)
+		}
+	}
+}
+
+func TestProxyProtocolUntrusted(t *testing.T) {
+	addr := serveProxy(t, chain.ProxyProtocolOptions{Trusted: []string{"10.0.0.0/8"}})
+
+	if got := proxyRequest(t, addr, nil); !strings.HasPrefix(got, "127.0.0.1:") {
+		t.Errorf("RemoteAddr = %q, want peer address", got)
+	}
+	if got := proxyRequest(t, addr, []byte("PROXY TCP4 192.0.2.1 198.51.100.1 1 2\r\n")); got == "192.0.2.1:1" {
+		t.Errorf("untrusted header was accepted: RemoteAddr = %q", got)
+	}
+}
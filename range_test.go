@@ -0,0 +1,90 @@
+package chain_test
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jpl-au/chain"
+)
+
+// countingReaderAt records the bytes read through it.
+type countingReaderAt struct {
+	r    io.ReaderAt
+	read int
+}
+
+func (c *countingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	n, err := c.r.ReadAt(p, off)
+	c.read += n
+	return n, err
+}
+
+func TestServeRangeAt(t *testing.T) {
+	content := strings.Repeat("0123456789", 1000)
+	opts := chain.RangeOptions{ContentType: "video/mp4", ETag: "v1", LastModified: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)}
+
+	serve := func(headers map[string]string) (*httptest.ResponseRecorder, int) {
+		src := &countingReaderAt{r: strings.NewReader(content)}
+		req := httptest.NewRequest("GET", "/video", nil)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		chain.ServeRangeAt(rec, req, src, int64(len(content)), opts)
+		return rec, src.read
+	}
+
+	rec, read := serve(map[string]string{"Range": "bytes=10-19"})
+	if rec.Code != http.StatusPartialContent || rec.Body.String() != "0123456789" {
+		t.Fatalf("Expected single range, got %d %q", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("Content-Range"); got != "bytes 10-19/10000" {
+		t.Errorf("Unexpected Content-Range '%s'", got)
+	}
+	if read > 512 {
+		t.Errorf("Expected only the range to be read, read %d bytes", read)
+	}
+
+	rec, _ = serve(map[string]string{"Range": "bytes=0-1,5-6"})
+	mediaType, params, _ := mime.ParseMediaType(rec.Header().Get("Content-Type"))
+	if rec.Code != http.StatusPartialContent || mediaType != "multipart/byteranges" {
+		t.Fatalf("Expected multipart response, got %d '%s'", rec.Code, mediaType)
+	}
+	mr := multipart.NewReader(rec.Body, params["boundary"])
+	var parts []string
+	for {
+		p, err := mr.NextPart()
+		if err != nil {
+			break
+		}
+		if ct := p.Header.Get("Content-Type"); ct != "video/mp4" {
+			t.Errorf("Expected part content type video/mp4, got '%s'", ct)
+		}
+		b, _ := io.ReadAll(p)
+		parts = append(parts, string(b))
+	}
+	if strings.Join(parts, ",") != "01,56" {
+		t.Errorf("Unexpected parts %q", parts)
+	}
+
+	rec, _ = serve(map[string]string{"Range": "bytes=0-9", "If-Range": `"v0"`})
+	if rec.Code != http.StatusOK || rec.Body.Len() != len(content) {
+		t.Errorf("Expected full content for stale If-Range, got %d with %d bytes", rec.Code, rec.Body.Len())
+	}
+
+	rec, _ = serve(map[string]string{"Range": "bytes=0-9", "If-Range": `"v1"`})
+	if rec.Code != http.StatusPartialContent {
+		t.Errorf("Expected range for current If-Range, got %d", rec.Code)
+	}
+
+	rec, _ = serve(map[string]string{"Range": "bytes=20000-"})
+	if rec.Code != http.StatusRequestedRangeNotSatisfiable {
+		t.Errorf("Expected 416, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,42 @@
+package chain
+
+import (
+	"net/http"
+	"slices"
+)
+
+// ReadyWhen gates routes registered afterwards on this Mux on readiness checks,
+// such as a database connection being up. While any check reports false, those
+// routes respond with 503 Service Unavailable through the error format, with a
+// Retry-After of one second and without running their middleware, while routes
+// outside the group keep serving. Checks run on every request and should be
+// cheap, typically reading a flag maintained by a background probe. Checks added
+// in nested groups are combined with those of the enclosing group. Returns the
+// Mux instance for method chaining.
+func (m *Mux) ReadyWhen(checks ...func() bool) *Mux {
+	for _, check := range checks {
+		if check == nil {
+			panic("chain: nil check passed to ReadyWhen")
+		}
+	}
+	m.ready = append(slices.Clip(m.ready), checks...)
+	return m
+}
+
+// gateReady wraps handler with the Mux's readiness checks, if any. Other handlers
+// are returned unchanged.
+func (m *Mux) gateReady(handler http.Handler) http.Handler {
+	if len(m.ready) == 0 {
+		return handler
+	}
+	checks := slices.Clone(m.ready)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, ready := range checks {
+			if !ready() {
+				WriteRetryAfter(w, r, http.StatusServiceUnavailable, defaultRetryAfter, "")
+				return
+			}
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
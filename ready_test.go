@@ -0,0 +1,73 @@
+package chain_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/jpl-au/chain"
+)
+
+func TestReadyWhen(t *testing.T) {
+	var dbReady, cacheReady atomic.Bool
+	dbReady.Store(true)
+	cacheReady.Store(true)
+	ran := false
+
+	mux := chain.New()
+	mux.Route("/api", func(api *chain.Mux) {
+		api.ReadyWhen(dbReady.Load)
+		api.Use(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				ran = true
+				next.ServeHTTP(w, r)
+			})
+		})
+		api.HandleFunc("GET /users", func(w http.ResponseWriter, r *http.Request) {})
+		api.Route("/search", func(search *chain.Mux) {
+			search.ReadyWhen(cacheReady.Load)
+			search.HandleFunc("GET /", func(w http.ResponseWriter, r *http.Request) {})
+		})
+	})
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {})
+
+	status := func(path string) int {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		return rec.Code
+	}
+
+	if got := status("/api/users"); got != http.StatusOK {
+		t.Errorf("Expected 200 while ready, got %d", got)
+	}
+
+	cacheReady.Store(false)
+	if got := status("/api/search/"); got != http.StatusServiceUnavailable {
+		t.Errorf("Expected nested group gated on its own check, got %d", got)
+	}
+	if got := status("/api/users"); got != http.StatusOK {
+		t.Errorf("Expected outer group unaffected by nested check, got %d", got)
+	}
+
+	dbReady.Store(false)
+	cacheReady.Store(true)
+	ran = false
+	if got := status("/api/users"); got != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 while not ready, got %d", got)
+	}
+	if ran {
+		t.Error("Expected middleware skipped while not ready")
+	}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/api/users", nil))
+	if got := rec.Header().Get("Retry-After"); got != "1" {
+		t.Errorf("Expected Retry-After 1 while not ready, got %q", got)
+	}
+	if got := status("/api/search/"); got != http.StatusServiceUnavailable {
+		t.Errorf("Expected nested group gated on enclosing check, got %d", got)
+	}
+	if got := status("/healthz"); got != http.StatusOK {
+		t.Errorf("Expected ungated route to serve, got %d", got)
+	}
+}
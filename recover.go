@@ -0,0 +1,164 @@
+package chain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"runtime/debug"
+	"strings"
+)
+
+// RecoverOptions configures the Recoverer middleware.
+type RecoverOptions struct {
+	// Reporter receives each recovered panic. Defaults to NopReporter.
+	Reporter Reporter
+	// Verbose renders the panic value, stack trace and request details in the 500
+	// response, as an HTML page for browsers and JSON otherwise. It must only be
+	// enabled during development.
+	Verbose bool
+}
+
+// Recoverer returns middleware that recovers panics in later handlers, reports them
+// with their stack to the configured Reporter and responds with a 500 problem
+// detail, or through the format set with WithErrorFormat, if the response has not
+// been started. http.ErrAbortHandler is re-panicked
+// so that net/http can abort the response as intended.
+//
+// The Development and Production profiles install a Recoverer that reports to the
+// Reporter set with WithReporter. Under Development its error page also lists the
+// route's middleware.
+func Recoverer(opts RecoverOptions) func(http.Handler) http.Handler {
+	return recoverer{opts: opts}.middleware
+}
+
+// WithReporter sets the Reporter used by the Recoverer installed by WithProfile.
+// Calling WithReporter inside a group sets it on the root Mux.
+// Returns the Mux instance for chaining.
+func (m *Mux) WithReporter(rep Reporter) *Mux {
+	m.root.reporter = rep
+	return m
+}
+
+// recoverer implements Recoverer. When mux is set the reporter is taken from the
+// router at panic time and verbose pages include the route's middleware.
+type recoverer struct {
+	opts RecoverOptions
+	mux  *Mux
+}
+
+func (rc recoverer) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			if v == http.ErrAbortHandler {
+				panic(v)
+			}
+			err, stack := panicError(v), debug.Stack()
+			rc.reporter().Report(r.Context(), err, stack, r)
+			RecordError(r, err)
+			emitEvent(w, PanicRecovered{Request: r, Err: err, Stack: stack})
+
+			if rw, ok := w.(ResponseWriter); ok && rw.Written() {
+				return
+			}
+			if !rc.opts.Verbose {
+				if rw := findResponseWriter(w); rw != nil && rw.errorFormat != nil {
+					WriteError(w, r, http.StatusInternalServerError, "")
+					return
+				}
+				WriteProblem(w, Problem{Status: http.StatusInternalServerError})
+				return
+			}
+			rc.writeDebug(w, r, err, stack)
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// reporter returns the Reporter to use for a panic.
+func (rc recoverer) reporter() Reporter {
+	if rc.mux != nil && rc.mux.root.reporter != nil {
+		return rc.mux.root.reporter
+	}
+	if rc.opts.Reporter != nil {
+		return rc.opts.Reporter
+	}
+	return NopReporter
+}
+
+// panicDetails is the verbose 500 response, rendered as problem+json or HTML.
+type panicDetails struct {
+	Problem
+	Panic      string      `json:"panic"`
+	Stack      []string    `json:"stack"`
+	Method     string      `json:"method"`
+	URL        string      `json:"url"`
+	Route      string      `json:"route,omitempty"`
+	Middleware []string    `json:"middleware,omitempty"`
+	Header     http.Header `json:"header"`
+}
+
+// writeDebug renders the verbose 500 response for err.
+func (rc recoverer) writeDebug(w http.ResponseWriter, r *http.Request, err error, stack []byte) {
+	d := panicDetails{
+		Problem: Problem{Title: http.StatusText(http.StatusInternalServerError), Status: http.StatusInternalServerError},
+		Panic:   err.Error(),
+		Stack:   strings.Split(strings.TrimSpace(string(stack)), "\n"),
+		Method:  r.Method,
+		URL:     r.URL.String(),
+		Route:   RoutePattern(w),
+		Header:  r.Header,
+	}
+	if rc.mux != nil {
+		if rt, ok := rc.mux.lookup(d.Route); ok {
+			d.Middleware = rt.info.Middleware
+		}
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if strings.Contains(r.Header.Get("Accept"), "text/html") {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusInternalServerError)
+		panicPage.Execute(w, d)
+		return
+	}
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(http.StatusInternalServerError)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(d)
+}
+
+// panicPage renders panicDetails for browsers.
+var panicPage = template.Must(template.New("panic").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>500: {{.Panic}}</title></head>
+<body>
+<h1>{{.Panic}}</h1>
+<p>{{.Method}} {{.URL}}{{if .Route}} matched <code>{{.Route}}</code>{{end}}</p>
+{{- if .Middleware}}
+<h2>Middleware</h2>
+<ol>{{range .Middleware}}<li><code>{{.}}</code></li>{{end}}</ol>
+{{- end}}
+<h2>Stack</h2>
+<pre>{{range .Stack}}{{.}}
+{{end}}</pre>
+<h2>Request Headers</h2>
+<table>{{range $k, $v := .Header}}<tr><th>{{$k}}</th><td>{{range $v}}{{.}} {{end}}</td></tr>{{end}}</table>
+</body>
+</html>
+`))
+
+// panicError converts a recovered value to an error.
+func panicError(v any) error {
+	if err, ok := v.(error); ok {
+		return fmt.Errorf("panic: %w", err)
+	}
+	return errors.New("panic: " + fmt.Sprint(v))
+}
@@ -0,0 +1,144 @@
+package chain_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jpl-au/chain"
+)
+
+func TestRecoverer(t *testing.T) {
+	var reported error
+	var stack []byte
+	var path string
+	rep := chain.ReporterFunc(func(ctx context.Context, err error, s []byte, r *http.Request) {
+		reported, stack, path = err, s, r.URL.Path
+	})
+
+	mux := chain.New().Use(chain.Recoverer(chain.RecoverOptions{Reporter: rep}))
+	mux.HandleFunc("GET /panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+	mux.HandleFunc("GET /partial", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("partial"))
+		panic(errors.New("late"))
+	})
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/panic", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500, got %d", rec.Code)
+	}
+	if reported == nil || reported.Error() != "panic: boom" || path != "/panic" {
+		t.Errorf("Expected panic to be reported, got %v for %s", reported, path)
+	}
+	if !strings.Contains(string(stack), "TestRecoverer") {
+		t.Error("Expected stack to include the panicking handler")
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/partial", nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "partial" {
+		t.Errorf("Expected started response to be left alone, got %d '%s'", rec.Code, rec.Body.String())
+	}
+	if reported == nil || reported.Error() != "panic: late" {
+		t.Errorf("Expected error panic to be reported, got %v", reported)
+	}
+}
+
+func TestRecovererReraisesAbort(t *testing.T) {
+	mux := chain.New().Use(chain.Recoverer(chain.RecoverOptions{}))
+	mux.HandleFunc("GET /", func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	})
+
+	defer func() {
+		if recover() != http.ErrAbortHandler {
+			t.Error("Expected http.ErrAbortHandler to be re-panicked")
+		}
+	}()
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+}
+
+func TestAsyncReporter(t *testing.T) {
+	got := make(chan string, 10)
+	async := chain.NewAsyncReporter(chain.ReporterFunc(func(ctx context.Context, err error, s []byte, r *http.Request) {
+		if ctx.Err() != nil {
+			t.Error("Expected delivery context not to be cancelled")
+		}
+		got <- err.Error() + " " + r.URL.Path
+	}), 10)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest("GET", "/a", nil).WithContext(ctx)
+	async.Report(ctx, errors.New("first"), nil, req)
+	cancel()
+	async.Close()
+
+	if msg := <-got; msg != "first /a" {
+		t.Errorf("Expected 'first /a', got '%s'", msg)
+	}
+
+	async.Report(context.Background(), errors.New("late"), nil, nil)
+	if async.Dropped() != 1 {
+		t.Errorf("Expected report after Close to be dropped, got %d drops", async.Dropped())
+	}
+}
+
+func TestRecovererVerbose(t *testing.T) {
+	mux := chain.New().Use(chain.Recoverer(chain.RecoverOptions{Verbose: true}))
+	mux.HandleFunc("GET /orders/{id}", func(w http.ResponseWriter, r *http.Request) {
+		panic("nil order")
+	})
+
+	req := httptest.NewRequest("GET", "/orders/9", nil)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	var body struct {
+		Status int      `json:"status"`
+		Panic  string   `json:"panic"`
+		Route  string   `json:"route"`
+		Stack  []string `json:"stack"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Invalid JSON body: %v", err)
+	}
+	if body.Status != 500 || body.Panic != "panic: nil order" || body.Route != "GET /orders/{id}" || len(body.Stack) == 0 {
+		t.Errorf("Unexpected verbose body: %+v", body)
+	}
+
+	req.Header.Set("Accept", "text/html")
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+		t.Errorf("Expected HTML page for browsers, got '%s'", ct)
+	}
+	if !strings.Contains(rec.Body.String(), "<h1>panic: nil order</h1>") {
+		t.Errorf("Expected panic in page, got '%s'", rec.Body.String())
+	}
+}
+
+func TestProfileRecoverer(t *testing.T) {
+	var reported error
+	mux := chain.NewProd().WithReporter(chain.ReporterFunc(func(ctx context.Context, err error, s []byte, r *http.Request) {
+		reported = err
+	}))
+	mux.HandleFunc("GET /", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if rec.Code != http.StatusInternalServerError || strings.Contains(rec.Body.String(), "boom") {
+		t.Errorf("Expected terse 500, got %d '%s'", rec.Code, rec.Body.String())
+	}
+	if reported == nil {
+		t.Error("Expected panic to reach the router's reporter")
+	}
+}
@@ -0,0 +1,310 @@
+package chain
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrRememberNotFound is returned by RememberStore implementations for unknown selectors.
+var ErrRememberNotFound = errors.New("chain: remember-me token not found")
+
+// RememberToken is a persistent login token as held by a RememberStore. Only a
+// hash of the validator is stored, so a leaked store cannot be used to log in.
+type RememberToken struct {
+	// Selector identifies the token and is safe to index.
+	Selector string
+	// UserID is the user the token logs in.
+	UserID string
+	// Hash is the SHA-256 hash of the current validator.
+	Hash []byte
+	// PreviousHash is the hash of the validator replaced at Rotated, still
+	// accepted for a short grace period so that concurrent requests carrying the
+	// old cookie are not mistaken for theft.
+	PreviousHash []byte
+	Rotated      time.Time
+	// Expires is when the token stops being accepted.
+	Expires time.Time
+}
+
+// RememberStore persists remember-me tokens.
+type RememberStore interface {
+	// Save creates or replaces the token with t's selector.
+	Save(ctx context.Context, t RememberToken) error
+	// Find returns the token with the given selector, or ErrRememberNotFound.
+	Find(ctx context.Context, selector string) (RememberToken, error)
+	// Delete removes the token with the given selector.
+	Delete(ctx context.Context, selector string) error
+	// DeleteUser removes every token of the given user.
+	DeleteUser(ctx context.Context, userID string) error
+}
+
+// RememberOptions configures NewRemember.
+type RememberOptions struct {
+	// Store persists tokens. Required.
+	Store RememberStore
+	// Load establishes the user's login for a request authenticated by a token,
+	// typically by starting a session and storing the principal in the returned
+	// context. An error rejects the token. Required.
+	Load func(r *http.Request, userID string) (context.Context, error)
+	// Authenticated reports whether a request is already logged in, such as by a
+	// session cookie, in which case the remember-me cookie is not consulted.
+	Authenticated func(r *http.Request) bool
+	// OnTheft, if set, is called when a token is presented with a validator that
+	// does not match, which indicates that it was copied and used elsewhere. All of
+	// the user's tokens have already been revoked when it is called.
+	OnTheft func(r *http.Request, userID string)
+	// Cookie names the cookie. Defaults to "chain_remember".
+	Cookie string
+	// TTL is how long a token remains valid after login. Defaults to 30 days.
+	TTL time.Duration
+	// Grace is how long a replaced validator is still accepted. Defaults to 1 minute.
+	Grace time.Duration
+	// Path is the cookie path. Defaults to "/".
+	Path string
+	// Domain is the cookie domain. Defaults to the request host.
+	Domain string
+	// Secure restricts the cookie to HTTPS.
+	Secure bool
+	// SameSite is the cookie's SameSite attribute. Defaults to Lax.
+	SameSite http.SameSite
+}
+
+// Remember issues and checks rotating remember-me tokens, which keep users logged
+// in across browser sessions. Each token is a selector, which looks it up, and a
+// validator, which is compared against a stored hash and replaced every time the
+// token is used. A validator that does not match its selector means that a copy
+// of the cookie has been used, so all of the user's tokens are revoked.
+type Remember struct {
+	opts RememberOptions
+}
+
+// rememberKey is the context key marking requests logged in by a token.
+type rememberKey struct{}
+
+// NewRemember returns a Remember configured by opts.
+func NewRemember(opts RememberOptions) *Remember {
+	if opts.Store == nil {
+		panic("chain: nil Store passed to NewRemember")
+	}
+	if opts.Load == nil {
+		panic("chain: nil Load passed to NewRemember")
+	}
+	if opts.Cookie == "" {
+		opts.Cookie = "chain_remember"
+	}
+	if opts.TTL <= 0 {
+		opts.TTL = 30 * 24 * time.Hour
+	}
+	if opts.Grace <= 0 {
+		opts.Grace = time.Minute
+	}
+	if opts.Path == "" {
+		opts.Path = "/"
+	}
+	if opts.SameSite == 0 {
+		opts.SameSite = http.SameSiteLaxMode
+	}
+	return &Remember{opts: opts}
+}
+
+// Issue creates a token for userID and sets its cookie, for a login handler to
+// call when the user asks to be remembered.
+func (m *Remember) Issue(w http.ResponseWriter, r *http.Request, userID string) error {
+	validator := rememberSecret(32)
+	t := RememberToken{
+		Selector: rememberSecret(12),
+		UserID:   userID,
+		Hash:     rememberHash(validator),
+		Expires:  time.Now().Add(m.opts.TTL),
+	}
+	if err := m.opts.Store.Save(r.Context(), t); err != nil {
+		return err
+	}
+	m.setCookie(w, t.Selector+":"+validator, t.Expires)
+	return nil
+}
+
+// Forget revokes the request's token, if any, and clears its cookie, for a
+// logout handler to call.
+func (m *Remember) Forget(w http.ResponseWriter, r *http.Request) error {
+	m.setCookie(w, "", time.Time{})
+	if selector, _, ok := m.token(r); ok {
+		return m.opts.Store.Delete(r.Context(), selector)
+	}
+	return nil
+}
+
+// Middleware logs in requests that are not otherwise authenticated but carry a
+// valid token, passing the context returned by Load to the next handler and
+// rotating the token's validator. Requests with a missing, expired or rejected
+// token continue unauthenticated, with the cookie cleared.
+func (m *Remember) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if m.opts.Authenticated != nil && m.opts.Authenticated(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		selector, validator, ok := m.token(r)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ctx, err := m.login(w, r, selector, validator)
+		if err != nil {
+			m.setCookie(w, "", time.Time{})
+			next.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(ctx, rememberKey{}, true)))
+	})
+}
+
+// Remembered reports whether r was logged in by a remember-me token rather than
+// by the user entering credentials, so that sensitive actions can ask them to
+// log in again.
+func Remembered(r *http.Request) bool {
+	ok, _ := r.Context().Value(rememberKey{}).(bool)
+	return ok
+}
+
+// errRememberRejected is returned by login for tokens that must not log in.
+var errRememberRejected = errors.New("chain: remember-me token rejected")
+
+// login checks a token and, if it is valid, rotates it and loads its user.
+func (m *Remember) login(w http.ResponseWriter, r *http.Request, selector, validator string) (context.Context, error) {
+	ctx := r.Context()
+	t, err := m.opts.Store.Find(ctx, selector)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if !now.Before(t.Expires) {
+		m.opts.Store.Delete(ctx, selector)
+		return nil, errRememberRejected
+	}
+
+	hash := rememberHash(validator)
+	switch {
+	case subtle.ConstantTimeCompare(hash, t.Hash) == 1:
+		// Rotate so that a copied cookie is detected the next time either copy
+		// is used
+		next := rememberSecret(32)
+		t.PreviousHash, t.Rotated, t.Hash = t.Hash, now, rememberHash(next)
+		if err := m.opts.Store.Save(ctx, t); err != nil {
+			return nil, err
+		}
+		m.setCookie(w, selector+":"+next, t.Expires)
+	case t.PreviousHash != nil && now.Sub(t.Rotated) < m.opts.Grace &&
+		subtle.ConstantTimeCompare(hash, t.PreviousHash) == 1:
+		// A concurrent request sent before the browser received the rotated
+		// cookie
+	default:
+		m.opts.Store.DeleteUser(ctx, t.UserID)
+		if m.opts.OnTheft != nil {
+			m.opts.OnTheft(r, t.UserID)
+		}
+		return nil, errRememberRejected
+	}
+	return m.opts.Load(r, t.UserID)
+}
+
+// token returns the selector and validator from r's cookie.
+func (m *Remember) token(r *http.Request) (string, string, bool) {
+	c, err := r.Cookie(m.opts.Cookie)
+	if err != nil {
+		return "", "", false
+	}
+	selector, validator, ok := strings.Cut(c.Value, ":")
+	return selector, validator, ok && selector != "" && validator != ""
+}
+
+// setCookie sets the token cookie, or clears it if value is empty.
+func (m *Remember) setCookie(w http.ResponseWriter, value string, expires time.Time) {
+	c := &http.Cookie{
+		Name:     m.opts.Cookie,
+		Value:    value,
+		Path:     m.opts.Path,
+		Domain:   m.opts.Domain,
+		Secure:   m.opts.Secure,
+		HttpOnly: true,
+		SameSite: m.opts.SameSite,
+	}
+	if value == "" {
+		c.MaxAge = -1
+	} else {
+		c.Expires = expires
+	}
+	http.SetCookie(w, c)
+}
+
+// rememberSecret returns n random bytes encoded for use in a cookie.
+func rememberSecret(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// rememberHash returns the stored form of a validator.
+func rememberHash(validator string) []byte {
+	sum := sha256.Sum256([]byte(validator))
+	return sum[:]
+}
+
+// MemoryRememberStore is an in-memory RememberStore, suited to tests and
+// single-instance deployments.
+type MemoryRememberStore struct {
+	mu     sync.Mutex
+	tokens map[string]RememberToken
+}
+
+// NewMemoryRememberStore returns an empty MemoryRememberStore.
+func NewMemoryRememberStore() *MemoryRememberStore {
+	return &MemoryRememberStore{tokens: make(map[string]RememberToken)}
+}
+
+// Save implements RememberStore.
+func (s *MemoryRememberStore) Save(_ context.Context, t RememberToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens[t.Selector] = t
+	return nil
+}
+
+// Find implements RememberStore.
+func (s *MemoryRememberStore) Find(_ context.Context, selector string) (RememberToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.tokens[selector]
+	if !ok {
+		return RememberToken{}, ErrRememberNotFound
+	}
+	return t, nil
+}
+
+// Delete implements RememberStore.
+func (s *MemoryRememberStore) Delete(_ context.Context, selector string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.tokens, selector)
+	return nil
+}
+
+// DeleteUser implements RememberStore.
+func (s *MemoryRememberStore) DeleteUser(_ context.Context, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k, t := range s.tokens {
+		if t.UserID == userID {
+			delete(s.tokens, k)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package chain_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jpl-au/chain"
+)
+
+type userKey struct{}
+
+// rememberMux returns a router whose /me route reports the logged in user.
+func rememberMux(opts chain.RememberOptions) (*chain.Mux, *chain.Remember) {
+	opts.Load = func(r *http.Request, userID string) (context.Context, error) {
+		return context.WithValue(r.Context(), userKey{}, userID), nil
+	}
+	rm := chain.NewRemember(opts)
+	mux := chain.New().Use(rm.Middleware)
+	mux.HandleFunc("POST /login", func(w http.ResponseWriter, r *http.Request) {
+		rm.Issue(w, r, "alice")
+	})
+	mux.HandleFunc("POST /logout", func(w http.ResponseWriter, r *http.Request) {
+		rm.Forget(w, r)
+	})
+	mux.HandleFunc("GET /me", func(w http.ResponseWriter, r *http.Request) {
+		user, _ := r.Context().Value(userKey{}).(string)
+		if chain.Remembered(r) {
+			user += " (remembered)"
+		}
+		w.Write([]byte(user))
+	})
+	return mux, rm
+}
+
+// send serves a request carrying cookie, returning the response and the cookie
+// the response set, if any.
+func send(mux http.Handler, method, path string, cookie *http.Cookie) (*httptest.ResponseRecorder, *http.Cookie) {
+	req := httptest.NewRequest(method, path, nil)
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	cookies := rec.Result().Cookies()
+	if len(cookies) == 0 {
+		return rec, nil
+	}
+	return rec, cookies[0]
+}
+
+func TestRememberRotation(t *testing.T) {
+	store := chain.NewMemoryRememberStore()
+	mux, _ := rememberMux(chain.RememberOptions{Store: store, Secure: true})
+
+	_, first := send(mux, "POST", "/login", nil)
+	if first == nil || !first.HttpOnly || !first.Secure || first.Expires.IsZero() {
+		t.Fatalf("Expected a persistent HttpOnly cookie, got %+v", first)
+	}
+
+	rec, second := send(mux, "GET", "/me", first)
+	if rec.Body.String() != "alice (remembered)" {
+		t.Fatalf("Expected remembered login, got '%s'", rec.Body.String())
+	}
+	if second == nil || second.Value == first.Value {
+		t.Fatal("Expected the validator to be rotated")
+	}
+
+	// A concurrent request with the previous cookie is accepted during the grace period
+	if rec, _ := send(mux, "GET", "/me", first); rec.Body.String() != "alice (remembered)" {
+		t.Errorf("Expected the previous validator to be accepted, got '%s'", rec.Body.String())
+	}
+
+	rec, third := send(mux, "GET", "/me", second)
+	if rec.Body.String() != "alice (remembered)" || third == nil {
+		t.Fatalf("Expected the rotated cookie to log in, got '%s'", rec.Body.String())
+	}
+
+	// Logging out revokes the token
+	send(mux, "POST", "/logout", third)
+	if rec, cleared := send(mux, "GET", "/me", third); rec.Body.String() != "" || cleared == nil || cleared.MaxAge >= 0 {
+		t.Errorf("Expected a revoked token to be rejected and cleared, got '%s'", rec.Body.String())
+	}
+}
+
+func TestRememberTheft(t *testing.T) {
+	store := chain.NewMemoryRememberStore()
+	var stolen string
+	mux, _ := rememberMux(chain.RememberOptions{
+		Store:   store,
+		Grace:   time.Nanosecond,
+		OnTheft: func(r *http.Request, userID string) { stolen = userID },
+	})
+
+	_, original := send(mux, "POST", "/login", nil)
+	_, other := send(mux, "POST", "/login", nil)
+
+	// The attacker uses the copied cookie first, rotating it
+	send(mux, "GET", "/me", original)
+	time.Sleep(time.Millisecond)
+
+	// The victim's next request carries the old validator
+	rec, _ := send(mux, "GET", "/me", original)
+	if rec.Body.String() != "" {
+		t.Errorf("Expected the request to be unauthenticated, got '%s'", rec.Body.String())
+	}
+	if stolen != "alice" {
+		t.Errorf("Expected OnTheft for alice, got '%s'", stolen)
+	}
+	if rec, _ := send(mux, "GET", "/me", other); rec.Body.String() != "" {
+		t.Errorf("Expected all of the user's tokens to be revoked, got '%s'", rec.Body.String())
+	}
+}
+
+func TestRememberSkipsAuthenticated(t *testing.T) {
+	mux, _ := rememberMux(chain.RememberOptions{
+		Store:         chain.NewMemoryRememberStore(),
+		Authenticated: func(r *http.Request) bool { return r.Header.Get("X-Session") != "" },
+	})
+	_, cookie := send(mux, "POST", "/login", nil)
+
+	req := httptest.NewRequest("GET", "/me", nil)
+	req.AddCookie(cookie)
+	req.Header.Set("X-Session", "1")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Body.String() != "" || rec.Header().Get("Set-Cookie") != "" {
+		t.Errorf("Expected the token to be ignored for a logged in request, got '%s'", rec.Body.String())
+	}
+}
+
+func TestRememberExpired(t *testing.T) {
+	store := chain.NewMemoryRememberStore()
+	mux, _ := rememberMux(chain.RememberOptions{Store: store, TTL: time.Millisecond})
+	_, cookie := send(mux, "POST", "/login", nil)
+	time.Sleep(2 * time.Millisecond)
+	if rec, _ := send(mux, "GET", "/me", cookie); rec.Body.String() != "" {
+		t.Errorf("Expected an expired token to be rejected, got '%s'", rec.Body.String())
+	}
+}
@@ -0,0 +1,32 @@
+package chain
+
+import (
+	"net/http"
+)
+
+// JSON writes v as an application/json response with the given status.
+func JSON(w http.ResponseWriter, status int, v any) error {
+	return Render(w, status, JSONCodec, v)
+}
+
+// XML writes v as an application/xml response with the given status, preceded by
+// the standard XML declaration.
+func XML(w http.ResponseWriter, status int, v any) error {
+	return Render(w, status, XMLCodec, v)
+}
+
+// Render writes v as a response with the given status, encoded with codec.
+func Render(w http.ResponseWriter, status int, codec Codec, v any) error {
+	w.Header().Set("Content-Type", codec.ContentType())
+	w.WriteHeader(status)
+	return codec.Encode(w, v)
+}
+
+// Respond writes v with the given status, encoded with the codec that best matches
+// the request's Accept header among those set with WithCodecs. By default that is
+// XML for clients asking for application/xml or text/xml ahead of JSON, and JSON
+// otherwise.
+func Respond(w http.ResponseWriter, r *http.Request, status int, v any) error {
+	w.Header().Add("Vary", "Accept")
+	return Render(w, status, negotiateCodec(codecsFor(w), r.Header.Get("Accept")), v)
+}
@@ -0,0 +1,47 @@
+package chain_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/jpl-au/chain"
+)
+
+func TestReplayProtection(t *testing.T) {
+	mux := chain.New().Use(chain.ReplayProtection(chain.ReplayOptions{
+		Store:  chain.NewMemoryNonceStore(),
+		Window: time.Minute,
+	}))
+	mux.HandleFunc("POST /", func(w http.ResponseWriter, r *http.Request) {})
+
+	now := time.Now().Unix()
+	tests := []struct {
+		name      string
+		nonce     string
+		timestamp string
+		status    int
+	}{
+		{"fresh request", "n1", strconv.FormatInt(now, 10), http.StatusOK},
+		{"replayed nonce", "n1", strconv.FormatInt(now, 10), http.StatusUnauthorized},
+		{"second nonce", "n2", strconv.FormatInt(now, 10), http.StatusOK},
+		{"stale timestamp", "n3", strconv.FormatInt(now-120, 10), http.StatusUnauthorized},
+		{"future timestamp", "n4", strconv.FormatInt(now+120, 10), http.StatusUnauthorized},
+		{"missing nonce", "", strconv.FormatInt(now, 10), http.StatusUnauthorized},
+		{"malformed timestamp", "n5", "yesterday", http.StatusUnauthorized},
+	}
+
+	// Cases run in order since the replay case depends on the first
+	for _, tt := range tests {
+		req := httptest.NewRequest("POST", "/", nil)
+		req.Header.Set("X-Nonce", tt.nonce)
+		req.Header.Set("X-Timestamp", tt.timestamp)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		if rec.Code != tt.status {
+			t.Errorf("%s: expected status %d, got %d", tt.name, tt.status, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package chain
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+)
+
+// Reporter receives errors and recovered panics for forwarding to an error tracking
+// service such as Sentry or Rollbar. stack is the goroutine stack at the point of
+// failure and may be nil. Implementations must not retain r beyond the call unless
+// they are invoked through an AsyncReporter, which passes a detached copy.
+type Reporter interface {
+	Report(ctx context.Context, err error, stack []byte, r *http.Request)
+}
+
+// ReporterFunc adapts a function to the Reporter interface.
+type ReporterFunc func(ctx context.Context, err error, stack []byte, r *http.Request)
+
+// Report calls f(ctx, err, stack, r).
+func (f ReporterFunc) Report(ctx context.Context, err error, stack []byte, r *http.Request) {
+	f(ctx, err, stack, r)
+}
+
+// NopReporter is a Reporter that discards every report. It is the default when no
+// Reporter is configured.
+var NopReporter Reporter = ReporterFunc(func(context.Context, error, []byte, *http.Request) {})
+
+// report is a queued call to Report.
+type report struct {
+	ctx   context.Context
+	err   error
+	stack []byte
+	r     *http.Request
+}
+
+// AsyncReporter is a Reporter that queues reports in a bounded buffer and delivers
+// them to another Reporter on a background goroutine, so that slow tracking
+// services do not delay responses. Reports are dropped when the buffer is full.
+type AsyncReporter struct {
+	next    Reporter
+	queue   chan report
+	done    chan struct{}
+	closing sync.Once
+	mu      sync.RWMutex
+	closed  bool
+	dropped atomic.Uint64
+}
+
+// NewAsyncReporter starts a dispatcher that delivers reports to next, buffering up to
+// size reports. size defaults to 100. Call Close on shutdown to deliver the reports
+// still queued.
+func NewAsyncReporter(next Reporter, size int) *AsyncReporter {
+	if next == nil {
+		panic("chain: nil reporter passed to NewAsyncReporter")
+	}
+	if size <= 0 {
+		size = 100
+	}
+	a := &AsyncReporter{
+		next:  next,
+		queue: make(chan report, size),
+		done:  make(chan struct{}),
+	}
+	go a.run()
+	return a
+}
+
+// Report queues the report without blocking. The request is cloned with a context
+// that is not cancelled when the request ends, since delivery happens later.
+func (a *AsyncReporter) Report(ctx context.Context, err error, stack []byte, r *http.Request) {
+	ctx = context.WithoutCancel(ctx)
+	if r != nil {
+		r = r.Clone(ctx)
+		r.Body = http.NoBody
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.closed {
+		a.dropped.Add(1)
+		return
+	}
+	select {
+	case a.queue <- report{ctx: ctx, err: err, stack: stack, r: r}:
+	default:
+		a.dropped.Add(1)
+	}
+}
+
+// Dropped returns the number of reports discarded because the buffer was full or
+// the reporter was closed.
+func (a *AsyncReporter) Dropped() uint64 {
+	return a.dropped.Load()
+}
+
+// Close stops accepting reports and waits until the queued reports are delivered.
+func (a *AsyncReporter) Close() {
+	a.closing.Do(func() {
+		a.mu.Lock()
+		a.closed = true
+		close(a.queue)
+		a.mu.Unlock()
+	})
+	<-a.done
+}
+
+// run delivers queued reports until the queue is closed. A panicking Reporter is
+// recovered so that it does not stop delivery of later reports.
+func (a *AsyncReporter) run() {
+	defer close(a.done)
+	for rep := range a.queue {
+		func() {
+			defer func() { recover() }()
+			a.next.Report(rep.ctx, rep.err, rep.stack, rep.r)
+		}()
+	}
+}
@@ -0,0 +1,108 @@
+//go:build !chain_nodebug
+
+package chain
+
+import (
+	"context"
+	"html/template"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// requestLogKey is the context key under which the in-progress entry is stored.
+type requestLogKey struct{}
+
+// NewRequestLog returns an empty RequestLog.
+func NewRequestLog(opts RequestLogOptions) *RequestLog {
+	if opts.Size <= 0 {
+		opts.Size = 100
+	}
+	if opts.RequestIDHeader == "" {
+		opts.RequestIDHeader = "X-Request-Id"
+	}
+	return &RequestLog{opts: opts, entries: make([]RequestEntry, opts.Size)}
+}
+
+// Middleware returns middleware recording each request once it completes.
+func (l *RequestLog) Middleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			entry := &RequestEntry{
+				Time:      time.Now(),
+				Method:    r.Method,
+				Path:      r.URL.Path,
+				RequestID: r.Header.Get(l.opts.RequestIDHeader),
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestLogKey{}, entry)))
+
+			entry.Duration = time.Since(entry.Time)
+			entry.Route = RoutePattern(w)
+			entry.Status = http.StatusOK
+			if rw, ok := w.(ResponseWriter); ok {
+				entry.Status = rw.Status()
+			}
+			l.add(*entry)
+		})
+	}
+}
+
+// RecordError attaches err to the entry RequestLog is recording for r. It does
+// nothing if the middleware is not running.
+func RecordError(r *http.Request, err error) {
+	if entry, ok := r.Context().Value(requestLogKey{}).(*RequestEntry); ok && err != nil {
+		entry.Error = err.Error()
+	}
+}
+
+// add stores e, overwriting the oldest entry once full.
+func (l *RequestLog) add(e RequestEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries[l.next] = e
+	l.next = (l.next + 1) % len(l.entries)
+	if l.next == 0 {
+		l.full = true
+	}
+}
+
+// Entries returns the recorded requests, newest first.
+func (l *RequestLog) Entries() []RequestEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	n := l.next
+	if l.full {
+		n = len(l.entries)
+	}
+	out := make([]RequestEntry, n)
+	for i := range n {
+		out[i] = l.entries[(l.next-1-i+len(l.entries))%len(l.entries)]
+	}
+	return out
+}
+
+// ServeHTTP serves the entries as JSON, or as an HTML table to browsers.
+func (l *RequestLog) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	if !strings.Contains(r.Header.Get("Accept"), "text/html") {
+		JSON(w, http.StatusOK, l.Entries())
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	requestLogPage.Execute(w, l.Entries())
+}
+
+// requestLogPage renders RequestLog entries for browsers.
+var requestLogPage = template.Must(template.New("requests").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Recent requests</title>
+<style>body{font-family:sans-serif}td,th{padding:2px 8px;text-align:left}.err{color:#b00}</style></head>
+<body>
+<h1>Recent requests</h1>
+<table>
+<tr><th>Time</th><th>Method</th><th>Path</th><th>Route</th><th>Status</th><th>Duration</th><th>Request ID</th><th>Error</th></tr>
+{{range .}}<tr{{if ge .Status 500}} class="err"{{end}}><td>{{.Time.Format "15:04:05.000"}}</td><td>{{.Method}}</td><td>{{.Path}}</td><td>{{.Route}}</td><td>{{.Status}}</td><td>{{.Duration}}</td><td>{{.RequestID}}</td><td>{{.Error}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
@@ -0,0 +1,62 @@
+//go:build !chain_nodebug
+
+package chain_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jpl-au/chain"
+)
+
+func TestRequestLog(t *testing.T) {
+	requests := chain.NewRequestLog(chain.RequestLogOptions{Size: 3})
+	mux := chain.New().UsePre(requests.Middleware())
+	mux.Use(chain.Recoverer(chain.RecoverOptions{}))
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("GET /fail", func(w http.ResponseWriter, r *http.Request) {
+		chain.RecordError(r, errors.New("database unavailable"))
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	mux.HandleFunc("GET /panic", func(w http.ResponseWriter, r *http.Request) { panic("boom") })
+	mux.Handle("GET /debug/requests", requests)
+
+	for _, path := range []string{"/users/1", "/users/2", "/fail", "/panic"} {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("X-Request-Id", "req"+path)
+		mux.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	entries := requests.Entries()
+	if len(entries) != 3 {
+		t.Fatalf("Expected 3 entries, got %d", len(entries))
+	}
+	if e := entries[0]; e.Path != "/panic" || e.Status != http.StatusInternalServerError || !strings.Contains(e.Error, "boom") {
+		t.Errorf("Unexpected newest entry %+v", e)
+	}
+	if e := entries[1]; e.Error != "database unavailable" || e.RequestID != "req/fail" {
+		t.Errorf("Unexpected error entry %+v", e)
+	}
+	if e := entries[2]; e.Path != "/users/2" || e.Route != "GET /users/{id}" {
+		t.Errorf("Expected oldest kept entry /users/2, got %+v", e)
+	}
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/debug/requests", nil))
+	var got []chain.RequestEntry
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil || len(got) != 3 || got[0].Path != "/panic" {
+		t.Errorf("Unexpected JSON view %q (%v)", rec.Body.String(), err)
+	}
+
+	req := httptest.NewRequest("GET", "/debug/requests", nil)
+	req.Header.Set("Accept", "text/html")
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if !strings.Contains(rec.Body.String(), "<td>GET /panic</td>") {
+		t.Errorf("Expected HTML table, got %q", rec.Body.String())
+	}
+}
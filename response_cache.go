@@ -0,0 +1,321 @@
+package chain
+
+import (
+	"context"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CacheOptions configures the ResponseCache middleware.
+type CacheOptions struct {
+	// TTL is how long a cached response is fresh. Defaults to 1 minute.
+	TTL time.Duration
+	// StaleWhileRevalidate enables serving a stale response for this long after it
+	// expires while a single background request refreshes it. Zero disables it.
+	StaleWhileRevalidate time.Duration
+	// Jitter randomly shortens each entry's TTL by up to this fraction (0 to 1) so
+	// that entries cached together do not all expire together.
+	Jitter float64
+	// MaxEntries bounds the number of cached responses. Defaults to 1000.
+	MaxEntries int
+	// Key derives the cache key from a request. Defaults to CacheKey.
+	Key func(r *http.Request) string
+	// Vary lists request headers whose values partition the cache, such as
+	// Accept-Encoding and Accept-Language, so that negotiated responses are
+	// stored once per variant. Values are compared ignoring case and spaces.
+	Vary []string
+	// Partition, if set, returns a further dimension of the key, such as the
+	// tenant or whether the request is authenticated.
+	Partition func(r *http.Request) string
+	// MaxVariants caps the variants stored for one key across Vary and
+	// Partition, so that clients sending arbitrary header values cannot fill the
+	// cache. Requests for further variants are passed to the handler uncached.
+	// Defaults to 16.
+	MaxVariants int
+}
+
+// cacheEntry is a stored response.
+type cacheEntry struct {
+	header     http.Header
+	body       []byte
+	expires    time.Time
+	staleUntil time.Time
+	refreshing bool
+	base       string // key without its variant
+	variant    string
+}
+
+// cacheFill is an in-progress request that other requests for the same key wait on,
+// preventing a stampede on a cold or expired key.
+type cacheFill struct {
+	done  chan struct{}
+	entry *cacheEntry // nil if the response was not cacheable
+}
+
+// responseCache holds the state shared by all requests through one middleware.
+type responseCache struct {
+	opts     CacheOptions
+	mu       sync.Mutex
+	entries  map[string]*cacheEntry
+	fills    map[string]*cacheFill
+	variants map[string]int // stored variants per key, when Vary or Partition is set
+}
+
+// ResponseCache returns middleware that caches successful GET and HEAD responses in
+// memory. Concurrent misses for the same key are collapsed into a single request to
+// the handler. With StaleWhileRevalidate set, expired entries continue to be served
+// during the stale window while one background goroutine per key refreshes them.
+// Responses are marked with an X-Cache header of HIT, STALE or MISS.
+//
+// Only 200 responses without Set-Cookie and without a Cache-Control of no-store or
+// private are stored, and responses with a Vary header only when every header it
+// names is listed in Vary. Because responses are buffered, the middleware is not
+// suitable for streaming handlers.
+func ResponseCache(opts CacheOptions) func(http.Handler) http.Handler {
+	if opts.TTL <= 0 {
+		opts.TTL = time.Minute
+	}
+	if opts.MaxEntries <= 0 {
+		opts.MaxEntries = 1000
+	}
+	if opts.MaxVariants <= 0 {
+		opts.MaxVariants = 16
+	}
+	c := &responseCache{
+		opts:     opts,
+		entries:  make(map[string]*cacheEntry),
+		fills:    make(map[string]*cacheFill),
+		variants: make(map[string]int),
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+			c.serve(next, w, r)
+		})
+	}
+}
+
+// serve answers r from the cache, refreshing or filling it as needed.
+func (c *responseCache) serve(next http.Handler, w http.ResponseWriter, r *http.Request) {
+	var base string
+	if c.opts.Key != nil {
+		base = c.opts.Key(r)
+	} else {
+		base = CacheKey(w, r)
+	}
+	variant := c.variant(r)
+	key := base
+	if variant != "" {
+		key += "\n" + variant
+	}
+	now := time.Now()
+
+	c.mu.Lock()
+	e, ok := c.entries[key]
+	if !ok && variant != "" && c.variants[base] >= c.opts.MaxVariants {
+		c.mu.Unlock()
+		next.ServeHTTP(w, r)
+		return
+	}
+	if ok {
+		if now.Before(e.expires) {
+			c.mu.Unlock()
+			e.writeTo(w, "HIT")
+			return
+		}
+		if now.Before(e.staleUntil) {
+			if !e.refreshing {
+				e.refreshing = true
+				go c.refresh(next, r, e)
+			}
+			c.mu.Unlock()
+			e.writeTo(w, "STALE")
+			return
+		}
+	}
+
+	if fill, ok := c.fills[key]; ok {
+		c.mu.Unlock()
+		<-fill.done
+		if fill.entry != nil {
+			fill.entry.writeTo(w, "HIT")
+			return
+		}
+		// The leader's response was not cacheable, so this request needs its own
+		next.ServeHTTP(w, r)
+		return
+	}
+	fill := &cacheFill{done: make(chan struct{})}
+	c.fills[key] = fill
+	c.mu.Unlock()
+
+	// The buffer starts without the headers set by earlier middleware so that
+	// request-specific values are not stored with the response
+	buf := newBufferedResponse(http.Header{})
+	defer func() {
+		c.mu.Lock()
+		delete(c.fills, key)
+		c.mu.Unlock()
+		close(fill.done)
+	}()
+	next.ServeHTTP(buf, r)
+
+	fill.entry = c.store(base, variant, buf)
+	writeResponse(w, buf.Status(), buf.header, buf.body.Bytes(), "MISS")
+}
+
+// refresh re-runs the handler for a stale entry in the background. The request is
+// detached from the client's cancellation since the client has already been served.
+func (c *responseCache) refresh(next http.Handler, r *http.Request, stale *cacheEntry) {
+	r = r.Clone(context.WithoutCancel(r.Context()))
+	buf := newBufferedResponse(http.Header{})
+	defer func() {
+		// A panicking handler must not leave the entry marked as refreshing forever
+		if recover() != nil || c.store(stale.base, stale.variant, buf) == nil {
+			c.mu.Lock()
+			stale.refreshing = false
+			c.mu.Unlock()
+		}
+	}()
+	next.ServeHTTP(buf, r)
+}
+
+// store caches buf under the key and variant if it is cacheable and returns the
+// new entry, or nil.
+func (c *responseCache) store(base, variant string, buf *bufferedResponse) *cacheEntry {
+	if !cacheable(buf) || !c.covers(buf.header) {
+		return nil
+	}
+	key := base
+	if variant != "" {
+		key += "\n" + variant
+	}
+
+	ttl := c.opts.TTL
+	if c.opts.Jitter > 0 {
+		ttl -= time.Duration(rand.Float64() * c.opts.Jitter * float64(ttl))
+	}
+	now := time.Now()
+	e := &cacheEntry{
+		header:     buf.header.Clone(),
+		body:       append([]byte(nil), buf.body.Bytes()...),
+		expires:    now.Add(ttl),
+		staleUntil: now.Add(ttl + c.opts.StaleWhileRevalidate),
+		base:       base,
+		variant:    variant,
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, exists := c.entries[key]; !exists {
+		if variant != "" && c.variants[base] >= c.opts.MaxVariants {
+			// Concurrent fills of new variants raced past the check in serve
+			return nil
+		}
+		if len(c.entries) >= c.opts.MaxEntries {
+			c.evict(now)
+		}
+		if variant != "" {
+			c.variants[base]++
+		}
+	}
+	c.entries[key] = e
+	return e
+}
+
+// variant returns the Vary and Partition dimensions of r, or an empty string if
+// neither is configured.
+func (c *responseCache) variant(r *http.Request) string {
+	if len(c.opts.Vary) == 0 && c.opts.Partition == nil {
+		return ""
+	}
+	var b strings.Builder
+	for _, name := range c.opts.Vary {
+		v := strings.Join(r.Header.Values(name), ",")
+		b.WriteString(strings.ToLower(strings.ReplaceAll(v, " ", "")))
+		b.WriteByte('\n')
+	}
+	if c.opts.Partition != nil {
+		b.WriteString(c.opts.Partition(r))
+	}
+	return b.String()
+}
+
+// covers reports whether every header named by the response's Vary header is one
+// the cache partitions on, so that the stored response can be served to every
+// request sharing its key.
+func (c *responseCache) covers(header http.Header) bool {
+	for _, v := range header.Values("Vary") {
+		for _, name := range strings.Split(v, ",") {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+			if !slices.ContainsFunc(c.opts.Vary, func(h string) bool { return strings.EqualFold(h, name) }) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// evict removes entries past their stale window, or an arbitrary entry if none
+// have expired. c.mu must be held.
+func (c *responseCache) evict(now time.Time) {
+	for k, e := range c.entries {
+		if now.After(e.staleUntil) {
+			c.remove(k, e)
+		}
+	}
+	if len(c.entries) < c.opts.MaxEntries {
+		return
+	}
+	for k, e := range c.entries {
+		c.remove(k, e)
+		return
+	}
+}
+
+// remove deletes an entry and its variant count. c.mu must be held.
+func (c *responseCache) remove(key string, e *cacheEntry) {
+	delete(c.entries, key)
+	if e.variant == "" {
+		return
+	}
+	if c.variants[e.base]--; c.variants[e.base] <= 0 {
+		delete(c.variants, e.base)
+	}
+}
+
+// cacheable reports whether a buffered response may be stored.
+func cacheable(buf *bufferedResponse) bool {
+	if buf.Status() != http.StatusOK || buf.header.Get("Set-Cookie") != "" {
+		return false
+	}
+	cc := strings.ToLower(buf.header.Get("Cache-Control"))
+	return !strings.Contains(cc, "no-store") && !strings.Contains(cc, "private")
+}
+
+// writeTo sends the cached response to w, marking it with the given X-Cache status.
+func (e *cacheEntry) writeTo(w http.ResponseWriter, status string) {
+	writeResponse(w, http.StatusOK, e.header, e.body, status)
+}
+
+// writeResponse merges header into w's headers and writes the response.
+func writeResponse(w http.ResponseWriter, code int, header http.Header, body []byte, xcache string) {
+	h := w.Header()
+	for k, v := range header {
+		h[k] = append([]string(nil), v...)
+	}
+	h.Set("X-Cache", xcache)
+	w.WriteHeader(code)
+	w.Write(body)
+}
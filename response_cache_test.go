@@ -0,0 +1,190 @@
+package chain_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jpl-au/chain"
+)
+
+func TestResponseCache(t *testing.T) {
+	var calls atomic.Int32
+	mux := chain.New().Use(chain.ResponseCache(chain.CacheOptions{TTL: time.Hour}))
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		w.Write([]byte("v" + strconv.Itoa(int(n))))
+	})
+	mux.HandleFunc("/private", func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Cache-Control", "private")
+	})
+
+	tests := []struct {
+		method string
+		path   string
+		xcache string
+		body   string
+	}{
+		{"GET", "/", "MISS", "v1"},
+		{"GET", "/", "HIT", "v1"},
+		{"GET", "/?page=2", "MISS", "v2"},
+		{"POST", "/", "", "v3"},
+		{"GET", "/private", "MISS", ""},
+		{"GET", "/private", "MISS", ""},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+		if got := rec.Header().Get("X-Cache"); got != tt.xcache {
+			t.Errorf("%s %s: expected X-Cache '%s', got '%s'", tt.method, tt.path, tt.xcache, got)
+		}
+		if rec.Body.String() != tt.body {
+			t.Errorf("%s %s: expected body '%s', got '%s'", tt.method, tt.path, tt.body, rec.Body.String())
+		}
+	}
+	if calls.Load() != 5 {
+		t.Errorf("Expected 5 handler calls, got %d", calls.Load())
+	}
+}
+
+func TestResponseCacheStaleWhileRevalidate(t *testing.T) {
+	var calls atomic.Int32
+	refreshed := make(chan struct{}, 10)
+	mux := chain.New().Use(chain.ResponseCache(chain.CacheOptions{
+		TTL:                  20 * time.Millisecond,
+		StaleWhileRevalidate: time.Hour,
+	}))
+	mux.HandleFunc("GET /", func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		w.Write([]byte("v" + strconv.Itoa(int(n))))
+		if n > 1 {
+			refreshed <- struct{}{}
+		}
+	})
+
+	get := func() (string, string) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+		return rec.Header().Get("X-Cache"), rec.Body.String()
+	}
+
+	get()
+	time.Sleep(30 * time.Millisecond)
+
+	// Several stale reads must trigger exactly one background refresh
+	for i := 0; i < 5; i++ {
+		if xcache, body := get(); xcache != "STALE" || body != "v1" {
+			t.Fatalf("Expected STALE v1, got %s %s", xcache, body)
+		}
+	}
+
+	select {
+	case <-refreshed:
+	case <-time.After(time.Second):
+		t.Fatal("Background refresh did not run")
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	if xcache, body := get(); xcache != "HIT" || body != "v2" {
+		t.Errorf("Expected refreshed HIT v2, got %s %s", xcache, body)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("Expected 2 handler calls, got %d", calls.Load())
+	}
+}
+
+func TestResponseCacheCollapsesConcurrentMisses(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	mux := chain.New().Use(chain.ResponseCache(chain.CacheOptions{TTL: time.Hour}))
+	mux.HandleFunc("GET /", func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		<-release
+		w.Write([]byte("slow"))
+	})
+
+	var wg sync.WaitGroup
+	bodies := make([]string, 10)
+	for i := range bodies {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+			bodies[i] = rec.Body.String()
+		}(i)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if calls.Load() != 1 {
+		t.Errorf("Expected 1 handler call, got %d", calls.Load())
+	}
+	for i, body := range bodies {
+		if body != "slow" {
+			t.Errorf("Request %d: expected body 'slow', got '%s'", i, body)
+		}
+	}
+}
+
+func TestResponseCacheVary(t *testing.T) {
+	var calls atomic.Int32
+	mux := chain.New().Use(chain.ResponseCache(chain.CacheOptions{
+		TTL:         time.Hour,
+		Vary:        []string{"Accept-Language"},
+		Partition:   func(r *http.Request) string { return r.Header.Get("X-Tenant") },
+		MaxVariants: 3,
+	}))
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Vary", "Accept-Language")
+		w.Write([]byte(r.Header.Get("X-Tenant") + ":" + r.Header.Get("Accept-Language")))
+	})
+	mux.HandleFunc("/encoded", func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Vary", "Accept-Encoding")
+	})
+
+	tests := []struct {
+		path   string
+		lang   string
+		tenant string
+		xcache string
+		body   string
+	}{
+		{"/", "en", "a", "MISS", "a:en"},
+		{"/", "EN", "a", "HIT", "a:en"},
+		{"/", "fr", "a", "MISS", "a:fr"},
+		{"/", "en", "b", "MISS", "b:en"},
+		{"/", "en", "a", "HIT", "a:en"},
+		// The fourth variant exceeds MaxVariants and is not cached
+		{"/", "de", "a", "", "a:de"},
+		{"/", "de", "a", "", "a:de"},
+		// Responses varying on a header the cache does not partition on are not stored
+		{"/encoded", "", "", "MISS", ""},
+		{"/encoded", "", "", "MISS", ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", tt.path, nil)
+		req.Header.Set("Accept-Language", tt.lang)
+		req.Header.Set("X-Tenant", tt.tenant)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		if got := rec.Header().Get("X-Cache"); got != tt.xcache {
+			t.Errorf("%s %s/%s: expected X-Cache '%s', got '%s'", tt.path, tt.tenant, tt.lang, tt.xcache, got)
+		}
+		if rec.Body.String() != tt.body {
+			t.Errorf("%s %s/%s: expected body '%s', got '%s'", tt.path, tt.tenant, tt.lang, tt.body, rec.Body.String())
+		}
+	}
+	if calls.Load() != 7 {
+		t.Errorf("Expected 7 handler calls, got %d", calls.Load())
+	}
+}
@@ -2,8 +2,10 @@ package chain
 
 import (
 	"bufio"
+	"context"
 	"net"
 	"net/http"
+	"sync"
 )
 
 // responseWriter wraps http.ResponseWriter and tracks response status and size.
@@ -20,6 +22,45 @@ type responseWriter struct {
 	notFound         http.Handler
 	methodNotAllowed http.Handler
 	ignoreWrites     bool
+	preserved        http.Header
+	hijacked         bool
+
+	// pattern is the pattern of the route serving the request, and pathValues
+	// the route's wildcard names alternating with their matched values, set by
+	// Mux.wrap. The values are recorded here because middleware running before
+	// routing may see a different *http.Request from the one ServeMux matched.
+	pattern    string
+	pathValues []string
+
+	// sloBreaches holds the reasons the request missed its route's SLO, set by
+	// Mux.trackSLO
+	sloBreaches []string
+
+	// errorFormat renders errors written with WriteError, and codecs encode and
+	// decode bodies for Bind and Respond, set by Mux.wrapWriter
+	errorFormat ErrorFormatter
+	codecs      []Codec
+
+	// events is the bus of the router serving the request, set by Mux.wrapWriter
+	events *eventBus
+
+	// priority and cache key query parameters of the route serving the request,
+	// set alongside pattern
+	priority   Priority
+	cacheQuery []string
+
+	// Drain tracking of hijacked and streaming responses, set by Mux.serve
+	drain     *drainer
+	cancel    context.CancelFunc
+	conn      net.Conn
+	longLived bool
+
+	// Hooks registered via OnWriteHeader, run once just before the status is sent
+	beforeWriteHeader []func(status int)
+
+	// Hooks registered by captureBody, run with each part of the body sent. Parts
+	// discarded after interception are not passed to them.
+	onWrite []func(b []byte)
 }
 
 // Compile-time interface checks
@@ -55,6 +96,13 @@ func (rw *responseWriter) WriteHeader(status int) {
 		return
 	}
 
+	// Informational responses such as 100 Continue and 103 Early Hints precede the
+	// final response, so they are passed through without being recorded
+	if status >= 100 && status < 200 && status != http.StatusSwitchingProtocols {
+		rw.ResponseWriter.WriteHeader(status)
+		return
+	}
+
 	// Check for interception (only on first write, before status is set)
 	if rw.status == 0 {
 		if status == http.StatusNotFound && rw.notFound != nil {
@@ -69,6 +117,11 @@ func (rw *responseWriter) WriteHeader(status int) {
 
 	rw.status = status
 	rw.written = true
+	hooks := rw.beforeWriteHeader
+	rw.beforeWriteHeader = nil
+	for _, fn := range hooks {
+		fn(status)
+	}
 	rw.ResponseWriter.WriteHeader(status)
 }
 
@@ -78,11 +131,19 @@ func (rw *responseWriter) handleInterception(handler http.Handler) {
 	rw.methodNotAllowed = nil
 
 	// Clear headers set by the original handler (e.g. ServeMux sets Content-Type)
-	// so the custom handler has a clean slate
+	// so the custom handler has a clean slate, keeping any set before dispatch and
+	// the Allow header that a 405 response must carry
 	h := rw.ResponseWriter.Header()
+	allow := h.Values("Allow")
 	for k := range h {
 		delete(h, k)
 	}
+	for k, v := range rw.preserved {
+		h[k] = v
+	}
+	if len(allow) > 0 {
+		h["Allow"] = allow
+	}
 
 	handler.ServeHTTP(rw, rw.req)
 
@@ -97,11 +158,13 @@ func (rw *responseWriter) Write(b []byte) (int, error) {
 		return len(b), nil
 	}
 	if !rw.written {
-		rw.written = true
-		rw.status = http.StatusOK
+		rw.WriteHeader(http.StatusOK)
 	}
 	size, err := rw.ResponseWriter.Write(b)
 	rw.size += size
+	for _, fn := range rw.onWrite {
+		fn(b[:size])
+	}
 	return size, err
 }
 
@@ -114,13 +177,27 @@ func (rw *responseWriter) Unwrap() http.ResponseWriter {
 // Flush implements http.Flusher.
 // Sends any buffered data to the client.
 func (rw *responseWriter) Flush() {
+	rw.trackLongLived(nil)
 	http.NewResponseController(rw.ResponseWriter).Flush()
 }
 
 // Hijack implements http.Hijacker.
 // Allows the caller to take over the connection.
 func (rw *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
-	return http.NewResponseController(rw.ResponseWriter).Hijack()
+	conn, buf, err := http.NewResponseController(rw.ResponseWriter).Hijack()
+	if err == nil {
+		rw.hijacked = true
+		rw.trackLongLived(conn)
+	}
+	return conn, buf, err
+}
+
+// finish sends the implicit 200 OK for handlers that returned without writing,
+// so that OnWriteHeader hooks run for empty responses too.
+func (rw *responseWriter) finish() {
+	if !rw.written && !rw.hijacked {
+		rw.WriteHeader(http.StatusOK)
+	}
 }
 
 // Push implements http.Pusher.
@@ -133,12 +210,65 @@ func (rw *responseWriter) Push(target string, opts *http.PushOptions) error {
 	return pusher.Push(target, opts)
 }
 
+// OnWriteHeader registers fn to run immediately before the response status and
+// headers are sent, which is the last point at which headers can be changed. fn
+// receives the status code about to be written. It reports false if w was not
+// wrapped by chain (directly or through writers implementing Unwrap), in which
+// case fn is never called.
+func OnWriteHeader(w http.ResponseWriter, fn func(status int)) bool {
+	rw := findResponseWriter(w)
+	if rw == nil {
+		return false
+	}
+	rw.beforeWriteHeader = append(rw.beforeWriteHeader, fn)
+	return true
+}
+
+// RoutePattern returns the full pattern of the route that served the request
+// written to w, such as "GET /users/{id}", or an empty string if no route matched
+// or w was not wrapped by chain. It is intended for middleware registered with
+// UsePre, such as metrics, which can call it after the next handler returns.
+func RoutePattern(w http.ResponseWriter) string {
+	if rw := findResponseWriter(w); rw != nil {
+		return rw.pattern
+	}
+	return ""
+}
+
+// findResponseWriter returns the chain wrapper underlying w, following Unwrap, or nil.
+func findResponseWriter(w http.ResponseWriter) *responseWriter {
+	for {
+		switch rw := w.(type) {
+		case *responseWriter:
+			return rw
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = rw.Unwrap()
+		default:
+			return nil
+		}
+	}
+}
+
 // wrapResponseWriter wraps an http.ResponseWriter.
+// The wrapper is taken from writerPool; Mux.serve returns it once the request
+// is complete.
 func wrapResponseWriter(w http.ResponseWriter, r *http.Request, notFound, methodNotAllowed http.Handler) ResponseWriter {
-	return &responseWriter{
-		ResponseWriter:   w,
-		req:              r,
-		notFound:         notFound,
-		methodNotAllowed: methodNotAllowed,
-	}
+	rw := writerPool.Get().(*responseWriter)
+	rw.ResponseWriter = w
+	rw.req = r
+	rw.notFound = notFound
+	rw.methodNotAllowed = methodNotAllowed
+	return rw
+}
+
+// writerPool recycles response wrappers. Handlers must not use the writer after
+// returning, as net/http already requires.
+var writerPool = sync.Pool{New: func() any { return new(responseWriter) }}
+
+// reset clears rw for reuse, keeping the storage of pathValues so that routes
+// with wildcards do not allocate it for every request.
+func (rw *responseWriter) reset() {
+	values := rw.pathValues
+	clear(values)
+	*rw = responseWriter{pathValues: values[:0]}
 }
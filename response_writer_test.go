@@ -414,3 +414,30 @@ func TestResponseWriter_WithHttpTestServer(t *testing.T) {
 	}
 
 }
+
+func TestOnWriteHeader(t *testing.T) {
+	mock := newMockResponseWriter()
+	rw := wrapResponseWriter(mock, nil, nil, nil)
+
+	var calls []int
+	if !OnWriteHeader(rw, func(status int) {
+		calls = append(calls, status)
+		rw.Header().Set("X-Hook", "1")
+	}) {
+		t.Fatal("OnWriteHeader should accept a chain ResponseWriter")
+	}
+
+	// Writing the body implies a 200 status and must trigger the hook once
+	rw.Write([]byte("a"))
+	rw.Write([]byte("b"))
+
+	if len(calls) != 1 || calls[0] != http.StatusOK {
+		t.Errorf("Expected hook to run once with 200, got %v", calls)
+	}
+	if mock.headers.Get("X-Hook") != "1" {
+		t.Error("Header set by hook was not sent")
+	}
+	if OnWriteHeader(newMockResponseWriter(), func(int) {}) {
+		t.Error("OnWriteHeader should reject a writer not wrapped by chain")
+	}
+}
@@ -0,0 +1,369 @@
+package chain
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tusVersion is the version of the tus resumable upload protocol implemented by
+// Mux.Resumable.
+const tusVersion = "1.0.0"
+
+// Errors returned by UploadStorage implementations.
+var (
+	ErrUploadNotFound = errors.New("chain: upload not found")
+	ErrUploadOffset   = errors.New("chain: upload offset mismatch")
+)
+
+// UploadInfo describes a resumable upload.
+type UploadInfo struct {
+	ID       string
+	Size     int64
+	Offset   int64
+	Metadata map[string]string
+	Expires  time.Time
+}
+
+// UploadStorage persists resumable uploads for Mux.Resumable.
+type UploadStorage interface {
+	// Create records a new, empty upload.
+	Create(ctx context.Context, info UploadInfo) error
+	// Info returns the upload with the given ID, or ErrUploadNotFound.
+	Info(ctx context.Context, id string) (UploadInfo, error)
+	// WriteChunk appends data from r to the upload, which must currently be at
+	// offset, and returns the number of bytes stored. It returns ErrUploadOffset if
+	// the upload is not at offset. Bytes read before r fails must be kept so that
+	// the client can resume from them.
+	WriteChunk(ctx context.Context, id string, offset int64, r io.Reader) (int64, error)
+	// Delete removes the upload and its data.
+	Delete(ctx context.Context, id string) error
+}
+
+// ResumableOptions configures Mux.Resumable.
+type ResumableOptions struct {
+	// Storage persists uploads. Required.
+	Storage UploadStorage
+	// MaxSize is the largest upload accepted, in bytes. Zero means no limit.
+	MaxSize int64
+	// Expiry is how long an upload may remain incomplete. Defaults to 24 hours.
+	Expiry time.Duration
+	// OnComplete, if set, is called once the final chunk of an upload is stored.
+	OnComplete func(ctx context.Context, info UploadInfo)
+}
+
+// resumable serves the tus protocol against a storage backend.
+type resumable struct {
+	opts ResumableOptions
+	path string
+}
+
+// Resumable mounts a resumable upload endpoint implementing the core tus 1.0.0
+// protocol with the creation, expiration and termination extensions:
+//
+//   - OPTIONS prefix advertises the protocol version, extensions and size limit
+//   - POST prefix creates an upload from Upload-Length and Upload-Metadata
+//   - HEAD prefix/{id} reports the current Upload-Offset so clients can resume
+//   - PATCH prefix/{id} appends application/offset+octet-stream data at Upload-Offset
+//   - DELETE prefix/{id} terminates an upload
+//
+// Uploads not completed before they expire answer 410 Gone and are deleted.
+// Returns the Mux instance for method chaining.
+func (m *Mux) Resumable(prefix string, opts ResumableOptions) *Mux {
+	if opts.Storage == nil {
+		panic("chain: nil Storage passed to Resumable")
+	}
+	if opts.Expiry <= 0 {
+		opts.Expiry = 24 * time.Hour
+	}
+	prefix = strings.TrimSuffix(prefix, "/")
+	u := &resumable{opts: opts, path: m.prefix + prefix}
+
+	return m.HandleFunc("OPTIONS "+prefix, u.options).
+		HandleFunc("POST "+prefix, u.create).
+		HandleFunc("HEAD "+prefix+"/{id}", u.head).
+		HandleFunc("PATCH "+prefix+"/{id}", u.patch).
+		HandleFunc("DELETE "+prefix+"/{id}", u.terminate)
+}
+
+// options advertises the server's capabilities.
+func (u *resumable) options(w http.ResponseWriter, r *http.Request) {
+	h := w.Header()
+	h.Set("Tus-Resumable", tusVersion)
+	h.Set("Tus-Version", tusVersion)
+	h.Set("Tus-Extension", "creation,expiration,termination")
+	if u.opts.MaxSize > 0 {
+		h.Set("Tus-Max-Size", strconv.FormatInt(u.opts.MaxSize, 10))
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// create starts a new upload.
+func (u *resumable) create(w http.ResponseWriter, r *http.Request) {
+	if !u.checkVersion(w, r) {
+		return
+	}
+	size, err := strconv.ParseInt(r.Header.Get("Upload-Length"), 10, 64)
+	if err != nil || size < 0 {
+		WriteError(w, r, http.StatusBadRequest, "invalid Upload-Length")
+		return
+	}
+	if u.opts.MaxSize > 0 && size > u.opts.MaxSize {
+		WriteError(w, r, http.StatusRequestEntityTooLarge, "")
+		return
+	}
+	meta, ok := parseUploadMetadata(r.Header.Get("Upload-Metadata"))
+	if !ok {
+		WriteError(w, r, http.StatusBadRequest, "invalid Upload-Metadata")
+		return
+	}
+
+	id := make([]byte, 16)
+	rand.Read(id)
+	info := UploadInfo{
+		ID:       hex.EncodeToString(id),
+		Size:     size,
+		Metadata: meta,
+		Expires:  time.Now().Add(u.opts.Expiry),
+	}
+	if err := u.opts.Storage.Create(r.Context(), info); err != nil {
+		WriteError(w, r, http.StatusInternalServerError, "")
+		return
+	}
+	if size == 0 && u.opts.OnComplete != nil {
+		u.opts.OnComplete(r.Context(), info)
+	}
+
+	w.Header().Set("Location", u.path+"/"+info.ID)
+	w.Header().Set("Upload-Expires", info.Expires.UTC().Format(http.TimeFormat))
+	w.WriteHeader(http.StatusCreated)
+}
+
+// head reports the upload's offset.
+func (u *resumable) head(w http.ResponseWriter, r *http.Request) {
+	if !u.checkVersion(w, r) {
+		return
+	}
+	info, ok := u.lookup(w, r)
+	if !ok {
+		return
+	}
+	h := w.Header()
+	h.Set("Cache-Control", "no-store")
+	h.Set("Upload-Offset", strconv.FormatInt(info.Offset, 10))
+	h.Set("Upload-Length", strconv.FormatInt(info.Size, 10))
+	if len(info.Metadata) > 0 {
+		h.Set("Upload-Metadata", formatUploadMetadata(info.Metadata))
+	}
+	if info.Offset < info.Size {
+		h.Set("Upload-Expires", info.Expires.UTC().Format(http.TimeFormat))
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// patch appends a chunk to the upload.
+func (u *resumable) patch(w http.ResponseWriter, r *http.Request) {
+	if !u.checkVersion(w, r) {
+		return
+	}
+	if r.Header.Get("Content-Type") != "application/offset+octet-stream" {
+		WriteError(w, r, http.StatusUnsupportedMediaType, "")
+		return
+	}
+	offset, err := strconv.ParseInt(r.Header.Get("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		WriteError(w, r, http.StatusBadRequest, "invalid Upload-Offset")
+		return
+	}
+	info, ok := u.lookup(w, r)
+	if !ok {
+		return
+	}
+	if offset != info.Offset {
+		WriteError(w, r, http.StatusConflict, "")
+		return
+	}
+
+	n, err := u.opts.Storage.WriteChunk(r.Context(), info.ID, offset, io.LimitReader(r.Body, info.Size-offset))
+	info.Offset += n
+	if errors.Is(err, ErrUploadOffset) {
+		WriteError(w, r, http.StatusConflict, "")
+		return
+	}
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, "")
+		return
+	}
+	if info.Offset == info.Size && n > 0 && u.opts.OnComplete != nil {
+		u.opts.OnComplete(r.Context(), info)
+	}
+
+	w.Header().Set("Upload-Offset", strconv.FormatInt(info.Offset, 10))
+	if info.Offset < info.Size {
+		w.Header().Set("Upload-Expires", info.Expires.UTC().Format(http.TimeFormat))
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// terminate deletes the upload.
+func (u *resumable) terminate(w http.ResponseWriter, r *http.Request) {
+	if !u.checkVersion(w, r) {
+		return
+	}
+	info, ok := u.lookup(w, r)
+	if !ok {
+		return
+	}
+	if err := u.opts.Storage.Delete(r.Context(), info.ID); err != nil {
+		WriteError(w, r, http.StatusInternalServerError, "")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// checkVersion sets the Tus-Resumable response header and rejects requests for an
+// unsupported protocol version with 412 Precondition Failed.
+func (u *resumable) checkVersion(w http.ResponseWriter, r *http.Request) bool {
+	w.Header().Set("Tus-Resumable", tusVersion)
+	if r.Header.Get("Tus-Resumable") != tusVersion {
+		w.Header().Set("Tus-Version", tusVersion)
+		WriteError(w, r, http.StatusPreconditionFailed, "")
+		return false
+	}
+	return true
+}
+
+// lookup loads the upload named by the request path, answering 404 if it does not
+// exist and 410 if it has expired incomplete.
+func (u *resumable) lookup(w http.ResponseWriter, r *http.Request) (UploadInfo, bool) {
+	info, err := u.opts.Storage.Info(r.Context(), r.PathValue("id"))
+	if errors.Is(err, ErrUploadNotFound) {
+		WriteError(w, r, http.StatusNotFound, "")
+		return info, false
+	}
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, "")
+		return info, false
+	}
+	if info.Offset < info.Size && time.Now().After(info.Expires) {
+		u.opts.Storage.Delete(r.Context(), info.ID)
+		WriteError(w, r, http.StatusGone, "")
+		return info, false
+	}
+	return info, true
+}
+
+// parseUploadMetadata parses comma-separated "key base64value" pairs. The value
+// may be omitted.
+func parseUploadMetadata(header string) (map[string]string, bool) {
+	meta := make(map[string]string)
+	if strings.TrimSpace(header) == "" {
+		return meta, true
+	}
+	for _, pair := range strings.Split(header, ",") {
+		key, encoded, _ := strings.Cut(strings.TrimSpace(pair), " ")
+		if key == "" {
+			return nil, false
+		}
+		value, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, false
+		}
+		meta[key] = string(value)
+	}
+	return meta, true
+}
+
+// formatUploadMetadata encodes metadata for the Upload-Metadata header.
+func formatUploadMetadata(meta map[string]string) string {
+	pairs := make([]string, 0, len(meta))
+	for k, v := range meta {
+		pairs = append(pairs, k+" "+base64.StdEncoding.EncodeToString([]byte(v)))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// MemoryUploadStorage is an in-memory UploadStorage, suited to tests and
+// single-instance deployments with small uploads.
+type MemoryUploadStorage struct {
+	mu      sync.Mutex
+	uploads map[string]*memoryUpload
+}
+
+// memoryUpload is an upload held by MemoryUploadStorage.
+type memoryUpload struct {
+	info UploadInfo
+	data []byte
+}
+
+// NewMemoryUploadStorage returns an empty MemoryUploadStorage.
+func NewMemoryUploadStorage() *MemoryUploadStorage {
+	return &MemoryUploadStorage{uploads: make(map[string]*memoryUpload)}
+}
+
+// Create implements UploadStorage.
+func (s *MemoryUploadStorage) Create(_ context.Context, info UploadInfo) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.uploads[info.ID] = &memoryUpload{info: info}
+	return nil
+}
+
+// Info implements UploadStorage.
+func (s *MemoryUploadStorage) Info(_ context.Context, id string) (UploadInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	up, ok := s.uploads[id]
+	if !ok {
+		return UploadInfo{}, ErrUploadNotFound
+	}
+	return up.info, nil
+}
+
+// WriteChunk implements UploadStorage. The chunk is read fully before it is
+// appended, keeping whatever was received if reading fails.
+func (s *MemoryUploadStorage) WriteChunk(_ context.Context, id string, offset int64, r io.Reader) (int64, error) {
+	chunk, readErr := io.ReadAll(r)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	up, ok := s.uploads[id]
+	if !ok {
+		return 0, ErrUploadNotFound
+	}
+	if up.info.Offset != offset {
+		return 0, ErrUploadOffset
+	}
+	up.data = append(up.data, chunk...)
+	up.info.Offset += int64(len(chunk))
+	return int64(len(chunk)), readErr
+}
+
+// Delete implements UploadStorage.
+func (s *MemoryUploadStorage) Delete(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.uploads, id)
+	return nil
+}
+
+// Data returns a copy of the bytes received so far for the upload with the given ID.
+func (s *MemoryUploadStorage) Data(id string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	up, ok := s.uploads[id]
+	if !ok {
+		return nil, ErrUploadNotFound
+	}
+	return append([]byte(nil), up.data...), nil
+}
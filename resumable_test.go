@@ -0,0 +1,136 @@
+package chain_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jpl-au/chain"
+)
+
+// tusRequest builds a request carrying the Tus-Resumable header.
+func tusRequest(method, target, body string) *http.Request {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Tus-Resumable", "1.0.0")
+	return req
+}
+
+func TestResumable(t *testing.T) {
+	store := chain.NewMemoryUploadStorage()
+	var completed chain.UploadInfo
+	mux := chain.New().Resumable("/files", chain.ResumableOptions{
+		Storage:    store,
+		MaxSize:    100,
+		OnComplete: func(ctx context.Context, info chain.UploadInfo) { completed = info },
+	})
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("OPTIONS", "/files", nil))
+	if rec.Code != http.StatusNoContent || rec.Header().Get("Tus-Max-Size") != "100" {
+		t.Fatalf("Unexpected OPTIONS response %d %v", rec.Code, rec.Header())
+	}
+
+	// Create
+	req := tusRequest("POST", "/files", "")
+	req.Header.Set("Upload-Length", "11")
+	req.Header.Set("Upload-Metadata", "filename aGVsbG8udHh0")
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d", rec.Code)
+	}
+	location := rec.Header().Get("Location")
+	if !strings.HasPrefix(location, "/files/") {
+		t.Fatalf("Unexpected Location '%s'", location)
+	}
+
+	patch := func(offset, body string) *httptest.ResponseRecorder {
+		req := tusRequest("PATCH", location, body)
+		req.Header.Set("Content-Type", "application/offset+octet-stream")
+		req.Header.Set("Upload-Offset", offset)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := patch("0", "hello "); rec.Code != http.StatusNoContent || rec.Header().Get("Upload-Offset") != "6" {
+		t.Fatalf("Unexpected first PATCH %d offset %s", rec.Code, rec.Header().Get("Upload-Offset"))
+	}
+	if rec := patch("0", "again"); rec.Code != http.StatusConflict {
+		t.Errorf("Expected 409 for stale offset, got %d", rec.Code)
+	}
+
+	// Resume: discover the offset, then send the rest
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, tusRequest("HEAD", location, ""))
+	if rec.Header().Get("Upload-Offset") != "6" || rec.Header().Get("Upload-Length") != "11" {
+		t.Fatalf("Unexpected HEAD headers %v", rec.Header())
+	}
+	if rec := patch("6", "world"); rec.Code != http.StatusNoContent || rec.Header().Get("Upload-Offset") != "11" {
+		t.Fatalf("Unexpected final PATCH %d offset %s", rec.Code, rec.Header().Get("Upload-Offset"))
+	}
+
+	id := path.Base(location)
+	if data, _ := store.Data(id); string(data) != "hello world" {
+		t.Errorf("Expected stored 'hello world', got '%s'", data)
+	}
+	if completed.ID != id || completed.Metadata["filename"] != "hello.txt" {
+		t.Errorf("Expected OnComplete with metadata, got %+v", completed)
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, tusRequest("DELETE", location, ""))
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("Expected 204 on terminate, got %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, tusRequest("HEAD", location, ""))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 after terminate, got %d", rec.Code)
+	}
+}
+
+func TestResumableRejections(t *testing.T) {
+	store := chain.NewMemoryUploadStorage()
+	mux := chain.New().Resumable("/files", chain.ResumableOptions{Storage: store, MaxSize: 10})
+
+	req := httptest.NewRequest("POST", "/files", nil)
+	req.Header.Set("Upload-Length", "5")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusPreconditionFailed {
+		t.Errorf("Expected 412 without Tus-Resumable, got %d", rec.Code)
+	}
+
+	req = tusRequest("POST", "/files", "")
+	req.Header.Set("Upload-Length", "50")
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected 413 above MaxSize, got %d", rec.Code)
+	}
+
+	store.Create(context.Background(), chain.UploadInfo{ID: "old", Size: 5, Expires: time.Now().Add(-time.Minute)})
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, tusRequest("HEAD", "/files/old", ""))
+	if rec.Code != http.StatusGone {
+		t.Errorf("Expected 410 for expired upload, got %d", rec.Code)
+	}
+}
+
+func TestResumableErrorFormat(t *testing.T) {
+	mux := chain.New().WithErrorFormat(chain.ProblemErrors)
+	mux.Resumable("/files", chain.ResumableOptions{Storage: chain.NewMemoryUploadStorage(), MaxSize: 10})
+
+	req := tusRequest("POST", "/files", "")
+	req.Header.Set("Upload-Length", "x")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest || rec.Header().Get("Content-Type") != "application/problem+json" {
+		t.Errorf("Expected problem response, got %d %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+}
@@ -0,0 +1,245 @@
+package chain
+
+import (
+	"context"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// RetryOptions configures RetryTransport.
+type RetryOptions struct {
+	// Attempts is the maximum number of attempts per request, including the
+	// first. Defaults to 3.
+	Attempts int
+	// Backoff is the delay before the first retry, doubled for each further
+	// retry and randomised with full jitter. Defaults to 50ms.
+	Backoff time.Duration
+	// MaxBackoff caps the delay between retries. Defaults to 1 second.
+	MaxBackoff time.Duration
+	// Budget limits retries and hedged requests to this fraction of requests, so
+	// that retries cannot multiply the load on an upstream that is already
+	// failing. Up to 10 retries may be made in a burst. Defaults to 0.2.
+	Budget float64
+	// Retry reports whether an attempt failed in a way worth retrying. Defaults to
+	// transport errors and 502, 503 and 504 responses.
+	Retry func(resp *http.Response, err error) bool
+	// HedgeAfter sends a second copy of a request that has not been answered
+	// within this long, using whichever response arrives first. Zero disables
+	// hedging.
+	HedgeAfter time.Duration
+}
+
+// retryTransport holds the state shared by all requests through one RetryTransport.
+type retryTransport struct {
+	base http.RoundTripper
+	opts RetryOptions
+
+	mu     sync.Mutex
+	tokens float64 // retries the budget allows
+}
+
+// retryBurst caps the retry budget's tokens.
+const retryBurst = 10
+
+// RetryTransport returns an http.RoundTripper that retries failed idempotent
+// requests with exponential backoff, for proxies and clients fronting flaky
+// upstreams. Requests with a body are only retried when GetBody is set, as it is
+// for requests built by http.NewRequest and for bodies buffered with BufferBody.
+// Retries stop when the request's context is done, so they stay within its
+// deadline. base defaults to http.DefaultTransport.
+//
+// A failed response is only retried before it is returned, so a proxy never
+// retries once response bytes have been written to its client. With HedgeAfter
+// set, slow requests are also hedged: a second copy is sent and the first
+// successful response is used, cancelling the other.
+func RetryTransport(base http.RoundTripper, opts RetryOptions) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if opts.Attempts <= 0 {
+		opts.Attempts = 3
+	}
+	if opts.Backoff <= 0 {
+		opts.Backoff = 50 * time.Millisecond
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = time.Second
+	}
+	if opts.Budget <= 0 {
+		opts.Budget = 0.2
+	}
+	if opts.Retry == nil {
+		opts.Retry = retryable
+	}
+	return &retryTransport{base: base, opts: opts, tokens: retryBurst}
+}
+
+func (t *retryTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if !idempotent(r.Method) {
+		return t.base.RoundTrip(r)
+	}
+	t.deposit()
+
+	out := r
+	backoff := t.opts.Backoff
+	for attempt := 1; ; attempt++ {
+		resp, err := t.attempt(out)
+		if attempt >= t.opts.Attempts || !t.opts.Retry(resp, err) || r.Context().Err() != nil {
+			return resp, err
+		}
+		next, ok := replay(r)
+		if !ok || !t.withdraw() {
+			return resp, err
+		}
+		if resp != nil {
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(time.Duration(rand.Int64N(int64(backoff) + 1)))
+		select {
+		case <-timer.C:
+		case <-r.Context().Done():
+			timer.Stop()
+			return nil, r.Context().Err()
+		}
+		backoff = min(backoff*2, t.opts.MaxBackoff)
+		out = next
+	}
+}
+
+// hedgeResult is the outcome of one copy of a hedged request.
+type hedgeResult struct {
+	resp   *http.Response
+	err    error
+	cancel context.CancelFunc
+}
+
+// attempt sends r, hedging it if HedgeAfter is set.
+func (t *retryTransport) attempt(r *http.Request) (*http.Response, error) {
+	if t.opts.HedgeAfter <= 0 {
+		return t.base.RoundTrip(r)
+	}
+
+	results := make(chan hedgeResult, 2)
+	send := func(r *http.Request) {
+		ctx, cancel := context.WithCancel(r.Context())
+		go func() {
+			resp, err := t.base.RoundTrip(r.WithContext(ctx))
+			results <- hedgeResult{resp, err, cancel}
+		}()
+	}
+	send(r)
+	pending := 1
+	timer := time.NewTimer(t.opts.HedgeAfter)
+	defer timer.Stop()
+
+	hedge := timer.C
+	var failed *hedgeResult
+	for {
+		select {
+		case <-hedge:
+			hedge = nil
+			if next, ok := replay(r); ok && t.withdraw() {
+				send(next)
+				pending++
+			}
+		case res := <-results:
+			pending--
+			if pending > 0 && t.opts.Retry(res.resp, res.err) {
+				// The other copy may still succeed
+				failed = &res
+				continue
+			}
+			if failed != nil {
+				failed.close()
+			}
+			if pending > 0 {
+				// Abandon the slower copy, discarding its response when it arrives
+				go func() {
+					late := <-results
+					late.close()
+				}()
+			}
+			return res.finish()
+		}
+	}
+}
+
+// close discards a response that will not be returned.
+func (h hedgeResult) close() {
+	if h.resp != nil {
+		h.resp.Body.Close()
+	}
+	h.cancel()
+}
+
+// finish returns the result, releasing its context once the body is closed.
+func (h hedgeResult) finish() (*http.Response, error) {
+	if h.err != nil {
+		h.cancel()
+		return nil, h.err
+	}
+	h.resp.Body = cancelBody{h.resp.Body, h.cancel}
+	return h.resp, nil
+}
+
+// deposit credits the retry budget for a new request.
+func (t *retryTransport) deposit() {
+	t.mu.Lock()
+	t.tokens = min(t.tokens+t.opts.Budget, retryBurst)
+	t.mu.Unlock()
+}
+
+// withdraw takes one retry from the budget, reporting false if it is spent.
+func (t *retryTransport) withdraw() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.tokens < 1 {
+		return false
+	}
+	t.tokens--
+	return true
+}
+
+// replay returns a copy of r that can be sent again, with a fresh body from
+// GetBody. It reports false if r's body cannot be replayed.
+func replay(r *http.Request) (*http.Request, bool) {
+	out := r.Clone(r.Context())
+	if r.Body == nil || r.Body == http.NoBody {
+		return out, true
+	}
+	if r.GetBody == nil {
+		return nil, false
+	}
+	body, err := r.GetBody()
+	if err != nil {
+		return nil, false
+	}
+	out.Body = body
+	return out, true
+}
+
+// retryable is the default RetryOptions.Retry.
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// idempotent reports whether requests with method may safely be sent more than once.
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,135 @@
+package chain_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jpl-au/chain"
+)
+
+func TestRetryTransport(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		failures int32
+		opts     chain.RetryOptions
+		status   int
+		calls    int32
+	}{
+		{"recovers", "GET", 2, chain.RetryOptions{}, http.StatusOK, 3},
+		{"gives up after attempts", "GET", 5, chain.RetryOptions{}, http.StatusServiceUnavailable, 3},
+		{"custom attempts", "GET", 4, chain.RetryOptions{Attempts: 5}, http.StatusOK, 5},
+		{"non-idempotent not retried", "POST", 1, chain.RetryOptions{}, http.StatusServiceUnavailable, 1},
+		{"idempotent put retried", "PUT", 1, chain.RetryOptions{}, http.StatusOK, 2},
+		{"custom predicate", "GET", 1, chain.RetryOptions{Retry: func(*http.Response, error) bool { return false }}, http.StatusServiceUnavailable, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if calls.Add(1) <= tt.failures {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				body, _ := io.ReadAll(r.Body)
+				w.Write(body)
+			}))
+			defer upstream.Close()
+
+			tt.opts.Backoff = time.Millisecond
+			client := &http.Client{Transport: chain.RetryTransport(nil, tt.opts)}
+			req, _ := http.NewRequest(tt.method, upstream.URL, strings.NewReader("payload"))
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if resp.StatusCode != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, resp.StatusCode)
+			}
+			if resp.StatusCode == http.StatusOK && string(body) != "payload" {
+				t.Errorf("Expected replayed body 'payload', got '%s'", body)
+			}
+			if calls.Load() != tt.calls {
+				t.Errorf("Expected %d calls, got %d", tt.calls, calls.Load())
+			}
+		})
+	}
+}
+
+func TestRetryTransportBudget(t *testing.T) {
+	var calls atomic.Int32
+	base := roundTripper(func(r *http.Request) (*http.Response, error) {
+		calls.Add(1)
+		return nil, errors.New("connection refused")
+	})
+	client := &http.Client{Transport: chain.RetryTransport(base, chain.RetryOptions{Attempts: 100, Backoff: time.Microsecond})}
+
+	// The initial burst of 10 retries, plus 0.2 for the request itself, is spent
+	// by the first request; the second may not retry at all
+	client.Get("http://upstream/")
+	if calls.Load() != 11 {
+		t.Errorf("Expected 11 calls for the first request, got %d", calls.Load())
+	}
+	calls.Store(0)
+	client.Get("http://upstream/")
+	if calls.Load() != 1 {
+		t.Errorf("Expected 1 call once the budget is spent, got %d", calls.Load())
+	}
+}
+
+func TestRetryTransportUnreplayableBody(t *testing.T) {
+	var calls atomic.Int32
+	base := roundTripper(func(r *http.Request) (*http.Response, error) {
+		calls.Add(1)
+		return nil, errors.New("connection reset")
+	})
+	req, _ := http.NewRequest("PUT", "http://upstream/", io.NopCloser(strings.NewReader("once")))
+	if _, err := chain.RetryTransport(base, chain.RetryOptions{}).RoundTrip(req); err == nil {
+		t.Error("Expected an error")
+	}
+	if calls.Load() != 1 {
+		t.Errorf("Expected a body without GetBody not to be retried, got %d calls", calls.Load())
+	}
+}
+
+func TestRetryTransportHedge(t *testing.T) {
+	var calls atomic.Int32
+	slowDone := make(chan struct{})
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			// The first copy stalls until it is cancelled
+			<-r.Context().Done()
+			close(slowDone)
+			return
+		}
+		w.Write([]byte("hedged"))
+	}))
+	defer upstream.Close()
+
+	target, _ := url.Parse(upstream.URL)
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	proxy.Transport = chain.RetryTransport(nil, chain.RetryOptions{HedgeAfter: 20 * time.Millisecond})
+	mux := chain.New()
+	mux.Handle("/", proxy)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "hedged" {
+		t.Errorf("Expected hedged response, got %d '%s'", rec.Code, rec.Body.String())
+	}
+	select {
+	case <-slowDone:
+	case <-time.After(time.Second):
+		t.Error("Expected the slower copy to be cancelled")
+	}
+}
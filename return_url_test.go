@@ -0,0 +1,92 @@
+package chain_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/jpl-au/chain"
+)
+
+func TestSafeReturnURL(t *testing.T) {
+	r := httptest.NewRequest("GET", "http://app.example/login", nil)
+
+	tests := []struct {
+		raw  string
+		want string
+		ok   bool
+	}{
+		{"/orders", "/orders", true},
+		{"/orders?page=2#top", "/orders?page=2#top", true},
+		{"/caf%C3%A9", "/caf%C3%A9", true},
+		{"https://app.example/account", "/account", true},
+		{"https://APP.example", "/", true},
+		{"", "", false},
+		{"orders", "", false},
+		{"//evil.example/", "", false},
+		{"/\\evil.example", "", false},
+		{"https://evil.example/", "", false},
+		{"https://app.example.evil.example/", "", false},
+		{"https://user@app.example/", "", false},
+		{"javascript:alert(1)", "", false},
+		{"/ok\r\nSet-Cookie: x=1", "", false},
+		{"ftp://app.example/", "", false},
+		{"/" + strings.Repeat("a", 3000), "", false},
+	}
+	for _, tt := range tests {
+		got, ok := chain.SafeReturnURL(r, tt.raw)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("%q: expected (%q, %v), got (%q, %v)", tt.raw, tt.want, tt.ok, got, ok)
+		}
+	}
+}
+
+func TestLoginRedirect(t *testing.T) {
+	mux := chain.New()
+	mux.WithAuthorizer(chain.AuthorizerFunc(func(*http.Request, chain.Requirements) bool { return false }))
+	mux.WithForbidden(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		chain.LoginRedirect(w, r, "/login?lang=en")
+	}))
+	mux.Group(func(m *chain.Mux) {
+		m.RequireRole("user")
+		m.HandleFunc("/orders", func(w http.ResponseWriter, r *http.Request) {})
+	})
+	mux.HandleFunc("POST /login", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, chain.ReturnURL(r, "/"), http.StatusSeeOther)
+	})
+
+	tests := []struct {
+		method   string
+		location string
+	}{
+		{"GET", "/login?lang=en&return_to=" + url.QueryEscape("/orders?page=2")},
+		{"POST", "/login?lang=en"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(tt.method, "/orders?page=2", nil))
+		if rec.Code != http.StatusSeeOther || rec.Header().Get("Location") != tt.location {
+			t.Errorf("%s: expected 303 to %s, got %d %s", tt.method, tt.location, rec.Code, rec.Header().Get("Location"))
+		}
+	}
+
+	logins := []struct {
+		returnTo string
+		location string
+	}{
+		{"/orders?page=2", "/orders?page=2"},
+		{"https://evil.example/", "/"},
+	}
+	for _, tt := range logins {
+		form := url.Values{chain.ReturnParam: {tt.returnTo}}
+		req := httptest.NewRequest("POST", "/login", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		if got := rec.Header().Get("Location"); got != tt.location {
+			t.Errorf("%s: expected return to %s, got %s", tt.returnTo, tt.location, got)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package chain
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// RevalidateOptions configures RevalidatingTransport.
+type RevalidateOptions struct {
+	// MaxEntries bounds the number of cached responses. Defaults to 1000.
+	MaxEntries int
+	// MaxBody is the largest response body cached, in bytes. Larger responses are
+	// passed through uncached. Defaults to 1 MiB.
+	MaxBody int64
+	// Key derives the cache key from an outbound request. Defaults to the URL.
+	Key func(r *http.Request) string
+}
+
+// revalidateEntry is an upstream response held by RevalidatingTransport.
+type revalidateEntry struct {
+	header http.Header
+	body   []byte
+}
+
+// revalidatingTransport holds the state shared by all requests through one
+// RevalidatingTransport.
+type revalidatingTransport struct {
+	base    http.RoundTripper
+	opts    RevalidateOptions
+	mu      sync.Mutex
+	entries map[string]*revalidateEntry
+}
+
+// RevalidatingTransport returns an http.RoundTripper that caches upstream GET
+// responses carrying an ETag or Last-Modified validator, and revalidates them
+// with If-None-Match and If-Modified-Since on every later request. When the
+// origin answers 304 Not Modified, the cached body is returned as a 200 response
+// with the headers refreshed from the 304, so the origin sends only headers for
+// content that has not changed. base defaults to http.DefaultTransport.
+//
+// Used as the Transport of an httputil.ReverseProxy, this turns the proxy into a
+// small caching gateway; it can equally be passed to Client. Responses are marked
+// with an X-Cache header of REVALIDATED or MISS.
+//
+// The cache is shared between all callers, so requests carrying Authorization or
+// Cookie headers, conditional or Range requests, and responses with Set-Cookie,
+// Vary or a Cache-Control of no-store or private are passed through uncached.
+func RevalidatingTransport(base http.RoundTripper, opts RevalidateOptions) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if opts.MaxEntries <= 0 {
+		opts.MaxEntries = 1000
+	}
+	if opts.MaxBody <= 0 {
+		opts.MaxBody = 1 << 20
+	}
+	if opts.Key == nil {
+		opts.Key = func(r *http.Request) string { return r.URL.String() }
+	}
+	return &revalidatingTransport{base: base, opts: opts, entries: make(map[string]*revalidateEntry)}
+}
+
+func (t *revalidatingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if !revalidatable(r) {
+		return t.base.RoundTrip(r)
+	}
+	key := t.opts.Key(r)
+
+	t.mu.Lock()
+	e := t.entries[key]
+	t.mu.Unlock()
+
+	out := r
+	if e != nil {
+		// RoundTrippers must not modify the caller's request
+		out = r.Clone(r.Context())
+		if etag := e.header.Get("ETag"); etag != "" {
+			out.Header.Set("If-None-Match", etag)
+		}
+		if modified := e.header.Get("Last-Modified"); modified != "" {
+			out.Header.Set("If-Modified-Since", modified)
+		}
+	}
+
+	resp, err := t.base.RoundTrip(out)
+	if err != nil {
+		return nil, err
+	}
+	if e != nil && resp.StatusCode == http.StatusNotModified {
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		return t.revalidated(key, e, resp), nil
+	}
+	if resp.StatusCode != http.StatusOK || !storable(resp.Header) {
+		if e != nil {
+			t.mu.Lock()
+			delete(t.entries, key)
+			t.mu.Unlock()
+		}
+		return resp, nil
+	}
+	return t.store(key, resp)
+}
+
+// revalidated builds the response for a 304 from the origin, refreshing the
+// cached headers with those the 304 carries.
+func (t *revalidatingTransport) revalidated(key string, e *revalidateEntry, notModified *http.Response) *http.Response {
+	header := e.header.Clone()
+	for k, v := range notModified.Header {
+		header[k] = append([]string(nil), v...)
+	}
+	header.Set("Content-Length", strconv.Itoa(len(e.body)))
+
+	t.mu.Lock()
+	t.entries[key] = &revalidateEntry{header: header.Clone(), body: e.body}
+	t.mu.Unlock()
+
+	header.Set("X-Cache", "REVALIDATED")
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         notModified.Proto,
+		ProtoMajor:    notModified.ProtoMajor,
+		ProtoMinor:    notModified.ProtoMinor,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(e.body)),
+		ContentLength: int64(len(e.body)),
+		Request:       notModified.Request,
+		TLS:           notModified.TLS,
+	}
+}
+
+// store reads resp's body and caches it under key if it is within MaxBody. resp
+// is returned with its body replaced by the bytes read.
+func (t *revalidatingTransport) store(key string, resp *http.Response) (*http.Response, error) {
+	body, err := io.ReadAll(io.LimitReader(resp.Body, t.opts.MaxBody+1))
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	if int64(len(body)) > t.opts.MaxBody {
+		// Too large to cache, so the rest is streamed to the caller as it arrives
+		resp.Body = readCloser{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+		return resp, nil
+	}
+	resp.Body.Close()
+
+	t.mu.Lock()
+	if _, exists := t.entries[key]; !exists && len(t.entries) >= t.opts.MaxEntries {
+		for k := range t.entries {
+			delete(t.entries, k)
+			break
+		}
+	}
+	t.entries[key] = &revalidateEntry{header: resp.Header.Clone(), body: body}
+	t.mu.Unlock()
+
+	resp.Header.Set("X-Cache", "MISS")
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
+
+// revalidatable reports whether an outbound request may be answered from or
+// stored in the shared cache.
+func revalidatable(r *http.Request) bool {
+	if r.Method != http.MethodGet {
+		return false
+	}
+	for _, name := range []string{"Authorization", "Cookie", "Range", "If-None-Match", "If-Modified-Since", "If-Match", "If-Unmodified-Since"} {
+		if r.Header.Get(name) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// storable reports whether an upstream response may be stored in the shared cache.
+func storable(h http.Header) bool {
+	if h.Get("ETag") == "" && h.Get("Last-Modified") == "" {
+		return false
+	}
+	if h.Get("Set-Cookie") != "" || h.Get("Vary") != "" {
+		return false
+	}
+	cc := strings.ToLower(h.Get("Cache-Control"))
+	return !strings.Contains(cc, "no-store") && !strings.Contains(cc, "private")
+}
@@ -0,0 +1,149 @@
+package chain_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/jpl-au/chain"
+)
+
+func TestRevalidatingTransport(t *testing.T) {
+	var version, full atomic.Int32
+	version.Store(1)
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		etag := `"v` + strconv.Itoa(int(version.Load())) + `"`
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Cache-Control", "max-age=0")
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		full.Add(1)
+		w.Write([]byte("body " + etag))
+	}))
+	defer origin.Close()
+
+	target, _ := url.Parse(origin.URL)
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	proxy.Transport = chain.RevalidatingTransport(nil, chain.RevalidateOptions{})
+	mux := chain.New()
+	mux.Handle("/", proxy)
+
+	tests := []struct {
+		name    string
+		version int32
+		xcache  string
+		body    string
+	}{
+		{"first request fetches", 1, "MISS", `body "v1"`},
+		{"unchanged is revalidated", 1, "REVALIDATED", `body "v1"`},
+		{"changed is fetched", 2, "MISS", `body "v2"`},
+		{"new version is revalidated", 2, "REVALIDATED", `body "v2"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			version.Store(tt.version)
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest("GET", "/config", nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("Expected 200, got %d", rec.Code)
+			}
+			if got := rec.Header().Get("X-Cache"); got != tt.xcache {
+				t.Errorf("Expected X-Cache '%s', got '%s'", tt.xcache, got)
+			}
+			if rec.Body.String() != tt.body {
+				t.Errorf("Expected body '%s', got '%s'", tt.body, rec.Body.String())
+			}
+		})
+	}
+	if full.Load() != 2 {
+		t.Errorf("Expected 2 full responses from the origin, got %d", full.Load())
+	}
+}
+
+func TestRevalidatingTransportPassThrough(t *testing.T) {
+	var requests, conditional atomic.Int32
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Header.Get("If-None-Match") != "" {
+			conditional.Add(1)
+		}
+		w.Header().Set("ETag", `"x"`)
+		switch r.URL.Path {
+		case "/private":
+			w.Header().Set("Cache-Control", "private")
+		case "/vary":
+			w.Header().Set("Vary", "Accept-Encoding")
+		case "/none":
+			w.Header().Del("ETag")
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer origin.Close()
+
+	client := &http.Client{Transport: chain.RevalidatingTransport(nil, chain.RevalidateOptions{})}
+	get := func(path string, header http.Header) {
+		req, _ := http.NewRequest("GET", origin.URL+path, nil)
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(body) != "ok" {
+			t.Errorf("%s: expected body 'ok', got '%s'", path, body)
+		}
+	}
+
+	for _, path := range []string{"/private", "/vary", "/none"} {
+		get(path, nil)
+		get(path, nil)
+	}
+	auth := http.Header{"Authorization": {"Bearer t"}}
+	get("/auth", auth)
+	get("/auth", auth)
+
+	if conditional.Load() != 0 {
+		t.Errorf("Expected no conditional requests, got %d", conditional.Load())
+	}
+	if requests.Load() != 8 {
+		t.Errorf("Expected 8 requests to reach the origin, got %d", requests.Load())
+	}
+}
+
+func TestRevalidatingTransportMaxBody(t *testing.T) {
+	var conditional atomic.Int32
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") != "" {
+			conditional.Add(1)
+		}
+		w.Header().Set("ETag", `"big"`)
+		w.Write([]byte("0123456789"))
+	}))
+	defer origin.Close()
+
+	client := &http.Client{Transport: chain.RevalidatingTransport(nil, chain.RevalidateOptions{MaxBody: 4})}
+	for range 2 {
+		resp, err := client.Get(origin.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(body) != "0123456789" {
+			t.Errorf("Expected full body, got '%s'", body)
+		}
+	}
+	if conditional.Load() != 0 {
+		t.Errorf("Expected oversized response not to be cached, got %d conditional requests", conditional.Load())
+	}
+}
@@ -0,0 +1,94 @@
+package chain
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// rewriteRule rewrites request paths matching re to the expansion of template.
+type rewriteRule struct {
+	source   string
+	re       *regexp.Regexp
+	template string
+}
+
+// Rewrite registers a rule that rewrites request paths before routing. from is a
+// path using the pattern wildcard syntax, where "{name}" matches one segment and
+// "{name...}" matches the remainder; to may reference the matched wildcards:
+//
+//	mux.Rewrite("/index.php/{page}", "/pages/{page}")
+//
+// Rules are tried in registration order and only the first match is applied. Each
+// rewrite is logged at debug level to help trace which rule matched. Calling
+// Rewrite inside a group registers it on the root Mux.
+// Returns the Mux instance for method chaining.
+func (m *Mux) Rewrite(from, to string) *Mux {
+	var expr, tmpl strings.Builder
+	expr.WriteString("^")
+	for _, s := range patternSegments(from) {
+		switch {
+		case s.param == "":
+			expr.WriteString(regexp.QuoteMeta(s.literal))
+		case s.rest:
+			expr.WriteString("(?P<" + s.param + ">.*)")
+		default:
+			expr.WriteString("(?P<" + s.param + ">[^/]+)")
+		}
+	}
+	expr.WriteString("$")
+
+	// Convert {name} references in to into regexp.Expand's ${name} syntax
+	for _, s := range patternSegments(to) {
+		if s.param == "" {
+			tmpl.WriteString(strings.ReplaceAll(s.literal, "$", "$$"))
+		} else {
+			tmpl.WriteString("${" + s.param + "}")
+		}
+	}
+
+	return m.addRewrite(expr.String(), regexp.MustCompile(expr.String()), tmpl.String())
+}
+
+// RewriteRegexp registers a rule that rewrites request paths matching re before
+// routing. replacement is expanded as by regexp.Regexp.Expand, so it may refer to
+// submatches as $1 or ${name}. The whole path is replaced, and rules are applied
+// as described for Rewrite. Returns the Mux instance for method chaining.
+func (m *Mux) RewriteRegexp(re *regexp.Regexp, replacement string) *Mux {
+	if re == nil {
+		panic("chain: nil regexp passed to RewriteRegexp")
+	}
+	return m.addRewrite(re.String(), re, replacement)
+}
+
+// addRewrite appends a rule to the root Mux.
+func (m *Mux) addRewrite(source string, re *regexp.Regexp, template string) *Mux {
+	m.root.rewrites = append(m.root.rewrites, rewriteRule{source: source, re: re, template: template})
+	return m
+}
+
+// rewrite returns r with its path rewritten by the first matching rule, or r itself
+// if no rule matches. The original request is left untouched.
+func (m *Mux) rewrite(r *http.Request) *http.Request {
+	for _, rule := range m.rewrites {
+		match := rule.re.FindStringSubmatchIndex(r.URL.Path)
+		if match == nil {
+			continue
+		}
+		path := string(rule.re.ExpandString(nil, rule.template, r.URL.Path, match))
+		m.log().Debug("chain: rewrote request path",
+			"rule", rule.source,
+			"from", r.URL.Path,
+			"to", path,
+		)
+
+		r2 := new(http.Request)
+		*r2 = *r
+		u := *r.URL
+		u.Path = path
+		u.RawPath = ""
+		r2.URL = &u
+		return r2
+	}
+	return r
+}
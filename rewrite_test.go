@@ -0,0 +1,70 @@
+package chain_test
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/jpl-au/chain"
+)
+
+func TestRewrite(t *testing.T) {
+	var logs bytes.Buffer
+	mux := chain.New().
+		WithLogger(slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))).
+		Rewrite("/index.php/{page}", "/pages/{page}").
+		Rewrite("/legacy/{rest...}", "/{rest}").
+		Rewrite("/home/{$}", "/pages/home").
+		RewriteRegexp(regexp.MustCompile(`^/article-(\d+)\.html$`), "/articles/$1")
+
+	mux.HandleFunc("GET /pages/{page}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("page " + r.PathValue("page")))
+	})
+	mux.HandleFunc("GET /articles/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("article " + r.PathValue("id")))
+	})
+
+	tests := []struct {
+		path string
+		body string
+	}{
+		{"/index.php/about", "page about"},
+		{"/legacy/pages/contact", "page contact"},
+		{"/article-42.html", "article 42"},
+		{"/pages/direct", "page direct"},
+		{"/home/", "page home"},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest("GET", tt.path, nil))
+		if rec.Body.String() != tt.body {
+			t.Errorf("%s: expected body '%s', got '%s'", tt.path, tt.body, rec.Body.String())
+		}
+	}
+
+	if !strings.Contains(logs.String(), "from=/index.php/about to=/pages/about") {
+		t.Errorf("Expected rewrite to be traced, got '%s'", logs.String())
+	}
+}
+
+func TestRewriteLeavesOriginalRequest(t *testing.T) {
+	var prePath string
+	mux := chain.New().Rewrite("/old", "/new")
+	mux.UsePre(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r)
+			prePath = r.URL.Path
+		})
+	})
+	mux.HandleFunc("/new", func(w http.ResponseWriter, r *http.Request) {})
+
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/old", nil))
+	if prePath != "/old" {
+		t.Errorf("Expected pre-routing middleware to keep '/old', got '%s'", prePath)
+	}
+}
@@ -0,0 +1,143 @@
+package chain
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"runtime"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// RouteInfo describes a registered route.
+type RouteInfo struct {
+	// Pattern is the full pattern as registered with the router, including any prefix.
+	Pattern string
+	// Prefix is the prefix contributed by enclosing Route calls.
+	Prefix string
+	// Middleware holds the function names of the middleware applied to the route,
+	// outermost first.
+	Middleware []string
+	// Requirements are the roles and permissions declared for the route.
+	Requirements Requirements
+	// Deprecated reports whether the route was marked with Deprecated.
+	Deprecated bool
+	// SLOLatency and SLOObjective are the objective declared with SLO, or zero.
+	SLOLatency   time.Duration
+	SLOObjective float64
+	// Priority is the load shedding priority declared with Priority.
+	Priority Priority
+	// CacheQuery lists the query parameters significant to CacheKey, declared
+	// with CacheKeyQuery, or is nil if every parameter is.
+	CacheQuery []string
+	// Disabled is set while the route is switched off with Disable.
+	Disabled *DisabledRoute
+}
+
+// route is a registration recorded on the root Mux.
+type route struct {
+	info    RouteInfo
+	handler http.Handler // with middleware applied
+	wrap    func(http.Handler) http.Handler
+
+	// raw is the handler as registered and group a copy of the settings of the
+	// Mux it was registered on, from which Clone rebuilds handler on the clone.
+	// Both are nil for routes registered without middleware. alias is the
+	// pattern of the canonical route for routes registered with Alias.
+	raw   http.Handler
+	group *Mux
+	alias string
+}
+
+// RouteTable returns every route registered on the router, in registration order.
+func (m *Mux) RouteTable() []RouteInfo {
+	m.root.mu.RLock()
+	defer m.root.mu.RUnlock()
+	disabled := m.root.disabled.Load()
+	table := make([]RouteInfo, len(m.root.routes))
+	for i, rt := range m.root.routes {
+		table[i] = rt.info.clone()
+		if disabled != nil {
+			if d, ok := (*disabled)[rt.info.Pattern]; ok {
+				table[i].Disabled = &d
+			}
+		}
+	}
+	return table
+}
+
+// PrintRoutes writes the route table to w as aligned columns of pattern and
+// middleware, in registration order.
+func (m *Mux) PrintRoutes(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, info := range m.RouteTable() {
+		fmt.Fprintf(tw, "%s\t%s\n", info.Pattern, strings.Join(info.Middleware, ", "))
+	}
+	tw.Flush()
+}
+
+// Match returns the route that would serve r, applying any rewrite rules first.
+// It reports false if no route matches.
+func (m *Mux) Match(r *http.Request) (RouteInfo, bool) {
+	_, pattern := m.root.router.Handler(m.root.rewrite(r))
+	if pattern == "" {
+		return RouteInfo{}, false
+	}
+	rt, ok := m.lookup(pattern)
+	return rt.info.clone(), ok
+}
+
+// lookup returns the registered route with the given full pattern.
+func (m *Mux) lookup(pattern string) (route, bool) {
+	m.root.mu.RLock()
+	defer m.root.mu.RUnlock()
+	for _, rt := range m.root.routes {
+		if rt.info.Pattern == pattern {
+			return rt, true
+		}
+	}
+	return route{}, false
+}
+
+// routeInfo describes a route registered on m under the full pattern.
+func (m *Mux) routeInfo(pattern string) RouteInfo {
+	names := make([]string, len(m.middlewares))
+	for i, mw := range m.middlewares {
+		names[i] = funcName(mw)
+	}
+	info := RouteInfo{
+		Pattern:      pattern,
+		Prefix:       m.prefix,
+		Middleware:   names,
+		Requirements: m.required.clone(),
+		Deprecated:   m.deprecation != nil,
+		Priority:     m.priority,
+		CacheQuery:   slices.Clone(m.cacheQuery),
+	}
+	if m.slo != nil {
+		info.SLOLatency, info.SLOObjective = m.slo.latency, m.slo.objective
+	}
+	return info
+}
+
+// funcName returns the fully qualified name of fn, such as
+// "github.com/acme/app/middleware.Logger". Closures are reported with the
+// compiler-generated suffix, for example "main.main.func1".
+func funcName(fn any) string {
+	return runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Name()
+}
+
+// clone returns a deep copy of info.
+func (info RouteInfo) clone() RouteInfo {
+	info.Middleware = slices.Clone(info.Middleware)
+	info.Requirements = info.Requirements.clone()
+	info.CacheQuery = slices.Clone(info.CacheQuery)
+	if info.Disabled != nil {
+		d := *info.Disabled
+		info.Disabled = &d
+	}
+	return info
+}
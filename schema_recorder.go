@@ -0,0 +1,226 @@
+//go:build !chain_nodebug
+
+package chain
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// NewSchemaRecorder returns an empty SchemaRecorder.
+func NewSchemaRecorder(opts SchemaOptions) *SchemaRecorder {
+	if opts.MaxBody <= 0 {
+		opts.MaxBody = 64 << 10
+	}
+	if opts.Redact == nil {
+		opts.Redact = []string{"password", "secret", "token", "key", "authorization", "card"}
+	}
+	redact := make([]string, len(opts.Redact))
+	for i, s := range opts.Redact {
+		redact[i] = strings.ToLower(s)
+	}
+	opts.Redact = redact
+	return &SchemaRecorder{opts: opts, routes: make(map[string]*RouteSchema)}
+}
+
+// Middleware returns middleware recording each request to a matched route once
+// it completes. Request bodies remain fully readable by the handler.
+func (s *SchemaRecorder) Middleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var reqBody []byte
+			reqType := r.Header.Get("Content-Type")
+			if r.Body != nil && r.Body != http.NoBody && isJSON(reqType) {
+				reqBody, _ = io.ReadAll(io.LimitReader(r.Body, int64(s.opts.MaxBody)+1))
+				r.Body = readCloser{io.MultiReader(bytes.NewReader(reqBody), r.Body), r.Body}
+			}
+
+			rw, ok := w.(ResponseWriter)
+			if !ok {
+				rw = wrapResponseWriter(w, r, nil, nil)
+			}
+			captured := captureBody(rw, s.opts.MaxBody)
+			next.ServeHTTP(rw, r)
+
+			route := RoutePattern(w)
+			if route == "" {
+				return
+			}
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			rs := s.routes[route]
+			if rs == nil {
+				rs = &RouteSchema{Route: route, Responses: make(map[int]*BodySchema)}
+				s.routes[route] = rs
+			}
+			rs.Count++
+			for name := range r.URL.Query() {
+				if !slices.Contains(rs.Query, name) {
+					rs.Query = append(rs.Query, name)
+					slices.Sort(rs.Query)
+				}
+			}
+			if reqBody != nil {
+				if rs.Request == nil {
+					rs.Request = &BodySchema{}
+				}
+				s.observe(rs.Request, reqType, reqBody)
+			}
+			body := rs.Responses[rw.Status()]
+			if body == nil {
+				body = &BodySchema{}
+				rs.Responses[rw.Status()] = body
+			}
+			ct := rw.Header().Get("Content-Type")
+			if isJSON(ct) {
+				s.observe(body, ct, captured.buf.Bytes())
+			} else if body.ContentType == "" {
+				body.ContentType = ct
+			}
+		})
+	}
+}
+
+// Schemas returns the routes observed so far, sorted by route.
+func (s *SchemaRecorder) Schemas() []RouteSchema {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]RouteSchema, 0, len(s.routes))
+	for _, rs := range s.routes {
+		c := *rs
+		c.Query = slices.Clone(rs.Query)
+		c.Request = rs.Request.clone()
+		c.Responses = make(map[int]*BodySchema, len(rs.Responses))
+		for status, b := range rs.Responses {
+			c.Responses[status] = b.clone()
+		}
+		out = append(out, c)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Route < out[j].Route })
+	return out
+}
+
+// ServeHTTP serves the schemas as JSON.
+func (s *SchemaRecorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	JSON(w, http.StatusOK, s.Schemas())
+}
+
+// observe merges a JSON body into b, keeping the first as the example. Bodies
+// that are truncated or not valid JSON are ignored. s.mu must be held.
+func (s *SchemaRecorder) observe(b *BodySchema, contentType string, body []byte) {
+	if len(body) == 0 || len(body) > s.opts.MaxBody {
+		return
+	}
+	var v any
+	if json.Unmarshal(body, &v) != nil {
+		return
+	}
+	if b.ContentType == "" {
+		b.ContentType = contentType
+	}
+	if b.Shape == nil {
+		b.Shape = &Shape{}
+	}
+	b.Shape.merge(v)
+	if b.Example == nil {
+		b.Example, _ = json.Marshal(s.redact(v))
+	}
+}
+
+// redact replaces the values of sensitive object members in v.
+func (s *SchemaRecorder) redact(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, x := range v {
+			lower := strings.ToLower(k)
+			if slices.ContainsFunc(s.opts.Redact, func(r string) bool { return strings.Contains(lower, r) }) {
+				v[k] = "[REDACTED]"
+			} else {
+				v[k] = s.redact(x)
+			}
+		}
+	case []any:
+		for i, x := range v {
+			v[i] = s.redact(x)
+		}
+	}
+	return v
+}
+
+// merge adds the structure of the decoded JSON value v to sh.
+func (sh *Shape) merge(v any) {
+	sh.Count++
+	var typ string
+	switch v := v.(type) {
+	case nil:
+		typ = "null"
+	case bool:
+		typ = "boolean"
+	case float64:
+		typ = "number"
+	case string:
+		typ = "string"
+	case []any:
+		typ = "array"
+		for _, x := range v {
+			if sh.Items == nil {
+				sh.Items = &Shape{}
+			}
+			sh.Items.merge(x)
+		}
+	case map[string]any:
+		typ = "object"
+		if sh.Properties == nil {
+			sh.Properties = make(map[string]*Shape)
+		}
+		for k, x := range v {
+			p := sh.Properties[k]
+			if p == nil {
+				p = &Shape{}
+				sh.Properties[k] = p
+			}
+			p.merge(x)
+		}
+	}
+	if !slices.Contains(sh.Types, typ) {
+		sh.Types = append(sh.Types, typ)
+		slices.Sort(sh.Types)
+	}
+}
+
+// clone returns a deep copy of sh.
+func (sh *Shape) clone() *Shape {
+	if sh == nil {
+		return nil
+	}
+	c := &Shape{Types: slices.Clone(sh.Types), Count: sh.Count, Items: sh.Items.clone()}
+	if sh.Properties != nil {
+		c.Properties = make(map[string]*Shape, len(sh.Properties))
+		for k, p := range sh.Properties {
+			c.Properties[k] = p.clone()
+		}
+	}
+	return c
+}
+
+// clone returns a deep copy of b.
+func (b *BodySchema) clone() *BodySchema {
+	if b == nil {
+		return nil
+	}
+	return &BodySchema{ContentType: b.ContentType, Shape: b.Shape.clone(), Example: slices.Clone(b.Example)}
+}
+
+// isJSON reports whether contentType is JSON, including types such as
+// application/problem+json.
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"))
+}
@@ -0,0 +1,81 @@
+//go:build !chain_nodebug
+
+package chain_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jpl-au/chain"
+)
+
+func TestSchemaRecorder(t *testing.T) {
+	schemas := chain.NewSchemaRecorder(chain.SchemaOptions{})
+	mux := chain.New()
+	mux.UsePre(schemas.Middleware())
+	mux.HandleFunc("POST /users", func(w http.ResponseWriter, r *http.Request) {
+		var in map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+			chain.WriteError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+		chain.JSON(w, http.StatusCreated, map[string]any{"id": 1, "email": in["email"], "tags": []string{"new"}})
+	})
+	mux.HandleFunc("GET /health", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("ok"))
+	})
+	mux.Handle("GET /debug/schemas", schemas)
+
+	post := func(body string) {
+		req := httptest.NewRequest("POST", "/users?invite=1", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("Expected the handler to read the full body, got %d %s", rec.Code, rec.Body.String())
+		}
+	}
+	post(`{"email":"a@example.com","password":"hunter2","age":30}`)
+	post(`{"email":"b@example.com","password":"letmein","age":null}`)
+	post(`{"email":"c@example.com","password":"x"}`)
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/health", nil))
+
+	got := schemas.Schemas()
+	if len(got) != 2 || got[0].Route != "GET /health" || got[1].Route != "POST /users" {
+		t.Fatalf("Unexpected routes: %+v", got)
+	}
+	if health := got[0].Responses[http.StatusOK]; health == nil || health.ContentType != "text/plain" || health.Shape != nil {
+		t.Errorf("Expected a plain text response without shape, got %+v", health)
+	}
+
+	users := got[1]
+	if users.Count != 3 || !slices.Equal(users.Query, []string{"invite"}) {
+		t.Errorf("Unexpected count %d or query %v", users.Count, users.Query)
+	}
+	req := users.Request.Shape
+	if req.Count != 3 || req.Properties["email"].Count != 3 {
+		t.Errorf("Expected email in all 3 requests, got %+v", req)
+	}
+	if age := req.Properties["age"]; age.Count != 2 || !slices.Equal(age.Types, []string{"null", "number"}) {
+		t.Errorf("Expected optional nullable age, got %+v", age)
+	}
+	if strings.Contains(string(users.Request.Example), "hunter2") || !strings.Contains(string(users.Request.Example), "[REDACTED]") {
+		t.Errorf("Expected the password to be redacted, got %s", users.Request.Example)
+	}
+	created := users.Responses[http.StatusCreated]
+	if created == nil || created.Shape.Properties["tags"].Items == nil || created.Shape.Properties["tags"].Items.Types[0] != "string" {
+		t.Errorf("Expected response shape with string tags, got %+v", created)
+	}
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/debug/schemas", nil))
+	var exported []chain.RouteSchema
+	if err := json.Unmarshal(rec.Body.Bytes(), &exported); err != nil || len(exported) != 2 {
+		t.Errorf("Expected exported schemas, got %v: %s", err, rec.Body.String())
+	}
+}
@@ -0,0 +1,155 @@
+package chain
+
+import (
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// SLO breach reasons, reported in SLOStatus and as the reason tag of the StatsD
+// http.slo.breach counter.
+const (
+	SLOBreachLatency = "latency"
+	SLOBreachError   = "error"
+)
+
+// slo is the service level objective declared for routes registered on a Mux.
+type slo struct {
+	latency   time.Duration
+	objective float64
+}
+
+// sloStats counts requests served by a route with an SLO.
+type sloStats struct {
+	slo
+	total    atomic.Uint64
+	slow     atomic.Uint64
+	failed   atomic.Uint64
+	bad      atomic.Uint64
+	breached atomic.Bool
+}
+
+// SLOStatus reports how a route has performed against its objective since the
+// router started.
+type SLOStatus struct {
+	// Latency is the latency target and Objective the fraction of requests that
+	// should meet it without a server error, as passed to SLO.
+	Latency   time.Duration
+	Objective float64
+	// Total counts requests served, Slow those slower than Latency, Failed those
+	// answered with a 5xx status and Bad those that were slow, failed or both.
+	Total, Slow, Failed, Bad uint64
+}
+
+// Compliance returns the fraction of good requests, or 1 if none were served.
+func (s SLOStatus) Compliance() float64 {
+	if s.Total == 0 {
+		return 1
+	}
+	return float64(s.Total-s.Bad) / float64(s.Total)
+}
+
+// SLO declares a service level objective for routes registered afterwards on this
+// Mux: at least objective (such as 0.999) of requests should complete within
+// latency without a 5xx status. Each request missing the objective is counted
+// against the route, reported as an http.slo.breach counter by StatsD.Middleware,
+// and a warning is logged when the route's compliance falls below the objective.
+// Returns the Mux instance for method chaining.
+func (m *Mux) SLO(latency time.Duration, objective float64) *Mux {
+	if latency <= 0 || objective <= 0 || objective > 1 {
+		panic("chain: invalid objective passed to SLO")
+	}
+	m.slo = &slo{latency: latency, objective: objective}
+	return m
+}
+
+// SLOReport returns the status of each route with an SLO, keyed by pattern.
+func (m *Mux) SLOReport() map[string]SLOStatus {
+	root := m.root
+	root.mu.RLock()
+	defer root.mu.RUnlock()
+	report := make(map[string]SLOStatus, len(root.slos))
+	for pattern, s := range root.slos {
+		report[pattern] = s.status()
+	}
+	return report
+}
+
+// status returns a snapshot of the counters.
+func (s *sloStats) status() SLOStatus {
+	return SLOStatus{
+		Latency:   s.latency,
+		Objective: s.objective,
+		Total:     s.total.Load(),
+		Slow:      s.slow.Load(),
+		Failed:    s.failed.Load(),
+		Bad:       s.bad.Load(),
+	}
+}
+
+// trackSLO wraps handler to measure it against the Mux's SLO, if one was
+// declared. Other handlers are returned unchanged.
+func (m *Mux) trackSLO(pattern string, handler http.Handler) http.Handler {
+	if m.slo == nil {
+		return handler
+	}
+	stats := &sloStats{slo: *m.slo}
+	root := m.root
+	root.mu.Lock()
+	if root.slos == nil {
+		root.slos = make(map[string]*sloStats)
+	}
+	root.slos[pattern] = stats
+	root.mu.Unlock()
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		handler.ServeHTTP(w, r)
+		elapsed := time.Since(start)
+
+		status := http.StatusOK
+		rw := findResponseWriter(w)
+		if rw != nil {
+			status = rw.Status()
+		}
+
+		stats.total.Add(1)
+		var reasons []string
+		if elapsed > stats.latency {
+			stats.slow.Add(1)
+			reasons = append(reasons, SLOBreachLatency)
+		}
+		if status >= http.StatusInternalServerError {
+			stats.failed.Add(1)
+			reasons = append(reasons, SLOBreachError)
+		}
+		if len(reasons) > 0 {
+			stats.bad.Add(1)
+		}
+		if rw != nil {
+			rw.sloBreaches = reasons
+		}
+
+		// Warn once each time compliance drops below the objective
+		below := stats.status().Compliance() < stats.objective
+		if stats.breached.Swap(below) != below && below {
+			s := stats.status()
+			m.log().Warn("chain: route below SLO",
+				"pattern", pattern,
+				"objective", stats.objective,
+				"compliance", s.Compliance(),
+				"latency_target", stats.latency,
+				"total", s.Total,
+			)
+		}
+	})
+}
+
+// sloBreaches returns the reasons the request written to w missed its route's
+// SLO, if any.
+func sloBreaches(w http.ResponseWriter) []string {
+	if rw := findResponseWriter(w); rw != nil {
+		return rw.sloBreaches
+	}
+	return nil
+}
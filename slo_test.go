@@ -0,0 +1,94 @@
+package chain_test
+
+import (
+	"bytes"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jpl-au/chain"
+)
+
+func TestSLO(t *testing.T) {
+	var logs bytes.Buffer
+	mux := chain.New().WithLogger(slog.New(slog.NewTextHandler(&logs, nil)))
+	mux.Route("/api", func(api *chain.Mux) {
+		api.SLO(20*time.Millisecond, 0.5)
+		api.HandleFunc("GET /fast", func(w http.ResponseWriter, r *http.Request) {})
+		api.HandleFunc("GET /slow", func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(30 * time.Millisecond)
+		})
+		api.HandleFunc("GET /fail", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		})
+	})
+	mux.HandleFunc("GET /other", func(w http.ResponseWriter, r *http.Request) {})
+
+	for _, path := range []string{"/api/fast", "/api/fast", "/api/slow", "/api/fail", "/api/fail", "/other"} {
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	report := mux.SLOReport()
+	if _, ok := report["GET /other"]; ok {
+		t.Error("Expected no SLO for route registered outside the group")
+	}
+	fast := report["GET /api/fast"]
+	if fast.Total != 2 || fast.Bad != 0 || fast.Compliance() != 1 {
+		t.Errorf("Unexpected fast status %+v", fast)
+	}
+	if slow := report["GET /api/slow"]; slow.Slow != 1 || slow.Bad != 1 {
+		t.Errorf("Unexpected slow status %+v", slow)
+	}
+	fail := report["GET /api/fail"]
+	if fail.Failed != 2 || fail.Latency != 20*time.Millisecond || fail.Objective != 0.5 {
+		t.Errorf("Unexpected fail status %+v", fail)
+	}
+
+	// Each breaching route warns once as it drops below the objective
+	if n := strings.Count(logs.String(), "route below SLO"); n != 2 {
+		t.Errorf("Expected 2 warnings, got %d:\n%s", n, logs.String())
+	}
+
+	for _, info := range mux.RouteTable() {
+		if info.Pattern == "GET /api/fast" && info.SLOObjective != 0.5 {
+			t.Errorf("Expected SLO in route info, got %+v", info)
+		}
+	}
+}
+
+func TestSLOStatsD(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	stats, err := chain.NewStatsD(chain.StatsDOptions{Addr: conn.LocalAddr().String(), DogStatsD: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mux := chain.New().UsePre(stats.Middleware()).SLO(time.Second, 0.99)
+	mux.HandleFunc("GET /fail", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/fail", nil))
+	stats.Close()
+
+	want := "http.slo.breach:1|c|#route:GET /fail,reason:error"
+	if got := readPackets(t, conn); !strings.Contains(got, want) {
+		t.Errorf("Expected %q in:\n%s", want, got)
+	}
+}
+
+func TestSLOInvalid(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected panic for objective above 1")
+		}
+	}()
+	chain.New().SLO(time.Second, 1.5)
+}
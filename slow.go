@@ -0,0 +1,115 @@
+package chain
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"runtime/pprof"
+	"sync"
+	"time"
+)
+
+// SlowRequestOptions configures the SlowRequests middleware.
+type SlowRequestOptions struct {
+	// Threshold is the latency above which a request is considered slow.
+	// Defaults to 1 second.
+	Threshold time.Duration
+	// Logger receives a warning for each slow request. Defaults to slog.Default().
+	Logger *slog.Logger
+	// Sustained is the number of slow requests within Window that triggers
+	// OnSustained. Zero disables profiling.
+	Sustained int
+	// Window is the period over which slow requests are counted for Sustained, and
+	// the minimum time between two calls to OnSustained. Defaults to 1 minute.
+	Window time.Duration
+	// OnSustained receives a goroutine profile, in the text format of
+	// pprof.Lookup("goroutine") with debug=1, when slow requests are sustained.
+	// It is called on its own goroutine.
+	OnSustained func(profile []byte)
+}
+
+// SlowRequests returns middleware that logs requests taking longer than the
+// threshold at warn level, with the method, path, matched route, path parameters,
+// status and duration. With Sustained and OnSustained set, it also captures a
+// goroutine profile when slow requests keep occurring, to help diagnose tail
+// latency without full tracing. Register it with UsePre so that the matched route
+// and its parameters are known after the handler returns.
+func SlowRequests(opts SlowRequestOptions) func(http.Handler) http.Handler {
+	if opts.Threshold <= 0 {
+		opts.Threshold = time.Second
+	}
+	if opts.Window <= 0 {
+		opts.Window = time.Minute
+	}
+	var mu sync.Mutex
+	var recent []time.Time
+	var lastProfile time.Time
+
+	sustained := func(now time.Time) bool {
+		mu.Lock()
+		defer mu.Unlock()
+		cutoff := now.Add(-opts.Window)
+		i := 0
+		for i < len(recent) && recent[i].Before(cutoff) {
+			i++
+		}
+		recent = append(recent[i:], now)
+		if len(recent) < opts.Sustained || now.Sub(lastProfile) < opts.Window {
+			return false
+		}
+		lastProfile = now
+		recent = recent[:0]
+		return true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			next.ServeHTTP(w, r)
+			elapsed := time.Since(start)
+			if elapsed < opts.Threshold {
+				return
+			}
+
+			logger := opts.Logger
+			if logger == nil {
+				logger = slog.Default()
+			}
+			attrs := []any{
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.Duration("duration", elapsed),
+			}
+			if rw, ok := w.(ResponseWriter); ok {
+				attrs = append(attrs, slog.Int("status", rw.Status()))
+			}
+			if route := RoutePattern(w); route != "" {
+				attrs = append(attrs, slog.String("route", route))
+				if params := pathParams(w); len(params) > 0 {
+					attrs = append(attrs, slog.Group("params", params...))
+				}
+			}
+			logger.WarnContext(r.Context(), "slow request", attrs...)
+
+			if opts.Sustained > 0 && opts.OnSustained != nil && sustained(start) {
+				var b bytes.Buffer
+				pprof.Lookup("goroutine").WriteTo(&b, 1)
+				go opts.OnSustained(b.Bytes())
+			}
+		})
+	}
+}
+
+// pathParams returns the wildcard values matched for the route serving w as
+// alternating names and values, in the order they appear in its pattern.
+func pathParams(w http.ResponseWriter) []any {
+	rw := findResponseWriter(w)
+	if rw == nil {
+		return nil
+	}
+	params := make([]any, len(rw.pathValues))
+	for i, v := range rw.pathValues {
+		params[i] = v
+	}
+	return params
+}
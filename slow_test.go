@@ -0,0 +1,76 @@
+package chain_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jpl-au/chain"
+)
+
+func TestSlowRequests(t *testing.T) {
+	var logs bytes.Buffer
+	profiles := make(chan []byte, 1)
+	mux := chain.New().UsePre(chain.SlowRequests(chain.SlowRequestOptions{
+		Threshold:   20 * time.Millisecond,
+		Logger:      slog.New(slog.NewTextHandler(&logs, nil)),
+		Sustained:   2,
+		OnSustained: func(p []byte) { profiles <- p },
+	}))
+	mux.HandleFunc("GET /reports/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Has("slow") {
+			time.Sleep(30 * time.Millisecond)
+		}
+	})
+
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/reports/7", nil))
+	if logs.Len() != 0 {
+		t.Errorf("Expected fast request not to be logged, got '%s'", logs.String())
+	}
+
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/reports/7?slow", nil))
+	for _, want := range []string{"level=WARN", `msg="slow request"`, `route="GET /reports/{id}"`, "params.id=7", "status=200"} {
+		if !strings.Contains(logs.String(), want) {
+			t.Errorf("Expected %s in log, got '%s'", want, logs.String())
+		}
+	}
+	select {
+	case <-profiles:
+		t.Fatal("Expected no profile after a single slow request")
+	default:
+	}
+
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/reports/8?slow", nil))
+	select {
+	case p := <-profiles:
+		if !bytes.Contains(p, []byte("goroutine profile")) {
+			t.Errorf("Expected goroutine profile, got '%.80s'", p)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected a profile after sustained slow requests")
+	}
+}
+
+func TestSlowRequestsParamsAfterDerivedRequest(t *testing.T) {
+	var logs bytes.Buffer
+	mux := chain.New().UsePre(
+		chain.SlowRequests(chain.SlowRequestOptions{Threshold: time.Nanosecond, Logger: slog.New(slog.NewTextHandler(&logs, nil))}),
+		// Pre-routing middleware that derives a new request, as Trace does
+		func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				next.ServeHTTP(w, r.WithContext(context.WithoutCancel(r.Context())))
+			})
+		},
+	)
+	mux.HandleFunc("GET /reports/{id}", func(w http.ResponseWriter, r *http.Request) {})
+
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/reports/7", nil))
+	if !strings.Contains(logs.String(), "params.id=7") {
+		t.Errorf("Expected params.id=7 in log, got '%s'", logs.String())
+	}
+}
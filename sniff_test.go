@@ -0,0 +1,84 @@
+package chain_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jpl-au/chain"
+)
+
+var (
+	pngData = append([]byte("\x89PNG\r\n\x1a\n"), bytes.Repeat([]byte{0}, 100)...)
+	svgData = []byte(`<?xml version="1.0"?><svg xmlns="http://www.w3.org/2000/svg"><script>alert(1)</script></svg>`)
+)
+
+func TestSniffContentType(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"png", pngData, "image/png"},
+		{"svg", svgData, "image/svg+xml"},
+		{"bare svg", []byte(`<svg onload="alert(1)"></svg>`), "image/svg+xml"},
+		{"svg after long comment", []byte("<!--" + strings.Repeat("x", 600) + "--><svg></svg>"), "image/svg+xml"},
+		{"html", []byte("<html><body>hi</body></html>"), "text/html; charset=utf-8"},
+		{"text", []byte("hello"), "text/plain; charset=utf-8"},
+	}
+	for _, tt := range tests {
+		if got := chain.SniffContentType(tt.data); got != tt.want {
+			t.Errorf("%s: expected '%s', got '%s'", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestSniffUploads(t *testing.T) {
+	mux := chain.New().Use(chain.SniffUploads(chain.SniffOptions{AllowedTypes: []string{"image/png", "text/plain"}}))
+	mux.HandleFunc("PUT /avatar", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Write(body)
+	})
+
+	tests := []struct {
+		name     string
+		body     []byte
+		declared string
+		status   int
+	}{
+		{"png", pngData, "image/png", http.StatusOK},
+		{"text with charset", []byte("plain notes"), "text/plain", http.StatusOK},
+		{"svg declared as png", svgData, "image/png", http.StatusUnsupportedMediaType},
+		{"html declared as png", []byte("<html><script>x</script></html>"), "image/png", http.StatusUnsupportedMediaType},
+		{"empty", nil, "image/png", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("PUT", "/avatar", bytes.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.declared)
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+			if rec.Code != tt.status {
+				t.Fatalf("Expected status %d, got %d", tt.status, rec.Code)
+			}
+			if tt.status == http.StatusOK && !bytes.Equal(rec.Body.Bytes(), tt.body) {
+				t.Errorf("Expected the full body to reach the handler")
+			}
+		})
+	}
+}
+
+func TestUploadRejectsSVGAsImage(t *testing.T) {
+	req := multipartRequest(t, nil, map[string][]byte{"cat.png": svgData})
+	_, err := chain.Upload(req, chain.UploadOptions{
+		Sink:         func(chain.UploadPart) (io.Writer, error) { return io.Discard, nil },
+		AllowedTypes: []string{"image/png", "image/jpeg"},
+	})
+	if !errors.Is(err, chain.ErrUploadType) {
+		t.Errorf("Expected ErrUploadType, got %v", err)
+	}
+}
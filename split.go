@@ -0,0 +1,124 @@
+package chain
+
+import (
+	"context"
+	"math/rand/v2"
+	"net/http"
+	"time"
+)
+
+// Variant is one destination of Split, such as the stable and canary builds of a
+// service, typically an httputil.ReverseProxy for its upstream.
+type Variant struct {
+	// Name identifies the variant in the affinity cookie. Required.
+	Name string
+	// Weight is the variant's share of new visitors relative to the other
+	// variants. A variant with zero weight only serves visitors already assigned
+	// to it, which lets a variant be drained.
+	Weight int
+	// Handler serves requests assigned to the variant. Required.
+	Handler http.Handler
+}
+
+// SplitOptions configures the affinity cookie issued by Split.
+type SplitOptions struct {
+	// Cookie names the affinity cookie. Defaults to "chain_split".
+	Cookie string
+	// TTL is how long a visitor stays assigned to a variant. Defaults to 24 hours.
+	TTL time.Duration
+	// Path is the cookie path. Defaults to "/".
+	Path string
+	// Domain is the cookie domain. Defaults to the request host.
+	Domain string
+	// Secure restricts the cookie to HTTPS.
+	Secure bool
+	// SameSite is the cookie's SameSite attribute. Defaults to Lax.
+	SameSite http.SameSite
+}
+
+// splitKey is the context key under which the chosen variant name is stored.
+type splitKey struct{}
+
+// Split returns a handler that divides traffic between variants by weight and
+// keeps each visitor on the variant first chosen for them, using an HttpOnly
+// affinity cookie, so that stateful canaries and multi-upstream proxies see a
+// consistent client. Visitors whose cookie is missing, expired or names an
+// unknown variant are assigned afresh. The chosen variant is available to the
+// variant's handler and middleware via SplitVariant.
+func Split(opts SplitOptions, variants ...Variant) http.Handler {
+	if len(variants) == 0 {
+		panic("chain: no variants passed to Split")
+	}
+	byName := make(map[string]*Variant, len(variants))
+	total := 0
+	for i := range variants {
+		v := &variants[i]
+		if v.Name == "" || v.Handler == nil {
+			panic("chain: variant without name or handler passed to Split")
+		}
+		if _, dup := byName[v.Name]; dup {
+			panic("chain: duplicate variant " + v.Name + " passed to Split")
+		}
+		if v.Weight < 0 {
+			panic("chain: negative weight passed to Split")
+		}
+		byName[v.Name] = v
+		total += v.Weight
+	}
+	if total == 0 {
+		panic("chain: no weighted variants passed to Split")
+	}
+	if opts.Cookie == "" {
+		opts.Cookie = "chain_split"
+	}
+	if opts.TTL <= 0 {
+		opts.TTL = 24 * time.Hour
+	}
+	if opts.Path == "" {
+		opts.Path = "/"
+	}
+	if opts.SameSite == 0 {
+		opts.SameSite = http.SameSiteLaxMode
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var v *Variant
+		if c, err := r.Cookie(opts.Cookie); err == nil {
+			v = byName[c.Value]
+		}
+		if v == nil {
+			v = pickVariant(variants, total)
+			http.SetCookie(w, &http.Cookie{
+				Name:     opts.Cookie,
+				Value:    v.Name,
+				Path:     opts.Path,
+				Domain:   opts.Domain,
+				MaxAge:   int(opts.TTL / time.Second),
+				Secure:   opts.Secure,
+				HttpOnly: true,
+				SameSite: opts.SameSite,
+			})
+		}
+		w.Header().Add("Vary", "Cookie")
+		v.Handler.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), splitKey{}, v.Name)))
+	})
+}
+
+// SplitVariant returns the name of the variant Split chose for r, or an empty
+// string if r was not served through Split.
+func SplitVariant(r *http.Request) string {
+	name, _ := r.Context().Value(splitKey{}).(string)
+	return name
+}
+
+// pickVariant chooses a variant at random in proportion to its weight.
+func pickVariant(variants []Variant, total int) *Variant {
+	n := rand.IntN(total)
+	for i := range variants {
+		if n < variants[i].Weight {
+			return &variants[i]
+		}
+		n -= variants[i].Weight
+	}
+	return &variants[len(variants)-1]
+}
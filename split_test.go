@@ -0,0 +1,108 @@
+package chain_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jpl-au/chain"
+)
+
+func variant(name string, weight int) chain.Variant {
+	return chain.Variant{Name: name, Weight: weight, Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(chain.SplitVariant(r)))
+	})}
+}
+
+func TestSplitAffinity(t *testing.T) {
+	h := chain.Split(chain.SplitOptions{TTL: time.Hour, Secure: true}, variant("stable", 9), variant("canary", 1))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("Expected one cookie, got %d", len(cookies))
+	}
+	c := cookies[0]
+	if c.Name != "chain_split" || c.Value != rec.Body.String() {
+		t.Errorf("Expected cookie naming '%s', got %s=%s", rec.Body.String(), c.Name, c.Value)
+	}
+	if c.MaxAge != 3600 || !c.Secure || !c.HttpOnly || c.SameSite != http.SameSiteLaxMode || c.Path != "/" {
+		t.Errorf("Unexpected cookie attributes: %+v", c)
+	}
+
+	// Later requests stay on the assigned variant without a new cookie
+	for range 20 {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.AddCookie(c)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Body.String() != c.Value {
+			t.Fatalf("Expected variant '%s', got '%s'", c.Value, rec.Body.String())
+		}
+		if rec.Header().Get("Set-Cookie") != "" {
+			t.Fatalf("Expected no new cookie, got '%s'", rec.Header().Get("Set-Cookie"))
+		}
+	}
+}
+
+func TestSplitWeights(t *testing.T) {
+	h := chain.Split(chain.SplitOptions{}, variant("drained", 0), variant("stable", 3), variant("canary", 1))
+
+	counts := map[string]int{}
+	for range 2000 {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+		counts[rec.Body.String()]++
+	}
+	if counts["drained"] != 0 {
+		t.Errorf("Expected no new visitors on a zero-weight variant, got %d", counts["drained"])
+	}
+	if counts["canary"] < 350 || counts["canary"] > 650 {
+		t.Errorf("Expected about 500 canary visitors, got %d", counts["canary"])
+	}
+
+	// Visitors already on a zero-weight variant keep it, and unknown names are reassigned
+	tests := []struct {
+		cookie   string
+		reassign bool
+	}{
+		{"drained", false},
+		{"retired", true},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.AddCookie(&http.Cookie{Name: "chain_split", Value: tt.cookie})
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if got := rec.Header().Get("Set-Cookie") != ""; got != tt.reassign {
+			t.Errorf("%s: expected reassignment %v, got %v", tt.cookie, tt.reassign, got)
+		}
+		if !tt.reassign && rec.Body.String() != tt.cookie {
+			t.Errorf("%s: expected to stay on variant, got '%s'", tt.cookie, rec.Body.String())
+		}
+	}
+}
+
+func TestSplitPanics(t *testing.T) {
+	tests := []struct {
+		name     string
+		variants []chain.Variant
+	}{
+		{"none", nil},
+		{"duplicate", []chain.Variant{variant("a", 1), variant("a", 1)}},
+		{"no weight", []chain.Variant{variant("a", 0)}},
+		{"nil handler", []chain.Variant{{Name: "a", Weight: 1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("Expected panic")
+				}
+			}()
+			chain.Split(chain.SplitOptions{}, tt.variants...)
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package chain
+
+import (
+	"net/http"
+	"sync"
+)
+
+// stacks holds the named middleware stacks registered via Stack and Extend.
+var stacks = struct {
+	sync.RWMutex
+	m map[string][]func(http.Handler) http.Handler
+}{m: make(map[string][]func(http.Handler) http.Handler)}
+
+// Stack registers a named middleware stack that can later be applied with UseStack.
+// Registering a stack under an existing name replaces the previous definition.
+// Stacks are typically defined once at startup so that bundles such as "api" or
+// "admin" stay consistent across every file that registers routes.
+func Stack(name string, mw ...func(http.Handler) http.Handler) {
+	for _, fn := range mw {
+		if fn == nil {
+			panic("chain: nil middleware passed to Stack")
+		}
+	}
+	stacks.Lock()
+	defer stacks.Unlock()
+	stacks.m[name] = append([]func(http.Handler) http.Handler{}, mw...)
+}
+
+// Extend appends middleware to an existing named stack.
+// It panics if no stack has been registered under name.
+func Extend(name string, mw ...func(http.Handler) http.Handler) {
+	for _, fn := range mw {
+		if fn == nil {
+			panic("chain: nil middleware passed to Extend")
+		}
+	}
+	stacks.Lock()
+	defer stacks.Unlock()
+	existing, ok := stacks.m[name]
+	if !ok {
+		panic("chain: unknown stack " + name + " passed to Extend")
+	}
+	stacks.m[name] = append(append([]func(http.Handler) http.Handler{}, existing...), mw...)
+}
+
+// UseStack appends the middleware of each named stack to the Mux's middleware chain,
+// in the order given. The stacks are resolved when UseStack is called, so later
+// changes to a stack do not affect routes that have already applied it.
+// It panics if a name has not been registered with Stack.
+// Returns the Mux instance for method chaining.
+func (m *Mux) UseStack(names ...string) *Mux {
+	stacks.RLock()
+	defer stacks.RUnlock()
+	for _, name := range names {
+		mw, ok := stacks.m[name]
+		if !ok {
+			panic("chain: unknown stack " + name + " passed to UseStack")
+		}
+		m.middlewares = append(m.middlewares, mw...)
+	}
+	return m
+}
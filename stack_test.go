@@ -0,0 +1,84 @@
+package chain_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jpl-au/chain"
+)
+
+// tag returns middleware that appends name to the X-Order response header.
+func tag(name string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("X-Order", name)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func TestUseStack(t *testing.T) {
+	chain.Stack("stack-test-api", tag("a"), tag("b"))
+
+	mux := chain.New()
+	mux.Use(tag("global"))
+	mux.Group(func(api *chain.Mux) {
+		api.UseStack("stack-test-api")
+		api.HandleFunc("GET /api", func(w http.ResponseWriter, r *http.Request) {})
+	})
+	mux.HandleFunc("GET /plain", func(w http.ResponseWriter, r *http.Request) {})
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/api", nil))
+	if got := strings.Join(rec.Header().Values("X-Order"), ","); got != "global,a,b" {
+		t.Errorf("Expected order 'global,a,b', got '%s'", got)
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/plain", nil))
+	if got := strings.Join(rec.Header().Values("X-Order"), ","); got != "global" {
+		t.Errorf("Expected stack to be scoped to the group, got '%s'", got)
+	}
+}
+
+func TestStackExtendAndOverride(t *testing.T) {
+	chain.Stack("stack-test-ext", tag("a"))
+	chain.Extend("stack-test-ext", tag("b"))
+
+	mux := chain.New().UseStack("stack-test-ext")
+	mux.HandleFunc("GET /", func(w http.ResponseWriter, r *http.Request) {})
+
+	// Overriding after UseStack must not affect the already-applied stack
+	chain.Stack("stack-test-ext", tag("c"))
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if got := strings.Join(rec.Header().Values("X-Order"), ","); got != "a,b" {
+		t.Errorf("Expected order 'a,b', got '%s'", got)
+	}
+
+	mux2 := chain.New().UseStack("stack-test-ext")
+	mux2.HandleFunc("GET /", func(w http.ResponseWriter, r *http.Request) {})
+	rec = httptest.NewRecorder()
+	mux2.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if got := strings.Join(rec.Header().Values("X-Order"), ","); got != "c" {
+		t.Errorf("Expected overridden stack 'c', got '%s'", got)
+	}
+}
+
+func TestUnknownStackPanics(t *testing.T) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			t.Fatal("Expected panic for unknown stack, got none")
+		}
+		msg, ok := r.(string)
+		if !ok || msg != "chain: unknown stack missing passed to UseStack" {
+			t.Fatalf("Expected panic message 'chain: unknown stack missing passed to UseStack', got '%v'", r)
+		}
+	}()
+
+	chain.New().UseStack("missing")
+}
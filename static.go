@@ -0,0 +1,190 @@
+package chain
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"html/template"
+	"io"
+	"io/fs"
+	"maps"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// StaticOptions configures Mux.Static.
+type StaticOptions struct {
+	// CacheControl is sent with files requested by their original name. Files
+	// requested by their hashed name are always cached as immutable.
+	// Defaults to "no-cache".
+	CacheControl string
+	// Listing enables directory listings. Directories are otherwise not found.
+	// Files and directories whose names begin with a dot are then hidden from
+	// listings and not found when requested directly.
+	Listing bool
+	// ListingTemplate renders directory listings with a ListingData.
+	// Defaults to DefaultListingTemplate.
+	ListingTemplate *template.Template
+}
+
+// immutable is the Cache-Control value for content-hashed assets.
+const immutable = "public, max-age=31536000, immutable"
+
+// staticFiles serves the regular files of a file system under their original and
+// content-hashed names.
+type staticFiles struct {
+	fsys   fs.FS
+	opts   StaticOptions
+	hashed map[string]string // hashed name to original name
+	names  map[string]string // original name to hashed name
+	dirs   map[string]bool
+}
+
+// Static serves the files of fsys, typically an embed.FS, under prefix. Each file is
+// available both under its original name and under a name containing a hash of its
+// content, such as "/static/app.3f2a1b9c.js". Hashed names are served with a
+// year-long immutable Cache-Control, so templates should link to them via AssetPath
+// and clients fetch new content whenever it changes.
+//
+// Directory listings can be enabled with StaticOptions.Listing. Hashes are computed
+// when Static is called, and only files and directories found then are served, so
+// request paths never reach fsys unchecked. It panics if fsys cannot be read.
+// Returns the Mux instance for method chaining.
+func (m *Mux) Static(prefix string, fsys fs.FS, opts StaticOptions) *Mux {
+	if fsys == nil {
+		panic("chain: nil file system passed to Static")
+	}
+	if opts.CacheControl == "" {
+		opts.CacheControl = "no-cache"
+	}
+	prefix = strings.TrimSuffix(prefix, "/")
+
+	s := &staticFiles{fsys: fsys, opts: opts, hashed: make(map[string]string), names: make(map[string]string), dirs: make(map[string]bool)}
+	if err := s.hash(); err != nil {
+		panic("chain: unreadable file system passed to Static: " + err.Error())
+	}
+
+	urlPrefix := m.prefix + prefix + "/"
+	root := m.root
+	root.mu.Lock()
+	if root.assets == nil {
+		root.assets = make(map[string]string)
+	}
+	for name, hashed := range s.names {
+		root.assets[name] = urlPrefix + hashed
+	}
+	root.mu.Unlock()
+
+	return m.Handle("GET "+prefix+"/{path...}", s)
+}
+
+// AssetPath returns the URL path of the content-hashed version of the named file
+// served by Static anywhere on the router, for use in templates:
+//
+//	template.FuncMap{"asset": mux.AssetPath}
+//
+// Names are relative to the root of the file system passed to Static. If the file
+// is unknown, name is returned unchanged. When several Static mounts contain the
+// same name, the most recent one wins.
+func (m *Mux) AssetPath(name string) string {
+	root := m.root
+	root.mu.RLock()
+	defer root.mu.RUnlock()
+	if p, ok := root.assets[strings.TrimPrefix(name, "/")]; ok {
+		return p
+	}
+	return name
+}
+
+// AssetManifest returns a copy of the mapping from file names to content-hashed URL
+// paths for every file served by Static on the router, for example to hand to a
+// frontend build.
+func (m *Mux) AssetManifest() map[string]string {
+	root := m.root
+	root.mu.RLock()
+	defer root.mu.RUnlock()
+	return maps.Clone(root.assets)
+}
+
+// hash computes the content-hashed name of every regular file in s.fsys.
+func (s *staticFiles) hash() error {
+	return fs.WalkDir(s.fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			s.dirs[name] = true
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		data, err := fs.ReadFile(s.fsys, name)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(data)
+		hashed := hashedName(name, hex.EncodeToString(sum[:4]))
+		s.hashed[hashed] = name
+		s.names[name] = hashed
+		return nil
+	})
+}
+
+// hashedName inserts hash before the extension of name, so "js/app.js" becomes
+// "js/app.<hash>.js".
+func hashedName(name, hash string) string {
+	ext := path.Ext(name)
+	return strings.TrimSuffix(name, ext) + "." + hash + ext
+}
+
+// ServeHTTP serves the file named by the path wildcard.
+func (s *staticFiles) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("path")
+	if s.opts.Listing && hidden(name) {
+		WriteError(w, r, http.StatusNotFound, "")
+		return
+	}
+	if dir := strings.TrimSuffix(name, "/"); s.opts.Listing && (dir == "" || s.dirs[dir]) {
+		if dir == "" {
+			dir = "."
+		}
+		s.serveListing(w, r, dir)
+		return
+	}
+
+	cacheControl := s.opts.CacheControl
+	if original, ok := s.hashed[name]; ok {
+		name = original
+		cacheControl = immutable
+	} else if _, ok := s.names[name]; !ok {
+		WriteError(w, r, http.StatusNotFound, "")
+		return
+	}
+
+	f, err := s.fsys.Open(name)
+	if err != nil {
+		WriteError(w, r, http.StatusNotFound, "")
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, "")
+		return
+	}
+
+	content, ok := f.(io.ReadSeeker)
+	if !ok {
+		data, err := io.ReadAll(f)
+		if err != nil {
+			WriteError(w, r, http.StatusInternalServerError, "")
+			return
+		}
+		content = bytes.NewReader(data)
+	}
+
+	w.Header().Set("Cache-Control", cacheControl)
+	http.ServeContent(w, r, name, info.ModTime(), content)
+}
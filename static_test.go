@@ -0,0 +1,161 @@
+package chain_test
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/jpl-au/chain"
+)
+
+func TestStatic(t *testing.T) {
+	fsys := fstest.MapFS{
+		"app.js":        {Data: []byte("console.log(1)")},
+		"css/site.css":  {Data: []byte("body{}")},
+		"secret/.keep":  {Data: []byte("")},
+		"css/print.css": {Data: []byte("@media print{}")},
+	}
+	mux := chain.New()
+	mux.Route("/assets", func(a *chain.Mux) {
+		a.Static("/static", fsys, chain.StaticOptions{})
+	})
+
+	hashed := mux.AssetPath("app.js")
+	if !strings.HasPrefix(hashed, "/assets/static/app.") || !strings.HasSuffix(hashed, ".js") || hashed == "/assets/static/app.js" {
+		t.Fatalf("Expected hashed asset path, got '%s'", hashed)
+	}
+	if got := mux.AssetPath("missing.js"); got != "missing.js" {
+		t.Errorf("Expected unknown asset to be returned unchanged, got '%s'", got)
+	}
+	if got := mux.AssetManifest()["css/site.css"]; got != mux.AssetPath("css/site.css") {
+		t.Errorf("Expected manifest to match AssetPath, got '%s'", got)
+	}
+
+	tests := []struct {
+		path         string
+		status       int
+		body         string
+		cacheControl string
+	}{
+		{hashed, http.StatusOK, "console.log(1)", "public, max-age=31536000, immutable"},
+		{"/assets/static/app.js", http.StatusOK, "console.log(1)", "no-cache"},
+		{mux.AssetPath("css/site.css"), http.StatusOK, "body{}", "public, max-age=31536000, immutable"},
+		{"/assets/static/css", http.StatusNotFound, "", ""},
+		{"/assets/static/app.00000000.js", http.StatusNotFound, "", ""},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest("GET", tt.path, nil))
+		if rec.Code != tt.status {
+			t.Errorf("%s: expected status %d, got %d", tt.path, tt.status, rec.Code)
+			continue
+		}
+		if tt.status != http.StatusOK {
+			continue
+		}
+		if rec.Body.String() != tt.body {
+			t.Errorf("%s: expected body '%s', got '%s'", tt.path, tt.body, rec.Body.String())
+		}
+		if got := rec.Header().Get("Cache-Control"); got != tt.cacheControl {
+			t.Errorf("%s: expected Cache-Control '%s', got '%s'", tt.path, tt.cacheControl, got)
+		}
+	}
+}
+
+func TestStaticListing(t *testing.T) {
+	now := time.Now()
+	fsys := fstest.MapFS{
+		"b.txt":         {Data: []byte("bb"), ModTime: now},
+		"a.txt":         {Data: []byte("aaaa"), ModTime: now.Add(-time.Hour)},
+		".env":          {Data: []byte("SECRET=1")},
+		".git/config":   {Data: []byte("[core]")},
+		"docs/x.md":     {Data: []byte("x")},
+		"docs/sub/y.md": {Data: []byte("y")},
+		"my docs/a b":   {Data: []byte("z")},
+	}
+	mux := chain.New().Static("/files", fsys, chain.StaticOptions{Listing: true})
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/files/", nil))
+	body := rec.Body.String()
+	if rec.Code != http.StatusOK || !strings.Contains(body, `href="/files/docs/"`) {
+		t.Fatalf("Expected listing of root, got %d:\n%s", rec.Code, body)
+	}
+	if strings.Contains(body, ".env") || strings.Contains(body, ".git") {
+		t.Error("Expected dotfiles to be hidden from listings")
+	}
+	for _, target := range []string{"/files/.env", "/files/.git/", "/files/.git/config"} {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest("GET", target, nil))
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("%s: expected hidden path not found, got %d", target, rec.Code)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/files/my%20docs/", nil))
+	if body := rec.Body.String(); !strings.Contains(body, `href="/files/my%20docs/a%20b"`) {
+		t.Errorf("Expected escaped entry href, got %d:\n%s", rec.Code, body)
+	}
+	if strings.Index(body, "a.txt") > strings.Index(body, "b.txt") {
+		t.Error("Expected entries sorted by name by default")
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/files/?sort=size&order=desc", nil))
+	body = rec.Body.String()
+	if strings.Index(body, "a.txt") > strings.Index(body, "b.txt") {
+		t.Error("Expected larger a.txt first when sorted by size descending")
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/files/docs", nil))
+	if rec.Code != http.StatusMovedPermanently || rec.Header().Get("Location") != "/files/docs/" {
+		t.Errorf("Expected redirect to /files/docs/, got %d %s", rec.Code, rec.Header().Get("Location"))
+	}
+
+	tmpl := template.Must(template.New("custom").Parse(`{{.Parent}}|{{range .Entries}}{{.Name}},{{end}}`))
+	custom := chain.New().Static("/files", fsys, chain.StaticOptions{Listing: true, ListingTemplate: tmpl})
+	rec = httptest.NewRecorder()
+	custom.ServeHTTP(rec, httptest.NewRequest("GET", "/files/docs/", nil))
+	if got := rec.Body.String(); got != "/files/|sub,x.md," {
+		t.Errorf("Expected custom listing '/files/|sub,x.md,', got '%s'", got)
+	}
+}
+
+func TestStaticErrorFormat(t *testing.T) {
+	fsys := fstest.MapFS{"a.txt": {Data: []byte("a")}, ".env": {Data: []byte("SECRET=1")}}
+	mux := chain.New().WithErrorFormat(chain.ProblemErrors)
+	mux.Static("/files", fsys, chain.StaticOptions{Listing: true})
+
+	for _, target := range []string{"/files/missing.txt", "/files/.env"} {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest("GET", target, nil))
+		if rec.Code != http.StatusNotFound || rec.Header().Get("Content-Type") != "application/problem+json" {
+			t.Errorf("%s: expected problem 404, got %d %s", target, rec.Code, rec.Header().Get("Content-Type"))
+		}
+	}
+}
+
+func TestAssetPathPerRouter(t *testing.T) {
+	fsys := fstest.MapFS{"app.js": {Data: []byte("console.log(2)")}}
+	a := chain.New().Static("/a", fsys, chain.StaticOptions{})
+	b := chain.New()
+
+	if got := a.AssetPath("app.js"); !strings.HasPrefix(got, "/a/app.") {
+		t.Errorf("Expected hashed path under /a, got '%s'", got)
+	}
+	if got := b.AssetPath("app.js"); got != "app.js" {
+		t.Errorf("Expected another router's assets not shared, got '%s'", got)
+	}
+	if got := a.Clone(true).AssetPath("app.js"); got != a.AssetPath("app.js") {
+		t.Errorf("Expected clone with routes to keep asset paths, got '%s'", got)
+	}
+	if got := a.Clone(false).AssetPath("app.js"); got != "app.js" {
+		t.Errorf("Expected clone without routes to have no asset paths, got '%s'", got)
+	}
+}
@@ -0,0 +1,203 @@
+package chain
+
+import (
+	"bytes"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StatsDOptions configures a StatsD client.
+type StatsDOptions struct {
+	// Addr is the UDP address of the StatsD agent. Defaults to "127.0.0.1:8125".
+	Addr string
+	// Prefix is prepended to every metric name, for example "myapp.".
+	Prefix string
+	// Tags are added to every metric, for example "env:prod". Tags are only sent
+	// when DogStatsD is set.
+	Tags []string
+	// DogStatsD enables the Datadog tag extension. Plain StatsD has no tags, so the
+	// route and status class are encoded in the metric name instead.
+	DogStatsD bool
+	// FlushInterval is how often buffered metrics are sent. Defaults to 1 second.
+	FlushInterval time.Duration
+	// MaxPacketSize is the largest UDP payload sent. Defaults to 1432 bytes, which
+	// fits a typical Ethernet MTU.
+	MaxPacketSize int
+}
+
+// StatsD buffers metrics and sends them to a StatsD or DogStatsD agent over UDP.
+// Send failures are ignored, as is usual for StatsD, so metrics never affect
+// request handling.
+type StatsD struct {
+	opts StatsDOptions
+	conn net.Conn
+	mu   sync.Mutex
+	buf  bytes.Buffer
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewStatsD returns a StatsD client that flushes in the background until Close is
+// called.
+func NewStatsD(opts StatsDOptions) (*StatsD, error) {
+	if opts.Addr == "" {
+		opts.Addr = "127.0.0.1:8125"
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = time.Second
+	}
+	if opts.MaxPacketSize <= 0 {
+		opts.MaxPacketSize = 1432
+	}
+	conn, err := net.Dial("udp", opts.Addr)
+	if err != nil {
+		return nil, err
+	}
+	s := &StatsD{opts: opts, conn: conn, stop: make(chan struct{}), done: make(chan struct{})}
+	go s.run()
+	return s, nil
+}
+
+// Count adds n to the counter name.
+func (s *StatsD) Count(name string, n int64, tags ...string) {
+	s.emit(name, strconv.FormatInt(n, 10), "c", tags)
+}
+
+// Timing records a duration for the timer name, in milliseconds.
+func (s *StatsD) Timing(name string, d time.Duration, tags ...string) {
+	s.emit(name, strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', 3, 64), "ms", tags)
+}
+
+// Gauge sets the gauge name to value.
+func (s *StatsD) Gauge(name string, value float64, tags ...string) {
+	s.emit(name, strconv.FormatFloat(value, 'f', -1, 64), "g", tags)
+}
+
+// Close flushes buffered metrics and closes the connection.
+func (s *StatsD) Close() error {
+	close(s.stop)
+	<-s.done
+	return s.conn.Close()
+}
+
+// Middleware returns middleware that records, for every request, a counter
+// "http.requests" and a timer "http.request.duration" keyed by the matched route
+// pattern, method and status class (such as "2xx"). Unmatched requests use the
+// route "unmatched". Requests missing the SLO of their route also increment
+// "http.slo.breach", keyed by route and reason ("latency" or "error"). Register it
+// with UsePre so that the route is known and 404s are included.
+func (s *StatsD) Middleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			next.ServeHTTP(w, r)
+			elapsed := time.Since(start)
+
+			status := http.StatusOK
+			if rw, ok := w.(ResponseWriter); ok {
+				status = rw.Status()
+			}
+			route := RoutePattern(w)
+			if route == "" {
+				route = "unmatched"
+			}
+			class := strconv.Itoa(status/100) + "xx"
+
+			breaches := sloBreaches(w)
+
+			if s.opts.DogStatsD {
+				tags := []string{"route:" + route, "method:" + r.Method, "status_class:" + class}
+				s.Count("http.requests", 1, tags...)
+				s.Timing("http.request.duration", elapsed, tags...)
+				for _, reason := range breaches {
+					s.Count("http.slo.breach", 1, "route:"+route, "reason:"+reason)
+				}
+				return
+			}
+			key := "." + statsDName(route) + "." + class
+			s.Count("http.requests"+key, 1)
+			s.Timing("http.request.duration"+key, elapsed)
+			for _, reason := range breaches {
+				s.Count("http.slo.breach."+statsDName(route)+"."+reason, 1)
+			}
+		})
+	}
+}
+
+// emit buffers one metric line, flushing first if it would overflow a packet.
+func (s *StatsD) emit(name, value, kind string, tags []string) {
+	var line strings.Builder
+	line.WriteString(s.opts.Prefix)
+	line.WriteString(name)
+	line.WriteByte(':')
+	line.WriteString(value)
+	line.WriteByte('|')
+	line.WriteString(kind)
+	if s.opts.DogStatsD && len(s.opts.Tags)+len(tags) > 0 {
+		line.WriteString("|#")
+		line.WriteString(strings.Join(append(append([]string(nil), s.opts.Tags...), tags...), ","))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.buf.Len() > 0 && s.buf.Len()+1+line.Len() > s.opts.MaxPacketSize {
+		s.flushLocked()
+	}
+	if s.buf.Len() > 0 {
+		s.buf.WriteByte('\n')
+	}
+	s.buf.WriteString(line.String())
+}
+
+// run flushes on every tick until Close.
+func (s *StatsD) run() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.opts.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.flush()
+		case <-s.stop:
+			s.flush()
+			return
+		}
+	}
+}
+
+// flush sends the buffered metrics.
+func (s *StatsD) flush() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.flushLocked()
+}
+
+// flushLocked sends the buffered metrics. s.mu must be held.
+func (s *StatsD) flushLocked() {
+	if s.buf.Len() == 0 {
+		return
+	}
+	s.conn.Write(s.buf.Bytes())
+	s.buf.Reset()
+}
+
+// statsDName converts a route pattern into a metric name segment, so that
+// "GET /users/{id}" becomes "GET_users_id".
+func statsDName(pattern string) string {
+	var b strings.Builder
+	underscore := false
+	for _, c := range pattern {
+		if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' {
+			b.WriteRune(c)
+			underscore = false
+		} else if !underscore && b.Len() > 0 {
+			b.WriteByte('_')
+			underscore = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "_")
+}
@@ -0,0 +1,72 @@
+package chain_test
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jpl-au/chain"
+)
+
+// readPackets collects the UDP payloads received by conn until it goes quiet.
+func readPackets(t *testing.T, conn net.PacketConn) string {
+	t.Helper()
+	var all []string
+	buf := make([]byte, 65536)
+	for {
+		conn.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			return strings.Join(all, "\n")
+		}
+		all = append(all, string(buf[:n]))
+	}
+}
+
+func TestStatsDMiddleware(t *testing.T) {
+	tests := []struct {
+		name string
+		opts chain.StatsDOptions
+		want []string
+	}{
+		{"dogstatsd", chain.StatsDOptions{Prefix: "app.", Tags: []string{"env:test"}, DogStatsD: true}, []string{
+			"app.http.requests:1|c|#env:test,route:GET /users/{id},method:GET,status_class:2xx",
+			"app.http.requests:1|c|#env:test,route:unmatched,method:GET,status_class:4xx",
+			"app.http.request.duration:",
+		}},
+		{"statsd", chain.StatsDOptions{}, []string{
+			"http.requests.GET_users_id.2xx:1|c",
+			"http.requests.unmatched.4xx:1|c",
+			"http.request.duration.GET_users_id.2xx:",
+		}},
+	}
+
+	for _, tt := range tests {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		tt.opts.Addr = conn.LocalAddr().String()
+		stats, err := chain.NewStatsD(tt.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		mux := chain.New().UsePre(stats.Middleware())
+		mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {})
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/1", nil))
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/missing", nil))
+		stats.Close()
+
+		got := readPackets(t, conn)
+		conn.Close()
+		for _, want := range tt.want {
+			if !strings.Contains(got, want) {
+				t.Errorf("%s: expected %q in:\n%s", tt.name, want, got)
+			}
+		}
+	}
+}
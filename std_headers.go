@@ -0,0 +1,59 @@
+package chain
+
+import (
+	"net/http"
+	"time"
+)
+
+// StandardHeaderOptions configures the StandardHeaders middleware.
+type StandardHeaderOptions struct {
+	// Server is sent as the Server header when non-empty.
+	Server string
+	// RequestIDHeader names a request header, such as "X-Request-Id", whose value is
+	// echoed on the response when present.
+	RequestIDHeader string
+	// ResponseTime adds an X-Response-Time header with the time elapsed between
+	// the middleware running and the response headers being sent.
+	ResponseTime bool
+	// Static headers are set on every response.
+	Static map[string]string
+}
+
+// StandardHeaders returns middleware that appends standard headers to every
+// response just before they are sent, using OnWriteHeader. Headers set this way
+// survive custom 404/405 handlers and reflect the final response, so registering
+// it with UsePre covers every request. Headers already set by a handler are not
+// overwritten.
+func StandardHeaders(opts StandardHeaderOptions) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			var requestID string
+			if opts.RequestIDHeader != "" {
+				requestID = r.Header.Get(opts.RequestIDHeader)
+			}
+
+			OnWriteHeader(w, func(int) {
+				h := w.Header()
+				setDefault := func(key, value string) {
+					if h.Get(key) == "" {
+						h.Set(key, value)
+					}
+				}
+				if opts.Server != "" {
+					setDefault("Server", opts.Server)
+				}
+				if requestID != "" {
+					setDefault(opts.RequestIDHeader, requestID)
+				}
+				if opts.ResponseTime {
+					setDefault("X-Response-Time", time.Since(start).String())
+				}
+				for k, v := range opts.Static {
+					setDefault(k, v)
+				}
+			})
+			next.ServeHTTP(w, r)
+		})
+	}
+}
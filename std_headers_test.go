@@ -0,0 +1,56 @@
+package chain_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jpl-au/chain"
+)
+
+func TestStandardHeaders(t *testing.T) {
+	mux := chain.New().
+		WithNotFound(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		})).
+		UsePre(chain.StandardHeaders(chain.StandardHeaderOptions{
+			Server:          "chain",
+			RequestIDHeader: "X-Request-Id",
+			ResponseTime:    true,
+			Static:          map[string]string{"X-Frame-Options": "DENY"},
+		}))
+	mux.HandleFunc("GET /custom", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Frame-Options", "SAMEORIGIN")
+		w.Write([]byte("ok"))
+	})
+
+	tests := []struct {
+		path  string
+		frame string
+	}{
+		{"/custom", "SAMEORIGIN"},
+		{"/missing", "DENY"},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", tt.path, nil)
+		req.Header.Set("X-Request-Id", "req-1")
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+
+		h := rec.Header()
+		if h.Get("Server") != "chain" {
+			t.Errorf("%s: expected Server 'chain', got '%s'", tt.path, h.Get("Server"))
+		}
+		if h.Get("X-Request-Id") != "req-1" {
+			t.Errorf("%s: expected echoed request ID, got '%s'", tt.path, h.Get("X-Request-Id"))
+		}
+		if _, err := time.ParseDuration(h.Get("X-Response-Time")); err != nil {
+			t.Errorf("%s: expected X-Response-Time duration, got '%s'", tt.path, h.Get("X-Response-Time"))
+		}
+		if h.Get("X-Frame-Options") != tt.frame {
+			t.Errorf("%s: expected X-Frame-Options '%s', got '%s'", tt.path, tt.frame, h.Get("X-Frame-Options"))
+		}
+	}
+}
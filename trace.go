@@ -0,0 +1,200 @@
+package chain
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// TraceFormat selects the trace propagation headers used by TraceTransport.
+type TraceFormat int
+
+// Supported trace propagation formats.
+const (
+	// TraceB3 uses the Zipkin multi-header format: X-B3-TraceId, X-B3-SpanId,
+	// X-B3-ParentSpanId and X-B3-Sampled.
+	TraceB3 TraceFormat = iota
+	// TraceJaeger uses the uber-trace-id header.
+	TraceJaeger
+)
+
+// TraceContext identifies the span serving a request within a distributed trace.
+type TraceContext struct {
+	TraceID      string
+	SpanID       string
+	ParentSpanID string
+	Sampled      bool
+}
+
+// traceKey is the context key under which the request's TraceContext is stored.
+type traceKey struct{}
+
+// Trace returns middleware that joins the trace described by incoming B3 (multi or
+// single "b3" header) or uber-trace-id headers, or starts a new trace when there is
+// none. A new span ID is created for the request, with the caller's span as its
+// parent. The result is available via TraceFrom, added to log records by
+// TraceLogHandler, and forwarded on outbound requests by TraceTransport. This
+// offers log correlation across services without depending on OpenTelemetry.
+func Trace() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tc, ok := extractTrace(r.Header)
+			if ok {
+				tc = TraceContext{TraceID: tc.TraceID, SpanID: newTraceID(8), ParentSpanID: tc.SpanID, Sampled: tc.Sampled}
+			} else {
+				tc = TraceContext{TraceID: newTraceID(16), SpanID: newTraceID(8), Sampled: true}
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), traceKey{}, tc)))
+		})
+	}
+}
+
+// TraceFrom returns the trace context stored by Trace, reporting false if the
+// middleware did not run.
+func TraceFrom(ctx context.Context) (TraceContext, bool) {
+	tc, ok := ctx.Value(traceKey{}).(TraceContext)
+	return tc, ok
+}
+
+// extractTrace reads a trace context from B3 or Jaeger headers.
+func extractTrace(h http.Header) (TraceContext, bool) {
+	if id := h.Get("X-B3-TraceId"); id != "" {
+		span := h.Get("X-B3-SpanId")
+		if !isHexID(id) || !isHexID(span) {
+			return TraceContext{}, false
+		}
+		return TraceContext{TraceID: id, SpanID: span, Sampled: h.Get("X-B3-Sampled") != "0"}, true
+	}
+	if b3 := h.Get("B3"); b3 != "" {
+		// {TraceId}-{SpanId}-{SamplingState}-{ParentSpanId}
+		parts := strings.Split(b3, "-")
+		if len(parts) < 2 || !isHexID(parts[0]) || !isHexID(parts[1]) {
+			return TraceContext{}, false
+		}
+		return TraceContext{TraceID: parts[0], SpanID: parts[1], Sampled: len(parts) < 3 || parts[2] != "0"}, true
+	}
+	if uber := h.Get("Uber-Trace-Id"); uber != "" {
+		// {trace-id}:{span-id}:{parent-span-id}:{flags}
+		parts := strings.Split(uber, ":")
+		if len(parts) != 4 || !isHexID(parts[0]) || !isHexID(parts[1]) || !isHexID(parts[3]) {
+			return TraceContext{}, false
+		}
+		return TraceContext{TraceID: parts[0], SpanID: parts[1], Sampled: hexOdd(parts[3])}, true
+	}
+	return TraceContext{}, false
+}
+
+// hexOdd reports whether the hex number s has its lowest bit, the Jaeger sampled
+// flag, set.
+func hexOdd(s string) bool {
+	switch s[len(s)-1] {
+	case '1', '3', '5', '7', '9', 'b', 'd', 'f', 'B', 'D', 'F':
+		return true
+	}
+	return false
+}
+
+// injectTrace writes tc to h in format f.
+func injectTrace(h http.Header, tc TraceContext, f TraceFormat) {
+	switch f {
+	case TraceJaeger:
+		flags := "0"
+		if tc.Sampled {
+			flags = "1"
+		}
+		parent := tc.ParentSpanID
+		if parent == "" {
+			parent = "0"
+		}
+		h.Set("Uber-Trace-Id", tc.TraceID+":"+tc.SpanID+":"+parent+":"+flags)
+	default:
+		h.Set("X-B3-TraceId", tc.TraceID)
+		h.Set("X-B3-SpanId", tc.SpanID)
+		if tc.ParentSpanID != "" {
+			h.Set("X-B3-ParentSpanId", tc.ParentSpanID)
+		}
+		if tc.Sampled {
+			h.Set("X-B3-Sampled", "1")
+		} else {
+			h.Set("X-B3-Sampled", "0")
+		}
+	}
+}
+
+// TraceTransport returns an http.RoundTripper that propagates the trace context of
+// each outbound request's context in format f, as a child span of the current one.
+// base defaults to http.DefaultTransport. Requests whose context carries no trace
+// are sent unchanged.
+func TraceTransport(base http.RoundTripper, f TraceFormat) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		tc, ok := TraceFrom(r.Context())
+		if !ok {
+			return base.RoundTrip(r)
+		}
+		// RoundTrippers must not modify the caller's request
+		r = r.Clone(r.Context())
+		injectTrace(r.Header, TraceContext{TraceID: tc.TraceID, SpanID: newTraceID(8), ParentSpanID: tc.SpanID, Sampled: tc.Sampled}, f)
+		return base.RoundTrip(r)
+	})
+}
+
+// roundTripperFunc adapts a function to http.RoundTripper.
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+// TraceLogHandler wraps h so that records logged with a context carrying a trace,
+// such as logger.InfoContext(r.Context(), ...), gain trace_id and span_id attributes.
+func TraceLogHandler(h slog.Handler) slog.Handler {
+	return traceLogHandler{h}
+}
+
+// traceLogHandler implements TraceLogHandler.
+type traceLogHandler struct {
+	slog.Handler
+}
+
+func (h traceLogHandler) Handle(ctx context.Context, rec slog.Record) error {
+	if tc, ok := TraceFrom(ctx); ok {
+		rec = rec.Clone()
+		rec.AddAttrs(slog.String("trace_id", tc.TraceID), slog.String("span_id", tc.SpanID))
+	}
+	return h.Handler.Handle(ctx, rec)
+}
+
+func (h traceLogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return traceLogHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h traceLogHandler) WithGroup(name string) slog.Handler {
+	return traceLogHandler{h.Handler.WithGroup(name)}
+}
+
+// newTraceID returns n random bytes as lowercase hex.
+func newTraceID(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// isHexID reports whether s is a plausible trace or span ID: 1 to 32 hex digits.
+func isHexID(s string) bool {
+	if s == "" || len(s) > 32 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
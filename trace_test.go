@@ -0,0 +1,93 @@
+package chain_test
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jpl-au/chain"
+)
+
+func TestTraceExtract(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers map[string]string
+		trace   string
+		parent  string
+		sampled bool
+	}{
+		{"b3 multi", map[string]string{"X-B3-TraceId": "463ac35c9f6413ad", "X-B3-SpanId": "a2fb4a1d1a96d312", "X-B3-Sampled": "1"},
+			"463ac35c9f6413ad", "a2fb4a1d1a96d312", true},
+		{"b3 single", map[string]string{"b3": "80f198ee56343ba864fe8b2a57d3eff7-e457b5a2e4d86bd1-0"},
+			"80f198ee56343ba864fe8b2a57d3eff7", "e457b5a2e4d86bd1", false},
+		{"jaeger", map[string]string{"uber-trace-id": "abc123:def456:0:1"}, "abc123", "def456", true},
+		{"invalid", map[string]string{"X-B3-TraceId": "not-hex", "X-B3-SpanId": "zz"}, "", "", true},
+		{"none", nil, "", "", true},
+	}
+
+	for _, tt := range tests {
+		var got chain.TraceContext
+		h := chain.Trace()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got, _ = chain.TraceFrom(r.Context())
+		}))
+		req := httptest.NewRequest("GET", "/", nil)
+		for k, v := range tt.headers {
+			req.Header.Set(k, v)
+		}
+		h.ServeHTTP(httptest.NewRecorder(), req)
+
+		if got.SpanID == "" || got.SpanID == tt.parent {
+			t.Errorf("%s: expected a new span ID, got '%s'", tt.name, got.SpanID)
+		}
+		if tt.trace == "" {
+			if len(got.TraceID) != 32 || got.ParentSpanID != "" {
+				t.Errorf("%s: expected a new root trace, got %+v", tt.name, got)
+			}
+			continue
+		}
+		if got.TraceID != tt.trace || got.ParentSpanID != tt.parent || got.Sampled != tt.sampled {
+			t.Errorf("%s: expected trace %s parent %s sampled %v, got %+v", tt.name, tt.trace, tt.parent, tt.sampled, got)
+		}
+	}
+}
+
+func TestTraceTransportAndLogs(t *testing.T) {
+	var outbound http.Header
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		outbound = r.Header.Clone()
+	}))
+	defer backend.Close()
+
+	var logs bytes.Buffer
+	logger := slog.New(chain.TraceLogHandler(slog.NewTextHandler(&logs, nil)))
+	client := &http.Client{Transport: chain.TraceTransport(nil, chain.TraceJaeger)}
+
+	var tc chain.TraceContext
+	mux := chain.New().Use(chain.Trace())
+	mux.HandleFunc("GET /", func(w http.ResponseWriter, r *http.Request) {
+		tc, _ = chain.TraceFrom(r.Context())
+		logger.InfoContext(r.Context(), "calling backend")
+		req, _ := http.NewRequestWithContext(r.Context(), "GET", backend.URL, nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("Backend request failed: %v", err)
+		}
+		resp.Body.Close()
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-B3-TraceId", "463ac35c9f6413ad")
+	req.Header.Set("X-B3-SpanId", "a2fb4a1d1a96d312")
+	mux.ServeHTTP(httptest.NewRecorder(), req)
+
+	parts := strings.Split(outbound.Get("Uber-Trace-Id"), ":")
+	if len(parts) != 4 || parts[0] != tc.TraceID || parts[2] != tc.SpanID || parts[3] != "1" {
+		t.Errorf("Expected child span of %+v, got '%s'", tc, outbound.Get("Uber-Trace-Id"))
+	}
+	if !strings.Contains(logs.String(), "trace_id=463ac35c9f6413ad") || !strings.Contains(logs.String(), "span_id="+tc.SpanID) {
+		t.Errorf("Expected trace attributes in log, got '%s'", logs.String())
+	}
+}
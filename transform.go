@@ -0,0 +1,62 @@
+package chain
+
+import (
+	"net/http"
+)
+
+// TransformRule describes a request and/or response transformation applied by the
+// Transform middleware. Either function may be nil.
+type TransformRule struct {
+	// Request modifies the request before it reaches the next handler, for example to
+	// rewrite headers or the path. It is given a clone, so the caller's request is
+	// left untouched.
+	Request func(r *http.Request)
+	// Response rewrites the buffered response. It may modify header in place and
+	// returns the body to send, for example with a <base> tag injected or sensitive
+	// fields masked.
+	Response func(r *http.Request, status int, header http.Header, body []byte) []byte
+}
+
+// Transform returns middleware that applies rules in order. Request functions run
+// before the handler. If any rule has a Response function, the response is buffered
+// in memory and passed through each of them before being sent, with Content-Length
+// recalculated. Streaming responses are therefore not suitable for response rules.
+func Transform(rules ...TransformRule) func(http.Handler) http.Handler {
+	buffer, modify := false, false
+	for _, rule := range rules {
+		if rule.Response != nil {
+			buffer = true
+		}
+		if rule.Request != nil {
+			modify = true
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if modify {
+				r = r.Clone(r.Context())
+				for _, rule := range rules {
+					if rule.Request != nil {
+						rule.Request(r)
+					}
+				}
+			}
+			if !buffer {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			buf := newBufferedResponse(w.Header())
+			next.ServeHTTP(buf, r)
+
+			body := buf.body.Bytes()
+			for _, rule := range rules {
+				if rule.Response != nil {
+					body = rule.Response(r, buf.Status(), buf.header, body)
+				}
+			}
+			buf.writeTo(w, body)
+		})
+	}
+}
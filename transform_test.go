@@ -0,0 +1,64 @@
+package chain_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/jpl-au/chain"
+)
+
+func TestTransform(t *testing.T) {
+	var seenHeader string
+	mux := chain.New().Use(chain.Transform(
+		chain.TransformRule{
+			Request: func(r *http.Request) {
+				r.Header.Set("X-Forwarded-Prefix", "/app")
+			},
+		},
+		chain.TransformRule{
+			Response: func(r *http.Request, status int, header http.Header, body []byte) []byte {
+				header.Del("X-Internal")
+				return bytes.Replace(body, []byte("<head>"), []byte(`<head><base href="/app/">`), 1)
+			},
+		},
+		chain.TransformRule{
+			Response: func(r *http.Request, status int, header http.Header, body []byte) []byte {
+				return bytes.ReplaceAll(body, []byte("secret"), []byte("******"))
+			},
+		},
+	))
+	mux.HandleFunc("GET /", func(w http.ResponseWriter, r *http.Request) {
+		seenHeader = r.Header.Get("X-Forwarded-Prefix")
+		w.Header().Set("X-Internal", "1")
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte("<html><head></head><body>secret</body></html>"))
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	want := `<html><head><base href="/app/"></head><body>******</body></html>`
+	if rec.Body.String() != want {
+		t.Errorf("Expected body '%s', got '%s'", want, rec.Body.String())
+	}
+	if rec.Code != http.StatusAccepted {
+		t.Errorf("Expected status 202, got %d", rec.Code)
+	}
+	if rec.Header().Get("X-Internal") != "" {
+		t.Error("Expected X-Internal header to be removed")
+	}
+	if got := rec.Header().Get("Content-Length"); got != strconv.Itoa(len(want)) {
+		t.Errorf("Expected Content-Length %d, got '%s'", len(want), got)
+	}
+	if seenHeader != "/app" {
+		t.Errorf("Expected request transformation, got '%s'", seenHeader)
+	}
+	if got := req.Header.Get("X-Forwarded-Prefix"); got != "" {
+		t.Errorf("Expected the caller's request left untouched, got '%s'", got)
+	}
+}
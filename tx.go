@@ -0,0 +1,96 @@
+package chain
+
+import (
+	"context"
+	"net/http"
+)
+
+// Tx is a unit of work, such as a database transaction, scoped to one request.
+// *sql.Tx satisfies it.
+type Tx interface {
+	Commit() error
+	Rollback() error
+}
+
+// TxOptions configures the Transaction middleware.
+type TxOptions struct {
+	// Begin starts a transaction for the request. Required.
+	Begin func(r *http.Request) (Tx, error)
+	// Skip, if set, reports whether a request should run without a transaction,
+	// for example read-only routes.
+	Skip func(r *http.Request) bool
+	// Reporter receives errors from Begin, Commit and Rollback. Defaults to NopReporter.
+	Reporter Reporter
+}
+
+// txKey is the context key under which the request's transaction is stored.
+type txKey struct{}
+
+// Transaction returns middleware that runs each request in a transaction. The
+// transaction is begun before the handler runs and is available via TxFrom. It is
+// committed when the handler returns with a 1xx, 2xx or 3xx status and rolled back
+// on a 4xx or 5xx status or a panic, which is re-raised after the rollback.
+//
+// The commit happens after the handler returns, so a handler that has already sent
+// a success response cannot report a failed commit to the client. A failure to
+// begin responds with 500 without calling the handler; a failure to commit responds
+// with 500 only if the response has not been started.
+func Transaction(opts TxOptions) func(http.Handler) http.Handler {
+	if opts.Begin == nil {
+		panic("chain: nil Begin passed to Transaction")
+	}
+	if opts.Reporter == nil {
+		opts.Reporter = NopReporter
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if opts.Skip != nil && opts.Skip(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			tx, err := opts.Begin(r)
+			if err != nil {
+				opts.Reporter.Report(r.Context(), err, nil, r)
+				WriteError(w, r, http.StatusInternalServerError, "")
+				return
+			}
+
+			rw, ok := w.(ResponseWriter)
+			if !ok {
+				rw = wrapResponseWriter(w, r, nil, nil)
+			}
+
+			committed := false
+			defer func() {
+				if committed {
+					return
+				}
+				if err := tx.Rollback(); err != nil {
+					opts.Reporter.Report(r.Context(), err, nil, r)
+				}
+			}()
+
+			next.ServeHTTP(rw, r.WithContext(context.WithValue(r.Context(), txKey{}, tx)))
+
+			if rw.Status() >= http.StatusBadRequest {
+				return
+			}
+			committed = true
+			if err := tx.Commit(); err != nil {
+				opts.Reporter.Report(r.Context(), err, nil, r)
+				if !rw.Written() {
+					WriteError(rw, r, http.StatusInternalServerError, "")
+				}
+			}
+		})
+	}
+}
+
+// TxFrom returns the transaction begun by Transaction for r, or nil if the
+// middleware did not run or skipped the request.
+func TxFrom(r *http.Request) Tx {
+	tx, _ := r.Context().Value(txKey{}).(Tx)
+	return tx
+}
@@ -0,0 +1,89 @@
+package chain_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jpl-au/chain"
+)
+
+// fakeTx records how a transaction ended.
+type fakeTx struct {
+	committed, rolledBack bool
+	commitErr             error
+}
+
+func (tx *fakeTx) Commit() error   { tx.committed = true; return tx.commitErr }
+func (tx *fakeTx) Rollback() error { tx.rolledBack = true; return nil }
+
+func TestTransaction(t *testing.T) {
+	var tx *fakeTx
+	mux := chain.New().Use(chain.Transaction(chain.TxOptions{
+		Begin: func(r *http.Request) (chain.Tx, error) {
+			tx = &fakeTx{}
+			if r.URL.Query().Has("fail") {
+				tx.commitErr = errors.New("commit failed")
+			}
+			return tx, nil
+		},
+		Skip: func(r *http.Request) bool { return r.Method == http.MethodGet },
+	}))
+	mux.HandleFunc("POST /ok", func(w http.ResponseWriter, r *http.Request) {
+		if chain.TxFrom(r) == nil {
+			t.Error("Expected transaction in context")
+		}
+	})
+	mux.HandleFunc("POST /bad", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	})
+	mux.HandleFunc("POST /panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+	mux.HandleFunc("GET /ok", func(w http.ResponseWriter, r *http.Request) {
+		if chain.TxFrom(r) != nil {
+			t.Error("Expected skipped request to run without a transaction")
+		}
+	})
+
+	tests := []struct {
+		path       string
+		status     int
+		committed  bool
+		rolledBack bool
+	}{
+		{"/ok", http.StatusOK, true, false},
+		{"/ok?fail", http.StatusInternalServerError, true, false},
+		{"/bad", http.StatusUnprocessableEntity, false, true},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest("POST", tt.path, nil))
+		if rec.Code != tt.status {
+			t.Errorf("%s: expected status %d, got %d", tt.path, tt.status, rec.Code)
+		}
+		if tx.committed != tt.committed || tx.rolledBack != tt.rolledBack {
+			t.Errorf("%s: expected committed=%v rolledBack=%v, got %v %v",
+				tt.path, tt.committed, tt.rolledBack, tx.committed, tx.rolledBack)
+		}
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("Expected panic to be re-raised")
+			}
+		}()
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/panic", nil))
+	}()
+	if tx.committed || !tx.rolledBack {
+		t.Error("Expected panic to roll back the transaction")
+	}
+
+	tx = nil
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/ok", nil))
+	if tx != nil {
+		t.Error("Expected Begin not to be called for a skipped request")
+	}
+}
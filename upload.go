@@ -0,0 +1,293 @@
+package chain
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+)
+
+// Errors returned by Upload.
+var (
+	ErrUploadTooLarge = errors.New("chain: upload too large")
+	ErrUploadType     = errors.New("chain: upload content type not allowed")
+	ErrTooManyFiles   = errors.New("chain: too many files in upload")
+	ErrUploadRejected = errors.New("chain: upload rejected by scanner")
+)
+
+// Scanner inspects uploaded files for malware or prohibited content, typically by
+// streaming them to a service such as clamd or an ICAP server.
+type Scanner interface {
+	// Scan reads the file from r as Upload copies it to its sink, and returns a
+	// non-nil error if the file is infected or could not be scanned. Reading
+	// from r fails if the upload fails part way through.
+	Scan(ctx context.Context, part UploadPart, r io.Reader) error
+}
+
+// ScanPolicy selects what Upload does with a file its Scanner does not pass.
+type ScanPolicy int
+
+const (
+	// ScanReject fails the upload with an error wrapping ErrUploadRejected.
+	ScanReject ScanPolicy = iota
+	// ScanQuarantine keeps the file, marking it Quarantined in the result, and
+	// continues with the rest of the upload.
+	ScanQuarantine
+)
+
+// UploadOptions configures Upload.
+type UploadOptions struct {
+	// Sink returns the destination for a file part, such as a temporary file or an
+	// object storage writer. If the writer also implements io.Closer it is closed
+	// once the part has been copied, including when the copy fails. Required.
+	Sink func(part UploadPart) (io.Writer, error)
+	// MaxFileSize is the largest file accepted, in bytes. Defaults to 32 MiB.
+	MaxFileSize int64
+	// MaxTotalSize is the largest request body accepted, in bytes. Defaults to 128 MiB.
+	MaxTotalSize int64
+	// MaxFieldSize is the largest non-file form value accepted, in bytes.
+	// Defaults to 1 MiB.
+	MaxFieldSize int64
+	// MaxFiles is the largest number of files accepted. Defaults to 16.
+	MaxFiles int
+	// AllowedTypes lists the media types files may have, as detected by sniffing
+	// their leading bytes with SniffContentType, ignoring parameters such as
+	// charset. Empty allows any type.
+	AllowedTypes []string
+	// SniffLen is the number of leading bytes of each file examined to detect its
+	// type. Defaults to 512.
+	SniffLen int
+	// Progress, if set, is called as each chunk of a file is copied.
+	Progress func(p UploadProgress)
+	// Scanner, if set, scans each file as it is copied.
+	Scanner Scanner
+	// ScanPolicy selects how files the Scanner does not pass are handled, both
+	// when a threat is found and when scanning fails. Defaults to ScanReject.
+	ScanPolicy ScanPolicy
+}
+
+// UploadPart describes a file part passed to UploadOptions.Sink.
+type UploadPart struct {
+	// FormName is the name of the form field.
+	FormName string
+	// FileName is the file name supplied by the client. It is not sanitised and
+	// must not be used as a path.
+	FileName string
+	// ContentType is the sniffed content type.
+	ContentType string
+}
+
+// UploadProgress reports the progress of an upload.
+type UploadProgress struct {
+	UploadPart
+	// Written is the number of bytes of the current file copied so far.
+	Written int64
+	// Received is the number of body bytes read so far.
+	Received int64
+	// Total is the request's Content-Length, or -1 if unknown.
+	Total int64
+}
+
+// UploadedFile describes a file that Upload copied to its sink.
+type UploadedFile struct {
+	UploadPart
+	Size int64
+	// Quarantined is set when the Scanner did not pass the file under
+	// ScanQuarantine, with the Scanner's error in ScanErr.
+	Quarantined bool
+	ScanErr     error
+}
+
+// UploadResult is returned by Upload.
+type UploadResult struct {
+	Files  []UploadedFile
+	Fields url.Values
+}
+
+// Upload streams a multipart/form-data request body, copying each file part to the
+// writer returned by opts.Sink and collecting the remaining form values. Unlike
+// http.Request.ParseMultipartForm, files are never buffered in memory or on disk by
+// Upload itself. Limits are enforced as the body is read, and file types are
+// checked by content sniffing rather than trusting the client's Content-Type.
+// Exceeding a limit returns an error wrapping ErrUploadTooLarge, ErrTooManyFiles
+// or ErrUploadType; files copied before the error remain in their sinks.
+//
+// With opts.Scanner set, each file is also streamed to the scanner as it is
+// copied, and Upload waits for its verdict before moving to the next part. Since
+// the sink has already received a rejected file, sinks should write to a
+// temporary location that is only promoted once Upload succeeds.
+func Upload(r *http.Request, opts UploadOptions) (*UploadResult, error) {
+	if opts.Sink == nil {
+		panic("chain: nil Sink passed to Upload")
+	}
+	if opts.MaxFileSize <= 0 {
+		opts.MaxFileSize = 32 << 20
+	}
+	if opts.MaxTotalSize <= 0 {
+		opts.MaxTotalSize = 128 << 20
+	}
+	if opts.MaxFieldSize <= 0 {
+		opts.MaxFieldSize = 1 << 20
+	}
+	if opts.MaxFiles <= 0 {
+		opts.MaxFiles = 16
+	}
+	if opts.SniffLen <= 0 {
+		opts.SniffLen = sniffLen
+	}
+
+	body := &countingReader{r: r.Body, limit: opts.MaxTotalSize}
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{body, r.Body}
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return nil, err
+	}
+
+	res := &UploadResult{Fields: make(url.Values)}
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			// The multipart reader buffers ahead, so the limit may have been
+			// crossed without the error reaching it
+			return res, body.wrap(nil)
+		}
+		if err != nil {
+			return res, body.wrap(err)
+		}
+
+		if part.FileName() == "" {
+			value, err := io.ReadAll(io.LimitReader(part, opts.MaxFieldSize+1))
+			if err != nil {
+				return res, body.wrap(err)
+			}
+			if int64(len(value)) > opts.MaxFieldSize {
+				return res, fmt.Errorf("%w: field %s exceeds %d bytes", ErrUploadTooLarge, part.FormName(), opts.MaxFieldSize)
+			}
+			res.Fields.Add(part.FormName(), string(value))
+			continue
+		}
+
+		if len(res.Files) == opts.MaxFiles {
+			return res, fmt.Errorf("%w: limit is %d", ErrTooManyFiles, opts.MaxFiles)
+		}
+		file, err := opts.copyPart(r.Context(), part, body, r.ContentLength)
+		if err != nil {
+			return res, body.wrap(err)
+		}
+		res.Files = append(res.Files, file)
+	}
+}
+
+// copyPart sniffs, validates, copies and scans one file part.
+func (opts UploadOptions) copyPart(ctx context.Context, part *multipart.Part, body *countingReader, total int64) (UploadedFile, error) {
+	head := make([]byte, opts.SniffLen)
+	n, err := io.ReadFull(part, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return UploadedFile{}, err
+	}
+	head = head[:n]
+
+	info := UploadPart{FormName: part.FormName(), FileName: part.FileName(), ContentType: SniffContentType(head)}
+	if len(opts.AllowedTypes) > 0 && !allowedType(opts.AllowedTypes, info.ContentType) {
+		return UploadedFile{}, fmt.Errorf("%w: %s is %s", ErrUploadType, info.FileName, info.ContentType)
+	}
+
+	dst, err := opts.Sink(info)
+	if err != nil {
+		return UploadedFile{}, err
+	}
+	if c, ok := dst.(io.Closer); ok {
+		defer c.Close()
+	}
+	if opts.Scanner == nil {
+		return opts.copyFile(dst, io.MultiReader(bytes.NewReader(head), part), info, body, total)
+	}
+
+	pr, pw := io.Pipe()
+	verdict := make(chan error, 1)
+	go func() {
+		err := opts.Scanner.Scan(ctx, info, pr)
+		// Keep accepting the file if the scanner stopped reading early
+		io.Copy(io.Discard, pr)
+		verdict <- err
+	}()
+	file, err := opts.copyFile(io.MultiWriter(dst, pw), io.MultiReader(bytes.NewReader(head), part), info, body, total)
+	pw.CloseWithError(err)
+	scanErr := <-verdict
+	if err != nil || scanErr == nil {
+		return file, err
+	}
+	if opts.ScanPolicy != ScanQuarantine {
+		return file, fmt.Errorf("%w: %s: %w", ErrUploadRejected, info.FileName, scanErr)
+	}
+	file.Quarantined = true
+	file.ScanErr = scanErr
+	return file, nil
+}
+
+// copyFile copies one file from src to dst, enforcing the size limits.
+func (opts UploadOptions) copyFile(dst io.Writer, src io.Reader, info UploadPart, body *countingReader, total int64) (UploadedFile, error) {
+	file := UploadedFile{UploadPart: info}
+	buf := make([]byte, 32<<10)
+	for {
+		n, rerr := src.Read(buf)
+		if n > 0 {
+			file.Size += int64(n)
+			if body.n > body.limit {
+				return file, errBodyLimit
+			}
+			if file.Size > opts.MaxFileSize {
+				return file, fmt.Errorf("%w: %s exceeds %d bytes", ErrUploadTooLarge, info.FileName, opts.MaxFileSize)
+			}
+			if _, err := dst.Write(buf[:n]); err != nil {
+				return file, err
+			}
+			if opts.Progress != nil {
+				opts.Progress(UploadProgress{UploadPart: info, Written: file.Size, Received: body.n, Total: total})
+			}
+		}
+		if rerr == io.EOF {
+			return file, nil
+		}
+		if rerr != nil {
+			return file, rerr
+		}
+	}
+}
+
+// countingReader counts the bytes read from r and fails once limit is exceeded.
+type countingReader struct {
+	r     io.Reader
+	n     int64
+	limit int64
+}
+
+// errBodyLimit is returned by countingReader once its limit is exceeded.
+var errBodyLimit = errors.New("body limit exceeded")
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	if c.n > c.limit {
+		return 0, errBodyLimit
+	}
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	if c.n > c.limit {
+		return n, errBodyLimit
+	}
+	return n, err
+}
+
+// wrap converts an error caused by exceeding the body limit to ErrUploadTooLarge.
+func (c *countingReader) wrap(err error) error {
+	if c.n > c.limit {
+		return fmt.Errorf("%w: request exceeds %d bytes", ErrUploadTooLarge, c.limit)
+	}
+	return err
+}
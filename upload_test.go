@@ -0,0 +1,180 @@
+package chain_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jpl-au/chain"
+)
+
+// multipartRequest builds a multipart request with the given fields and files.
+func multipartRequest(t *testing.T, fields map[string]string, files map[string][]byte) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for k, v := range fields {
+		mw.WriteField(k, v)
+	}
+	for name, data := range files {
+		fw, err := mw.CreateFormFile("file", name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fw.Write(data)
+	}
+	mw.Close()
+	req := httptest.NewRequest("POST", "/upload", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func TestUpload(t *testing.T) {
+	png := append([]byte("\x89PNG\r\n\x1a\n"), bytes.Repeat([]byte{0}, 100)...)
+	req := multipartRequest(t, map[string]string{"title": "holiday"}, map[string][]byte{"photo.png": png})
+
+	sinks := map[string]*bytes.Buffer{}
+	var last chain.UploadProgress
+	res, err := chain.Upload(req, chain.UploadOptions{
+		Sink: func(p chain.UploadPart) (io.Writer, error) {
+			sinks[p.FileName] = &bytes.Buffer{}
+			return sinks[p.FileName], nil
+		},
+		AllowedTypes: []string{"image/png"},
+		Progress:     func(p chain.UploadProgress) { last = p },
+	})
+	if err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+
+	if res.Fields.Get("title") != "holiday" {
+		t.Errorf("Expected field title 'holiday', got '%s'", res.Fields.Get("title"))
+	}
+	if len(res.Files) != 1 || res.Files[0].ContentType != "image/png" || res.Files[0].Size != int64(len(png)) {
+		t.Fatalf("Unexpected files: %+v", res.Files)
+	}
+	if !bytes.Equal(sinks["photo.png"].Bytes(), png) {
+		t.Error("Expected sink to receive the file content")
+	}
+	if last.Written != int64(len(png)) || last.FileName != "photo.png" || last.Total != req.ContentLength {
+		t.Errorf("Unexpected final progress: %+v", last)
+	}
+}
+
+func TestUploadLimits(t *testing.T) {
+	discard := func(chain.UploadPart) (io.Writer, error) { return io.Discard, nil }
+
+	tests := []struct {
+		name  string
+		files map[string][]byte
+		opts  chain.UploadOptions
+		want  error
+	}{
+		{"file too large", map[string][]byte{"a.txt": []byte(strings.Repeat("a", 100))},
+			chain.UploadOptions{MaxFileSize: 10}, chain.ErrUploadTooLarge},
+		{"body too large", map[string][]byte{"a.txt": []byte(strings.Repeat("a", 1000))},
+			chain.UploadOptions{MaxTotalSize: 200}, chain.ErrUploadTooLarge},
+		{"wrong type", map[string][]byte{"a.png": []byte("not really a png")},
+			chain.UploadOptions{AllowedTypes: []string{"image/png"}}, chain.ErrUploadType},
+		{"too many files", map[string][]byte{"a.txt": []byte("a"), "b.txt": []byte("b")},
+			chain.UploadOptions{MaxFiles: 1}, chain.ErrTooManyFiles},
+	}
+	for _, tt := range tests {
+		tt.opts.Sink = discard
+		_, err := chain.Upload(multipartRequest(t, nil, tt.files), tt.opts)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, err)
+		}
+	}
+}
+
+// signatureScanner flags files containing the EICAR test signature.
+type signatureScanner struct{ scanned []string }
+
+func (s *signatureScanner) Scan(_ context.Context, part chain.UploadPart, r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	s.scanned = append(s.scanned, part.FileName+":"+string(data))
+	if bytes.Contains(data, []byte("EICAR")) {
+		return errors.New("Eicar-Test-Signature FOUND")
+	}
+	return nil
+}
+
+func TestUploadScanner(t *testing.T) {
+	files := map[string][]byte{"clean.txt": []byte("hello"), "virus.txt": []byte("X5O!P%@AP EICAR")}
+
+	tests := []struct {
+		name       string
+		policy     chain.ScanPolicy
+		want       error
+		quarantine bool
+	}{
+		{"reject", chain.ScanReject, chain.ErrUploadRejected, false},
+		{"quarantine", chain.ScanQuarantine, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := &signatureScanner{}
+			res, err := chain.Upload(multipartRequest(t, nil, files), chain.UploadOptions{
+				Sink:       func(chain.UploadPart) (io.Writer, error) { return io.Discard, nil },
+				Scanner:    scanner,
+				ScanPolicy: tt.policy,
+			})
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Expected %v, got %v", tt.want, err)
+			}
+			for _, s := range scanner.scanned {
+				name, data, _ := strings.Cut(s, ":")
+				if data != string(files[name]) {
+					t.Errorf("Expected scanner to receive all of %s, got '%s'", name, data)
+				}
+			}
+			if !tt.quarantine {
+				return
+			}
+			if len(res.Files) != 2 {
+				t.Fatalf("Expected both files to be kept, got %d", len(res.Files))
+			}
+			for _, f := range res.Files {
+				if f.Quarantined != (f.FileName == "virus.txt") {
+					t.Errorf("%s: unexpected quarantine state %v", f.FileName, f.Quarantined)
+				}
+				if f.Quarantined && f.ScanErr == nil {
+					t.Errorf("%s: expected the scanner's error", f.FileName)
+				}
+			}
+		})
+	}
+}
+
+// earlyScanner returns a verdict after reading only the first bytes.
+type earlyScanner struct{}
+
+func (earlyScanner) Scan(_ context.Context, _ chain.UploadPart, r io.Reader) error {
+	r.Read(make([]byte, 4))
+	return nil
+}
+
+func TestUploadScannerStopsEarly(t *testing.T) {
+	var sink bytes.Buffer
+	data := bytes.Repeat([]byte("a"), 200<<10)
+	res, err := chain.Upload(multipartRequest(t, nil, map[string][]byte{"big.txt": data}), chain.UploadOptions{
+		Sink:    func(chain.UploadPart) (io.Writer, error) { return &sink, nil },
+		Scanner: earlyScanner{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Files[0].Size != int64(len(data)) || sink.Len() != len(data) {
+		t.Errorf("Expected the whole file to be copied, got %d bytes", sink.Len())
+	}
+}
@@ -0,0 +1,130 @@
+package chain
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// FieldError describes one failed validation rule.
+type FieldError struct {
+	// Field is the name of the invalid field, as the client knows it.
+	Field string `json:"field"`
+	// Rule identifies the failed rule, such as "required" or "max".
+	Rule string `json:"rule"`
+	// Param is the rule's parameter, such as "255" for a max length, if any.
+	Param string `json:"param,omitempty"`
+	// Message is a human-readable description of the failure.
+	Message string `json:"message"`
+}
+
+// ValidationErrors collects every field error found while validating a request,
+// so that clients receive the full list in one response rather than the first
+// failure only.
+type ValidationErrors []FieldError
+
+// Add records a failed rule for field.
+func (v *ValidationErrors) Add(field, rule, param, message string) {
+	*v = append(*v, FieldError{Field: field, Rule: rule, Param: param, Message: message})
+}
+
+// Check records a failed rule for field unless ok is true, and reports ok.
+func (v *ValidationErrors) Check(ok bool, field, rule, param, message string) bool {
+	if !ok {
+		v.Add(field, rule, param, message)
+	}
+	return ok
+}
+
+// Err returns v as an error, or nil if no errors were recorded.
+func (v ValidationErrors) Err() error {
+	if len(v) == 0 {
+		return nil
+	}
+	return v
+}
+
+// Error joins the field errors into one message.
+func (v ValidationErrors) Error() string {
+	msgs := make([]string, len(v))
+	for i, fe := range v {
+		msgs[i] = fe.Field + ": " + fe.Message
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// validationMessages holds the localized message templates registered via
+// RegisterValidationMessages, keyed by locale and then rule.
+var validationMessages = struct {
+	sync.RWMutex
+	m map[string]map[string]string
+}{m: make(map[string]map[string]string)}
+
+// RegisterValidationMessages registers message templates for a locale, keyed by
+// rule. Templates may contain "{field}" and "{param}". WriteValidationErrors uses
+// them to localize messages for the locale negotiated by Localize. Registering a
+// locale again merges the new messages into the existing ones.
+func RegisterValidationMessages(locale string, messages map[string]string) {
+	validationMessages.Lock()
+	defer validationMessages.Unlock()
+	key := strings.ToLower(locale)
+	if validationMessages.m[key] == nil {
+		validationMessages.m[key] = make(map[string]string)
+	}
+	for rule, msg := range messages {
+		validationMessages.m[key][rule] = msg
+	}
+}
+
+// localizeFieldError returns fe with its message translated for locale, trying the
+// full tag before its base language. fe is returned unchanged if no message is
+// registered.
+func localizeFieldError(fe FieldError, locale string) FieldError {
+	if locale == "" {
+		return fe
+	}
+	validationMessages.RLock()
+	defer validationMessages.RUnlock()
+	locale = strings.ToLower(locale)
+	base, _, _ := strings.Cut(locale, "-")
+	for _, tag := range []string{locale, base} {
+		if msg, ok := validationMessages.m[tag][fe.Rule]; ok {
+			fe.Message = strings.NewReplacer("{field}", fe.Field, "{param}", fe.Param).Replace(msg)
+			return fe
+		}
+	}
+	return fe
+}
+
+// validationProblem is the 422 response body written by WriteValidationErrors.
+type validationProblem struct {
+	Problem
+	Errors []FieldError `json:"errors"`
+}
+
+// WriteValidationErrors responds with 422 Unprocessable Entity and a problem detail
+// listing every field error. Messages are localized for the locale negotiated by
+// Localize when messages have been registered for it. If err is not a
+// ValidationErrors (or does not wrap one), it is reported as a single error
+// without a field.
+func WriteValidationErrors(w http.ResponseWriter, r *http.Request, err error) {
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		errs = ValidationErrors{{Rule: "invalid", Message: err.Error()}}
+	}
+	locale := Locale(r)
+	out := make([]FieldError, len(errs))
+	for i, fe := range errs {
+		out[i] = localizeFieldError(fe, locale)
+	}
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	json.NewEncoder(w).Encode(validationProblem{
+		Problem: Problem{Title: http.StatusText(http.StatusUnprocessableEntity), Status: http.StatusUnprocessableEntity},
+		Errors:  out,
+	})
+}
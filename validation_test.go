@@ -0,0 +1,65 @@
+package chain_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jpl-au/chain"
+)
+
+func TestWriteValidationErrors(t *testing.T) {
+	chain.RegisterValidationMessages("fr", map[string]string{
+		"required": "{field} est obligatoire",
+	})
+
+	mux := chain.New().Use(chain.Localize(chain.LocaleOptions{Supported: []string{"en", "fr-FR"}}))
+	mux.HandleFunc("POST /users", func(w http.ResponseWriter, r *http.Request) {
+		var errs chain.ValidationErrors
+		errs.Check(r.FormValue("email") != "", "email", "required", "", "email is required")
+		errs.Check(len(r.FormValue("name")) <= 3, "name", "max", "3", "name is too long")
+		if err := errs.Err(); err != nil {
+			chain.WriteValidationErrors(w, r, fmt.Errorf("create user: %w", err))
+			return
+		}
+	})
+
+	req := httptest.NewRequest("POST", "/users?name=abcdef", nil)
+	req.Header.Set("Accept-Language", "fr-FR")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("Expected 422, got %d", rec.Code)
+	}
+	var body struct {
+		Status int                `json:"status"`
+		Errors []chain.FieldError `json:"errors"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Invalid body: %v", err)
+	}
+	want := []chain.FieldError{
+		{Field: "email", Rule: "required", Message: "email est obligatoire"},
+		{Field: "name", Rule: "max", Param: "3", Message: "name is too long"},
+	}
+	if len(body.Errors) != len(want) {
+		t.Fatalf("Expected %d errors, got %+v", len(want), body.Errors)
+	}
+	for i := range want {
+		if body.Errors[i] != want[i] {
+			t.Errorf("Error %d: expected %+v, got %+v", i, want[i], body.Errors[i])
+		}
+	}
+}
+
+func TestWriteValidationErrorsPlainError(t *testing.T) {
+	rec := httptest.NewRecorder()
+	chain.WriteValidationErrors(rec, httptest.NewRequest("POST", "/", nil), errors.New("bad input"))
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected 422, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,57 @@
+package chain
+
+import "net/http"
+
+// WebTransport returns a handler that accepts WebTransport sessions on a route,
+// such as "CONNECT /live". chain has no HTTP/3 dependency, so the session is
+// established by upgrade, typically the Upgrade method of a webtransport-go
+// server, which serves the router over HTTP/3:
+//
+//	wt := &webtransport.Server{H3: http3.Server{Handler: mux}}
+//	mux.Group(func(live *chain.Mux) {
+//		live.Use(auth)
+//		live.Handle("CONNECT /live", chain.WebTransport(wt.Upgrade,
+//			func(s *webtransport.Session, r *http.Request) {
+//				// serve streams and datagrams until the session ends
+//			}))
+//	})
+//
+// Middleware registered for the route runs on the CONNECT request before the
+// session is accepted, so authentication and logging apply as for any route.
+// upgrade receives the underlying writer, unwrapped from chain's wrapper and any
+// middleware wrappers, as HTTP/3 implementations require, and must not respond
+// itself when it fails: the request then receives 400 Bad Request.
+//
+// Sessions are tracked for Drain like hijacked connections: handler should watch
+// Draining and close the session, and its request context is cancelled if it
+// outlives the grace period.
+func WebTransport[S any](upgrade func(http.ResponseWriter, *http.Request) (S, error), handler func(S, *http.Request)) http.Handler {
+	if upgrade == nil || handler == nil {
+		panic("chain: nil function passed to WebTransport")
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := findResponseWriter(w)
+		session, err := upgrade(unwrapWriter(w), r)
+		if err != nil {
+			WriteError(w, r, http.StatusBadRequest, "")
+			return
+		}
+		if rw != nil {
+			// The upgrade has responded on the underlying writer
+			rw.hijacked = true
+			rw.trackLongLived(nil)
+		}
+		handler(session, r)
+	})
+}
+
+// unwrapWriter returns the innermost writer beneath w.
+func unwrapWriter(w http.ResponseWriter) http.ResponseWriter {
+	for {
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return w
+		}
+		w = u.Unwrap()
+	}
+}
@@ -0,0 +1,57 @@
+package chain_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jpl-au/chain"
+)
+
+type fakeSession struct{ underlying http.ResponseWriter }
+
+func TestWebTransport(t *testing.T) {
+	var authed bool
+	var got *fakeSession
+	mux := chain.New()
+	mux.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authed = true
+			next.ServeHTTP(w, r)
+		})
+	})
+	upgrade := func(w http.ResponseWriter, r *http.Request) (*fakeSession, error) {
+		if r.Header.Get("Sec-Webtransport-Http3-Draft02") == "" {
+			return nil, errors.New("not a webtransport request")
+		}
+		w.WriteHeader(http.StatusOK)
+		return &fakeSession{underlying: w}, nil
+	}
+	mux.Handle("CONNECT /live", chain.WebTransport(upgrade, func(s *fakeSession, r *http.Request) {
+		got = s
+	}))
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("CONNECT", "/live", nil)
+	req.Header.Set("Sec-Webtransport-Http3-Draft02", "1")
+	mux.ServeHTTP(rec, req)
+	if !authed {
+		t.Error("middleware did not run on the CONNECT request")
+	}
+	if got == nil {
+		t.Fatal("handler did not receive the session")
+	}
+	if got.underlying != http.ResponseWriter(rec) {
+		t.Errorf("upgrade received %T, want the underlying writer", got.underlying)
+	}
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want 200", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("CONNECT", "/live", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("failed upgrade status = %d, want 400", rec.Code)
+	}
+}